@@ -31,11 +31,14 @@ type KubectlStorageOSConfigSpec struct {
 	Verbose                     bool `json:"verbose,omitempty"`
 	Serial                      bool `json:"serial,omitempty"`
 	AirGap                      bool `json:"airGap,omitempty"`
+	// Output is the output format of lifecycle commands, one of text, json or yaml.
+	Output string `json:"output,omitempty"`
 
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 	Install   Install   `json:"install,omitempty"`
 	Uninstall Uninstall `json:"uninstall,omitempty"`
+	Backup    Backup    `json:"backup,omitempty"`
 }
 
 // GetOperatorNamespace tries to figure out operator namespace
@@ -60,8 +63,67 @@ func (spec *KubectlStorageOSConfigSpec) GetETCDValidationNamespace() (namespace
 	return spec.Install.StorageOSClusterNamespace
 }
 
+// InstallPhase is the phase reached by the last operation run against the cluster
+type InstallPhase string
+
+const (
+	PhaseInstalling      InstallPhase = "Installing"
+	PhaseInstalled       InstallPhase = "Installed"
+	PhaseInstallFailed   InstallPhase = "InstallFailed"
+	PhaseUpgrading       InstallPhase = "Upgrading"
+	PhaseUpgraded        InstallPhase = "Upgraded"
+	PhaseUpgradeFailed   InstallPhase = "UpgradeFailed"
+	PhaseUninstalling    InstallPhase = "Uninstalling"
+	PhaseUninstalled     InstallPhase = "Uninstalled"
+	PhaseUninstallFailed InstallPhase = "UninstallFailed"
+)
+
+// UpgradeStep is a checkpoint reached by an upgrade
+type UpgradeStep string
+
+const (
+	UpgradeStepStarted     UpgradeStep = "Started"
+	UpgradeStepBackedUp    UpgradeStep = "BackedUp"
+	UpgradeStepUninstalled UpgradeStep = "Uninstalled"
+	UpgradeStepCompleted   UpgradeStep = "Completed"
+	UpgradeStepRolledBack  UpgradeStep = "RolledBack"
+)
+
+// UpgradeCheckpoint records the progress of an upgrade so that it can be resumed
+type UpgradeCheckpoint struct {
+	FromVersion string `json:"fromVersion,omitempty"`
+	ToVersion   string `json:"toVersion,omitempty"`
+	// Step is the last step completed by the upgrade.
+	Step UpgradeStep `json:"step,omitempty"`
+	// BackupPath is the local directory holding the manifests backed up before the upgrade.
+	BackupPath string `json:"backupPath,omitempty"`
+	// LastTransitionTime is the time at which Step was reached.
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
 // KubectlStorageOSConfigStatus defines the observed state of KubectlStorageOSConfig
 type KubectlStorageOSConfigStatus struct {
+	// Phase is the phase reached by the last operation.
+	Phase InstallPhase `json:"phase,omitempty"`
+	// LastOperation is the name of the last operation run (install, upgrade or uninstall).
+	LastOperation string `json:"lastOperation,omitempty"`
+	// LastError is the error returned by the last operation, if any.
+	LastError string `json:"lastError,omitempty"`
+	// StartTime is the time at which the last operation started.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is the time at which the last operation completed.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	StorageOSVersion           string `json:"storageOSVersion,omitempty"`
+	EtcdOperatorVersion        string `json:"etcdOperatorVersion,omitempty"`
+	PortalManagerVersion       string `json:"portalManagerVersion,omitempty"`
+	StorageOSOperatorNamespace string `json:"storageOSOperatorNamespace,omitempty"`
+	StorageOSClusterNamespace  string `json:"storageOSClusterNamespace,omitempty"`
+	EtcdNamespace              string `json:"etcdNamespace,omitempty"`
+	EtcdEndpoints              string `json:"etcdEndpoints,omitempty"`
+
+	// Upgrade is the checkpoint of the last upgrade.
+	Upgrade *UpgradeCheckpoint `json:"upgrade,omitempty"`
 }
 
 // Install defines options for cli install subcommand
@@ -91,6 +153,7 @@ type Install struct {
 	EtcdMemoryLimit                 string `json:"etcdMemoryLimit,omitempty"`
 	EtcdReplicas                    string `json:"etcdReplicas,omitempty"`
 	SkipEtcdEndpointsValidation     bool   `json:"skipEtcdEndpointsValidation,omitempty"`
+	EtcdValidationMode              string `json:"etcdValidationMode,omitempty"`
 	EnablePortalManager             bool   `json:"enablePortalManager,omitempty"`
 	EtcdStorageClassName            string `json:"etcdStorageClassName,omitempty"`
 	AdminUsername                   string `json:"adminUsername,omitempty"`
@@ -105,8 +168,25 @@ type Install struct {
 	EnableMetrics                   *bool  `json:"enableMetrics,omitempty"`
 	MarkTestCluster                 bool   `json:"markTestCluster,omitempty"`
 	SkipK8sVersionCheck             bool   `json:"skipK8sVersionCheck,omitempty"`
+	SkipRollback                    bool   `json:"skipRollback,omitempty"`
 	EnableNodeGuard                 bool   `json:"enableNodeGuard,omitempty"`
 	NodeGuardEnv                    string `json:"nodeGuardEnv,omitempty"`
+	// ReadinessTimeout is the time to wait for newly installed CRDs and deployments to become ready.
+	ReadinessTimeout metav1.Duration `json:"readinessTimeout,omitempty"`
+	// ServerSideApply applies manifests with server-side apply, owning the fields set under the
+	// kubectl-storageos field manager.
+	ServerSideApply bool `json:"serverSideApply,omitempty"`
+	// ForceConflicts takes ownership of fields owned by other field managers on server-side apply.
+	ForceConflicts bool `json:"forceConflicts,omitempty"`
+	// FromBundle is the path of a release bundle created by bundle-release, which the manifests and
+	// versions to be installed are read from.
+	FromBundle string `json:"fromBundle,omitempty"`
+	// RegistryMirror is the registry which images are pulled from instead of the registries referenced
+	// by the manifests, their repositories are kept.
+	RegistryMirror string `json:"registryMirror,omitempty"`
+	// ImageMapping is the path of a file mapping image names, or prefixes of them, to the names to pull
+	// them as. A mapping wins over RegistryMirror.
+	ImageMapping string `json:"imageMapping,omitempty"`
 }
 
 // Uninstall defines options for cli uninstall subcommand
@@ -124,12 +204,27 @@ type Uninstall struct {
 	EtcdOperatorYaml                string `json:"etcdOperatorYaml,omitempty"`
 	EtcdClusterYaml                 string `json:"etcdClusterYaml,omitempty"`
 	LocalPathProvisionerYaml        string `json:"localPathProvisionerYaml,omitempty"`
+	// RemovalTimeout is the time to wait for uninstalled CRDs and operator-owned objects to be removed.
+	RemovalTimeout metav1.Duration `json:"removalTimeout,omitempty"`
+}
+
+// Backup defines options for cli backup and restore subcommands
+type Backup struct {
+	// BackupDir is the directory backups are written to and restored from.
+	BackupDir string `json:"backupDir,omitempty"`
+	// Archive writes the backup as a single tar.gz archive.
+	Archive bool `json:"archive,omitempty"`
+	// EtcdSnapshot includes a snapshot of the etcd keyspace in the backup.
+	EtcdSnapshot bool `json:"etcdSnapshot,omitempty"`
 }
 
 type InstallerMeta struct {
 	StorageOSSecretYaml string `json:"storageOSSecretYaml,omitempty"`
 	SecretName          string `json:"secretName,omitempty"`
 	SecretNamespace     string `json:"secretNamespace,omitempty"`
+	// MinKubernetesVersion is the minimum kubernetes version of the operator to be installed, if it is
+	// known without fetching the operator manifests image.
+	MinKubernetesVersion string `json:"minKubernetesVersion,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -25,6 +25,21 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Backup) DeepCopyInto(out *Backup) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Backup.
+func (in *Backup) DeepCopy() *Backup {
+	if in == nil {
+		return nil
+	}
+	out := new(Backup)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Install) DeepCopyInto(out *Install) {
 	*out = *in
@@ -66,7 +81,7 @@ func (in *KubectlStorageOSConfig) DeepCopyInto(out *KubectlStorageOSConfig) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	out.InstallerMeta = in.InstallerMeta
 }
 
@@ -125,6 +140,7 @@ func (in *KubectlStorageOSConfigSpec) DeepCopyInto(out *KubectlStorageOSConfigSp
 	*out = *in
 	in.Install.DeepCopyInto(&out.Install)
 	out.Uninstall = in.Uninstall
+	out.Backup = in.Backup
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubectlStorageOSConfigSpec.
@@ -140,6 +156,19 @@ func (in *KubectlStorageOSConfigSpec) DeepCopy() *KubectlStorageOSConfigSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubectlStorageOSConfigStatus) DeepCopyInto(out *KubectlStorageOSConfigStatus) {
 	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeCheckpoint)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubectlStorageOSConfigStatus.
@@ -166,3 +195,22 @@ func (in *Uninstall) DeepCopy() *Uninstall {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeCheckpoint) DeepCopyInto(out *UpgradeCheckpoint) {
+	*out = *in
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeCheckpoint.
+func (in *UpgradeCheckpoint) DeepCopy() *UpgradeCheckpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeCheckpoint)
+	in.DeepCopyInto(out)
+	return out
+}
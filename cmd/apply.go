@@ -1,16 +1,26 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/storageos/kubectl-storageos/pkg/apiclient"
 	"github.com/storageos/kubectl-storageos/pkg/forwarder"
 	"github.com/storageos/kubectl-storageos/pkg/logger"
 )
 
+const applyFromFileFlag = "from-file"
+
 func ApplyCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:                "apply",
 		Short:              "Make changes to existing resources",
+		Long:               `Apply a licence through the StorageOS API. Other resources are applied by the StorageOS CLI pod.`,
 		Args:               cobra.MinimumNArgs(0),
 		SilenceUsage:       true,
 		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
@@ -20,6 +30,45 @@ func ApplyCmd() *cobra.Command {
 			return forwarder.ForwardToCLIPod(logger.NewLogger(), storageosCommand(cmd, args))
 		},
 	}
+	cmd.AddCommand(applyLicenceCmd())
+
+	return cmd
+}
+
+func applyLicenceCmd() *cobra.Command {
+	cmd := apiCmd(&cobra.Command{
+		Use:     "licence",
+		Aliases: []string{"license"},
+		Args:    cobra.NoArgs,
+		Short:   "Apply a licence to the storageos cluster",
+		Example: `
+$ kubectl storageos apply licence --from-file licence.dat
+$ cat licence.dat | kubectl storageos apply licence --from-file -
+`,
+	}, func(cmd *cobra.Command, args []string, client *apiclient.Client) error {
+		path := cmd.Flags().Lookup(applyFromFileFlag).Value.String()
+		if path == "" {
+			return errors.Errorf("--%s is required", applyFromFileFlag)
+		}
+		var key []byte
+		var err error
+		if path == "-" {
+			key, err = io.ReadAll(cmd.InOrStdin())
+		} else {
+			key, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		licence, err := client.UpdateLicence(strings.TrimSpace(string(key)))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "licence applied, kind %s, capacity %s\n", licence.Kind, formatSize(licence.ClusterCapacityBytes))
+		return nil
+	})
+	cmd.Flags().String(applyFromFileFlag, "", "path of the licence key file, - for stdin")
 
 	return cmd
 }
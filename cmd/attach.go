@@ -1,25 +1,48 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 
-	"github.com/storageos/kubectl-storageos/pkg/forwarder"
-	"github.com/storageos/kubectl-storageos/pkg/logger"
+	"github.com/storageos/kubectl-storageos/pkg/apiclient"
 )
 
 func AttachCmd() *cobra.Command {
-	cmd := &cobra.Command{
-		Use:                "attach",
-		Short:              "Attach a volume to a node",
-		Args:               cobra.MinimumNArgs(0),
-		SilenceUsage:       true,
-		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
-		DisableFlagParsing: true,
-
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return forwarder.ForwardToCLIPod(logger.NewLogger(), storageosCommand(cmd, args))
-		},
-	}
+	cmd := apiCmd(&cobra.Command{
+		Use:   "attach VOLUME NODE",
+		Args:  cobra.ExactArgs(2),
+		Short: "Attach a volume to a node",
+	}, func(cmd *cobra.Command, args []string, client *apiclient.Client) error {
+		volume, node, err := volumeAndNode(cmd, client, args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if err = client.AttachVolume(volume, node.ID); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "volume/%s attached to node/%s\n", volume.Name, node.Name)
+		return nil
+	})
+	addAPINamespaceFlag(cmd)
 
 	return cmd
 }
+
+// volumeAndNode returns the volume called volumeName in the storageos namespace set by cmd, and the node
+// called nodeName.
+func volumeAndNode(cmd *cobra.Command, client *apiclient.Client, volumeName, nodeName string) (*apiclient.Volume, *apiclient.Node, error) {
+	namespace, err := apiNamespace(cmd, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	volume, err := client.GetVolumeByName(namespace.ID, volumeName)
+	if err != nil {
+		return nil, nil, err
+	}
+	node, err := client.GetNodeByName(nodeName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return volume, node, nil
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/consts"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+const backup = "backup"
+
+func BackupCmd() *cobra.Command {
+	var err error
+	var traceError bool
+	var backupPath string
+	pluginLogger := logger.NewLogger()
+	cmd := &cobra.Command{
+		Use:   backup,
+		Args:  cobra.MinimumNArgs(0),
+		Short: "Back up StorageOS control-plane configuration",
+		Long: fmt.Sprintf(`Back up the StorageOS cluster, secrets, configmaps, storage classes and (optionally) the ETCD keyspace.
+The backed up secrets are encrypted with a key derived from the passphrase in $%s, which is required.`, installer.BackupPassphraseEnvVar),
+		SilenceUsage: true,
+		PreRun:       func(cmd *cobra.Command, args []string) {},
+		Run: func(cmd *cobra.Command, args []string) {
+			defer pluginutils.ConvertPanicToError(func(e error) {
+				err = e
+			})
+
+			config := &apiv1.KubectlStorageOSConfig{}
+			if err = setBackupValues(cmd, config); err != nil {
+				return
+			}
+
+			traceError = config.Spec.StackTrace
+
+			backupPath, err = backupCmd(config, pluginLogger)
+		},
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			if err := pluginutils.HandleError(backup, err, traceError); err != nil {
+				pluginLogger.Error(fmt.Sprintf("%s%s", backup, " has failed"))
+				return err
+			}
+			pluginLogger.Successf("StorageOS backed up successfully to %s.", backupPath)
+			return nil
+		},
+	}
+	cmd.Flags().Bool(installer.StackTraceFlag, false, "print stack trace of error")
+	cmd.Flags().BoolP(installer.VerboseFlag, "v", false, "verbose logging")
+	cmd.Flags().String(installer.StosConfigPathFlag, "", "path to look for kubectl-storageos-config.yaml")
+	cmd.Flags().String(installer.StosOperatorNSFlag, consts.NewOperatorNamespace, "namespace of storageos operator")
+	cmd.Flags().String(installer.BackupDirFlag, "", "directory to write the backup to (default $HOME/.kube/storageos/backups/<cluster-id>)")
+	cmd.Flags().Bool(installer.ArchiveFlag, false, "write the backup as a tar.gz archive")
+	cmd.Flags().Bool(installer.EtcdSnapshotFlag, false, "include a snapshot of the etcd keyspace, taken through an etcd shell pod")
+
+	viper.BindPFlags(cmd.Flags())
+
+	cmd.AddCommand(BackupDecryptCmd())
+	cmd.AddCommand(BackupEncryptExistingCmd())
+
+	return cmd
+}
+
+func BackupDecryptCmd() *cobra.Command {
+	var err error
+	var traceError bool
+	pluginLogger := logger.NewLogger()
+	cmd := &cobra.Command{
+		Use:          "decrypt [file]",
+		Args:         cobra.ExactArgs(1),
+		Short:        "Print a backed up file in plaintext",
+		Long:         `Print a backed up file in plaintext, decrypting it if it has been encrypted`,
+		SilenceUsage: true,
+		PreRun:       func(cmd *cobra.Command, args []string) {},
+		Run: func(cmd *cobra.Command, args []string) {
+			defer pluginutils.ConvertPanicToError(func(e error) {
+				err = e
+			})
+
+			traceError, err = cmd.Flags().GetBool(installer.StackTraceFlag)
+			if err != nil {
+				return
+			}
+
+			// keep stdout for the plaintext, warnings go to stderr
+			pluginLogger.Writer = cmd.ErrOrStderr()
+
+			var data []byte
+			data, err = installer.NewBackupFileInstaller(pluginLogger).DecryptBackupFile(args[0])
+			if err != nil {
+				return
+			}
+			fmt.Fprint(cmd.OutOrStdout(), string(data))
+		},
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			if err := pluginutils.HandleError(backup, err, traceError); err != nil {
+				pluginLogger.Error(fmt.Sprintf("%s%s", backup, " decrypt has failed"))
+				return err
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Bool(installer.StackTraceFlag, false, "print stack trace of error")
+
+	return cmd
+}
+
+func BackupEncryptExistingCmd() *cobra.Command {
+	var err error
+	var traceError bool
+	var encrypted []string
+	pluginLogger := logger.NewLogger()
+	cmd := &cobra.Command{
+		Use:          "encrypt-existing",
+		Args:         cobra.NoArgs,
+		Short:        "Encrypt plaintext secret backups",
+		Long:         `Encrypt the plaintext secret backups written to $HOME/.kube/storageos by earlier versions of the plugin`,
+		SilenceUsage: true,
+		PreRun:       func(cmd *cobra.Command, args []string) {},
+		Run: func(cmd *cobra.Command, args []string) {
+			defer pluginutils.ConvertPanicToError(func(e error) {
+				err = e
+			})
+
+			traceError, err = cmd.Flags().GetBool(installer.StackTraceFlag)
+			if err != nil {
+				return
+			}
+
+			encrypted, err = installer.NewBackupFileInstaller(pluginLogger).EncryptPlaintextBackups()
+		},
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			if err := pluginutils.HandleError(backup, err, traceError); err != nil {
+				pluginLogger.Error(fmt.Sprintf("%s%s", backup, " encrypt-existing has failed"))
+				return err
+			}
+			pluginLogger.Successf("Encrypted %d plaintext backup file(s).", len(encrypted))
+			return nil
+		},
+	}
+	cmd.Flags().Bool(installer.StackTraceFlag, false, "print stack trace of error")
+
+	return cmd
+}
+
+func backupCmd(config *apiv1.KubectlStorageOSConfig, log *logger.Logger) (string, error) {
+	log.Verbose = config.Spec.Verbose
+
+	cliInstaller, err := installer.NewBackupInstaller(config, log)
+	if err != nil {
+		return "", err
+	}
+
+	log.Commencing(backup)
+	return cliInstaller.Backup()
+}
+
+func setBackupValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) error {
+	viper.BindPFlag(installer.StosConfigPathFlag, cmd.Flags().Lookup(installer.StosConfigPathFlag))
+	v := viper.GetViper()
+	viper.SetConfigName("kubectl-storageos-config")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath(v.GetString(installer.StosConfigPathFlag))
+
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			// Config file was found but another error was produced
+			return fmt.Errorf("error discovered in config file: %v", err)
+		}
+		// Config file not found; set fields in new config object directly
+		config.Spec.StackTrace, err = cmd.Flags().GetBool(installer.StackTraceFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.Verbose, err = cmd.Flags().GetBool(installer.VerboseFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.Backup.Archive, err = cmd.Flags().GetBool(installer.ArchiveFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.Backup.EtcdSnapshot, err = cmd.Flags().GetBool(installer.EtcdSnapshotFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.Install.StorageOSOperatorNamespace = cmd.Flags().Lookup(installer.StosOperatorNSFlag).Value.String()
+		config.Spec.Backup.BackupDir = cmd.Flags().Lookup(installer.BackupDirFlag).Value.String()
+		return nil
+	}
+	// config file read without error, set fields in new config object
+	config.Spec.StackTrace = viper.GetBool(installer.StackTraceConfig)
+	config.Spec.Verbose = viper.GetBool(installer.VerboseConfig)
+	config.Spec.Install.StorageOSOperatorNamespace = viper.GetString(installer.InstallStosOperatorNSConfig)
+	config.Spec.Backup.BackupDir = viper.GetString(installer.BackupDirConfig)
+	config.Spec.Backup.Archive = viper.GetBool(installer.ArchiveConfig)
+	config.Spec.Backup.EtcdSnapshot = viper.GetBool(installer.EtcdSnapshotConfig)
+	return nil
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+	"github.com/storageos/kubectl-storageos/pkg/version"
+)
+
+const (
+	bundleRelease = "bundle-release"
+
+	releaseBundleFileFormat = "storageos-%s.tar.gz"
+)
+
+func BundleReleaseCmd() *cobra.Command {
+	var err error
+	var traceError bool
+	pluginLogger := logger.NewLogger()
+	cmd := &cobra.Command{
+		Use:   bundleRelease + " [file]",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Package a StorageOS release for air gapped installs",
+		Long: `Package the manifests of a StorageOS release, the images they reference and their versions into a tar.gz bundle,
+which 'kubectl storageos install --from-bundle' installs from without network access.
+The bundle is written to file, by default storageos-<version>.tar.gz. The latest versions are bundled unless specified.`,
+		SilenceUsage: true,
+		PreRun:       func(cmd *cobra.Command, args []string) {},
+		Run: func(cmd *cobra.Command, args []string) {
+			defer pluginutils.ConvertPanicToError(func(e error) {
+				err = e
+			})
+
+			config := &apiv1.KubectlStorageOSConfig{}
+			if err = setBundleReleaseValues(cmd, config); err != nil {
+				return
+			}
+
+			traceError = config.Spec.StackTrace
+
+			bundlePath := ""
+			if len(args) > 0 {
+				bundlePath = args[0]
+			}
+			err = bundleReleaseCmd(config, bundlePath, pluginLogger)
+		},
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			if err := pluginutils.HandleError(bundleRelease, err, traceError); err != nil {
+				pluginLogger.Error(fmt.Sprintf("%s%s", bundleRelease, " has failed"))
+				return err
+			}
+			pluginLogger.Success("Release bundle created successfully.")
+			return nil
+		},
+	}
+	cmd.Flags().Bool(installer.StackTraceFlag, false, "print stack trace of error")
+	cmd.Flags().BoolP(installer.VerboseFlag, "v", false, "verbose logging")
+	cmd.Flags().String(installer.StosConfigPathFlag, "", "path to look for kubectl-storageos-config.yaml")
+	cmd.Flags().String(installer.StosVersionFlag, "", "version of storageos operator to bundle")
+	cmd.Flags().String(installer.EtcdOperatorVersionFlag, "", "version of etcd operator to bundle")
+	cmd.Flags().String(installer.PortalManagerVersionFlag, "", "version of portal manager to bundle")
+	cmd.Flags().Bool(installer.IncludeEtcdFlag, false, "bundle non-production etcd from github.com/storageos/etcd-cluster-operator")
+	cmd.Flags().Bool(installer.EnablePortalManagerFlag, false, "bundle storageos portal manager")
+	cmd.Flags().Bool(installer.IncludeLocalPathProvisionerFlag, false, "bundle the local path provisioner storage class")
+
+	viper.BindPFlags(cmd.Flags())
+
+	return cmd
+}
+
+func bundleReleaseCmd(config *apiv1.KubectlStorageOSConfig, bundlePath string, log *logger.Logger) error {
+	log.Verbose = config.Spec.Verbose
+
+	if err := setReleaseVersions(config); err != nil {
+		return err
+	}
+
+	if bundlePath == "" {
+		bundlePath = fmt.Sprintf(releaseBundleFileFormat, config.Spec.Install.StorageOSVersion)
+	}
+
+	log.Commencing(bundleRelease)
+	bundle, err := installer.BuildReleaseBundle(config, bundlePath)
+	if err != nil {
+		return err
+	}
+	log.Successf("Bundled %s with %d manifests and %d images to %s.", bundle, len(bundle.Manifests), len(bundle.Images), bundlePath)
+
+	return nil
+}
+
+// setReleaseVersions sets the versions of config which have not been specified to the latest supported,
+// those of etcd and portal manager only if enabled.
+func setReleaseVersions(config *apiv1.KubectlStorageOSConfig) error {
+	var err error
+	if config.Spec.Install.StorageOSVersion == "" {
+		if config.Spec.Install.StorageOSVersion, err = version.OperatorLatestSupportedVersion(); err != nil {
+			return err
+		}
+	}
+	if config.Spec.IncludeEtcd && config.Spec.Install.EtcdOperatorVersion == "" {
+		if config.Spec.Install.EtcdOperatorVersion, err = version.EtcdOperatorLatestSupportedVersion(); err != nil {
+			return err
+		}
+	}
+	if config.Spec.Install.EnablePortalManager {
+		if err := operatorSupportsFeature(config.Spec.Install.StorageOSVersion, version.FeaturePortalManager); err != nil {
+			return fmt.Errorf("failed to bundle portal manager: %w", err)
+		}
+		if config.Spec.Install.PortalManagerVersion == "" {
+			if config.Spec.Install.PortalManagerVersion, err = version.PortalManagerLatestSupportedVersion(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func setBundleReleaseValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) error {
+	viper.BindPFlag(installer.StosConfigPathFlag, cmd.Flags().Lookup(installer.StosConfigPathFlag))
+	v := viper.GetViper()
+	viper.SetConfigName("kubectl-storageos-config")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath(v.GetString(installer.StosConfigPathFlag))
+
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			// Config file was found but another error was produced
+			return fmt.Errorf("error discovered in config file: %v", err)
+		}
+		// Config file not found; set fields in new config object directly
+		config.Spec.StackTrace, err = cmd.Flags().GetBool(installer.StackTraceFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.Verbose, err = cmd.Flags().GetBool(installer.VerboseFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.IncludeEtcd, err = cmd.Flags().GetBool(installer.IncludeEtcdFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.Install.EnablePortalManager, err = cmd.Flags().GetBool(installer.EnablePortalManagerFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.IncludeLocalPathProvisioner, err = cmd.Flags().GetBool(installer.IncludeLocalPathProvisionerFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.Install.StorageOSVersion = cmd.Flags().Lookup(installer.StosVersionFlag).Value.String()
+		config.Spec.Install.EtcdOperatorVersion = cmd.Flags().Lookup(installer.EtcdOperatorVersionFlag).Value.String()
+		config.Spec.Install.PortalManagerVersion = cmd.Flags().Lookup(installer.PortalManagerVersionFlag).Value.String()
+		return nil
+	}
+	// config file read without error, set fields in new config object
+	config.Spec.StackTrace = viper.GetBool(installer.StackTraceConfig)
+	config.Spec.Verbose = viper.GetBool(installer.VerboseConfig)
+	config.Spec.IncludeEtcd = viper.GetBool(installer.IncludeEtcdConfig)
+	config.Spec.Install.EnablePortalManager = viper.GetBool(installer.EnablePortalManagerConfig)
+	config.Spec.IncludeLocalPathProvisioner = viper.GetBool(installer.IncludeLocalPathProvisionerConfig)
+	config.Spec.Install.StorageOSVersion = viper.GetString(installer.InstallStosVersionConfig)
+	config.Spec.Install.EtcdOperatorVersion = viper.GetString(installer.InstallEtcdOperatorVersionConfig)
+	config.Spec.Install.PortalManagerVersion = viper.GetString(installer.InstallPortalManagerVersionConfig)
+	return nil
+}
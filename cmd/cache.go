@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/storageos/kubectl-storageos/pkg/cache"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+const (
+	cacheName = "cache"
+
+	cachePruneAllFlag = "all"
+)
+
+func CacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cacheName,
+		Short: "Manage the local cache of fetched manifests and release metadata",
+		Long: fmt.Sprintf(`Manage the local cache of manifests fetched from urls, files extracted from manifest images and release metadata
+fetched from GitHub, stored in $HOME/.kube/storageos/cache unless overridden by $%s.
+Manifests expire after %s, release metadata after %s. Files of images are keyed by image digest and never expire.`,
+			cache.DirEnvVar, cache.TTLs[cache.KindManifest], cache.TTLs[cache.KindRelease]),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+	cmd.PersistentFlags().Bool(installer.StackTraceFlag, false, "print stack trace of error")
+
+	cmd.AddCommand(cacheListCmd())
+	cmd.AddCommand(cachePruneCmd())
+
+	return cmd
+}
+
+func cacheListCmd() *cobra.Command {
+	var err error
+	var entries []cache.Entry
+	pluginLogger := logger.NewLogger()
+	cmd := &cobra.Command{
+		Use:          "list",
+		Args:         cobra.NoArgs,
+		Short:        "List the cached entries",
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			var c *cache.Cache
+			if c, err = cache.Default(); err != nil {
+				return
+			}
+			entries, err = c.List()
+		},
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			traceError, _ := cmd.Flags().GetBool(installer.StackTraceFlag)
+			if err := pluginutils.HandleError(cacheName, err, traceError); err != nil {
+				pluginLogger.Error(fmt.Sprintf("%s list%s", cacheName, " has failed"))
+				return err
+			}
+			writeCacheEntries(cmd.OutOrStdout(), entries, time.Now())
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func cachePruneCmd() *cobra.Command {
+	var err error
+	var removed int
+	var freed int64
+	pluginLogger := logger.NewLogger()
+	cmd := &cobra.Command{
+		Use:          "prune",
+		Args:         cobra.NoArgs,
+		Short:        "Remove expired entries, or all entries with --all, from the cache",
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			var all bool
+			if all, err = cmd.Flags().GetBool(cachePruneAllFlag); err != nil {
+				return
+			}
+			var c *cache.Cache
+			if c, err = cache.Default(); err != nil {
+				return
+			}
+			removed, freed, err = c.Prune(all)
+		},
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			traceError, _ := cmd.Flags().GetBool(installer.StackTraceFlag)
+			if err := pluginutils.HandleError(cacheName, err, traceError); err != nil {
+				pluginLogger.Error(fmt.Sprintf("%s prune%s", cacheName, " has failed"))
+				return err
+			}
+			pluginLogger.Successf("Removed %d cache entries, freed %d bytes.", removed, freed)
+			return nil
+		},
+	}
+	cmd.Flags().Bool(cachePruneAllFlag, false, "remove all entries, not only expired ones")
+
+	return cmd
+}
+
+// writeCacheEntries writes a table of entries to w, with their age and expiry relative to now.
+func writeCacheEntries(w io.Writer, entries []cache.Entry, now time.Time) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "Cache is empty.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tKEY\tSIZE\tAGE\tEXPIRES")
+	for _, entry := range entries {
+		expires := "never"
+		switch {
+		case entry.Expired(now):
+			expires = "expired"
+		case entry.ExpiresAt != nil:
+			expires = "in " + duration.HumanDuration(entry.ExpiresAt.Sub(now))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", entry.Kind, entry.Key, entry.Size, duration.HumanDuration(now.Sub(entry.CreatedAt)), expires)
+	}
+	tw.Flush()
+}
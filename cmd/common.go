@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
@@ -9,9 +10,12 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
-	"github.com/spf13/viper"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/credentials"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
 	"github.com/storageos/kubectl-storageos/pkg/logger"
 	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
 	"github.com/storageos/kubectl-storageos/pkg/version"
@@ -27,6 +31,24 @@ To find out more please see the documentation page https://docs.ondat.io/docs/in
 
 `
 
+// AddOutputFlag adds the output flag honoured by every lifecycle command to flags, the persistent flags
+// of the root command. Commands with output formats of their own shadow it with a local flag.
+func AddOutputFlag(flags *pflag.FlagSet) {
+	flags.StringP(installer.OutputFlag, "o", logger.OutputText, fmt.Sprintf("output format of lifecycle commands, one of %q, %q or %q", logger.OutputText, logger.OutputJSON, logger.OutputYAML))
+}
+
+// withRootFlags parents cmd to a bare root command holding the persistent flags of the root command, for
+// commands built outside of the command tree to read them.
+func withRootFlags(cmd *cobra.Command) *cobra.Command {
+	root := &cobra.Command{}
+	AddOutputFlag(root.PersistentFlags())
+	root.AddCommand(cmd)
+	// merges the persistent flags into cmd.Flags(), which is otherwise done when parsing
+	cmd.InheritedFlags()
+
+	return cmd
+}
+
 // etcdEndpointsPrompt uses promptui to prompt the user to enter etcd endpoints. The internal validate
 // func is run on each character as it is entered as per the regexp - it does not refer to actual
 // endpoint validation which is handled later.
@@ -133,6 +155,26 @@ func k8sVersionPrompt(log *logger.Logger) (string, error) {
 	return pluginutils.AskUser(prompt, log)
 }
 
+// recordedInstallState returns the install state recorded in the cluster by a previous run of the
+// plugin. An empty state is returned if none has been recorded or it cannot be read, in which case
+// the caller falls back to discovering the installation.
+func recordedInstallState(log *logger.Logger) *apiv1.KubectlStorageOSConfigStatus {
+	clientConfig, err := pluginutils.NewClientConfig()
+	if err != nil {
+		return &apiv1.KubectlStorageOSConfigStatus{}
+	}
+
+	state, err := installer.ReadInstallState(clientConfig)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			log.Warnf("Unable to read recorded install state: %s", err.Error())
+		}
+		return &apiv1.KubectlStorageOSConfigStatus{}
+	}
+
+	return state
+}
+
 func valueOrDefault(value string, def string) string {
 	if value != "" {
 		return value
@@ -140,17 +182,37 @@ func valueOrDefault(value string, def string) string {
 	return def
 }
 
-func versionSupportsFeature(existingOperatorVersion, featureFirstSupportedVersion string) error {
-	if version.IsDevelop(existingOperatorVersion) {
-		return nil
+// operatorSupportsFeature returns an error if feature is not supported by operatorVersion.
+func operatorSupportsFeature(operatorVersion string, feature version.Feature) error {
+	return version.Matrix.Supports(feature, map[version.Component]string{version.OperatorComponent: operatorVersion})
+}
+
+// installVersions returns the versions of the components of install, which are not checked against
+// the compatibility matrix unless set.
+func installVersions(install apiv1.Install) map[version.Component]string {
+	return map[version.Component]string{
+		version.OperatorComponent:      install.StorageOSVersion,
+		version.EtcdOperatorComponent:  install.EtcdOperatorVersion,
+		version.PortalManagerComponent: install.PortalManagerVersion,
 	}
+}
 
-	supported, err := version.IsSupported(existingOperatorVersion, featureFirstSupportedVersion)
-	if err != nil {
+// checkCompatibility returns an error if the versions of install don't support StorageOS, or the
+// features it enables, according to the compatibility matrix.
+func checkCompatibility(install apiv1.Install) error {
+	versions := installVersions(install)
+	if err := version.Matrix.Supports(version.FeatureOperator, versions); err != nil {
 		return err
 	}
-	if !supported {
-		return fmt.Errorf("feature is not supported in StorageOS %s, requires minimum version %s", existingOperatorVersion, featureFirstSupportedVersion)
+	if install.EnableMetrics != nil && *install.EnableMetrics {
+		if err := version.Matrix.Supports(version.FeatureMetricsExporter, versions); err != nil {
+			return fmt.Errorf("failed to enable metrics exporter: %w", err)
+		}
+	}
+	if install.EnablePortalManager {
+		if err := version.Matrix.Supports(version.FeaturePortalManager, versions); err != nil {
+			return fmt.Errorf("failed to install portal manager: %w", err)
+		}
 	}
 
 	return nil
@@ -164,28 +226,42 @@ func validateResourceLimit(resourceLimit string) error {
 	return nil
 }
 
-func validatePassword(password string) error {
-	if len(password) < 8 {
-		return fmt.Errorf("password must be at least 8 characters long")
+// credentialReferenceHelp is appended to the usage of credential flags.
+const credentialReferenceHelp = "in plaintext or as a reference: file:<path>, env:<var>, secret:<namespace>/<name>#<key> or exec:<plugin>:<reference>"
+
+// credentialResolver resolves the references of credential fields, such as file:/path/to/password.
+var credentialResolver = credentials.NewResolver(credentials.DefaultSecretGetter)
+
+// completeCredentials replaces the references of the credential fields of install with the credentials
+// they reference, validating the admin password.
+func completeCredentials(install *apiv1.Install) error {
+	credentialFields := []struct {
+		flag  string
+		value *string
+	}{
+		{installer.AdminUsernameFlag, &install.AdminUsername},
+		{installer.AdminPasswordFlag, &install.AdminPassword},
+		{installer.PortalClientIDFlag, &install.PortalClientID},
+		{installer.PortalSecretFlag, &install.PortalSecret},
 	}
-	return nil
-}
-
-func GetBoolIfFlagSet(fs *pflag.FlagSet, flagName string) (*bool, error) {
-	if fs.Changed(flagName) {
-		enabled, err := fs.GetBool(flagName)
+	for _, field := range credentialFields {
+		credential, err := credentialResolver.Resolve(context.TODO(), *field.value)
 		if err != nil {
-			return nil, err
+			return errors.Wrapf(err, "unable to resolve --%s", field.flag)
 		}
-		return &enabled, nil
+		*field.value = credential
 	}
-	return nil, nil
+
+	if install.AdminPassword != "" {
+		return validatePassword(install.AdminPassword)
+	}
+
+	return nil
 }
 
-func GetBoolIfConfigSet(key string) *bool {
-	if viper.IsSet(key) {
-		enabled := viper.GetBool(key)
-		return &enabled
+func validatePassword(password string) error {
+	if len(password) < 8 {
+		return fmt.Errorf("password must be at least 8 characters long")
 	}
 	return nil
 }
@@ -213,3 +289,20 @@ func parseArgs(args []string) []string {
 
 	return parsedArgs
 }
+
+// validateServerSideApply returns an error if conflicts are to be forced without server-side apply, in
+// line with kubectl apply.
+func validateServerSideApply(install apiv1.Install) error {
+	if install.ForceConflicts && !install.ServerSideApply {
+		return fmt.Errorf("--%s only works with --%s", installer.ForceConflictsFlag, installer.ServerSideApplyFlag)
+	}
+
+	return nil
+}
+
+// validateImageRewrite returns an error if the image mapping file of install can't be read.
+func validateImageRewrite(install apiv1.Install) error {
+	_, err := pluginutils.NewImageRewriter(install.RegistryMirror, install.ImageMapping)
+
+	return err
+}
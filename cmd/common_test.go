@@ -3,6 +3,8 @@ package cmd
 import (
 	"reflect"
 	"testing"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
 )
 
 func TestParseArgs(t *testing.T) {
@@ -34,3 +36,48 @@ func TestParseArgs(t *testing.T) {
 		}
 	}
 }
+
+func TestCompleteCredentials(t *testing.T) {
+	t.Setenv("TEST_ADMIN_PASSWORD", "long-enough")
+	t.Setenv("TEST_SHORT_PASSWORD", "short")
+	t.Setenv("TEST_PORTAL_SECRET", "portal-secret")
+
+	tcases := []struct {
+		name       string
+		install    apiv1.Install
+		expInstall apiv1.Install
+		expErr     bool
+	}{
+		{
+			name:       "plaintext",
+			install:    apiv1.Install{AdminUsername: "admin", AdminPassword: "password"},
+			expInstall: apiv1.Install{AdminUsername: "admin", AdminPassword: "password"},
+		},
+		{
+			name:       "references",
+			install:    apiv1.Install{AdminPassword: "env:TEST_ADMIN_PASSWORD", PortalClientID: "client", PortalSecret: "env:TEST_PORTAL_SECRET"},
+			expInstall: apiv1.Install{AdminPassword: "long-enough", PortalClientID: "client", PortalSecret: "portal-secret"},
+		},
+		{
+			name:    "resolved password too short",
+			install: apiv1.Install{AdminPassword: "env:TEST_SHORT_PASSWORD"},
+			expErr:  true,
+		},
+		{
+			name:    "unresolved reference",
+			install: apiv1.Install{PortalSecret: "env:TEST_UNSET_SECRET"},
+			expErr:  true,
+		},
+	}
+	for _, tc := range tcases {
+		install := tc.install
+		err := completeCredentials(&install)
+		if (err != nil) != tc.expErr {
+			t.Errorf("case: %s - expected error %v, got %v", tc.name, tc.expErr, err)
+			continue
+		}
+		if !tc.expErr && !reflect.DeepEqual(install, tc.expInstall) {
+			t.Errorf("case: %s - expected %v, got %v", tc.name, tc.expInstall, install)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/storageos/kubectl-storageos/pkg/consts"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+	"github.com/storageos/kubectl-storageos/pkg/version"
+)
+
+const (
+	compat = "compat"
+
+	compatMatrixOnlyFlag = "matrix-only"
+)
+
+func CompatCmd() *cobra.Command {
+	var err error
+	var matrixOnly bool
+	var matrix version.Compatibility
+	var versions map[version.Component]string
+	pluginLogger := logger.NewLogger()
+	cmd := &cobra.Command{
+		Use:   compat,
+		Args:  cobra.NoArgs,
+		Short: "Print the compatibility matrix and check the current cluster against it",
+		Long: `Print the versions of the operator, etcd operator, portal manager and Kubernetes each feature requires, then
+check the versions installed in the current cluster against them. The minimum version of Kubernetes is read from the
+manifests image of the installed operator. Install and upgrade enforce the same matrix.`,
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			defer pluginutils.ConvertPanicToError(func(e error) {
+				err = e
+			})
+
+			if matrixOnly, err = cmd.Flags().GetBool(compatMatrixOnlyFlag); err != nil {
+				return
+			}
+			verbose, _ := cmd.Flags().GetBool(installer.VerboseFlag)
+			pluginLogger.Verbose = verbose
+
+			matrix = version.Matrix
+			if matrixOnly {
+				return
+			}
+			versions, err = detectComponentVersions(cmd, pluginLogger)
+			if err != nil {
+				return
+			}
+			if operatorVersion := versions[version.OperatorComponent]; operatorVersion != "" && !version.IsDevelop(operatorVersion) {
+				minVersion, err := installer.OperatorMinKubernetesVersion(operatorVersion)
+				if err != nil {
+					pluginLogger.Warnf("Unable to read the minimum version of Kubernetes of operator %s: %s", operatorVersion, err.Error())
+				} else if minVersion != "" {
+					matrix = matrix.WithRequirement(version.FeatureOperator, version.Requirement{Component: version.KubernetesComponent, MinVersion: minVersion})
+				}
+			}
+		},
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			traceError, _ := cmd.Flags().GetBool(installer.StackTraceFlag)
+			if err := pluginutils.HandleError(compat, err, traceError); err != nil {
+				pluginLogger.Error(fmt.Sprintf("%s%s", compat, " has failed"))
+				return err
+			}
+			writeCompatMatrix(cmd.OutOrStdout(), matrix)
+			if matrixOnly {
+				return nil
+			}
+			fmt.Fprintln(cmd.OutOrStdout())
+			if !writeCompatChecks(cmd.OutOrStdout(), matrix, versions) {
+				err := errors.New("the installed versions are not supported")
+				pluginLogger.Error(fmt.Sprintf("%s%s", compat, " has failed"))
+				return pluginutils.HandleError(compat, err, traceError)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Bool(installer.StackTraceFlag, false, "print stack trace of error")
+	cmd.Flags().BoolP(installer.VerboseFlag, "v", false, "verbose logging")
+	cmd.Flags().Bool(compatMatrixOnlyFlag, false, "print the compatibility matrix without checking the current cluster")
+	cmd.Flags().String(installer.StosOperatorNSFlag, consts.NewOperatorNamespace, "namespace of storageos operator")
+	cmd.Flags().String(installer.EtcdNamespaceFlag, consts.EtcdOperatorNamespace, "namespace of etcd operator")
+
+	return cmd
+}
+
+// detectComponentVersions returns the versions of the components installed in the current cluster.
+// Components which are not installed have no version.
+func detectComponentVersions(cmd *cobra.Command, log *logger.Logger) (map[version.Component]string, error) {
+	config, err := pluginutils.NewClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	kubernetesVersion, err := pluginutils.GetKubernetesVersion(config)
+	if err != nil {
+		return nil, err
+	}
+	versions := map[version.Component]string{
+		version.KubernetesComponent: kubernetesVersion.String(),
+	}
+
+	// an unsupported operator version is reported by the checks rather than failing detection
+	operatorVersion, err := version.GetInstalledOperatorVersion(cmd.Flags().Lookup(installer.StosOperatorNSFlag).Value.String())
+	if err != nil {
+		log.Infof("%s", err.Error())
+	}
+	versions[version.OperatorComponent] = operatorVersion
+	if versions[version.EtcdOperatorComponent], err = version.GetExistingEtcdOperatorVersion(cmd.Flags().Lookup(installer.EtcdNamespaceFlag).Value.String()); err != nil {
+		log.Infof("%s", err.Error())
+	}
+	if versions[version.PortalManagerComponent], err = version.GetExistingPortalManagerVersion(); err != nil {
+		log.Infof("%s", err.Error())
+	}
+
+	return versions, nil
+}
+
+// writeCompatMatrix writes a table of the requirements of each feature of matrix to w.
+func writeCompatMatrix(w io.Writer, matrix version.Compatibility) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprint(tw, "FEATURE")
+	for _, component := range version.Components {
+		fmt.Fprintf(tw, "\t%s", strings.ToUpper(string(component)))
+	}
+	fmt.Fprintln(tw, "\tDESCRIPTION")
+	for _, support := range matrix {
+		fmt.Fprint(tw, support.Feature)
+		for _, component := range version.Components {
+			requirement, ok := matrix.Requirement(support.Feature, component)
+			if !ok {
+				fmt.Fprint(tw, "\t-")
+				continue
+			}
+			fmt.Fprintf(tw, "\t%s", requirement.Range())
+		}
+		fmt.Fprintf(tw, "\t%s\n", support.Description)
+	}
+	tw.Flush()
+}
+
+// writeCompatChecks writes the installed version of each component, and whether each feature of matrix
+// is supported by them, to w. It returns false if the installed operator is unsupported.
+func writeCompatChecks(w io.Writer, matrix version.Compatibility, versions map[version.Component]string) bool {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tINSTALLED")
+	for _, component := range version.Components {
+		installed := versions[component]
+		if installed == "" {
+			installed = "not found"
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", component, installed)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+
+	if versions[version.OperatorComponent] == "" {
+		fmt.Fprintln(w, "StorageOS is not installed, features are not checked.")
+		return true
+	}
+
+	compatible := true
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FEATURE\tSUPPORTED\tREASON")
+	for _, support := range matrix {
+		supported, reason := "yes", ""
+		if err := matrix.Supports(support.Feature, versions); err != nil {
+			supported, reason = "no", err.Error()
+			compatible = compatible && support.Feature != version.FeatureOperator
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", support.Feature, supported, reason)
+	}
+	tw.Flush()
+
+	return compatible
+}
@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+)
+
+// configSource is a layer of the config of a command. Each field takes its value from the layer of the
+// highest precedence which sets it, in order: defaults, the config file, env vars, then flags.
+type configSource string
+
+const (
+	sourceDefault    configSource = "default"
+	sourceConfigFile configSource = "config file"
+	sourceEnv        configSource = "env"
+	sourceFlag       configSource = "flag"
+
+	configFileName = "kubectl-storageos-config"
+
+	// configEnvPrefix prefixes the env var of each flag, named after the flag in upper snake case, eg:
+	// KUBECTL_STORAGEOS_ETCD_ENDPOINTS for --etcd-endpoints.
+	configEnvPrefix = "KUBECTL_STORAGEOS_"
+
+	maskedConfigValue = "********"
+)
+
+// sensitiveFlags are the flags whose values are masked when the config is shown, unless they are
+// credential references.
+var sensitiveFlags = map[string]bool{
+	installer.AdminPasswordFlag:  true,
+	installer.PortalClientIDFlag: true,
+	installer.PortalSecretFlag:   true,
+}
+
+// maskCredential returns value masked, unless it is empty or a credential reference.
+func maskCredential(value string) string {
+	if value == "" || credentialResolver.IsReference(value) {
+		return value
+	}
+	return maskedConfigValue
+}
+
+// configField is a field of the effective config of a command, with the layer its value was taken from.
+type configField struct {
+	key    string
+	flag   string
+	value  string
+	source configSource
+}
+
+// origin returns the layer the value of the field was taken from, with the flag or env var setting it.
+func (f configField) origin() string {
+	switch f.source {
+	case sourceFlag:
+		return fmt.Sprintf("%s --%s", f.source, f.flag)
+	case sourceEnv:
+		return fmt.Sprintf("%s %s", f.source, configEnvVar(f.flag))
+	}
+	return string(f.source)
+}
+
+// configLayers merges the config of a command from its layers, recording the fields it resolves. Errors
+// parsing values are kept until Err is called, so that fields can be set without checking each of them.
+type configLayers struct {
+	flags      *pflag.FlagSet
+	configFile *viper.Viper
+	lookupEnv  func(string) (string, bool)
+	fields     []configField
+	err        error
+}
+
+// newConfigLayers returns the config layers of cmd, reading the config file from the path set by
+// --stos-config-path or its env var, if any.
+func newConfigLayers(cmd *cobra.Command) (*configLayers, error) {
+	layers := &configLayers{flags: cmd.Flags(), lookupEnv: os.LookupEnv}
+	path, _ := layers.resolve(installer.StosConfigPathFlag, "")
+
+	configFile := viper.New()
+	configFile.SetConfigName(configFileName)
+	configFile.SetConfigType("yaml")
+	configFile.AddConfigPath(path)
+	if err := configFile.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			// Config file was found but another error was produced
+			return nil, fmt.Errorf("error discovered in config file: %v", err)
+		}
+		return layers, nil
+	}
+	layers.configFile = configFile
+
+	return layers, nil
+}
+
+// configEnvVar returns the name of the env var of flag.
+func configEnvVar(flag string) string {
+	return configEnvPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// resolve returns the value of flag, or of key of the config file, and the layer it was taken from. Env
+// vars set to an empty string are ignored.
+func (l *configLayers) resolve(flag, key string) (string, configSource) {
+	f := l.flags.Lookup(flag)
+	if f == nil {
+		if l.err == nil {
+			l.err = fmt.Errorf("flag --%s is not defined", flag)
+		}
+		return "", sourceDefault
+	}
+	if f.Changed {
+		return f.Value.String(), sourceFlag
+	}
+	if value, ok := l.lookupEnv(configEnvVar(flag)); ok && value != "" {
+		return value, sourceEnv
+	}
+	if l.configFile != nil && key != "" && l.configFile.IsSet(key) {
+		return l.configFile.GetString(key), sourceConfigFile
+	}
+
+	return f.DefValue, sourceDefault
+}
+
+// field resolves and records the field key of flag.
+func (l *configLayers) field(flag, key string) configField {
+	value, source := l.resolve(flag, key)
+	field := configField{key: key, flag: flag, value: value, source: source}
+	l.fields = append(l.fields, field)
+
+	return field
+}
+
+// invalid records the error of a field whose value can't be parsed, if it is the first one.
+func (l *configLayers) invalid(field configField, err error) {
+	if l.err == nil {
+		l.err = fmt.Errorf("invalid value %q of %s from %s: %v", field.value, field.key, field.origin(), err)
+	}
+}
+
+// String returns the value of the field key of flag.
+func (l *configLayers) String(flag, key string) string {
+	return l.field(flag, key).value
+}
+
+// Bool returns the value of the boolean field key of flag.
+func (l *configLayers) Bool(flag, key string) bool {
+	field := l.field(flag, key)
+	value, err := strconv.ParseBool(field.value)
+	if err != nil {
+		l.invalid(field, err)
+	}
+
+	return value
+}
+
+// BoolIfSet returns the value of the boolean field key of flag, or nil if no layer other than the
+// defaults sets it.
+func (l *configLayers) BoolIfSet(flag, key string) *bool {
+	value := l.Bool(flag, key)
+	if l.fields[len(l.fields)-1].source == sourceDefault {
+		return nil
+	}
+
+	return &value
+}
+
+// Duration returns the value of the duration field key of flag.
+func (l *configLayers) Duration(flag, key string) metav1.Duration {
+	field := l.field(flag, key)
+	value, err := time.ParseDuration(field.value)
+	if err != nil {
+		l.invalid(field, err)
+	}
+
+	return metav1.Duration{Duration: value}
+}
+
+// Err returns the first error resolving the fields.
+func (l *configLayers) Err() error {
+	return l.err
+}
+
+// showConfig writes the fields of the config of cmd to its output if --show-config is set, returning
+// whether they were written, in which case cmd does nothing else.
+func showConfig(cmd *cobra.Command, fields ...[]configField) bool {
+	if show, _ := cmd.Flags().GetBool(installer.ShowConfigFlag); !show {
+		return false
+	}
+
+	merged := []configField{}
+	for _, layerFields := range fields {
+		merged = append(merged, layerFields...)
+	}
+	writeConfigFields(cmd.OutOrStdout(), merged)
+
+	return true
+}
+
+// writeConfigFields writes fields as a table of their keys, values and origins, masking sensitive values.
+// Fields resolved more than once, such as by both configs of upgrade, are written once.
+func writeConfigFields(w io.Writer, fields []configField) {
+	written := map[string]bool{}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tVALUE\tSOURCE")
+	for _, field := range fields {
+		if written[field.key] {
+			continue
+		}
+		written[field.key] = true
+
+		value := field.value
+		if sensitiveFlags[field.flag] {
+			value = maskCredential(value)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", field.key, valueOrDefault(value, "-"), field.origin())
+	}
+	tw.Flush()
+}
+
+// addShowConfigFlag adds --show-config to cmd.
+func addShowConfigFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool(installer.ShowConfigFlag, false, "print the effective config, with the source of each field, and exit")
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+)
+
+const testConfigFile = `apiVersion: storageos.com/v1
+kind: KubectlStorageOSConfig
+spec:
+  install:
+    storageOSVersion: v2.8.0
+    etcdEndpoints: 10.0.0.1:2379
+    enableMetrics: false
+    readinessTimeout: 2m
+`
+
+func TestSetInstallValuesLayers(t *testing.T) {
+	tcases := []struct {
+		name       string
+		configFile string
+		env        map[string]string
+		flags      map[string]string
+		expFields  map[string]string
+		expMetrics *bool
+		expErr     bool
+	}{
+		{
+			name: "defaults",
+			expFields: map[string]string{
+				installer.InstallStosVersionConfig: "=default",
+				installer.EtcdSecretNameConfig:     "storageos-etcd-secret=default",
+				installer.ReadinessTimeoutConfig:   "5m0s=default",
+			},
+		},
+		{
+			name:       "config file over defaults",
+			configFile: testConfigFile,
+			expFields: map[string]string{
+				installer.InstallStosVersionConfig: "v2.8.0=config file",
+				installer.EtcdEndpointsConfig:      "10.0.0.1:2379=config file",
+				installer.EtcdSecretNameConfig:     "storageos-etcd-secret=default",
+				installer.ReadinessTimeoutConfig:   "2m=config file",
+			},
+			expMetrics: new(bool),
+		},
+		{
+			name:       "env over config file",
+			configFile: testConfigFile,
+			env:        map[string]string{"KUBECTL_STORAGEOS_STOS_VERSION": "v2.9.0", "KUBECTL_STORAGEOS_ETCD_ENDPOINTS": ""},
+			expFields: map[string]string{
+				installer.InstallStosVersionConfig: "v2.9.0=env KUBECTL_STORAGEOS_STOS_VERSION",
+				installer.EtcdEndpointsConfig:      "10.0.0.1:2379=config file",
+			},
+			expMetrics: new(bool),
+		},
+		{
+			name:       "flags over env",
+			configFile: testConfigFile,
+			env:        map[string]string{"KUBECTL_STORAGEOS_STOS_VERSION": "v2.9.0"},
+			flags:      map[string]string{installer.StosVersionFlag: "v2.10.0", installer.EnableMetricsFlag: "true"},
+			expFields: map[string]string{
+				installer.InstallStosVersionConfig: "v2.10.0=flag --stos-version",
+				installer.EnableMetricsConfig:      "true=flag --enable-metrics",
+			},
+			expMetrics: func() *bool { enabled := true; return &enabled }(),
+		},
+		{
+			name:   "invalid env value",
+			env:    map[string]string{"KUBECTL_STORAGEOS_WAIT": "maybe"},
+			expErr: true,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := withRootFlags(InstallCmd())
+			if tc.configFile != "" {
+				dir := t.TempDir()
+				if err := os.WriteFile(filepath.Join(dir, configFileName+".yaml"), []byte(tc.configFile), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				if err := cmd.Flags().Set(installer.StosConfigPathFlag, dir); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+			for name, value := range tc.flags {
+				if err := cmd.Flags().Set(name, value); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			config := &apiv1.KubectlStorageOSConfig{}
+			fields, err := setInstallValues(cmd, config)
+			if (err != nil) != tc.expErr {
+				t.Fatalf("%s: expected error %v, got %v", tc.name, tc.expErr, err)
+			}
+			byKey := map[string]string{}
+			for _, field := range fields {
+				byKey[field.key] = field.value + "=" + field.origin()
+			}
+			for key, expected := range tc.expFields {
+				if byKey[key] != expected {
+					t.Errorf("%s: %s: expected %v, got %v", tc.name, key, expected, byKey[key])
+				}
+			}
+			if (config.Spec.Install.EnableMetrics == nil) != (tc.expMetrics == nil) ||
+				(tc.expMetrics != nil && *config.Spec.Install.EnableMetrics != *tc.expMetrics) {
+				t.Errorf("%s: expected enable metrics %v, got %v", tc.name, tc.expMetrics, config.Spec.Install.EnableMetrics)
+			}
+		})
+	}
+}
+
+func TestWriteConfigFields(t *testing.T) {
+	fields := []configField{
+		{key: installer.AdminUsernameConfig, flag: installer.AdminUsernameFlag, value: "admin", source: sourceConfigFile},
+		{key: installer.AdminPasswordConfig, flag: installer.AdminPasswordFlag, value: "password", source: sourceFlag},
+		{key: installer.PortalSecretConfig, flag: installer.PortalSecretFlag, source: sourceDefault},
+		{key: installer.AdminUsernameConfig, flag: installer.AdminUsernameFlag, value: "other", source: sourceEnv},
+	}
+	out := &bytes.Buffer{}
+	writeConfigFields(out, fields)
+
+	expected := `FIELD                       VALUE     SOURCE
+spec.install.adminUsername  admin     config file
+spec.install.adminPassword  ********  flag --admin-password
+spec.install.portalSecret   -         default
+`
+	if out.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,457 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/consts"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+const (
+	configName = "config"
+
+	configForceFlag = "force"
+
+	configFormatYAML  = "yaml"
+	configFormatTable = "table"
+)
+
+// configSetter sets the values of a command in config, as resolved from its layers.
+type configSetter func(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) ([]configField, error)
+
+// configCommand is a command whose effective config can be viewed.
+type configCommand struct {
+	newCmd  func() *cobra.Command
+	setters []configSetter
+}
+
+var configCommands = map[string]configCommand{
+	install:         {InstallCmd, []configSetter{setInstallValues}},
+	uninstall:       {UninstallCmd, []configSetter{setUninstallValues}},
+	upgrade:         {UpgradeCmd, []configSetter{setUpgradeUninstallValues, setUpgradeInstallValues}},
+	installPortal:   {InstallPortalCmd, []configSetter{setInstallPortalValues}},
+	uninstallPortal: {UninstallPortalCmd, []configSetter{setUninstallPortalValues}},
+	enablePortal:    {EnablePortalCmd, []configSetter{setEnablePortalValues}},
+	disablePortal:   {DisablePortalCmd, []configSetter{setDisablePortalValues}},
+}
+
+func ConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   configName,
+		Short: "Create, validate and view the kubectl-storageos config file",
+		Long: fmt.Sprintf(`Create, validate and view %s.yaml, the config file read from the directory set by
+--%s. Its fields are those of the %s CRD in config/crd.`, configFileName, installer.StosConfigPathFlag, installer.ConfigKind),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+	cmd.PersistentFlags().Bool(installer.StackTraceFlag, false, "print stack trace of error")
+	cmd.PersistentFlags().String(installer.StosConfigPathFlag, "", "path to look for kubectl-storageos-config.yaml")
+
+	cmd.AddCommand(configInitCmd())
+	cmd.AddCommand(configValidateCmd())
+	cmd.AddCommand(configViewCmd())
+
+	return cmd
+}
+
+func configInitCmd() *cobra.Command {
+	var err error
+	var path string
+	pluginLogger := logger.NewLogger()
+	cmd := &cobra.Command{
+		Use:   "init",
+		Args:  cobra.NoArgs,
+		Short: "Create a config file interactively",
+		Long: `Create a config file by answering a few questions about the install. The file is written to the directory
+set by --stos-config-path, or the current directory. Credentials are not asked for, set them with flags, env vars
+or references such as file:/path/to/password.`,
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			defer pluginutils.ConvertPanicToError(func(e error) {
+				err = e
+			})
+
+			path = configFilePath(cmd)
+			force, _ := cmd.Flags().GetBool(configForceFlag)
+			if _, statErr := os.Stat(path); statErr == nil && !force {
+				err = errors.Errorf("%s already exists, use --%s to overwrite it", path, configForceFlag)
+				return
+			}
+
+			err = configInit(path, func(prompt promptui.Prompt) (string, error) {
+				return pluginutils.AskUser(prompt, pluginLogger)
+			})
+		},
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			traceError, _ := cmd.Flags().GetBool(installer.StackTraceFlag)
+			if err := pluginutils.HandleError(configName, err, traceError); err != nil {
+				pluginLogger.Error(fmt.Sprintf("%s init%s", configName, " has failed"))
+				return err
+			}
+			pluginLogger.Successf("Config written to %s.", path)
+			return nil
+		},
+	}
+	cmd.Flags().Bool(configForceFlag, false, "overwrite an existing config file")
+
+	return cmd
+}
+
+func configValidateCmd() *cobra.Command {
+	var err error
+	var path string
+	pluginLogger := logger.NewLogger()
+	cmd := &cobra.Command{
+		Use:   "validate [file]",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Validate a config file against the schema of its CRD",
+		Long: `Validate a config file against the schema of its CRD, rejecting fields which are unknown to it, such as
+misspelled ones, and values of the wrong type. The file defaults to the one in the directory set by --stos-config-path.`,
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			defer pluginutils.ConvertPanicToError(func(e error) {
+				err = e
+			})
+
+			path = configFilePath(cmd)
+			if len(args) > 0 {
+				path = args[0]
+			}
+			err = validateConfigFile(cmd.OutOrStdout(), path)
+		},
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			traceError, _ := cmd.Flags().GetBool(installer.StackTraceFlag)
+			if err := pluginutils.HandleError(configName, err, traceError); err != nil {
+				pluginLogger.Error(fmt.Sprintf("%s validate%s", configName, " has failed"))
+				return err
+			}
+			pluginLogger.Successf("%s is valid.", path)
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func configViewCmd() *cobra.Command {
+	var err error
+	pluginLogger := logger.NewLogger()
+	commands := make([]string, 0, len(configCommands))
+	for name := range configCommands {
+		commands = append(commands, name)
+	}
+	sort.Strings(commands)
+
+	cmd := &cobra.Command{
+		Use:   "view [command]",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Print the effective config of a command",
+		Long: fmt.Sprintf(`Print the effective config of a command, one of %s, defaulting to install. It is merged from the
+defaults of the command, the config file and env vars as the command would merge it. Sensitive values are masked.`,
+			strings.Join(commands, ", ")),
+		Example: `
+$ kubectl storageos config view --stos-config-path ./prod
+$ kubectl storageos config view upgrade -o table
+`,
+		ValidArgs:    commands,
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			defer pluginutils.ConvertPanicToError(func(e error) {
+				err = e
+			})
+
+			command := install
+			if len(args) > 0 {
+				command = args[0]
+			}
+			format, _ := cmd.Flags().GetString(installer.OutputFlag)
+			path, _ := cmd.Flags().GetString(installer.StosConfigPathFlag)
+
+			err = viewConfig(cmd.OutOrStdout(), command, path, format)
+		},
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			traceError, _ := cmd.Flags().GetBool(installer.StackTraceFlag)
+			if err := pluginutils.HandleError(configName, err, traceError); err != nil {
+				pluginLogger.Error(fmt.Sprintf("%s view%s", configName, " has failed"))
+				return err
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringP(installer.OutputFlag, "o", configFormatYAML, fmt.Sprintf("output format, one of %q or %q, which shows the source of each field", configFormatYAML, configFormatTable))
+
+	return cmd
+}
+
+// configFilePath returns the path of the config file in the directory set by --stos-config-path or its
+// env var.
+func configFilePath(cmd *cobra.Command) string {
+	layers := &configLayers{flags: cmd.Flags(), lookupEnv: os.LookupEnv}
+	dir, _ := layers.resolve(installer.StosConfigPathFlag, "")
+	return filepath.Join(valueOrDefault(dir, "."), configFileName+".yaml")
+}
+
+// validateConfigFile writes the invalid fields of the config file at path to w, returning an error if
+// there are any.
+func validateConfigFile(w io.Writer, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	fieldErrs, err := installer.ValidateConfig(data)
+	if err != nil {
+		return err
+	}
+	for _, fieldErr := range fieldErrs {
+		fmt.Fprintln(w, fieldErr.Error())
+	}
+	if len(fieldErrs) != 0 {
+		return errors.Errorf("%s has %d invalid fields", path, len(fieldErrs))
+	}
+
+	return nil
+}
+
+// viewConfig writes the effective config of command, with the config file read from path, to w.
+func viewConfig(w io.Writer, command, path, format string) error {
+	configCmd, ok := configCommands[command]
+	if !ok {
+		return errors.Errorf("unknown command %q", command)
+	}
+	if format != configFormatYAML && format != configFormatTable {
+		return errors.Errorf("unknown format %q, expected %q or %q", format, configFormatYAML, configFormatTable)
+	}
+
+	cmd := withRootFlags(configCmd.newCmd())
+	if path != "" {
+		if err := cmd.Flags().Set(installer.StosConfigPathFlag, path); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	config := &apiv1.KubectlStorageOSConfig{}
+	fields := []configField{}
+	for _, setValues := range configCmd.setters {
+		setterFields, err := setValues(cmd, config)
+		if err != nil {
+			return err
+		}
+		fields = append(fields, setterFields...)
+	}
+
+	if format == configFormatTable {
+		writeConfigFields(w, fields)
+		return nil
+	}
+	config.Spec.Install.AdminPassword = maskCredential(config.Spec.Install.AdminPassword)
+	config.Spec.Install.PortalClientID = maskCredential(config.Spec.Install.PortalClientID)
+	config.Spec.Install.PortalSecret = maskCredential(config.Spec.Install.PortalSecret)
+	data, err := marshalConfig(config.Spec)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+
+	return errors.WithStack(err)
+}
+
+// marshalConfig returns spec as the YAML of a config file, without the fields which are unset.
+func marshalConfig(spec apiv1.KubectlStorageOSConfigSpec) ([]byte, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	specFields := map[string]interface{}{}
+	if err = json.Unmarshal(data, &specFields); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	pruneConfig(specFields)
+
+	data, err = yaml.Marshal(map[string]interface{}{
+		"apiVersion": apiv1.GroupVersion.String(),
+		"kind":       installer.ConfigKind,
+		"metadata":   map[string]interface{}{"name": configFileName},
+		"spec":       specFields,
+	})
+
+	return data, errors.WithStack(err)
+}
+
+// pruneConfig removes the fields of object which are unset, being empty objects or zero durations, which
+// omitempty keeps.
+func pruneConfig(object map[string]interface{}) {
+	for key, value := range object {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			pruneConfig(value)
+			if len(value) == 0 {
+				delete(object, key)
+			}
+		case string:
+			if value == "0s" {
+				delete(object, key)
+			}
+		}
+	}
+}
+
+// configInit asks the questions of the config wizard with ask, writing the config to path.
+func configInit(path string, ask func(promptui.Prompt) (string, error)) error {
+	spec, err := configInitSpec(ask)
+	if err != nil {
+		return err
+	}
+	data, err := marshalConfig(spec)
+	if err != nil {
+		return err
+	}
+	fieldErrs, err := installer.ValidateConfig(data)
+	if err != nil {
+		return err
+	}
+	if len(fieldErrs) != 0 {
+		return fieldErrs.ToAggregate()
+	}
+
+	return errors.WithStack(os.WriteFile(path, data, 0o600))
+}
+
+var (
+	namespaceRegexp    = regexp.MustCompile("^[a-z0-9]([-a-z0-9]*[a-z0-9])?$")
+	versionRegexp      = regexp.MustCompile(`^v[0-9]+\.[0-9]+\.[0-9]+$`)
+	resourceNameRegexp = regexp.MustCompile("^[a-z0-9.-]+$")
+	etcdEndpointRegexp = regexp.MustCompile("^[a-z0-9,.:-]+$")
+)
+
+// configInitSpec returns the spec of a config, as answered to the questions asked with ask.
+func configInitSpec(ask func(promptui.Prompt) (string, error)) (apiv1.KubectlStorageOSConfigSpec, error) {
+	spec := apiv1.KubectlStorageOSConfigSpec{}
+	install := &spec.Install
+	var err error
+
+	questions := []struct {
+		value    *string
+		label    string
+		def      string
+		optional bool
+		pattern  *regexp.Regexp
+	}{
+		{&install.StorageOSVersion, "StorageOS version, empty for the latest", "", true, versionRegexp},
+		{&install.KubernetesVersion, "Version of target Kubernetes cluster, empty to discover it", "", true, versionRegexp},
+		{&install.StorageOSOperatorNamespace, "StorageOS operator namespace", consts.NewOperatorNamespace, false, namespaceRegexp},
+		{&install.StorageOSClusterNamespace, "StorageOS cluster namespace", consts.NewOperatorNamespace, false, namespaceRegexp},
+	}
+	for _, q := range questions {
+		if *q.value, err = askConfigValue(ask, q.label, q.def, q.optional, q.pattern); err != nil {
+			return spec, err
+		}
+	}
+
+	if spec.IncludeEtcd, err = askConfigBool(ask, "Install non-production etcd"); err != nil {
+		return spec, err
+	}
+	if spec.IncludeEtcd {
+		if install.EtcdNamespace, err = askConfigValue(ask, "ETCD namespace", consts.EtcdOperatorNamespace, false, namespaceRegexp); err != nil {
+			return spec, err
+		}
+		if install.EtcdStorageClassName, err = askConfigValue(ask, "ETCD storage class name", "", false, resourceNameRegexp); err != nil {
+			return spec, err
+		}
+	} else {
+		if install.EtcdEndpoints, err = askConfigValue(ask, "ETCD endpoint(s)", "", false, etcdEndpointRegexp); err != nil {
+			return spec, err
+		}
+		if install.EtcdTLSEnabled, err = askConfigBool(ask, "ETCD is TLS enabled"); err != nil {
+			return spec, err
+		}
+		if install.EtcdTLSEnabled {
+			if install.EtcdSecretName, err = askConfigValue(ask, "ETCD TLS secret name", consts.EtcdSecretName, false, resourceNameRegexp); err != nil {
+				return spec, err
+			}
+		}
+	}
+
+	if install.EnablePortalManager, err = askConfigBool(ask, "Enable portal manager"); err != nil {
+		return spec, err
+	}
+	if install.EnablePortalManager {
+		if install.PortalTenantID, err = askConfigValue(ask, "Portal tenant id", "", false, nil); err != nil {
+			return spec, err
+		}
+		if install.PortalAPIURL, err = askConfigValue(ask, "Portal API URL", "", false, nil); err != nil {
+			return spec, err
+		}
+	}
+
+	return spec, nil
+}
+
+// askConfigValue asks for a value, which must match pattern if set, defaulting to def.
+func askConfigValue(ask func(promptui.Prompt) (string, error), label, def string, optional bool, pattern *regexp.Regexp) (string, error) {
+	prompt := promptui.Prompt{
+		Label:   label,
+		Default: def,
+		Validate: func(input string) error {
+			if input == "" {
+				if optional {
+					return nil
+				}
+				return errors.New("a value is required")
+			}
+			if pattern != nil && !pattern.MatchString(input) {
+				return errors.Errorf("invalid entry, must match %s", pattern.String())
+			}
+			return nil
+		},
+	}
+	value, err := ask(prompt)
+	if err != nil {
+		return "", err
+	}
+	if err = prompt.Validate(value); err != nil {
+		return "", errors.Wrap(err, label)
+	}
+
+	return value, nil
+}
+
+// askConfigBool asks a yes or no question, defaulting to no.
+func askConfigBool(ask func(promptui.Prompt) (string, error), label string) (bool, error) {
+	prompt := promptui.Prompt{
+		Label: label + " [y/N]",
+		Validate: func(input string) error {
+			switch strings.ToLower(input) {
+			case "", "n", "no", "y", "yes":
+				return nil
+			}
+			return errors.New("invalid input")
+		},
+	}
+	value, err := ask(prompt)
+	if err != nil {
+		return false, err
+	}
+	if err = prompt.Validate(value); err != nil {
+		return false, errors.Wrap(err, label)
+	}
+
+	answer := strings.ToLower(value)
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/manifoldco/promptui"
+)
+
+func TestConfigInit(t *testing.T) {
+	tcases := []struct {
+		name    string
+		answers map[string]string
+		expYAML string
+		expErr  bool
+	}{
+		{
+			name: "external etcd with tls",
+			answers: map[string]string{
+				"StorageOS version, empty for the latest":                    "v2.8.0",
+				"Version of target Kubernetes cluster, empty to discover it": "",
+				"StorageOS operator namespace":                               "storageos",
+				"StorageOS cluster namespace":                                "ondat",
+				"Install non-production etcd [y/N]":                          "n",
+				"ETCD endpoint(s)":                                           "10.0.0.1:2379,10.0.0.2:2379",
+				"ETCD is TLS enabled [y/N]":                                  "y",
+				"ETCD TLS secret name":                                       "storageos-etcd-secret",
+				"Enable portal manager [y/N]":                                "",
+			},
+			expYAML: `apiVersion: storageos.com/v1
+kind: KubectlStorageOSConfig
+metadata:
+  name: kubectl-storageos-config
+spec:
+  install:
+    etcdEndpoints: 10.0.0.1:2379,10.0.0.2:2379
+    etcdSecretName: storageos-etcd-secret
+    etcdTLSEnabled: true
+    storageOSClusterNamespace: ondat
+    storageOSOperatorNamespace: storageos
+    storageOSVersion: v2.8.0
+`,
+		},
+		{
+			name: "etcd and portal manager",
+			answers: map[string]string{
+				"StorageOS version, empty for the latest":                    "",
+				"Version of target Kubernetes cluster, empty to discover it": "v1.25.0",
+				"StorageOS operator namespace":                               "storageos",
+				"StorageOS cluster namespace":                                "storageos",
+				"Install non-production etcd [y/N]":                          "yes",
+				"ETCD namespace":                                             "storageos-etcd",
+				"ETCD storage class name":                                    "standard",
+				"Enable portal manager [y/N]":                                "y",
+				"Portal tenant id":                                           "tenant",
+				"Portal API URL":                                             "https://portal.example.com",
+			},
+			expYAML: `apiVersion: storageos.com/v1
+kind: KubectlStorageOSConfig
+metadata:
+  name: kubectl-storageos-config
+spec:
+  includeEtcd: true
+  install:
+    enablePortalManager: true
+    etcdNamespace: storageos-etcd
+    etcdStorageClassName: standard
+    k8sVersion: v1.25.0
+    portalAPIURL: https://portal.example.com
+    portalTenantID: tenant
+    storageOSClusterNamespace: storageos
+    storageOSOperatorNamespace: storageos
+`,
+		},
+		{
+			name: "invalid answer",
+			answers: map[string]string{
+				"StorageOS version, empty for the latest": "latest",
+			},
+			expErr: true,
+		},
+	}
+
+	for _, tc := range tcases {
+		path := filepath.Join(t.TempDir(), configFileName+".yaml")
+		err := configInit(path, func(prompt promptui.Prompt) (string, error) {
+			return tc.answers[prompt.Label.(string)], nil
+		})
+		if (err != nil) != tc.expErr {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.expErr, err)
+			continue
+		}
+		if tc.expErr {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tc.expYAML {
+			t.Errorf("%s: expected\n%s\ngot\n%s", tc.name, tc.expYAML, string(data))
+		}
+	}
+}
+
+func TestViewConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, configFileName+".yaml"), []byte(testConfigFile+"    adminPassword: secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECTL_STORAGEOS_STOS_CLUSTER_NAMESPACE", "ondat")
+
+	tcases := []struct {
+		name        string
+		command     string
+		format      string
+		expContains []string
+		expErr      bool
+	}{
+		{
+			name:    "install yaml",
+			command: install,
+			format:  configFormatYAML,
+			expContains: []string{
+				"kind: KubectlStorageOSConfig\n",
+				"storageOSVersion: v2.8.0\n",
+				"storageOSClusterNamespace: ondat\n",
+				"etcdSecretName: storageos-etcd-secret\n",
+				"adminPassword: '********'\n",
+				"readinessTimeout: 2m0s\n",
+			},
+		},
+		{
+			name:    "upgrade table",
+			command: upgrade,
+			format:  configFormatTable,
+			expContains: []string{
+				"spec.install.storageOSVersion v2.8.0 config file\n",
+				"spec.uninstall.removalTimeout 5m0s default\n",
+			},
+		},
+		{
+			name:    "unknown command",
+			command: "status",
+			format:  configFormatYAML,
+			expErr:  true,
+		},
+	}
+
+	for _, tc := range tcases {
+		out := &bytes.Buffer{}
+		err := viewConfig(out, tc.command, dir, tc.format)
+		if (err != nil) != tc.expErr {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.expErr, err)
+			continue
+		}
+		// ignore the padding of table columns
+		output := regexp.MustCompile(" +").ReplaceAllString(out.String(), " ")
+		for _, expected := range tc.expContains {
+			if !strings.Contains(output, expected) {
+				t.Errorf("%s: expected %q in\n%s", tc.name, expected, out.String())
+			}
+		}
+	}
+}
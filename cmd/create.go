@@ -1,16 +1,29 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 
+	"github.com/storageos/kubectl-storageos/pkg/apiclient"
 	"github.com/storageos/kubectl-storageos/pkg/forwarder"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
 	"github.com/storageos/kubectl-storageos/pkg/logger"
 )
 
+const (
+	createSizeFlag        = "size"
+	createFsTypeFlag      = "fs-type"
+	createDescriptionFlag = "description"
+	createReplicasFlag    = "replicas"
+)
+
 func CreateCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:                "create",
-		Short:              "Create new resources",
+		Use:   "create",
+		Short: "Create new resources",
+		Long: `Create volumes and namespaces through the StorageOS API. Other resources are created by the StorageOS CLI
+pod.`,
 		Args:               cobra.MinimumNArgs(0),
 		SilenceUsage:       true,
 		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
@@ -20,6 +33,90 @@ func CreateCmd() *cobra.Command {
 			return forwarder.ForwardToCLIPod(logger.NewLogger(), storageosCommand(cmd, args))
 		},
 	}
+	cmd.AddCommand(createVolumeCmd())
+	cmd.AddCommand(createNamespaceCmd())
+
+	return cmd
+}
+
+func createVolumeCmd() *cobra.Command {
+	cmd := apiCmd(&cobra.Command{
+		Use:     "volume NAME",
+		Aliases: []string{"vol"},
+		Args:    cobra.ExactArgs(1),
+		Short:   "Create a volume",
+		Example: `
+$ kubectl storageos create volume my-volume --size 10GiB --replicas 1 -n my-namespace
+`,
+	}, func(cmd *cobra.Command, args []string, client *apiclient.Client) error {
+		size, err := parseSize(cmd.Flags().Lookup(createSizeFlag).Value.String())
+		if err != nil {
+			return err
+		}
+		labels, err := parseLabels(cmd.Flags().Lookup(apiLabelsFlag).Value.String())
+		if err != nil {
+			return err
+		}
+		if cmd.Flags().Changed(createReplicasFlag) {
+			labels[replicasLabel] = cmd.Flags().Lookup(createReplicasFlag).Value.String()
+		}
+		namespace, err := apiNamespace(cmd, client)
+		if err != nil {
+			return err
+		}
+
+		volume, err := client.CreateVolume(namespace.ID, apiclient.CreateVolumeRequest{
+			Name:        args[0],
+			Description: cmd.Flags().Lookup(createDescriptionFlag).Value.String(),
+			FsType:      cmd.Flags().Lookup(createFsTypeFlag).Value.String(),
+			SizeBytes:   size,
+			Labels:      labels,
+		})
+		if err != nil {
+			return err
+		}
+
+		if output := cmd.Flags().Lookup(installer.OutputFlag).Value.String(); output != "" {
+			return writeAPIObject(cmd.OutOrStdout(), output, volume)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "volume/%s created\n", volume.Name)
+		return nil
+	})
+	addAPINamespaceFlag(cmd)
+	addAPIOutputFlag(cmd, false)
+	cmd.Flags().String(createSizeFlag, "5GiB", "size of the volume, such as 500MiB or 10GiB")
+	cmd.Flags().String(createFsTypeFlag, "ext4", "filesystem of the volume, one of ext4, xfs or block")
+	cmd.Flags().String(createDescriptionFlag, "", "description of the volume")
+	cmd.Flags().Uint(createReplicasFlag, 0, "number of replicas of the volume")
+	cmd.Flags().String(apiLabelsFlag, "", "labels of the volume as key=value[,key=value]")
+
+	return cmd
+}
+
+func createNamespaceCmd() *cobra.Command {
+	cmd := apiCmd(&cobra.Command{
+		Use:     "namespace NAME",
+		Aliases: []string{"ns"},
+		Args:    cobra.ExactArgs(1),
+		Short:   "Create a storageos namespace",
+	}, func(cmd *cobra.Command, args []string, client *apiclient.Client) error {
+		labels, err := parseLabels(cmd.Flags().Lookup(apiLabelsFlag).Value.String())
+		if err != nil {
+			return err
+		}
+		namespace, err := client.CreateNamespace(args[0], labels)
+		if err != nil {
+			return err
+		}
+
+		if output := cmd.Flags().Lookup(installer.OutputFlag).Value.String(); output != "" {
+			return writeAPIObject(cmd.OutOrStdout(), output, namespace)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "namespace/%s created\n", namespace.Name)
+		return nil
+	})
+	addAPIOutputFlag(cmd, false)
+	cmd.Flags().String(apiLabelsFlag, "", "labels of the namespace as key=value[,key=value]")
 
 	return cmd
 }
@@ -1,16 +1,21 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 
+	"github.com/storageos/kubectl-storageos/pkg/apiclient"
 	"github.com/storageos/kubectl-storageos/pkg/forwarder"
 	"github.com/storageos/kubectl-storageos/pkg/logger"
 )
 
 func DeleteCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:                "delete",
-		Short:              "Delete resources in the cluster",
+		Use:   "delete",
+		Short: "Delete resources in the cluster",
+		Long: `Delete volumes and namespaces through the StorageOS API. Other resources are deleted by the StorageOS CLI
+pod.`,
 		Args:               cobra.MinimumNArgs(0),
 		SilenceUsage:       true,
 		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
@@ -20,6 +25,57 @@ func DeleteCmd() *cobra.Command {
 			return forwarder.ForwardToCLIPod(logger.NewLogger(), storageosCommand(cmd, args))
 		},
 	}
+	cmd.AddCommand(deleteVolumeCmd())
+	cmd.AddCommand(deleteNamespaceCmd())
 
 	return cmd
 }
+
+func deleteVolumeCmd() *cobra.Command {
+	cmd := apiCmd(&cobra.Command{
+		Use:     "volume NAME...",
+		Aliases: []string{"volumes", "vol"},
+		Args:    cobra.MinimumNArgs(1),
+		Short:   "Delete detached volumes",
+	}, func(cmd *cobra.Command, args []string, client *apiclient.Client) error {
+		namespace, err := apiNamespace(cmd, client)
+		if err != nil {
+			return err
+		}
+		for _, name := range args {
+			volume, err := client.GetVolumeByName(namespace.ID, name)
+			if err != nil {
+				return err
+			}
+			if err = client.DeleteVolume(volume); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "volume/%s deleted\n", volume.Name)
+		}
+		return nil
+	})
+	addAPINamespaceFlag(cmd)
+
+	return cmd
+}
+
+func deleteNamespaceCmd() *cobra.Command {
+	return apiCmd(&cobra.Command{
+		Use:     "namespace NAME...",
+		Aliases: []string{"namespaces", "ns"},
+		Args:    cobra.MinimumNArgs(1),
+		Short:   "Delete storageos namespaces without volumes",
+	}, func(cmd *cobra.Command, args []string, client *apiclient.Client) error {
+		for _, name := range args {
+			namespace, err := client.GetNamespaceByName(name)
+			if err != nil {
+				return err
+			}
+			if err = client.DeleteNamespace(namespace); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "namespace/%s deleted\n", namespace.Name)
+		}
+		return nil
+	})
+}
@@ -1,16 +1,25 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
 	"github.com/spf13/cobra"
 
+	"github.com/storageos/kubectl-storageos/pkg/apiclient"
 	"github.com/storageos/kubectl-storageos/pkg/forwarder"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
 	"github.com/storageos/kubectl-storageos/pkg/logger"
 )
 
 func DescribeCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:                "describe",
-		Short:              "Fetch extended details for resources",
+		Use:   "describe",
+		Short: "Fetch extended details for resources",
+		Long: `Fetch extended details for volumes and nodes from the StorageOS API. Other resources are described by the
+StorageOS CLI pod.`,
 		Args:               cobra.MinimumNArgs(0),
 		SilenceUsage:       true,
 		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
@@ -20,6 +29,120 @@ func DescribeCmd() *cobra.Command {
 			return forwarder.ForwardToCLIPod(logger.NewLogger(), storageosCommand(cmd, args))
 		},
 	}
+	cmd.AddCommand(describeVolumeCmd())
+	cmd.AddCommand(describeNodeCmd())
+
+	return cmd
+}
+
+func describeVolumeCmd() *cobra.Command {
+	cmd := apiCmd(&cobra.Command{
+		Use:     "volume NAME",
+		Aliases: []string{"volumes", "vol"},
+		Args:    cobra.ExactArgs(1),
+		Short:   "Fetch extended details for a volume",
+	}, func(cmd *cobra.Command, args []string, client *apiclient.Client) error {
+		namespace, err := apiNamespace(cmd, client)
+		if err != nil {
+			return err
+		}
+		volume, err := client.GetVolumeByName(namespace.ID, args[0])
+		if err != nil {
+			return err
+		}
+
+		output := cmd.Flags().Lookup(installer.OutputFlag).Value.String()
+		if output != "" {
+			return writeAPIObject(cmd.OutOrStdout(), output, volume)
+		}
+		nodes, err := client.ListNodes()
+		if err != nil {
+			return err
+		}
+		describeVolume(cmd.OutOrStdout(), volumeRows([]apiclient.Volume{*volume}, []apiclient.Namespace{*namespace}, nodes)[0], nodes)
+		return nil
+	})
+	addAPINamespaceFlag(cmd)
+	addAPIOutputFlag(cmd, false)
+
+	return cmd
+}
+
+func describeNodeCmd() *cobra.Command {
+	cmd := apiCmd(&cobra.Command{
+		Use:     "node NAME",
+		Aliases: []string{"nodes"},
+		Args:    cobra.ExactArgs(1),
+		Short:   "Fetch extended details for a node",
+	}, func(cmd *cobra.Command, args []string, client *apiclient.Client) error {
+		node, err := client.GetNodeByName(args[0])
+		if err != nil {
+			return err
+		}
+
+		output := cmd.Flags().Lookup(installer.OutputFlag).Value.String()
+		if output != "" {
+			return writeAPIObject(cmd.OutOrStdout(), output, node)
+		}
+		describeNode(cmd.OutOrStdout(), node)
+		return nil
+	})
+	addAPIOutputFlag(cmd, false)
 
 	return cmd
 }
+
+func describeVolume(w io.Writer, row volumeRow, nodes []apiclient.Node) {
+	nodeNames := map[string]string{}
+	for _, node := range nodes {
+		nodeNames[node.ID] = node.Name
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%s\n", row.volume.ID)
+	fmt.Fprintf(tw, "Name:\t%s\n", row.volume.Name)
+	fmt.Fprintf(tw, "Namespace:\t%s\n", row.namespace)
+	fmt.Fprintf(tw, "Description:\t%s\n", valueOrDefault(row.volume.Description, "-"))
+	fmt.Fprintf(tw, "Size:\t%s\n", formatSize(row.volume.SizeBytes))
+	fmt.Fprintf(tw, "Filesystem:\t%s\n", row.volume.FsType)
+	fmt.Fprintf(tw, "Attached on:\t%s\n", valueOrDefault(row.attachedOn, "-"))
+	fmt.Fprintf(tw, "Attachment type:\t%s\n", valueOrDefault(row.volume.AttachmentType, "-"))
+	if row.volume.NFS != nil && row.volume.NFS.ServiceEndpoint != "" {
+		fmt.Fprintf(tw, "NFS endpoint:\t%s\n", row.volume.NFS.ServiceEndpoint)
+	}
+	fmt.Fprintf(tw, "Labels:\t%s\n", formatLabels(row.volume.Labels))
+	fmt.Fprintf(tw, "Created:\t%s\n", row.volume.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(tw, "Updated:\t%s\n", row.volume.UpdatedAt.Format(time.RFC3339))
+	fmt.Fprintf(tw, "Version:\t%s\n", row.volume.Version)
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "DEPLOYMENT\tID\tNODE\tHEALTH")
+	if row.volume.Master != nil {
+		fmt.Fprintf(tw, "master\t%s\t%s\t%s\n", row.volume.Master.ID, valueOrDefault(nodeNames[row.volume.Master.NodeID], row.volume.Master.NodeID), row.volume.Master.Health)
+	}
+	for _, replica := range row.volume.Replicas {
+		fmt.Fprintf(tw, "replica\t%s\t%s\t%s\n", replica.ID, valueOrDefault(nodeNames[replica.NodeID], replica.NodeID), replica.Health)
+	}
+	tw.Flush()
+}
+
+func describeNode(w io.Writer, node *apiclient.Node) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%s\n", node.ID)
+	fmt.Fprintf(tw, "Name:\t%s\n", node.Name)
+	fmt.Fprintf(tw, "Health:\t%s\n", node.Health)
+	fmt.Fprintf(tw, "IO endpoint:\t%s\n", valueOrDefault(node.IOEndpoint, "-"))
+	fmt.Fprintf(tw, "Supervisor endpoint:\t%s\n", valueOrDefault(node.SupervisorEndpoint, "-"))
+	fmt.Fprintf(tw, "Gossip endpoint:\t%s\n", valueOrDefault(node.GossipEndpoint, "-"))
+	fmt.Fprintf(tw, "Clustering endpoint:\t%s\n", valueOrDefault(node.ClusteringEndpoint, "-"))
+	if node.Capacity != nil {
+		fmt.Fprintf(tw, "Capacity:\t%s (%s free)\n", formatSize(node.Capacity.Total), formatSize(node.Capacity.Free))
+	}
+	fmt.Fprintf(tw, "Labels:\t%s\n", formatLabels(node.Labels))
+	fmt.Fprintf(tw, "Created:\t%s\n", node.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(tw, "Updated:\t%s\n", node.UpdatedAt.Format(time.RFC3339))
+	fmt.Fprintf(tw, "Version:\t%s\n", node.Version)
+	tw.Flush()
+}
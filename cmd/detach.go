@@ -1,25 +1,34 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 
-	"github.com/storageos/kubectl-storageos/pkg/forwarder"
-	"github.com/storageos/kubectl-storageos/pkg/logger"
+	"github.com/storageos/kubectl-storageos/pkg/apiclient"
 )
 
 func DetachCmd() *cobra.Command {
-	cmd := &cobra.Command{
-		Use:                "detach",
-		Short:              "Detach a volume from its current location",
-		Args:               cobra.MinimumNArgs(0),
-		SilenceUsage:       true,
-		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
-		DisableFlagParsing: true,
-
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return forwarder.ForwardToCLIPod(logger.NewLogger(), storageosCommand(cmd, args))
-		},
-	}
+	cmd := apiCmd(&cobra.Command{
+		Use:   "detach VOLUME",
+		Args:  cobra.ExactArgs(1),
+		Short: "Detach a volume from its current location",
+	}, func(cmd *cobra.Command, args []string, client *apiclient.Client) error {
+		namespace, err := apiNamespace(cmd, client)
+		if err != nil {
+			return err
+		}
+		volume, err := client.GetVolumeByName(namespace.ID, args[0])
+		if err != nil {
+			return err
+		}
+		if err = client.DetachVolume(volume); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "volume/%s detached\n", volume.Name)
+		return nil
+	})
+	addAPINamespaceFlag(cmd)
 
 	return cmd
 }
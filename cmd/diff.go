@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	utilexec "k8s.io/client-go/util/exec"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+	"github.com/storageos/kubectl-storageos/pkg/version"
+)
+
+const diff = "diff"
+
+// Exit codes of diff, as for kubectl diff.
+const (
+	diffChangesExitCode = 1
+	diffErrorExitCode   = 2
+)
+
+// diffHiddenFlags are the flags of install, upgrade and enable-portal which have no effect on a diff.
+var diffHiddenFlags = []string{
+	installer.DryRunFlag,
+	installer.WaitFlag,
+	installer.SerialFlag,
+	installer.ReadinessTimeoutFlag,
+	installer.ServerSideApplyFlag,
+	installer.ForceConflictsFlag,
+	installer.FromBundleFlag,
+	installer.SkipEtcdEndpointsValFlag,
+	installer.EtcdValidationModeFlag,
+	installer.SkipExistingWorkloadCheckFlag,
+	installer.SkipNamespaceDeletionFlag,
+	installer.ResumeFlag,
+	installer.SkipRollbackFlag,
+	installer.RemovalTimeoutFlag,
+}
+
+// diffConfigFunc returns the validated config of the operation to diff, read from the flags of cmd or
+// from the config file, and the config of its uninstall phase for upgrade.
+type diffConfigFunc func(cmd *cobra.Command, log *logger.Logger) (config, uninstallConfig *apiv1.KubectlStorageOSConfig, err error)
+
+func DiffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   diff,
+		Short: "Show the changes install, upgrade or enable-portal would make to the cluster",
+		Long: `Show the changes install, upgrade or enable-portal would make to the cluster, without making any.
+Manifests are rendered as for the operation and compared to the live objects with a server-side dry-run apply.
+Secret data is shown as a digest. Objects an upgrade would delete without recreating them are listed too.
+As for kubectl diff, exits with status 1 if any object would be changed, and greater than 1 on failure.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(diffRunCmd(installer.InstallOperation, "Show the changes install would make to the cluster", addInstallFlags, diffInstallConfig))
+	cmd.AddCommand(diffRunCmd(installer.UpgradeOperation, "Show the changes upgrade would make to the cluster", addUpgradeFlags, diffUpgradeConfig))
+	cmd.AddCommand(diffRunCmd(installer.EnablePortalOperation, "Show the changes enable-portal would make to the cluster", addEnablePortalFlags, diffEnablePortalConfig))
+
+	return cmd
+}
+
+// diffRunCmd returns a command printing the changes of operation, taking the flags of the operation.
+func diffRunCmd(operation, short string, addFlags func(cmd *cobra.Command), configFn diffConfigFunc) *cobra.Command {
+	var err error
+	var traceError bool
+	var verbose bool
+	var diffs []pluginutils.ObjectDiff
+	pluginLogger := logger.NewLogger()
+	cmd := &cobra.Command{
+		Use:          operation,
+		Args:         cobra.NoArgs,
+		Short:        short,
+		SilenceUsage: true,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			// bound on run, as the flags are shared with the command of the operation
+			viper.BindPFlags(cmd.Flags())
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			defer pluginutils.ConvertPanicToError(func(e error) {
+				err = e
+			})
+
+			var config, uninstallConfig *apiv1.KubectlStorageOSConfig
+			config, uninstallConfig, err = configFn(cmd, pluginLogger)
+			if config != nil {
+				traceError = config.Spec.StackTrace
+				verbose = config.Spec.Verbose
+			}
+			if err != nil {
+				return
+			}
+
+			var cliInstaller *installer.Installer
+			if cliInstaller, err = installer.NewDiffInstaller(config, uninstallConfig, operation, pluginLogger); err != nil {
+				return
+			}
+			diffs, err = cliInstaller.Diff(operation)
+		},
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			if err := pluginutils.HandleError(diff, err, traceError); err != nil {
+				pluginLogger.Error(fmt.Sprintf("%s %s%s", diff, operation, " has failed"))
+				return utilexec.CodeExitError{Err: err, Code: diffErrorExitCode}
+			}
+			writeDiffs(cmd.OutOrStdout(), diffs, verbose)
+			if len(diffs) > 0 {
+				return utilexec.CodeExitError{Err: fmt.Errorf("%d object(s) would be changed by %s", len(diffs), operation), Code: diffChangesExitCode}
+			}
+			return nil
+		},
+	}
+	addFlags(cmd)
+	for _, flag := range diffHiddenFlags {
+		if cmd.Flags().Lookup(flag) != nil {
+			cmd.Flags().MarkHidden(flag)
+		}
+	}
+
+	return cmd
+}
+
+func diffInstallConfig(cmd *cobra.Command, log *logger.Logger) (*apiv1.KubectlStorageOSConfig, *apiv1.KubectlStorageOSConfig, error) {
+	config := &apiv1.KubectlStorageOSConfig{}
+	if _, err := setInstallValues(cmd, config); err != nil {
+		return nil, nil, err
+	}
+	log.Verbose = config.Spec.Verbose
+
+	return config, nil, completeInstallConfig(config, log)
+}
+
+func diffUpgradeConfig(cmd *cobra.Command, log *logger.Logger) (*apiv1.KubectlStorageOSConfig, *apiv1.KubectlStorageOSConfig, error) {
+	uninstallConfig := &apiv1.KubectlStorageOSConfig{}
+	if _, err := setUpgradeUninstallValues(cmd, uninstallConfig); err != nil {
+		return nil, nil, err
+	}
+	installConfig := &apiv1.KubectlStorageOSConfig{}
+	if _, err := setUpgradeInstallValues(cmd, installConfig); err != nil {
+		return nil, nil, err
+	}
+	log.Verbose = installConfig.Spec.Verbose
+
+	if err := installer.FlagsAreSet(upgradeFlagsFilter(uninstallConfig, installConfig)); err != nil {
+		return installConfig, uninstallConfig, err
+	}
+
+	state := recordedInstallState(log)
+	if err := setStorageOSVersionsInConfigs(uninstallConfig, installConfig, state, log); err != nil {
+		return installConfig, uninstallConfig, err
+	}
+	if err := setPortalManagerVersionsInConfigs(uninstallConfig, installConfig, state, log); err != nil {
+		return installConfig, uninstallConfig, err
+	}
+
+	if err := completeCredentials(&installConfig.Spec.Install); err != nil {
+		return installConfig, uninstallConfig, err
+	}
+
+	if err := validateImageRewrite(installConfig.Spec.Install); err != nil {
+		return installConfig, uninstallConfig, err
+	}
+
+	return installConfig, uninstallConfig, nil
+}
+
+func diffEnablePortalConfig(cmd *cobra.Command, log *logger.Logger) (*apiv1.KubectlStorageOSConfig, *apiv1.KubectlStorageOSConfig, error) {
+	config := &apiv1.KubectlStorageOSConfig{}
+	if _, err := setEnablePortalValues(cmd, config); err != nil {
+		return nil, nil, err
+	}
+	log.Verbose = config.Spec.Verbose
+
+	existingOperatorVersion, err := version.GetExistingOperatorVersion(config.Spec.Install.StorageOSOperatorNamespace)
+	if err != nil {
+		return config, nil, err
+	}
+
+	return config, nil, operatorSupportsFeature(existingOperatorVersion, version.FeaturePortalManager)
+}
+
+// writeDiffs writes the changes of each object to w, or that there are none. The fields of objects to be
+// created or deleted are only written if verbose is set.
+func writeDiffs(w io.Writer, diffs []pluginutils.ObjectDiff, verbose bool) {
+	if len(diffs) == 0 {
+		fmt.Fprintln(w, "No changes.")
+		return
+	}
+
+	for _, objectDiff := range diffs {
+		name := objectDiff.Name
+		if objectDiff.Namespace != "" {
+			name = objectDiff.Namespace + "/" + name
+		}
+		symbol := "~"
+		switch objectDiff.Action {
+		case pluginutils.DiffCreate:
+			symbol = "+"
+		case pluginutils.DiffDelete:
+			symbol = "-"
+		}
+		fmt.Fprintf(w, "%s %s %s %s\n", symbol, objectDiff.APIVersion, objectDiff.Kind, name)
+		if objectDiff.Action != pluginutils.DiffUpdate && !verbose {
+			continue
+		}
+
+		for _, change := range objectDiff.Changes {
+			switch {
+			case change.Live == "":
+				fmt.Fprintf(w, "    + %s: %s\n", change.Path, change.Applied)
+			case change.Applied == "":
+				fmt.Fprintf(w, "    - %s: %s\n", change.Path, change.Live)
+			default:
+				fmt.Fprintf(w, "    ~ %s: %s -> %s\n", change.Path, change.Live, change.Applied)
+			}
+		}
+	}
+}
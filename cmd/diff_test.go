@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/spf13/cobra"
+	utilexec "k8s.io/client-go/util/exec"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+func TestWriteDiffs(t *testing.T) {
+	clusterDiff := pluginutils.ObjectDiff{
+		APIVersion: "storageos.com/v1",
+		Kind:       "StorageOSCluster",
+		Namespace:  "storageos",
+		Name:       "storageoscluster",
+		Action:     pluginutils.DiffUpdate,
+		Changes: []pluginutils.FieldChange{
+			{Path: "spec.enablePortalManager", Applied: "true"},
+			{Path: "spec.images.nodeContainer", Live: `"storageos/node:v2.7.0"`, Applied: `"storageos/node:v2.8.0"`},
+			{Path: "spec.tolerations", Live: "[]"},
+		},
+	}
+	configMapDiff := pluginutils.ObjectDiff{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Namespace:  "storageos",
+		Name:       "storageos-portal-manager",
+		Action:     pluginutils.DiffCreate,
+		Changes: []pluginutils.FieldChange{
+			{Path: "data.URL", Applied: `"https://portal.storageos.com"`},
+		},
+	}
+
+	roleDiff := pluginutils.ObjectDiff{
+		APIVersion: "rbac.authorization.k8s.io/v1",
+		Kind:       "ClusterRole",
+		Name:       "storageos:scheduler-extender",
+		Action:     pluginutils.DiffDelete,
+		Changes: []pluginutils.FieldChange{
+			{Path: "metadata.name", Live: `"storageos:scheduler-extender"`},
+		},
+	}
+
+	tcases := []struct {
+		name     string
+		diffs    []pluginutils.ObjectDiff
+		verbose  bool
+		expected string
+	}{
+		{
+			name:     "no changes",
+			expected: "No changes.\n",
+		},
+		{
+			name:  "update and create",
+			diffs: []pluginutils.ObjectDiff{clusterDiff, configMapDiff},
+			expected: `~ storageos.com/v1 StorageOSCluster storageos/storageoscluster
+    + spec.enablePortalManager: true
+    ~ spec.images.nodeContainer: "storageos/node:v2.7.0" -> "storageos/node:v2.8.0"
+    - spec.tolerations: []
++ v1 ConfigMap storageos/storageos-portal-manager
+`,
+		},
+		{
+			name:    "verbose create",
+			diffs:   []pluginutils.ObjectDiff{configMapDiff},
+			verbose: true,
+			expected: `+ v1 ConfigMap storageos/storageos-portal-manager
+    + data.URL: "https://portal.storageos.com"
+`,
+		},
+		{
+			name:     "delete",
+			diffs:    []pluginutils.ObjectDiff{roleDiff},
+			expected: "- rbac.authorization.k8s.io/v1 ClusterRole storageos:scheduler-extender\n",
+		},
+		{
+			name:    "verbose delete",
+			diffs:   []pluginutils.ObjectDiff{roleDiff},
+			verbose: true,
+			expected: `- rbac.authorization.k8s.io/v1 ClusterRole storageos:scheduler-extender
+    - metadata.name: "storageos:scheduler-extender"
+`,
+		},
+	}
+
+	for _, tc := range tcases {
+		buf := &bytes.Buffer{}
+		writeDiffs(buf, tc.diffs, tc.verbose)
+		if buf.String() != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, buf.String())
+		}
+	}
+}
+
+func TestDiffFailureExitCode(t *testing.T) {
+	configFn := func(cmd *cobra.Command, log *logger.Logger) (*apiv1.KubectlStorageOSConfig, *apiv1.KubectlStorageOSConfig, error) {
+		return nil, nil, errors.New("invalid config")
+	}
+	cmd := diffRunCmd(installer.InstallOperation, "", func(cmd *cobra.Command) {}, configFn)
+	cmd.SetArgs([]string{})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+
+	var exitErr utilexec.ExitError
+	err := cmd.Execute()
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("expected exit error, got %v", err)
+	}
+	if exitErr.ExitStatus() != diffErrorExitCode {
+		t.Errorf("expected %v, got %v", diffErrorExitCode, exitErr.ExitStatus())
+	}
+}
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"k8s.io/client-go/util/retry"
@@ -19,7 +17,7 @@ const disablePortal = "disable-portal"
 
 func DisablePortalCmd() *cobra.Command {
 	var err error
-	var traceError bool
+	var traceError, configShown bool
 	pluginLogger := logger.NewLogger()
 	cmd := &cobra.Command{
 		Use:          disablePortal,
@@ -34,20 +32,30 @@ func DisablePortalCmd() *cobra.Command {
 			})
 
 			config := &apiv1.KubectlStorageOSConfig{}
-			if err = setDisablePortalValues(cmd, config); err != nil {
+			var fields []configField
+			if fields, err = setDisablePortalValues(cmd, config); err != nil {
+				return
+			}
+			if configShown = showConfig(cmd, fields); configShown {
 				return
 			}
 
 			traceError = config.Spec.StackTrace
+			if err = pluginLogger.SetOutput(config.Spec.Output); err != nil {
+				return
+			}
 
 			err = disablePortalCmd(config, pluginLogger)
 		},
 		PostRunE: func(cmd *cobra.Command, args []string) error {
+			if configShown {
+				return nil
+			}
 			if err := pluginutils.HandleError(disablePortal, err, traceError); err != nil {
-				pluginLogger.Error(fmt.Sprintf("%s%s", disablePortal, " has failed"))
+				pluginLogger.Result(disablePortal, err, "Portal Manager successfully disabled.")
 				return err
 			}
-			pluginLogger.Success("Portal Manager successfully disabled.")
+			pluginLogger.Result(disablePortal, nil, "Portal Manager successfully disabled.")
 			return nil
 		},
 	}
@@ -55,6 +63,7 @@ func DisablePortalCmd() *cobra.Command {
 	cmd.Flags().BoolP(installer.VerboseFlag, "v", false, "verbose logging")
 	cmd.Flags().String(installer.StosConfigPathFlag, "", "path to look for kubectl-storageos-config.yaml")
 	cmd.Flags().String(installer.StosOperatorNSFlag, consts.NewOperatorNamespace, "namespace of storageos operator")
+	addShowConfigFlag(cmd)
 
 	viper.BindPFlags(cmd.Flags())
 
@@ -68,7 +77,7 @@ func disablePortalCmd(config *apiv1.KubectlStorageOSConfig, log *logger.Logger)
 		return err
 	}
 
-	if err := versionSupportsFeature(existingOperatorVersion, consts.PortalManagerFirstSupportedVersion); err != nil {
+	if err := operatorSupportsFeature(existingOperatorVersion, version.FeaturePortalManager); err != nil {
 		return err
 	}
 
@@ -83,33 +92,16 @@ func disablePortalCmd(config *apiv1.KubectlStorageOSConfig, log *logger.Logger)
 	})
 }
 
-func setDisablePortalValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) error {
-	viper.BindPFlag(installer.StosConfigPathFlag, cmd.Flags().Lookup(installer.StosConfigPathFlag))
-	v := viper.GetViper()
-	viper.SetConfigName("kubectl-storageos-config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(v.GetString(installer.StosConfigPathFlag))
-
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			// Config file was found but another error was produced
-			return fmt.Errorf("error discovered in config file: %v", err)
-		}
-		// Config file not found; set fields in new config object directly
-		config.Spec.StackTrace, err = cmd.Flags().GetBool(installer.StackTraceFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Verbose, err = cmd.Flags().GetBool(installer.VerboseFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Install.StorageOSOperatorNamespace = cmd.Flags().Lookup(installer.StosOperatorNSFlag).Value.String()
-		return nil
+func setDisablePortalValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) ([]configField, error) {
+	layers, err := newConfigLayers(cmd)
+	if err != nil {
+		return nil, err
 	}
-	// config file read without error, set fields in new config object
-	config.Spec.StackTrace = viper.GetBool(installer.StackTraceConfig)
-	config.Spec.Verbose = viper.GetBool(installer.VerboseConfig)
-	config.Spec.Install.StorageOSOperatorNamespace = viper.GetString(installer.InstallStosOperatorNSConfig)
-	return nil
+
+	config.Spec.StackTrace = layers.Bool(installer.StackTraceFlag, installer.StackTraceConfig)
+	config.Spec.Verbose = layers.Bool(installer.VerboseFlag, installer.VerboseConfig)
+	config.Spec.Output = layers.String(installer.OutputFlag, installer.OutputConfig)
+	config.Spec.Install.StorageOSOperatorNamespace = layers.String(installer.StosOperatorNSFlag, installer.InstallStosOperatorNSConfig)
+
+	return layers.fields, layers.Err()
 }
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"k8s.io/client-go/util/retry"
@@ -19,7 +17,7 @@ const enablePortal = "enable-portal"
 
 func EnablePortalCmd() *cobra.Command {
 	var err error
-	var traceError bool
+	var traceError, configShown bool
 	pluginLogger := logger.NewLogger()
 	cmd := &cobra.Command{
 		Use:          enablePortal,
@@ -34,33 +32,49 @@ func EnablePortalCmd() *cobra.Command {
 			})
 
 			config := &apiv1.KubectlStorageOSConfig{}
-			if err = setEnablePortalValues(cmd, config); err != nil {
+			var fields []configField
+			if fields, err = setEnablePortalValues(cmd, config); err != nil {
+				return
+			}
+			if configShown = showConfig(cmd, fields); configShown {
 				return
 			}
 
 			traceError = config.Spec.StackTrace
+			if err = pluginLogger.SetOutput(config.Spec.Output); err != nil {
+				return
+			}
 
 			err = enablePortalCmd(config, pluginLogger)
 		},
 		PostRunE: func(cmd *cobra.Command, args []string) error {
+			if configShown {
+				return nil
+			}
 			if err := pluginutils.HandleError(enablePortal, err, traceError); err != nil {
-				pluginLogger.Error(fmt.Sprintf("%s%s", enablePortal, " has failed"))
+				pluginLogger.Result(enablePortal, err, "Portal Manager enabled successfully.")
 				return err
 			}
-			pluginLogger.Success("Portal Manager enabled successfully.")
+			pluginLogger.Result(enablePortal, nil, "Portal Manager enabled successfully.")
 			return nil
 		},
 	}
-	cmd.Flags().Bool(installer.StackTraceFlag, false, "print stack trace of error")
-	cmd.Flags().BoolP(installer.VerboseFlag, "v", false, "verbose logging")
-	cmd.Flags().String(installer.StosConfigPathFlag, "", "path to look for kubectl-storageos-config.yaml")
-	cmd.Flags().String(installer.StosOperatorNSFlag, consts.NewOperatorNamespace, "namespace of storageos operator")
+	addEnablePortalFlags(cmd)
+	addShowConfigFlag(cmd)
 
 	viper.BindPFlags(cmd.Flags())
 
 	return cmd
 }
 
+// addEnablePortalFlags adds the flags of enable-portal to cmd.
+func addEnablePortalFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool(installer.StackTraceFlag, false, "print stack trace of error")
+	cmd.Flags().BoolP(installer.VerboseFlag, "v", false, "verbose logging")
+	cmd.Flags().String(installer.StosConfigPathFlag, "", "path to look for kubectl-storageos-config.yaml")
+	cmd.Flags().String(installer.StosOperatorNSFlag, consts.NewOperatorNamespace, "namespace of storageos operator")
+}
+
 func enablePortalCmd(config *apiv1.KubectlStorageOSConfig, log *logger.Logger) error {
 	log.Verbose = config.Spec.Verbose
 	existingOperatorVersion, err := version.GetExistingOperatorVersion(config.Spec.Install.StorageOSOperatorNamespace)
@@ -68,7 +82,7 @@ func enablePortalCmd(config *apiv1.KubectlStorageOSConfig, log *logger.Logger) e
 		return err
 	}
 
-	if err := versionSupportsFeature(existingOperatorVersion, consts.PortalManagerFirstSupportedVersion); err != nil {
+	if err := operatorSupportsFeature(existingOperatorVersion, version.FeaturePortalManager); err != nil {
 		return err
 	}
 
@@ -82,33 +96,16 @@ func enablePortalCmd(config *apiv1.KubectlStorageOSConfig, log *logger.Logger) e
 	})
 }
 
-func setEnablePortalValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) error {
-	viper.BindPFlag(installer.StosConfigPathFlag, cmd.Flags().Lookup(installer.StosConfigPathFlag))
-	v := viper.GetViper()
-	viper.SetConfigName("kubectl-storageos-config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(v.GetString(installer.StosConfigPathFlag))
-
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			// Config file was found but another error was produced
-			return fmt.Errorf("error discovered in config file: %v", err)
-		}
-		// Config file not found; set fields in new config object directly
-		config.Spec.StackTrace, err = cmd.Flags().GetBool(installer.StackTraceFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Verbose, err = cmd.Flags().GetBool(installer.VerboseFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Install.StorageOSOperatorNamespace = cmd.Flags().Lookup(installer.StosOperatorNSFlag).Value.String()
-		return nil
+func setEnablePortalValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) ([]configField, error) {
+	layers, err := newConfigLayers(cmd)
+	if err != nil {
+		return nil, err
 	}
-	// config file read without error, set fields in new config object
-	config.Spec.StackTrace = viper.GetBool(installer.StackTraceConfig)
-	config.Spec.Verbose = viper.GetBool(installer.VerboseConfig)
-	config.Spec.Install.StorageOSOperatorNamespace = viper.GetString(installer.InstallStosOperatorNSConfig)
-	return nil
+
+	config.Spec.StackTrace = layers.Bool(installer.StackTraceFlag, installer.StackTraceConfig)
+	config.Spec.Verbose = layers.Bool(installer.VerboseFlag, installer.VerboseConfig)
+	config.Spec.Output = layers.String(installer.OutputFlag, installer.OutputConfig)
+	config.Spec.Install.StorageOSOperatorNamespace = layers.String(installer.StosOperatorNSFlag, installer.InstallStosOperatorNSConfig)
+
+	return layers.fields, layers.Err()
 }
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+const (
+	etcd = "etcd"
+
+	etcdRestoreOutputFlag                   = "output"
+	etcdRestoreNameFlag                     = "name"
+	etcdRestoreInitialClusterFlag           = "initial-cluster"
+	etcdRestoreInitialAdvertisePeerURLsFlag = "initial-advertise-peer-urls"
+)
+
+// etcdRunFunc runs an etcd operation with an etcd installer, returning output to print.
+type etcdRunFunc func(cmd *cobra.Command, args []string, cliInstaller *installer.Installer) (string, error)
+
+func EtcdCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   etcd,
+		Short: "Day-2 operations on the ETCD cluster of StorageOS",
+		Long: `Day-2 operations on the ETCD cluster of StorageOS, run with etcdctl through a temporary etcd shell pod.
+ETCD endpoints, TLS and secret default to those of the StorageOS cluster.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+	cmd.PersistentFlags().Bool(installer.StackTraceFlag, false, "print stack trace of error")
+	cmd.PersistentFlags().BoolP(installer.VerboseFlag, "v", false, "verbose logging")
+	cmd.PersistentFlags().String(installer.StosConfigPathFlag, "", "path to look for kubectl-storageos-config.yaml")
+	cmd.PersistentFlags().String(installer.EtcdEndpointsFlag, "", "endpoints of the etcd cluster (default those of the storageos cluster)")
+	cmd.PersistentFlags().Bool(installer.EtcdTLSEnabledFlag, false, "etcd cluster is tls enabled")
+	cmd.PersistentFlags().String(installer.EtcdSecretNameFlag, "", "name of etcd secret in storageos cluster namespace")
+	cmd.PersistentFlags().String(installer.StosClusterNSFlag, "", "namespace of the storageos cluster, where the etcd shell pod runs")
+
+	cmd.AddCommand(etcdRunCmd("status", "Show the status of each ETCD endpoint", cobra.NoArgs,
+		func(cmd *cobra.Command, args []string, cliInstaller *installer.Installer) (string, error) {
+			return cliInstaller.EtcdStatus()
+		}))
+	cmd.AddCommand(etcdMemberCmd())
+	cmd.AddCommand(etcdEndpointCmd())
+	cmd.AddCommand(etcdRunCmd("defrag", "Defragment the backend database of each ETCD endpoint", cobra.NoArgs,
+		func(cmd *cobra.Command, args []string, cliInstaller *installer.Installer) (string, error) {
+			return cliInstaller.EtcdDefrag()
+		}))
+	cmd.AddCommand(etcdSnapshotCmd())
+	cmd.AddCommand(etcdRunCmd("compact [revision]", "Compact the ETCD keyspace at revision (default the current revision)", cobra.MaximumNArgs(1),
+		func(cmd *cobra.Command, args []string, cliInstaller *installer.Installer) (string, error) {
+			var revision int64
+			if len(args) > 0 {
+				var err error
+				if revision, err = strconv.ParseInt(args[0], 10, 64); err != nil {
+					return "", fmt.Errorf("invalid revision %q: %v", args[0], err)
+				}
+			}
+			_, err := cliInstaller.EtcdCompact(revision)
+			return "", err
+		}))
+
+	return cmd
+}
+
+func etcdMemberCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "member",
+		Short:        "ETCD cluster membership",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+	cmd.AddCommand(etcdRunCmd("list", "List the members of the ETCD cluster", cobra.NoArgs,
+		func(cmd *cobra.Command, args []string, cliInstaller *installer.Installer) (string, error) {
+			return cliInstaller.EtcdMemberList()
+		}))
+
+	return cmd
+}
+
+func etcdEndpointCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "endpoint",
+		Short:        "ETCD endpoints",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+	cmd.AddCommand(etcdRunCmd("health", "Check the health of each ETCD endpoint", cobra.NoArgs,
+		func(cmd *cobra.Command, args []string, cliInstaller *installer.Installer) (string, error) {
+			return cliInstaller.EtcdEndpointHealth()
+		}))
+
+	return cmd
+}
+
+func etcdSnapshotCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "snapshot",
+		Short:        "Save and restore ETCD snapshots",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+	cmd.AddCommand(etcdRunCmd("save [file]", "Save a snapshot of the ETCD keyspace to file", cobra.ExactArgs(1),
+		func(cmd *cobra.Command, args []string, cliInstaller *installer.Installer) (string, error) {
+			return "", cliInstaller.EtcdSnapshotSave(args[0])
+		}))
+
+	restoreCmd := etcdRunCmd("restore [file]", "Restore an ETCD snapshot to a data directory archive", cobra.ExactArgs(1),
+		func(cmd *cobra.Command, args []string, cliInstaller *installer.Installer) (string, error) {
+			options := installer.EtcdRestoreOptions{
+				Name:                     cmd.Flags().Lookup(etcdRestoreNameFlag).Value.String(),
+				InitialCluster:           cmd.Flags().Lookup(etcdRestoreInitialClusterFlag).Value.String(),
+				InitialAdvertisePeerURLs: cmd.Flags().Lookup(etcdRestoreInitialAdvertisePeerURLsFlag).Value.String(),
+			}
+			return "", cliInstaller.EtcdSnapshotRestore(args[0], cmd.Flags().Lookup(etcdRestoreOutputFlag).Value.String(), options)
+		})
+	restoreCmd.Long = `Restore an ETCD snapshot to a data directory, written as a tar.gz archive. The ETCD cluster is left untouched,
+the data directory must be put in place of each member's while the member is stopped.`
+	restoreCmd.Flags().String(etcdRestoreOutputFlag, "etcd-data.tar.gz", "path of the data directory archive to write")
+	restoreCmd.Flags().String(etcdRestoreNameFlag, "", "name of the member the data directory is restored for")
+	restoreCmd.Flags().String(etcdRestoreInitialClusterFlag, "", "initial cluster configuration of the restored cluster")
+	restoreCmd.Flags().String(etcdRestoreInitialAdvertisePeerURLsFlag, "", "peer urls of the member the data directory is restored for")
+	cmd.AddCommand(restoreCmd)
+
+	return cmd
+}
+
+// etcdRunCmd returns a command running fn with an etcd installer, printing the output returned.
+func etcdRunCmd(use, short string, args cobra.PositionalArgs, fn etcdRunFunc) *cobra.Command {
+	var err error
+	var traceError bool
+	var output string
+	pluginLogger := logger.NewLogger()
+	cmd := &cobra.Command{
+		Use:          use,
+		Args:         args,
+		Short:        short,
+		SilenceUsage: true,
+		PreRun:       func(cmd *cobra.Command, args []string) {},
+		Run: func(cmd *cobra.Command, args []string) {
+			defer pluginutils.ConvertPanicToError(func(e error) {
+				err = e
+			})
+
+			config := &apiv1.KubectlStorageOSConfig{}
+			if err = setEtcdValues(cmd, config); err != nil {
+				return
+			}
+
+			traceError = config.Spec.StackTrace
+			pluginLogger.Verbose = config.Spec.Verbose
+
+			var cliInstaller *installer.Installer
+			if cliInstaller, err = installer.NewEtcdInstaller(config, pluginLogger); err != nil {
+				return
+			}
+			output, err = fn(cmd, args, cliInstaller)
+		},
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			if err := pluginutils.HandleError(etcd, err, traceError); err != nil {
+				pluginLogger.Error(fmt.Sprintf("%s %s%s", etcd, cmd.Name(), " has failed"))
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), output)
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func setEtcdValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) error {
+	viper.BindPFlag(installer.StosConfigPathFlag, cmd.Flags().Lookup(installer.StosConfigPathFlag))
+	v := viper.GetViper()
+	viper.SetConfigName("kubectl-storageos-config")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath(v.GetString(installer.StosConfigPathFlag))
+
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			// Config file was found but another error was produced
+			return fmt.Errorf("error discovered in config file: %v", err)
+		}
+		// Config file not found; set fields in new config object directly
+		config.Spec.StackTrace, err = cmd.Flags().GetBool(installer.StackTraceFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.Verbose, err = cmd.Flags().GetBool(installer.VerboseFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.Install.EtcdTLSEnabled, err = cmd.Flags().GetBool(installer.EtcdTLSEnabledFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.Install.EtcdEndpoints = cmd.Flags().Lookup(installer.EtcdEndpointsFlag).Value.String()
+		config.Spec.Install.EtcdSecretName = cmd.Flags().Lookup(installer.EtcdSecretNameFlag).Value.String()
+		config.Spec.Install.StorageOSClusterNamespace = cmd.Flags().Lookup(installer.StosClusterNSFlag).Value.String()
+		return nil
+	}
+	// config file read without error, set fields in new config object
+	config.Spec.StackTrace = viper.GetBool(installer.StackTraceConfig)
+	config.Spec.Verbose = viper.GetBool(installer.VerboseConfig)
+	config.Spec.Install.EtcdEndpoints = viper.GetString(installer.EtcdEndpointsConfig)
+	config.Spec.Install.EtcdTLSEnabled = viper.GetBool(installer.EtcdTLSEnabledConfig)
+	config.Spec.Install.EtcdSecretName = viper.GetString(installer.EtcdSecretNameConfig)
+	config.Spec.Install.StorageOSClusterNamespace = viper.GetString(installer.StosClusterNSConfig)
+	return nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+const (
+	export = "export"
+
+	exportDirFlag    = "dir"
+	defaultExportDir = "storageos-export"
+)
+
+func ExportCmd() *cobra.Command {
+	var err error
+	var traceError bool
+	var dir string
+	pluginLogger := logger.NewLogger()
+	cmd := &cobra.Command{
+		Use:   export,
+		Args:  cobra.NoArgs,
+		Short: "Export the install manifests as a kustomize directory for GitOps",
+		Long: `Export the manifests of install to a directory, without installing anything. Each component is written
+as a kustomize directory holding its manifests, with the changes made by the install flags expressed as
+kustomization patches. A top-level kustomization.yaml includes the components in install order, so that Argo CD
+or Flux can reconcile the directory directly. It takes the same flags as install.`,
+		Example: `
+$ kubectl storageos export --dir ./clusters/prod/storageos --include-etcd --etcd-storage-class standard --k8s-version v1.24.0
+$ kubectl storageos export --dir ./storageos --etcd-endpoints 10.42.0.10:2379 --stos-cluster-namespace ondat --k8s-version v1.24.0
+`,
+		SilenceUsage: true,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			// bound on run, as the flags are shared with install
+			viper.BindPFlags(cmd.Flags())
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			defer pluginutils.ConvertPanicToError(func(e error) {
+				err = e
+			})
+
+			config := &apiv1.KubectlStorageOSConfig{}
+			if _, err = setInstallValues(cmd, config); err != nil {
+				return
+			}
+			traceError = config.Spec.StackTrace
+			dir = cmd.Flags().Lookup(exportDirFlag).Value.String()
+
+			err = exportCmd(config, dir, pluginLogger)
+		},
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			if err := pluginutils.HandleError(export, err, traceError); err != nil {
+				pluginLogger.Error(fmt.Sprintf("%s%s", export, " has failed"))
+				return err
+			}
+			pluginLogger.Successf("StorageOS manifests exported to %s.", dir)
+			return nil
+		},
+	}
+	addInstallFlags(cmd)
+	cmd.Flags().String(exportDirFlag, defaultExportDir, "directory to export the manifests to")
+	for _, flag := range diffHiddenFlags {
+		if cmd.Flags().Lookup(flag) != nil {
+			cmd.Flags().MarkHidden(flag)
+		}
+	}
+
+	return cmd
+}
+
+func exportCmd(config *apiv1.KubectlStorageOSConfig, dir string, log *logger.Logger) error {
+	log.Verbose = config.Spec.Verbose
+
+	if err := completeRenderConfig(config, log); err != nil {
+		return err
+	}
+
+	cliInstaller, err := installer.NewExportInstaller(config, log)
+	if err != nil {
+		return err
+	}
+	log.Commencing(export)
+
+	return cliInstaller.Export(dir)
+}
@@ -3,14 +3,18 @@ package cmd
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/storageos/kubectl-storageos/pkg/apiclient"
 	"github.com/storageos/kubectl-storageos/pkg/forwarder"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
 	"github.com/storageos/kubectl-storageos/pkg/logger"
 )
 
 func GetCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:                "get",
-		Short:              "Fetch basic details for resources",
+		Use:   "get",
+		Short: "Fetch basic details for resources",
+		Long: `Fetch basic details for volumes, nodes, namespaces and the licence from the StorageOS API. Other resources
+are fetched by the StorageOS CLI pod.`,
 		Args:               cobra.MinimumNArgs(0),
 		SilenceUsage:       true,
 		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
@@ -20,6 +24,168 @@ func GetCmd() *cobra.Command {
 			return forwarder.ForwardToCLIPod(logger.NewLogger(), storageosCommand(cmd, args))
 		},
 	}
+	cmd.AddCommand(getVolumesCmd())
+	cmd.AddCommand(getNodesCmd())
+	cmd.AddCommand(getNamespacesCmd())
+	cmd.AddCommand(getLicenceCmd())
 
 	return cmd
 }
+
+func getVolumesCmd() *cobra.Command {
+	cmd := apiCmd(&cobra.Command{
+		Use:     "volumes [NAME...]",
+		Aliases: []string{"volume", "vol", "vols"},
+		Short:   "Fetch basic details for volumes",
+		Example: `
+$ kubectl storageos get volumes
+$ kubectl storageos get volumes --all-namespaces -o wide
+$ kubectl storageos get volume my-volume -n my-namespace -o yaml
+`,
+	}, func(cmd *cobra.Command, args []string, client *apiclient.Client) error {
+		namespaces, err := apiNamespaces(cmd, client)
+		if err != nil {
+			return err
+		}
+		volumes := []apiclient.Volume{}
+		for _, namespace := range namespaces {
+			nsVolumes, err := client.ListVolumes(namespace.ID)
+			if err != nil {
+				return err
+			}
+			volumes = append(volumes, nsVolumes...)
+		}
+		if volumes, err = filterVolumes(volumes, args); err != nil {
+			return err
+		}
+
+		output := cmd.Flags().Lookup(installer.OutputFlag).Value.String()
+		if output == "" || output == outputWide {
+			nodes, err := client.ListNodes()
+			if err != nil {
+				return err
+			}
+			writeVolumes(cmd.OutOrStdout(), output, volumeRows(volumes, namespaces, nodes))
+			return nil
+		}
+		return writeAPIObject(cmd.OutOrStdout(), output, volumes)
+	})
+	addAPINamespaceFlag(cmd)
+	cmd.Flags().BoolP(apiAllNamespacesFlag, "A", false, "fetch volumes of all storageos namespaces")
+	addAPIOutputFlag(cmd, true)
+
+	return cmd
+}
+
+func getNodesCmd() *cobra.Command {
+	cmd := apiCmd(&cobra.Command{
+		Use:     "nodes [NAME...]",
+		Aliases: []string{"node"},
+		Short:   "Fetch basic details for nodes",
+	}, func(cmd *cobra.Command, args []string, client *apiclient.Client) error {
+		nodes, err := client.ListNodes()
+		if err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			selected := []apiclient.Node{}
+			for _, name := range args {
+				node, err := client.GetNodeByName(name)
+				if err != nil {
+					return err
+				}
+				selected = append(selected, *node)
+			}
+			nodes = selected
+		}
+
+		output := cmd.Flags().Lookup(installer.OutputFlag).Value.String()
+		if output == "" || output == outputWide {
+			writeNodes(cmd.OutOrStdout(), output, nodes)
+			return nil
+		}
+		return writeAPIObject(cmd.OutOrStdout(), output, nodes)
+	})
+	addAPIOutputFlag(cmd, true)
+
+	return cmd
+}
+
+func getNamespacesCmd() *cobra.Command {
+	cmd := apiCmd(&cobra.Command{
+		Use:     "namespaces [NAME...]",
+		Aliases: []string{"namespace", "ns"},
+		Short:   "Fetch basic details for storageos namespaces",
+	}, func(cmd *cobra.Command, args []string, client *apiclient.Client) error {
+		namespaces, err := client.ListNamespaces()
+		if err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			selected := []apiclient.Namespace{}
+			for _, name := range args {
+				namespace, err := client.GetNamespaceByName(name)
+				if err != nil {
+					return err
+				}
+				selected = append(selected, *namespace)
+			}
+			namespaces = selected
+		}
+
+		output := cmd.Flags().Lookup(installer.OutputFlag).Value.String()
+		if output == "" || output == outputWide {
+			writeNamespaces(cmd.OutOrStdout(), output, namespaces)
+			return nil
+		}
+		return writeAPIObject(cmd.OutOrStdout(), output, namespaces)
+	})
+	addAPIOutputFlag(cmd, true)
+
+	return cmd
+}
+
+func getLicenceCmd() *cobra.Command {
+	cmd := apiCmd(&cobra.Command{
+		Use:     "licence",
+		Aliases: []string{"license"},
+		Args:    cobra.NoArgs,
+		Short:   "Fetch the licence of the storageos cluster",
+	}, func(cmd *cobra.Command, args []string, client *apiclient.Client) error {
+		licence, err := client.GetLicence()
+		if err != nil {
+			return err
+		}
+
+		output := cmd.Flags().Lookup(installer.OutputFlag).Value.String()
+		if output == "" {
+			writeLicence(cmd.OutOrStdout(), licence)
+			return nil
+		}
+		return writeAPIObject(cmd.OutOrStdout(), output, licence)
+	})
+	addAPIOutputFlag(cmd, false)
+
+	return cmd
+}
+
+// filterVolumes returns the volumes called names, in the same order, or all volumes if names is empty.
+func filterVolumes(volumes []apiclient.Volume, names []string) ([]apiclient.Volume, error) {
+	if len(names) == 0 {
+		return volumes, nil
+	}
+	filtered := []apiclient.Volume{}
+	for _, name := range names {
+		found := false
+		for _, volume := range volumes {
+			if volume.Name == name {
+				filtered = append(filtered, volume)
+				found = true
+			}
+		}
+		if !found {
+			return nil, apiclient.NotFound("volume", name)
+		}
+	}
+	return filtered, nil
+}
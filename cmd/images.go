@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+const images = "images"
+
+func ImagesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          images,
+		Short:        "Container images of StorageOS releases",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(imagesListCmd())
+
+	return cmd
+}
+
+func imagesListCmd() *cobra.Command {
+	var err error
+	var traceError bool
+	var releaseImages []string
+	var rewriter *pluginutils.ImageRewriter
+	pluginLogger := logger.NewLogger()
+	cmd := &cobra.Command{
+		Use:   "list",
+		Args:  cobra.NoArgs,
+		Short: "List the images a StorageOS release pulls",
+		Long: `List the container images installing a StorageOS release pulls, one per line, to pre-seed a private registry.
+The latest versions are listed unless specified. With --registry-mirror or --image-mapping, each image is followed
+by the image it is pulled as.`,
+		SilenceUsage: true,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			defer pluginutils.ConvertPanicToError(func(e error) {
+				err = e
+			})
+
+			config := &apiv1.KubectlStorageOSConfig{}
+			if err = setImagesListValues(cmd, config); err != nil {
+				return
+			}
+
+			traceError = config.Spec.StackTrace
+			pluginLogger.Verbose = config.Spec.Verbose
+
+			if rewriter, err = pluginutils.NewImageRewriter(config.Spec.Install.RegistryMirror, config.Spec.Install.ImageMapping); err != nil {
+				return
+			}
+			if err = setReleaseVersions(config); err != nil {
+				return
+			}
+			releaseImages, err = installer.ReleaseImages(config)
+		},
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			if err := pluginutils.HandleError(images, err, traceError); err != nil {
+				pluginLogger.Error(fmt.Sprintf("%s list%s", images, " has failed"))
+				return err
+			}
+			writeImages(cmd.OutOrStdout(), releaseImages, rewriter)
+			return nil
+		},
+	}
+	cmd.Flags().Bool(installer.StackTraceFlag, false, "print stack trace of error")
+	cmd.Flags().BoolP(installer.VerboseFlag, "v", false, "verbose logging")
+	cmd.Flags().String(installer.StosConfigPathFlag, "", "path to look for kubectl-storageos-config.yaml")
+	cmd.Flags().String(installer.StosVersionFlag, "", "version of storageos operator")
+	cmd.Flags().String(installer.EtcdOperatorVersionFlag, "", "version of etcd operator")
+	cmd.Flags().String(installer.PortalManagerVersionFlag, "", "version of portal manager")
+	cmd.Flags().Bool(installer.IncludeEtcdFlag, false, "include the images of non-production etcd from github.com/storageos/etcd-cluster-operator")
+	cmd.Flags().Bool(installer.EnablePortalManagerFlag, false, "include the images of storageos portal manager")
+	cmd.Flags().Bool(installer.IncludeLocalPathProvisionerFlag, false, "include the images of the local path provisioner storage class")
+	cmd.Flags().String(installer.RegistryMirrorFlag, "", "registry to pull all images from, keeping their repositories")
+	cmd.Flags().String(installer.ImageMappingFlag, "", "path of a yaml file mapping image names, or prefixes of them, to the names to pull them as")
+
+	return cmd
+}
+
+// writeImages writes each image to w, followed by the image it is rewritten to if rewriter is enabled.
+func writeImages(w io.Writer, images []string, rewriter *pluginutils.ImageRewriter) {
+	for _, image := range images {
+		if rewriter == nil || !rewriter.Enabled() {
+			fmt.Fprintln(w, image)
+			continue
+		}
+		fmt.Fprintf(w, "%s %s\n", image, rewriter.Rewrite(image))
+	}
+}
+
+func setImagesListValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) error {
+	viper.BindPFlag(installer.StosConfigPathFlag, cmd.Flags().Lookup(installer.StosConfigPathFlag))
+	v := viper.GetViper()
+	viper.SetConfigName("kubectl-storageos-config")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath(v.GetString(installer.StosConfigPathFlag))
+
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			// Config file was found but another error was produced
+			return fmt.Errorf("error discovered in config file: %v", err)
+		}
+		// Config file not found; set fields in new config object directly
+		config.Spec.StackTrace, err = cmd.Flags().GetBool(installer.StackTraceFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.Verbose, err = cmd.Flags().GetBool(installer.VerboseFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.IncludeEtcd, err = cmd.Flags().GetBool(installer.IncludeEtcdFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.Install.EnablePortalManager, err = cmd.Flags().GetBool(installer.EnablePortalManagerFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.IncludeLocalPathProvisioner, err = cmd.Flags().GetBool(installer.IncludeLocalPathProvisionerFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.Install.StorageOSVersion = cmd.Flags().Lookup(installer.StosVersionFlag).Value.String()
+		config.Spec.Install.EtcdOperatorVersion = cmd.Flags().Lookup(installer.EtcdOperatorVersionFlag).Value.String()
+		config.Spec.Install.PortalManagerVersion = cmd.Flags().Lookup(installer.PortalManagerVersionFlag).Value.String()
+		config.Spec.Install.RegistryMirror = cmd.Flags().Lookup(installer.RegistryMirrorFlag).Value.String()
+		config.Spec.Install.ImageMapping = cmd.Flags().Lookup(installer.ImageMappingFlag).Value.String()
+		return nil
+	}
+	// config file read without error, set fields in new config object
+	config.Spec.StackTrace = viper.GetBool(installer.StackTraceConfig)
+	config.Spec.Verbose = viper.GetBool(installer.VerboseConfig)
+	config.Spec.IncludeEtcd = viper.GetBool(installer.IncludeEtcdConfig)
+	config.Spec.Install.EnablePortalManager = viper.GetBool(installer.EnablePortalManagerConfig)
+	config.Spec.IncludeLocalPathProvisioner = viper.GetBool(installer.IncludeLocalPathProvisionerConfig)
+	config.Spec.Install.StorageOSVersion = viper.GetString(installer.InstallStosVersionConfig)
+	config.Spec.Install.EtcdOperatorVersion = viper.GetString(installer.InstallEtcdOperatorVersionConfig)
+	config.Spec.Install.PortalManagerVersion = viper.GetString(installer.InstallPortalManagerVersionConfig)
+	config.Spec.Install.RegistryMirror = viper.GetString(installer.RegistryMirrorConfig)
+	config.Spec.Install.ImageMapping = viper.GetString(installer.ImageMappingConfig)
+	return nil
+}
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"k8s.io/client-go/util/retry"
@@ -19,7 +17,7 @@ const installPortal = "install-portal"
 
 func InstallPortalCmd() *cobra.Command {
 	var err error
-	var traceError bool
+	var traceError, configShown bool
 	pluginLogger := logger.NewLogger()
 	cmd := &cobra.Command{
 		Use:          installPortal,
@@ -34,20 +32,30 @@ func InstallPortalCmd() *cobra.Command {
 			})
 
 			config := &apiv1.KubectlStorageOSConfig{}
-			if err = setInstallPortalValues(cmd, config); err != nil {
+			var fields []configField
+			if fields, err = setInstallPortalValues(cmd, config); err != nil {
+				return
+			}
+			if configShown = showConfig(cmd, fields); configShown {
 				return
 			}
 
 			traceError = config.Spec.StackTrace
+			if err = pluginLogger.SetOutput(config.Spec.Output); err != nil {
+				return
+			}
 
 			err = installPortalCmd(config, pluginLogger)
 		},
 		PostRunE: func(cmd *cobra.Command, args []string) error {
+			if configShown {
+				return nil
+			}
 			if err := pluginutils.HandleError(installPortal, err, traceError); err != nil {
-				pluginLogger.Error(fmt.Sprintf("%s%s", installPortal, " has failed"))
+				pluginLogger.Result(installPortal, err, "Portal Manager installed successfully.")
 				return err
 			}
-			pluginLogger.Success("Portal Manager installed successfully.")
+			pluginLogger.Result(installPortal, nil, "Portal Manager installed successfully.")
 			return nil
 		},
 	}
@@ -57,13 +65,14 @@ func InstallPortalCmd() *cobra.Command {
 	cmd.Flags().String(installer.StosPortalConfigYamlFlag, "", "storageos-portal-configmap.yaml path or url")
 	cmd.Flags().String(installer.StosPortalClientSecretYamlFlag, "", "storageos-portal-client-secret.yaml path or url")
 	cmd.Flags().String(installer.StosOperatorNSFlag, consts.NewOperatorNamespace, "namespace of storageos operator")
-	cmd.Flags().String(installer.PortalClientIDFlag, "", "storageos portal client id (plaintext)")
-	cmd.Flags().String(installer.PortalSecretFlag, "", "storageos portal secret (plaintext)")
+	cmd.Flags().String(installer.PortalClientIDFlag, "", "storageos portal client id, "+credentialReferenceHelp)
+	cmd.Flags().String(installer.PortalSecretFlag, "", "storageos portal secret, "+credentialReferenceHelp)
 	cmd.Flags().String(installer.PortalTenantIDFlag, "", "storageos portal tenant id")
 	cmd.Flags().String(installer.PortalAPIURLFlag, "", "storageos portal url")
 	cmd.Flags().String(installer.PortalManagerVersionFlag, "", "version of portal manager")
 	cmd.Flags().String(installer.PortalHTTPSProxyFlag, "", "HTTPS proxy of portal manager")
 	cmd.Flags().Bool(installer.AirGapFlag, false, "install portal manger in an air gapped environment")
+	addShowConfigFlag(cmd)
 
 	viper.BindPFlags(cmd.Flags())
 
@@ -88,17 +97,24 @@ func installPortalCmd(config *apiv1.KubectlStorageOSConfig, log *logger.Logger)
 		return err
 	}
 
+	if err := completeCredentials(&config.Spec.Install); err != nil {
+		return err
+	}
+
 	existingOperatorVersion, err := version.GetExistingOperatorVersion(config.Spec.Install.StorageOSOperatorNamespace)
 	if err != nil {
 		return err
 	}
 
-	if err := versionSupportsFeature(existingOperatorVersion, consts.PortalManagerFirstSupportedVersion); err != nil {
+	if err := operatorSupportsFeature(existingOperatorVersion, version.FeaturePortalManager); err != nil {
 		return err
 	}
 
 	if config.Spec.Install.PortalManagerVersion == "" {
-		config.Spec.Install.PortalManagerVersion = version.PortalManagerLatestSupportedVersion()
+		var err error
+		if config.Spec.Install.PortalManagerVersion, err = version.PortalManagerLatestSupportedVersion(); err != nil {
+			return err
+		}
 	}
 	version.SetPortalManagerLatestSupportedVersion(config.Spec.Install.PortalManagerVersion)
 
@@ -117,55 +133,25 @@ func installPortalCmd(config *apiv1.KubectlStorageOSConfig, log *logger.Logger)
 	})
 }
 
-func setInstallPortalValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) error {
-	viper.BindPFlag(installer.StosConfigPathFlag, cmd.Flags().Lookup(installer.StosConfigPathFlag))
-	v := viper.GetViper()
-	viper.SetConfigName("kubectl-storageos-config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(v.GetString(installer.StosConfigPathFlag))
-
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			// Config file was found but another error was produced
-			return fmt.Errorf("error discovered in config file: %v", err)
-		}
-		// Config file not found; set fields in new config object directly
-		config.Spec.StackTrace, err = cmd.Flags().GetBool(installer.StackTraceFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Verbose, err = cmd.Flags().GetBool(installer.VerboseFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.AirGap, err = cmd.Flags().GetBool(installer.AirGapFlag)
-		if err != nil {
-			return err
-		}
-
-		config.Spec.Install.StorageOSPortalConfigYaml = cmd.Flags().Lookup(installer.StosPortalConfigYamlFlag).Value.String()
-		config.Spec.Install.StorageOSPortalClientSecretYaml = cmd.Flags().Lookup(installer.StosPortalClientSecretYamlFlag).Value.String()
-		config.Spec.Install.StorageOSOperatorNamespace = cmd.Flags().Lookup(installer.StosOperatorNSFlag).Value.String()
-		config.Spec.Install.PortalClientID = cmd.Flags().Lookup(installer.PortalClientIDFlag).Value.String()
-		config.Spec.Install.PortalSecret = cmd.Flags().Lookup(installer.PortalSecretFlag).Value.String()
-		config.Spec.Install.PortalTenantID = cmd.Flags().Lookup(installer.PortalTenantIDFlag).Value.String()
-		config.Spec.Install.PortalAPIURL = cmd.Flags().Lookup(installer.PortalAPIURLFlag).Value.String()
-		config.Spec.Install.PortalManagerVersion = cmd.Flags().Lookup(installer.PortalManagerVersionFlag).Value.String()
-		config.Spec.Install.PortalHTTPSProxy = cmd.Flags().Lookup(installer.PortalHTTPSProxyFlag).Value.String()
-		return nil
+func setInstallPortalValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) ([]configField, error) {
+	layers, err := newConfigLayers(cmd)
+	if err != nil {
+		return nil, err
 	}
-	// config file read without error, set fields in new config object
-	config.Spec.StackTrace = viper.GetBool(installer.StackTraceConfig)
-	config.Spec.Verbose = viper.GetBool(installer.VerboseConfig)
-	config.Spec.AirGap = viper.GetBool(installer.AirGapConfig)
-	config.Spec.Install.StorageOSPortalConfigYaml = viper.GetString(installer.InstallStosPortalConfigYamlConfig)
-	config.Spec.Install.StorageOSPortalClientSecretYaml = viper.GetString(installer.InstallStosPortalClientSecretYamlConfig)
-	config.Spec.Install.StorageOSOperatorNamespace = viper.GetString(installer.InstallStosOperatorNSConfig)
-	config.Spec.Install.PortalClientID = viper.GetString(installer.PortalClientIDConfig)
-	config.Spec.Install.PortalSecret = viper.GetString(installer.PortalSecretConfig)
-	config.Spec.Install.PortalTenantID = viper.GetString(installer.PortalTenantIDConfig)
-	config.Spec.Install.PortalAPIURL = viper.GetString(installer.PortalAPIURLConfig)
-	config.Spec.Install.PortalManagerVersion = viper.GetString(installer.InstallPortalManagerVersionConfig)
-
-	return nil
+
+	config.Spec.StackTrace = layers.Bool(installer.StackTraceFlag, installer.StackTraceConfig)
+	config.Spec.Verbose = layers.Bool(installer.VerboseFlag, installer.VerboseConfig)
+	config.Spec.Output = layers.String(installer.OutputFlag, installer.OutputConfig)
+	config.Spec.AirGap = layers.Bool(installer.AirGapFlag, installer.AirGapConfig)
+	config.Spec.Install.StorageOSPortalConfigYaml = layers.String(installer.StosPortalConfigYamlFlag, installer.InstallStosPortalConfigYamlConfig)
+	config.Spec.Install.StorageOSPortalClientSecretYaml = layers.String(installer.StosPortalClientSecretYamlFlag, installer.InstallStosPortalClientSecretYamlConfig)
+	config.Spec.Install.StorageOSOperatorNamespace = layers.String(installer.StosOperatorNSFlag, installer.InstallStosOperatorNSConfig)
+	config.Spec.Install.PortalClientID = layers.String(installer.PortalClientIDFlag, installer.PortalClientIDConfig)
+	config.Spec.Install.PortalSecret = layers.String(installer.PortalSecretFlag, installer.PortalSecretConfig)
+	config.Spec.Install.PortalTenantID = layers.String(installer.PortalTenantIDFlag, installer.PortalTenantIDConfig)
+	config.Spec.Install.PortalAPIURL = layers.String(installer.PortalAPIURLFlag, installer.PortalAPIURLConfig)
+	config.Spec.Install.PortalManagerVersion = layers.String(installer.PortalManagerVersionFlag, installer.InstallPortalManagerVersionConfig)
+	config.Spec.Install.PortalHTTPSProxy = layers.String(installer.PortalHTTPSProxyFlag, installer.PortalHTTPSProxyConfig)
+
+	return layers.fields, layers.Err()
 }
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/spf13/cobra"
@@ -19,7 +20,7 @@ const install = "install"
 
 func InstallCmd() *cobra.Command {
 	var err error
-	var traceError bool
+	var traceError, configShown bool
 	pluginLogger := logger.NewLogger()
 	cmd := &cobra.Command{
 		Use:          install,
@@ -34,23 +35,43 @@ func InstallCmd() *cobra.Command {
 			})
 
 			config := &apiv1.KubectlStorageOSConfig{}
-			if err = setInstallValues(cmd, config); err != nil {
+			var fields []configField
+			if fields, err = setInstallValues(cmd, config); err != nil {
+				return
+			}
+			if configShown = showConfig(cmd, fields); configShown {
 				return
 			}
 
 			traceError = config.Spec.StackTrace
+			if err = pluginLogger.SetOutput(config.Spec.Output); err != nil {
+				return
+			}
 
 			err = installCmd(config, pluginLogger)
 		},
 		PostRunE: func(cmd *cobra.Command, args []string) error {
+			if configShown {
+				return nil
+			}
 			if err := pluginutils.HandleError(install, err, traceError); err != nil {
-				pluginLogger.Error(fmt.Sprintf("%s%s", install, " has failed"))
+				pluginLogger.Result(install, err, "StorageOS installed successfully.")
 				return err
 			}
-			pluginLogger.Success("StorageOS installed successfully.")
+			pluginLogger.Result(install, nil, "StorageOS installed successfully.")
 			return nil
 		},
 	}
+	addInstallFlags(cmd)
+	addShowConfigFlag(cmd)
+
+	viper.BindPFlags(cmd.Flags())
+
+	return cmd
+}
+
+// addInstallFlags adds the flags of install to cmd.
+func addInstallFlags(cmd *cobra.Command) {
 	cmd.Flags().Bool(installer.StackTraceFlag, false, "print stack trace of error")
 	cmd.Flags().BoolP(installer.VerboseFlag, "v", false, "verbose logging")
 	cmd.Flags().Bool(installer.WaitFlag, false, "wait for storageos cluster to enter running phase")
@@ -68,6 +89,7 @@ func InstallCmd() *cobra.Command {
 	cmd.Flags().Bool(installer.IncludeEtcdFlag, false, "install non-production etcd from github.com/storageos/etcd-cluster-operator")
 	cmd.Flags().Bool(installer.EtcdTLSEnabledFlag, false, "etcd cluster is tls enabled")
 	cmd.Flags().Bool(installer.SkipEtcdEndpointsValFlag, false, "skip validation of etcd endpoints")
+	cmd.Flags().String(installer.EtcdValidationModeFlag, installer.EtcdValidationShellPod, fmt.Sprintf("etcd endpoints validation mode, %q execs etcdctl in a shell pod, %q uses a native etcd client through a port-forward", installer.EtcdValidationShellPod, installer.EtcdValidationClient))
 	cmd.Flags().Bool(installer.SkipStosClusterFlag, false, "skip storageos cluster installation")
 	cmd.Flags().Bool(installer.EnablePortalManagerFlag, false, "enable storageos portal manager during installation")
 	cmd.Flags().String(installer.EtcdEndpointsFlag, "", "endpoints of pre-existing etcd backend for storageos (implies not --include-etcd)")
@@ -83,10 +105,10 @@ func InstallCmd() *cobra.Command {
 	cmd.Flags().String(installer.EtcdCPULimitFlag, "", "cpu resource limit for the etcd pods")
 	cmd.Flags().String(installer.EtcdMemoryLimitFlag, "", "memory resource limit for the etcd pods")
 	cmd.Flags().String(installer.EtcdReplicasFlag, "", "desired number of etcd pod replicas")
-	cmd.Flags().String(installer.AdminUsernameFlag, "", "storageos admin username (plaintext)")
-	cmd.Flags().String(installer.AdminPasswordFlag, "", "storageos admin password (plaintext)")
-	cmd.Flags().String(installer.PortalClientIDFlag, "", "storageos portal client id (plaintext)")
-	cmd.Flags().String(installer.PortalSecretFlag, "", "storageos portal secret (plaintext)")
+	cmd.Flags().String(installer.AdminUsernameFlag, "", "storageos admin username, "+credentialReferenceHelp)
+	cmd.Flags().String(installer.AdminPasswordFlag, "", "storageos admin password, "+credentialReferenceHelp)
+	cmd.Flags().String(installer.PortalClientIDFlag, "", "storageos portal client id, "+credentialReferenceHelp)
+	cmd.Flags().String(installer.PortalSecretFlag, "", "storageos portal secret, "+credentialReferenceHelp)
 	cmd.Flags().String(installer.PortalTenantIDFlag, "", "storageos portal tenant id")
 	cmd.Flags().String(installer.PortalAPIURLFlag, "", "storageos portal api url")
 	cmd.Flags().String(installer.PortalManagerVersionFlag, "", "version of portal manager")
@@ -100,12 +122,14 @@ func InstallCmd() *cobra.Command {
 	cmd.Flags().Bool(installer.AirGapFlag, false, "install in an air gapped environment")
 	cmd.Flags().Bool(installer.EnableNodeGuardFlag, false, "enable node guard")
 	cmd.Flags().String(installer.NodeGuardEnvFlag, "", "comma delimited string of environment variables for node guard - eg: \"MINIMUM_REPLICAS=2,WATCH_ALL_VOLUMES=true\"")
+	cmd.Flags().Duration(installer.ReadinessTimeoutFlag, installer.DefaultReadinessTimeout, "time to wait for installed CRDs and deployments to become ready")
+	cmd.Flags().Bool(installer.ServerSideApplyFlag, false, "apply manifests with server-side apply, as field manager \"kubectl-storageos\"")
+	cmd.Flags().Bool(installer.ForceConflictsFlag, false, "take ownership of fields owned by other field managers on server-side apply")
+	cmd.Flags().String(installer.FromBundleFlag, "", "path of a release bundle created by bundle-release to install from, without network access")
+	cmd.Flags().String(installer.RegistryMirrorFlag, "", "registry to pull all images from, keeping their repositories")
+	cmd.Flags().String(installer.ImageMappingFlag, "", "path of a yaml file mapping image names, or prefixes of them, to the names to pull them as")
 
 	cmd.Flags().MarkHidden(installer.TestClusterFlag)
-
-	viper.BindPFlags(cmd.Flags())
-
-	return cmd
 }
 
 func installCmd(config *apiv1.KubectlStorageOSConfig, log *logger.Logger) error {
@@ -115,23 +139,86 @@ func installCmd(config *apiv1.KubectlStorageOSConfig, log *logger.Logger) error
 		log.Warn(airGapInstallWarning)
 	}
 
-	if err := installer.FlagsAreSet(installFlagsFilter(config)); err != nil {
+	if config.Spec.Install.FromBundle != "" {
+		bundleDir, err := installer.UseReleaseBundle(config)
+		defer os.RemoveAll(bundleDir)
+		if err != nil {
+			return err
+		}
+		log.Successf("Installing from release bundle %s.", config.Spec.Install.FromBundle)
+	}
+
+	if err := completeInstallConfig(config, log); err != nil {
 		return err
 	}
 
-	if config.Spec.Install.AdminPassword != "" {
-		if err := validatePassword(config.Spec.Install.AdminPassword); err != nil {
+	var err error
+	if config.Spec.Install.DryRun {
+		if config.Spec.Install.KubernetesVersion == "" {
+			config.Spec.Install.KubernetesVersion, err = k8sVersionPrompt(log)
+			if err != nil {
+				return err
+			}
+		}
+		if config.Spec.IncludeEtcd && config.Spec.Install.EtcdStorageClassName == "" {
+			config.Spec.Install.EtcdStorageClassName, err = storageClassPrompt(log)
+			if err != nil {
+				return err
+			}
+		}
+		config.Spec.Install.SkipEtcdEndpointsValidation = true
+		cliInstaller, err := installer.NewDryRunInstaller(config, log)
+		if err != nil {
 			return err
 		}
+		log.Commencing(install)
+		return cliInstaller.Install(false)
 	}
 
+	cliInstaller, err := installer.NewInstaller(config, log)
+	if err != nil {
+		return err
+	}
+
+	log.Commencing(install)
+	cliInstaller.StartOperation(installer.InstallOperation)
+	err = cliInstaller.Install(false)
+	cliInstaller.FinishOperation(installer.InstallOperation, err)
+
+	return err
+}
+
+// completeInstallConfig validates the install config and sets the versions to be installed if they have
+// not been specified, prompting for etcd endpoints if they have not been specified either.
+func completeInstallConfig(config *apiv1.KubectlStorageOSConfig, log *logger.Logger) error {
+	if err := installer.FlagsAreSet(installFlagsFilter(config)); err != nil {
+		return err
+	}
+
+	if err := validateServerSideApply(config.Spec.Install); err != nil {
+		return err
+	}
+
+	if err := validateImageRewrite(config.Spec.Install); err != nil {
+		return err
+	}
+
+	if err := completeCredentials(&config.Spec.Install); err != nil {
+		return err
+	}
+
+	var err error
 	if config.Spec.Install.StorageOSVersion == "" {
-		config.Spec.Install.StorageOSVersion = version.OperatorLatestSupportedVersion()
+		if config.Spec.Install.StorageOSVersion, err = version.OperatorLatestSupportedVersion(); err != nil {
+			return err
+		}
 	}
 
 	if config.Spec.IncludeEtcd {
 		if config.Spec.Install.EtcdOperatorVersion == "" {
-			config.Spec.Install.EtcdOperatorVersion = version.EtcdOperatorLatestSupportedVersion()
+			if config.Spec.Install.EtcdOperatorVersion, err = version.EtcdOperatorLatestSupportedVersion(); err != nil {
+				return err
+			}
 		}
 		if config.Spec.Install.EtcdMemoryLimit != "" {
 			if err := validateResourceLimit(config.Spec.Install.EtcdMemoryLimit); err != nil {
@@ -152,237 +239,106 @@ func installCmd(config *apiv1.KubectlStorageOSConfig, log *logger.Logger) error
 		}
 	}
 
-	if config.Spec.Install.EnableMetrics != nil && *config.Spec.Install.EnableMetrics {
-		if err := versionSupportsFeature(config.Spec.Install.StorageOSVersion, consts.MetricsExporterFirstSupportedVersion); err != nil {
-			return fmt.Errorf("failed to enable metrics exporter: %w", err)
-		}
-	}
-
 	if config.Spec.Install.EnablePortalManager {
-		if err := versionSupportsFeature(config.Spec.Install.StorageOSVersion, consts.PortalManagerFirstSupportedVersion); err != nil {
+		if err := operatorSupportsFeature(config.Spec.Install.StorageOSVersion, version.FeaturePortalManager); err != nil {
 			return fmt.Errorf("failed to install portal manager: %w", err)
 		}
 		if config.Spec.Install.PortalManagerVersion == "" {
-			config.Spec.Install.PortalManagerVersion = (version.PortalManagerLatestSupportedVersion())
+			if config.Spec.Install.PortalManagerVersion, err = version.PortalManagerLatestSupportedVersion(); err != nil {
+				return err
+			}
 		}
 		version.SetPortalManagerLatestSupportedVersion(config.Spec.Install.PortalManagerVersion)
 	}
 
+	if err := checkCompatibility(config.Spec.Install); err != nil {
+		return err
+	}
+
 	// if node guard env vars have been set, set enable flag implicitly
 	if config.Spec.Install.NodeGuardEnv != "" {
 		config.Spec.Install.EnableNodeGuard = true
 	}
 
-	var err error
 	// if etcdEndpoints was not passed via flag or config, prompt user to enter manually
 	if !config.Spec.IncludeEtcd && config.Spec.Install.EtcdEndpoints == "" {
+		var err error
 		config.Spec.Install.EtcdEndpoints, err = etcdEndpointsPrompt(log)
 		if err != nil {
 			return err
 		}
 	}
 
-	if config.Spec.Install.DryRun {
-		if config.Spec.Install.KubernetesVersion == "" {
-			config.Spec.Install.KubernetesVersion, err = k8sVersionPrompt(log)
-			if err != nil {
-				return err
-			}
-		}
-		if config.Spec.IncludeEtcd && config.Spec.Install.EtcdStorageClassName == "" {
-			config.Spec.Install.EtcdStorageClassName, err = storageClassPrompt(log)
-			if err != nil {
-				return err
-			}
-		}
-		config.Spec.Install.SkipEtcdEndpointsValidation = true
-		cliInstaller, err := installer.NewDryRunInstaller(config, log)
-		if err != nil {
-			return err
-		}
-		log.Commencing(install)
-		return cliInstaller.Install(false)
-	}
+	return nil
+}
 
-	cliInstaller, err := installer.NewInstaller(config, log)
+// setInstallValues sets config from the flags of cmd, env vars, the config file and the flag defaults, in
+// that order of precedence, returning the fields resolved.
+func setInstallValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) ([]configField, error) {
+	layers, err := newConfigLayers(cmd)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	log.Commencing(install)
-	return cliInstaller.Install(false)
-}
-
-func setInstallValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) error {
-	viper.BindPFlag(installer.StosConfigPathFlag, cmd.Flags().Lookup(installer.StosConfigPathFlag))
-	v := viper.GetViper()
-	viper.SetConfigName("kubectl-storageos-config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(v.GetString(installer.StosConfigPathFlag))
-
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			// Config file was found but another error was produced
-			return fmt.Errorf("error discovered in config file: %v", err)
-		}
-		// Config file not found; set fields in new config object directly
-		config.Spec.StackTrace, err = cmd.Flags().GetBool(installer.StackTraceFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Verbose, err = cmd.Flags().GetBool(installer.VerboseFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.IncludeEtcd, err = cmd.Flags().GetBool(installer.IncludeEtcdFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.SkipStorageOSCluster, err = cmd.Flags().GetBool(installer.SkipStosClusterFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Install.EnablePortalManager, err = cmd.Flags().GetBool(installer.EnablePortalManagerFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Install.Wait, err = cmd.Flags().GetBool(installer.WaitFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Install.DryRun, err = cmd.Flags().GetBool(installer.DryRunFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Install.SkipEtcdEndpointsValidation, err = cmd.Flags().GetBool(installer.SkipEtcdEndpointsValFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Install.EtcdTLSEnabled, err = cmd.Flags().GetBool(installer.EtcdTLSEnabledFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Install.EnableMetrics, err = GetBoolIfFlagSet(cmd.Flags(), installer.EnableMetricsFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Install.MarkTestCluster, err = cmd.Flags().GetBool(installer.TestClusterFlag)
-		if err != nil {
-			return err
-		}
-
-		config.Spec.Install.SkipK8sVersionCheck, err = cmd.Flags().GetBool(installer.SkipK8sVersionCheckFlag)
-		if err != nil {
-			return err
-		}
-
-		config.Spec.Install.EnableNodeGuard, err = cmd.Flags().GetBool(installer.EnableNodeGuardFlag)
-		if err != nil {
-			return err
-		}
-
-		config.Spec.IncludeLocalPathProvisioner, err = cmd.Flags().GetBool(installer.IncludeLocalPathProvisionerFlag)
-		if err != nil {
-			return err
-		}
-
-		config.Spec.Serial, err = cmd.Flags().GetBool(installer.SerialFlag)
-		if err != nil {
-			return err
-		}
-
-		config.Spec.AirGap, err = cmd.Flags().GetBool(installer.AirGapFlag)
-		if err != nil {
-			return err
-		}
-
-		config.Spec.Install.StorageOSVersion = cmd.Flags().Lookup(installer.StosVersionFlag).Value.String()
-		config.Spec.Install.EtcdOperatorVersion = cmd.Flags().Lookup(installer.EtcdOperatorVersionFlag).Value.String()
-		config.Spec.Install.KubernetesVersion = cmd.Flags().Lookup(installer.K8sVersionFlag).Value.String()
-		config.Spec.Install.StorageOSOperatorYaml = cmd.Flags().Lookup(installer.StosOperatorYamlFlag).Value.String()
-		config.Spec.Install.StorageOSClusterYaml = cmd.Flags().Lookup(installer.StosClusterYamlFlag).Value.String()
-		config.Spec.Install.StorageOSPortalConfigYaml = cmd.Flags().Lookup(installer.StosPortalConfigYamlFlag).Value.String()
-		config.Spec.Install.StorageOSPortalClientSecretYaml = cmd.Flags().Lookup(installer.StosPortalClientSecretYamlFlag).Value.String()
-		config.Spec.Install.EtcdOperatorYaml = cmd.Flags().Lookup(installer.EtcdOperatorYamlFlag).Value.String()
-		config.Spec.Install.EtcdClusterYaml = cmd.Flags().Lookup(installer.EtcdClusterYamlFlag).Value.String()
-		config.Spec.Install.ResourceQuotaYaml = cmd.Flags().Lookup(installer.ResourceQuotaYamlFlag).Value.String()
-		config.Spec.Install.StorageOSOperatorNamespace = cmd.Flags().Lookup(installer.StosOperatorNSFlag).Value.String()
-		config.Spec.Install.StorageOSClusterNamespace = cmd.Flags().Lookup(installer.StosClusterNSFlag).Value.String()
-		config.Spec.Install.EtcdNamespace = cmd.Flags().Lookup(installer.EtcdNamespaceFlag).Value.String()
-		config.Spec.Install.EtcdEndpoints = cmd.Flags().Lookup(installer.EtcdEndpointsFlag).Value.String()
-		config.Spec.Install.EtcdSecretName = cmd.Flags().Lookup(installer.EtcdSecretNameFlag).Value.String()
-		config.Spec.Install.EtcdStorageClassName = cmd.Flags().Lookup(installer.EtcdStorageClassFlag).Value.String()
-		config.Spec.Install.EtcdDockerRepository = cmd.Flags().Lookup(installer.EtcdDockerRepositoryFlag).Value.String()
-		config.Spec.Install.AdminUsername = cmd.Flags().Lookup(installer.AdminUsernameFlag).Value.String()
-		config.Spec.Install.AdminPassword = cmd.Flags().Lookup(installer.AdminPasswordFlag).Value.String()
-		config.Spec.Install.PortalClientID = cmd.Flags().Lookup(installer.PortalClientIDFlag).Value.String()
-		config.Spec.Install.PortalSecret = cmd.Flags().Lookup(installer.PortalSecretFlag).Value.String()
-		config.Spec.Install.PortalTenantID = cmd.Flags().Lookup(installer.PortalTenantIDFlag).Value.String()
-		config.Spec.Install.PortalAPIURL = cmd.Flags().Lookup(installer.PortalAPIURLFlag).Value.String()
-		config.Spec.Install.PortalManagerVersion = cmd.Flags().Lookup(installer.PortalManagerVersionFlag).Value.String()
-		config.Spec.Install.PortalHTTPSProxy = cmd.Flags().Lookup(installer.PortalHTTPSProxyFlag).Value.String()
-		config.Spec.Install.LocalPathProvisionerYaml = cmd.Flags().Lookup(installer.LocalPathProvisionerYamlFlag).Value.String()
-		config.Spec.Install.EtcdTopologyKey = cmd.Flags().Lookup(installer.EtcdTopologyKeyFlag).Value.String()
-		config.Spec.Install.EtcdCPULimit = cmd.Flags().Lookup(installer.EtcdCPULimitFlag).Value.String()
-		config.Spec.Install.EtcdMemoryLimit = cmd.Flags().Lookup(installer.EtcdMemoryLimitFlag).Value.String()
-		config.Spec.Install.EtcdReplicas = cmd.Flags().Lookup(installer.EtcdReplicasFlag).Value.String()
-		config.Spec.Install.EtcdVersionTag = cmd.Flags().Lookup(installer.EtcdVersionTag).Value.String()
-		config.Spec.Install.NodeGuardEnv = cmd.Flags().Lookup(installer.NodeGuardEnvFlag).Value.String()
-		config.InstallerMeta.StorageOSSecretYaml = ""
-
-		return nil
-	}
-	// config file read without error, set fields in new config object
-	config.Spec.StackTrace = viper.GetBool(installer.StackTraceConfig)
-	config.Spec.Verbose = viper.GetBool(installer.VerboseConfig)
-	config.Spec.IncludeEtcd = viper.GetBool(installer.IncludeEtcdConfig)
-	config.Spec.SkipStorageOSCluster = viper.GetBool(installer.SkipStosClusterConfig)
-	config.Spec.Serial = viper.GetBool(installer.SerialConfig)
-	config.Spec.AirGap = viper.GetBool(installer.AirGapConfig)
-	config.Spec.Install.EnablePortalManager = viper.GetBool(installer.EnablePortalManagerConfig)
-	config.Spec.Install.Wait = viper.GetBool(installer.WaitConfig)
-	config.Spec.Install.DryRun = viper.GetBool(installer.DryRunConfig)
-	config.Spec.Install.EnableMetrics = GetBoolIfConfigSet(installer.EnableMetricsConfig)
-	config.Spec.Install.StorageOSVersion = viper.GetString(installer.InstallStosVersionConfig)
-	config.Spec.Install.EtcdOperatorVersion = viper.GetString(installer.InstallEtcdOperatorVersionConfig)
-	config.Spec.Install.KubernetesVersion = viper.GetString(installer.K8sVersionConfig)
-	config.Spec.Install.StorageOSOperatorYaml = viper.GetString(installer.InstallStosOperatorYamlConfig)
-	config.Spec.Install.StorageOSClusterYaml = viper.GetString(installer.InstallStosClusterYamlConfig)
-	config.Spec.Install.StorageOSPortalConfigYaml = viper.GetString(installer.InstallStosPortalConfigYamlConfig)
-	config.Spec.Install.StorageOSPortalClientSecretYaml = viper.GetString(installer.InstallStosPortalClientSecretYamlConfig)
-	config.Spec.Install.EtcdOperatorYaml = viper.GetString(installer.InstallEtcdOperatorYamlConfig)
-	config.Spec.Install.EtcdClusterYaml = viper.GetString(installer.InstallEtcdClusterYamlConfig)
-	config.Spec.Install.ResourceQuotaYaml = viper.GetString(installer.InstallResourceQuotaYamlConfig)
-	config.Spec.Install.StorageOSOperatorNamespace = valueOrDefault(viper.GetString(installer.InstallStosOperatorNSConfig), consts.NewOperatorNamespace)
-	config.Spec.Install.StorageOSClusterNamespace = valueOrDefault(viper.GetString(installer.StosClusterNSConfig), consts.NewOperatorNamespace)
-	config.Spec.Install.EtcdNamespace = valueOrDefault(viper.GetString(installer.InstallEtcdNamespaceConfig), consts.EtcdOperatorNamespace)
-	config.Spec.Install.EtcdEndpoints = viper.GetString(installer.EtcdEndpointsConfig)
-	config.Spec.Install.SkipEtcdEndpointsValidation = viper.GetBool(installer.SkipEtcdEndpointsValConfig)
-	config.Spec.Install.EtcdTLSEnabled = viper.GetBool(installer.EtcdTLSEnabledConfig)
-	config.Spec.Install.EtcdSecretName = viper.GetString(installer.EtcdSecretNameConfig)
-	config.Spec.Install.EtcdStorageClassName = viper.GetString(installer.EtcdStorageClassConfig)
-	config.Spec.Install.EtcdDockerRepository = viper.GetString(installer.EtcdDockerRepositoryConfig)
-	config.Spec.Install.EtcdVersionTag = viper.GetString(installer.EtcdVersionTagConfig)
-	config.Spec.Install.AdminUsername = viper.GetString(installer.AdminUsernameConfig)
-	config.Spec.Install.AdminPassword = viper.GetString(installer.AdminPasswordConfig)
-	config.Spec.Install.PortalClientID = viper.GetString(installer.PortalClientIDConfig)
-	config.Spec.Install.PortalSecret = viper.GetString(installer.PortalSecretConfig)
-	config.Spec.Install.PortalTenantID = viper.GetString(installer.PortalTenantIDConfig)
-	config.Spec.Install.PortalAPIURL = viper.GetString(installer.PortalAPIURLConfig)
-	config.Spec.Install.PortalManagerVersion = viper.GetString(installer.InstallPortalManagerVersionConfig)
+	config.Spec.StackTrace = layers.Bool(installer.StackTraceFlag, installer.StackTraceConfig)
+	config.Spec.Verbose = layers.Bool(installer.VerboseFlag, installer.VerboseConfig)
+	config.Spec.Output = layers.String(installer.OutputFlag, installer.OutputConfig)
+	config.Spec.IncludeEtcd = layers.Bool(installer.IncludeEtcdFlag, installer.IncludeEtcdConfig)
+	config.Spec.SkipStorageOSCluster = layers.Bool(installer.SkipStosClusterFlag, installer.SkipStosClusterConfig)
+	config.Spec.Serial = layers.Bool(installer.SerialFlag, installer.SerialConfig)
+	config.Spec.AirGap = layers.Bool(installer.AirGapFlag, installer.AirGapConfig)
+	config.Spec.IncludeLocalPathProvisioner = layers.Bool(installer.IncludeLocalPathProvisionerFlag, installer.IncludeLocalPathProvisionerConfig)
+	config.Spec.Install.EnablePortalManager = layers.Bool(installer.EnablePortalManagerFlag, installer.EnablePortalManagerConfig)
+	config.Spec.Install.Wait = layers.Bool(installer.WaitFlag, installer.WaitConfig)
+	config.Spec.Install.DryRun = layers.Bool(installer.DryRunFlag, installer.DryRunConfig)
+	config.Spec.Install.EnableMetrics = layers.BoolIfSet(installer.EnableMetricsFlag, installer.EnableMetricsConfig)
+	config.Spec.Install.StorageOSVersion = layers.String(installer.StosVersionFlag, installer.InstallStosVersionConfig)
+	config.Spec.Install.EtcdOperatorVersion = layers.String(installer.EtcdOperatorVersionFlag, installer.InstallEtcdOperatorVersionConfig)
+	config.Spec.Install.KubernetesVersion = layers.String(installer.K8sVersionFlag, installer.K8sVersionConfig)
+	config.Spec.Install.StorageOSOperatorYaml = layers.String(installer.StosOperatorYamlFlag, installer.InstallStosOperatorYamlConfig)
+	config.Spec.Install.StorageOSClusterYaml = layers.String(installer.StosClusterYamlFlag, installer.InstallStosClusterYamlConfig)
+	config.Spec.Install.StorageOSPortalConfigYaml = layers.String(installer.StosPortalConfigYamlFlag, installer.InstallStosPortalConfigYamlConfig)
+	config.Spec.Install.StorageOSPortalClientSecretYaml = layers.String(installer.StosPortalClientSecretYamlFlag, installer.InstallStosPortalClientSecretYamlConfig)
+	config.Spec.Install.EtcdOperatorYaml = layers.String(installer.EtcdOperatorYamlFlag, installer.InstallEtcdOperatorYamlConfig)
+	config.Spec.Install.EtcdClusterYaml = layers.String(installer.EtcdClusterYamlFlag, installer.InstallEtcdClusterYamlConfig)
+	config.Spec.Install.ResourceQuotaYaml = layers.String(installer.ResourceQuotaYamlFlag, installer.InstallResourceQuotaYamlConfig)
+	config.Spec.Install.StorageOSOperatorNamespace = layers.String(installer.StosOperatorNSFlag, installer.InstallStosOperatorNSConfig)
+	config.Spec.Install.StorageOSClusterNamespace = layers.String(installer.StosClusterNSFlag, installer.StosClusterNSConfig)
+	config.Spec.Install.EtcdNamespace = layers.String(installer.EtcdNamespaceFlag, installer.InstallEtcdNamespaceConfig)
+	config.Spec.Install.EtcdEndpoints = layers.String(installer.EtcdEndpointsFlag, installer.EtcdEndpointsConfig)
+	config.Spec.Install.SkipEtcdEndpointsValidation = layers.Bool(installer.SkipEtcdEndpointsValFlag, installer.SkipEtcdEndpointsValConfig)
+	config.Spec.Install.EtcdTLSEnabled = layers.Bool(installer.EtcdTLSEnabledFlag, installer.EtcdTLSEnabledConfig)
+	config.Spec.Install.EtcdSecretName = layers.String(installer.EtcdSecretNameFlag, installer.EtcdSecretNameConfig)
+	config.Spec.Install.EtcdValidationMode = layers.String(installer.EtcdValidationModeFlag, installer.EtcdValidationModeConfig)
+	config.Spec.Install.EtcdStorageClassName = layers.String(installer.EtcdStorageClassFlag, installer.EtcdStorageClassConfig)
+	config.Spec.Install.EtcdDockerRepository = layers.String(installer.EtcdDockerRepositoryFlag, installer.EtcdDockerRepositoryConfig)
+	config.Spec.Install.EtcdVersionTag = layers.String(installer.EtcdVersionTag, installer.EtcdVersionTagConfig)
+	config.Spec.Install.EtcdTopologyKey = layers.String(installer.EtcdTopologyKeyFlag, installer.EtcdTopologyKeyConfig)
+	config.Spec.Install.EtcdCPULimit = layers.String(installer.EtcdCPULimitFlag, installer.EtcdCPULimitConfig)
+	config.Spec.Install.EtcdMemoryLimit = layers.String(installer.EtcdMemoryLimitFlag, installer.EtcdMemoryLimitConfig)
+	config.Spec.Install.EtcdReplicas = layers.String(installer.EtcdReplicasFlag, installer.EtcdReplicasConfig)
+	config.Spec.Install.AdminUsername = layers.String(installer.AdminUsernameFlag, installer.AdminUsernameConfig)
+	config.Spec.Install.AdminPassword = layers.String(installer.AdminPasswordFlag, installer.AdminPasswordConfig)
+	config.Spec.Install.PortalClientID = layers.String(installer.PortalClientIDFlag, installer.PortalClientIDConfig)
+	config.Spec.Install.PortalSecret = layers.String(installer.PortalSecretFlag, installer.PortalSecretConfig)
+	config.Spec.Install.PortalTenantID = layers.String(installer.PortalTenantIDFlag, installer.PortalTenantIDConfig)
+	config.Spec.Install.PortalAPIURL = layers.String(installer.PortalAPIURLFlag, installer.PortalAPIURLConfig)
+	config.Spec.Install.PortalManagerVersion = layers.String(installer.PortalManagerVersionFlag, installer.InstallPortalManagerVersionConfig)
+	config.Spec.Install.PortalHTTPSProxy = layers.String(installer.PortalHTTPSProxyFlag, installer.PortalHTTPSProxyConfig)
+	config.Spec.Install.LocalPathProvisionerYaml = layers.String(installer.LocalPathProvisionerYamlFlag, installer.InstallLocalPathProvisionerYamlConfig)
+	config.Spec.Install.MarkTestCluster = layers.Bool(installer.TestClusterFlag, installer.TestClusterConfig)
+	config.Spec.Install.SkipK8sVersionCheck = layers.Bool(installer.SkipK8sVersionCheckFlag, installer.SkipK8sVersionCheckConfig)
+	config.Spec.Install.EnableNodeGuard = layers.Bool(installer.EnableNodeGuardFlag, installer.EnableNodeGuardConfig)
+	config.Spec.Install.NodeGuardEnv = layers.String(installer.NodeGuardEnvFlag, installer.NodeGuardEnvConfig)
+	config.Spec.Install.ReadinessTimeout = layers.Duration(installer.ReadinessTimeoutFlag, installer.ReadinessTimeoutConfig)
+	config.Spec.Install.ServerSideApply = layers.Bool(installer.ServerSideApplyFlag, installer.ServerSideApplyConfig)
+	config.Spec.Install.ForceConflicts = layers.Bool(installer.ForceConflictsFlag, installer.ForceConflictsConfig)
+	config.Spec.Install.FromBundle = layers.String(installer.FromBundleFlag, installer.FromBundleConfig)
+	config.Spec.Install.RegistryMirror = layers.String(installer.RegistryMirrorFlag, installer.RegistryMirrorConfig)
+	config.Spec.Install.ImageMapping = layers.String(installer.ImageMappingFlag, installer.ImageMappingConfig)
 	config.InstallerMeta.StorageOSSecretYaml = ""
-	config.Spec.IncludeLocalPathProvisioner = viper.GetBool(installer.IncludeLocalPathProvisionerConfig)
-	config.Spec.Install.LocalPathProvisionerYaml = viper.GetString(installer.InstallLocalPathProvisionerYamlConfig)
-	config.Spec.Install.EtcdCPULimit = viper.GetString(installer.EtcdCPULimitConfig)
-	config.Spec.Install.EtcdMemoryLimit = viper.GetString(installer.EtcdMemoryLimitConfig)
-	config.Spec.Install.EtcdReplicas = viper.GetString(installer.EtcdReplicasConfig)
-	config.Spec.Install.EtcdTopologyKey = viper.GetString(installer.EtcdTopologyKeyConfig)
-	config.Spec.Install.MarkTestCluster = viper.GetBool(installer.TestClusterConfig)
-	config.Spec.Install.SkipK8sVersionCheck = viper.GetBool(installer.SkipK8sVersionCheckConfig)
-	config.Spec.Install.EnableNodeGuard = viper.GetBool(installer.EnableNodeGuardConfig)
-	config.Spec.Install.NodeGuardEnv = viper.GetString(installer.NodeGuardEnvConfig)
 
-	return nil
+	return layers.fields, layers.Err()
 }
 
 func installFlagsFilter(config *apiv1.KubectlStorageOSConfig) map[string]string {
@@ -1,16 +1,21 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 
+	"github.com/storageos/kubectl-storageos/pkg/apiclient"
 	"github.com/storageos/kubectl-storageos/pkg/forwarder"
 	"github.com/storageos/kubectl-storageos/pkg/logger"
 )
 
 func NfsCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:                "nfs",
-		Short:              "Make changes and attach nfs volumes",
+		Use:   "nfs",
+		Short: "Make changes and attach nfs volumes",
+		Long: `Attach volumes over NFS and set their mount endpoint through the StorageOS API. Other changes are made by the
+StorageOS CLI pod.`,
 		Args:               cobra.MinimumNArgs(0),
 		SilenceUsage:       true,
 		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
@@ -20,6 +25,54 @@ func NfsCmd() *cobra.Command {
 			return forwarder.ForwardToCLIPod(logger.NewLogger(), storageosCommand(cmd, args))
 		},
 	}
+	cmd.AddCommand(nfsAttachCmd())
+	cmd.AddCommand(nfsEndpointCmd())
+
+	return cmd
+}
+
+func nfsAttachCmd() *cobra.Command {
+	cmd := apiCmd(&cobra.Command{
+		Use:   "attach VOLUME NODE",
+		Args:  cobra.ExactArgs(2),
+		Short: "Share a volume over NFS from a node",
+	}, func(cmd *cobra.Command, args []string, client *apiclient.Client) error {
+		volume, node, err := volumeAndNode(cmd, client, args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if err = client.AttachNFSVolume(volume, node.ID); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "volume/%s shared over nfs from node/%s\n", volume.Name, node.Name)
+		return nil
+	})
+	addAPINamespaceFlag(cmd)
+
+	return cmd
+}
+
+func nfsEndpointCmd() *cobra.Command {
+	cmd := apiCmd(&cobra.Command{
+		Use:   "endpoint VOLUME HOST:PORT",
+		Args:  cobra.ExactArgs(2),
+		Short: "Set the endpoint nfs clients mount a volume from",
+	}, func(cmd *cobra.Command, args []string, client *apiclient.Client) error {
+		namespace, err := apiNamespace(cmd, client)
+		if err != nil {
+			return err
+		}
+		volume, err := client.GetVolumeByName(namespace.ID, args[0])
+		if err != nil {
+			return err
+		}
+		if err = client.SetNFSMountEndpoint(volume, args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "volume/%s nfs endpoint set to %s\n", volume.Name, args[1])
+		return nil
+	})
+	addAPINamespaceFlag(cmd)
 
 	return cmd
 }
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+var update = flag.Bool("update", false, "update golden files in test-data")
+
+// forbiddenStatus is the response of the API server of TestPortalOutput to every request.
+const forbiddenStatus = `{"kind":"Status","apiVersion":"v1","status":"Failure","message":"access denied","reason":"Forbidden","code":403}`
+
+func TestPortalOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, forbiddenStatus)
+	}))
+	defer server.Close()
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+current-context: test
+`, server.URL)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", kubeconfig)
+
+	portalFlags := []string{"--portal-client-id=client", "--portal-secret=secret", "--portal-tenant-id=tenant", "--portal-api-url=https://portal.example.com"}
+	tcases := []struct {
+		name    string
+		newCmd  func() *cobra.Command
+		args    []string
+		outputs []string
+	}{
+		{name: installPortal, newCmd: InstallPortalCmd, args: portalFlags},
+		{name: uninstallPortal, newCmd: UninstallPortalCmd},
+		{name: enablePortal, newCmd: EnablePortalCmd},
+		{name: disablePortal, newCmd: DisablePortalCmd},
+	}
+
+	// the result document is emitted at its time of day
+	timestamp := regexp.MustCompile(`("time":"|time: ")[^"]*"`)
+	for _, tc := range tcases {
+		for _, output := range []string{"json", "yaml"} {
+			name := fmt.Sprintf("%s-%s", tc.name, output)
+			out := runWithStdout(t, func() {
+				root := &cobra.Command{Use: "kubectl-storageos", SilenceErrors: true}
+				AddOutputFlag(root.PersistentFlags())
+				root.AddCommand(tc.newCmd())
+				root.SetErr(io.Discard)
+				root.SetArgs(append([]string{tc.name, "--output", output}, tc.args...))
+				if err := root.Execute(); err == nil {
+					t.Errorf("%s: expected error, got none", name)
+				}
+			})
+			out = timestamp.ReplaceAll(out, []byte(`${1}2022-05-04T12:00:00Z"`))
+
+			golden := filepath.Join("test-data", name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, out, 0644); err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if !bytes.Equal(out, expected) {
+				t.Errorf("%s: expected %s, got %s", name, expected, out)
+			}
+		}
+	}
+}
+
+// runWithStdout returns what run writes to stdout, which the loggers of commands write to.
+func runWithStdout(t *testing.T, run func()) []byte {
+	file, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	stdout := os.Stdout
+	os.Stdout = file
+	run()
+	os.Stdout = stdout
+
+	out, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+const (
+	render = "render"
+
+	renderFormatFlag = "format"
+	renderDirFlag    = "dir"
+
+	// renderFormatYAML writes the manifests to stdout, renderFormatHelm writes a Helm chart to a directory.
+	renderFormatYAML = "yaml"
+	renderFormatHelm = "helm"
+
+	defaultRenderDir = "storageos-chart"
+)
+
+func RenderCmd() *cobra.Command {
+	var err error
+	var traceError bool
+	var format, dir string
+	pluginLogger := logger.NewLogger()
+	cmd := &cobra.Command{
+		Use:   render,
+		Args:  cobra.NoArgs,
+		Short: "Render the install manifests as yaml or as a Helm chart",
+		Long: `Render the manifests of install, without installing anything. They are written to stdout with --format yaml,
+or as a Helm chart to --dir with --format helm. The namespaces, etcd endpoints and TLS secret, portal manager settings,
+node guard env and metrics are parameters of values.yaml of the chart, defaulting to the flags. It takes the same flags
+as install.`,
+		Example: `
+$ kubectl storageos render --etcd-endpoints 10.42.0.10:2379 --k8s-version v1.24.0 > storageos.yaml
+$ kubectl storageos render --format helm --dir ./charts/storageos --etcd-endpoints 10.42.0.10:2379 --k8s-version v1.24.0
+`,
+		SilenceUsage: true,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			// bound on run, as the flags are shared with install
+			viper.BindPFlags(cmd.Flags())
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			defer pluginutils.ConvertPanicToError(func(e error) {
+				err = e
+			})
+
+			config := &apiv1.KubectlStorageOSConfig{}
+			if _, err = setInstallValues(cmd, config); err != nil {
+				return
+			}
+			traceError = config.Spec.StackTrace
+			format = cmd.Flags().Lookup(renderFormatFlag).Value.String()
+			dir = cmd.Flags().Lookup(renderDirFlag).Value.String()
+			if format == renderFormatYAML {
+				// keep stdout to the manifests, so that it can be redirected to a file
+				pluginLogger.Writer = cmd.ErrOrStderr()
+			}
+
+			err = renderCmd(cmd.OutOrStdout(), config, format, dir, pluginLogger)
+		},
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			if err := pluginutils.HandleError(render, err, traceError); err != nil {
+				pluginLogger.Error(fmt.Sprintf("%s%s", render, " has failed"))
+				return err
+			}
+			if format == renderFormatHelm {
+				pluginLogger.Successf("StorageOS Helm chart rendered to %s.", dir)
+			}
+			return nil
+		},
+	}
+	addInstallFlags(cmd)
+	cmd.Flags().String(renderFormatFlag, renderFormatYAML, fmt.Sprintf("format to render, one of %q or %q", renderFormatYAML, renderFormatHelm))
+	cmd.Flags().String(renderDirFlag, defaultRenderDir, "directory to render the Helm chart to")
+	for _, flag := range diffHiddenFlags {
+		if cmd.Flags().Lookup(flag) != nil {
+			cmd.Flags().MarkHidden(flag)
+		}
+	}
+
+	return cmd
+}
+
+func renderCmd(w io.Writer, config *apiv1.KubectlStorageOSConfig, format, dir string, log *logger.Logger) error {
+	log.Verbose = config.Spec.Verbose
+
+	if format != renderFormatYAML && format != renderFormatHelm {
+		return errors.Errorf("unknown format %q, expected %q or %q", format, renderFormatYAML, renderFormatHelm)
+	}
+	if err := completeRenderConfig(config, log); err != nil {
+		return err
+	}
+
+	if format == renderFormatHelm {
+		cliInstaller, err := installer.NewHelmInstaller(config, log)
+		if err != nil {
+			return err
+		}
+		return cliInstaller.HelmChart(dir)
+	}
+
+	cliInstaller, err := installer.NewRenderInstaller(config, log)
+	if err != nil {
+		return err
+	}
+	manifests, err := cliInstaller.Render()
+	if err != nil {
+		return err
+	}
+	for _, manifest := range manifests {
+		fmt.Fprintf(w, "---\n%s\n", strings.TrimSpace(manifest))
+	}
+
+	return nil
+}
+
+// completeRenderConfig completes the install config as for a dry-run, prompting for the values which are
+// otherwise read from the cluster.
+func completeRenderConfig(config *apiv1.KubectlStorageOSConfig, log *logger.Logger) error {
+	if err := completeInstallConfig(config, log); err != nil {
+		return err
+	}
+
+	var err error
+	if config.Spec.Install.KubernetesVersion == "" {
+		if config.Spec.Install.KubernetesVersion, err = k8sVersionPrompt(log); err != nil {
+			return err
+		}
+	}
+	if config.Spec.IncludeEtcd && config.Spec.Install.EtcdStorageClassName == "" {
+		if config.Spec.Install.EtcdStorageClassName, err = storageClassPrompt(log); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
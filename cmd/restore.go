@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+const restore = "restore"
+
+func RestoreCmd() *cobra.Command {
+	var err error
+	var traceError bool
+	pluginLogger := logger.NewLogger()
+	cmd := &cobra.Command{
+		Use:          restore + " [backup path]",
+		Args:         cobra.MaximumNArgs(1),
+		Short:        "Restore StorageOS control-plane configuration from a backup",
+		Long:         `Restore the StorageOS cluster, secrets, configmaps and storage classes from a backup directory or archive. The latest backup is restored if no path is given.`,
+		SilenceUsage: true,
+		PreRun:       func(cmd *cobra.Command, args []string) {},
+		Run: func(cmd *cobra.Command, args []string) {
+			defer pluginutils.ConvertPanicToError(func(e error) {
+				err = e
+			})
+
+			config := &apiv1.KubectlStorageOSConfig{}
+			if err = setRestoreValues(cmd, config); err != nil {
+				return
+			}
+
+			traceError = config.Spec.StackTrace
+
+			source := ""
+			if len(args) > 0 {
+				source = args[0]
+			}
+
+			err = restoreCmd(config, source, pluginLogger)
+		},
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			if err := pluginutils.HandleError(restore, err, traceError); err != nil {
+				pluginLogger.Error(fmt.Sprintf("%s%s", restore, " has failed"))
+				return err
+			}
+			pluginLogger.Success("StorageOS restored successfully.")
+			return nil
+		},
+	}
+	cmd.Flags().Bool(installer.StackTraceFlag, false, "print stack trace of error")
+	cmd.Flags().BoolP(installer.VerboseFlag, "v", false, "verbose logging")
+	cmd.Flags().String(installer.StosConfigPathFlag, "", "path to look for kubectl-storageos-config.yaml")
+	cmd.Flags().String(installer.BackupDirFlag, "", "directory to restore the latest backup from (default $HOME/.kube/storageos/backups/<cluster-id>)")
+	cmd.Flags().Bool(installer.SkipStosClusterFlag, false, "skip storageos cluster restoration")
+
+	viper.BindPFlags(cmd.Flags())
+
+	return cmd
+}
+
+func restoreCmd(config *apiv1.KubectlStorageOSConfig, source string, log *logger.Logger) error {
+	log.Verbose = config.Spec.Verbose
+
+	cliInstaller, err := installer.NewBackupInstaller(config, log)
+	if err != nil {
+		return err
+	}
+
+	log.Commencing(restore)
+	return cliInstaller.Restore(source)
+}
+
+func setRestoreValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) error {
+	viper.BindPFlag(installer.StosConfigPathFlag, cmd.Flags().Lookup(installer.StosConfigPathFlag))
+	v := viper.GetViper()
+	viper.SetConfigName("kubectl-storageos-config")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath(v.GetString(installer.StosConfigPathFlag))
+
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			// Config file was found but another error was produced
+			return fmt.Errorf("error discovered in config file: %v", err)
+		}
+		// Config file not found; set fields in new config object directly
+		config.Spec.StackTrace, err = cmd.Flags().GetBool(installer.StackTraceFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.Verbose, err = cmd.Flags().GetBool(installer.VerboseFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.SkipStorageOSCluster, err = cmd.Flags().GetBool(installer.SkipStosClusterFlag)
+		if err != nil {
+			return err
+		}
+		config.Spec.Backup.BackupDir = cmd.Flags().Lookup(installer.BackupDirFlag).Value.String()
+		return nil
+	}
+	// config file read without error, set fields in new config object
+	config.Spec.StackTrace = viper.GetBool(installer.StackTraceConfig)
+	config.Spec.Verbose = viper.GetBool(installer.VerboseConfig)
+	config.Spec.SkipStorageOSCluster = viper.GetBool(installer.SkipStosClusterConfig)
+	config.Spec.Backup.BackupDir = viper.GetString(installer.BackupDirConfig)
+	return nil
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/storageos/kubectl-storageos/pkg/consts"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+const (
+	status = "status"
+
+	statusWatchFlag    = "watch"
+	statusIntervalFlag = "interval"
+
+	// clearScreen moves the cursor home and clears the terminal.
+	clearScreen = "\033[H\033[2J"
+)
+
+func StatusCmd() *cobra.Command {
+	var err error
+	var healthy bool
+	pluginLogger := logger.NewLogger()
+	cmd := &cobra.Command{
+		Use:   status,
+		Args:  cobra.NoArgs,
+		Short: "Show the health of each component of StorageOS",
+		Long: `Show the health of the operator, the StorageOS cluster phase and conditions, the StorageOS pod of each node,
+the CSI driver registration, the health of the etcd endpoints, the portal manager and the metrics exporter. The command fails if
+any component is not ready, unless watching.`,
+		Example: `
+$ kubectl storageos status
+$ kubectl storageos status -o json
+$ kubectl storageos status --watch --interval 10s
+`,
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			defer pluginutils.ConvertPanicToError(func(e error) {
+				err = e
+			})
+
+			output := cmd.Flags().Lookup(installer.OutputFlag).Value.String()
+			if err = pluginLogger.SetOutput(output); err != nil {
+				return
+			}
+			var watch bool
+			if watch, err = cmd.Flags().GetBool(statusWatchFlag); err != nil {
+				return
+			}
+			var interval time.Duration
+			if interval, err = cmd.Flags().GetDuration(statusIntervalFlag); err != nil {
+				return
+			}
+			options := installer.StatusOptions{
+				OperatorNamespace: cmd.Flags().Lookup(installer.StosOperatorNSFlag).Value.String(),
+			}
+
+			healthy, err = runStatus(cmd.OutOrStdout(), output, options, watch, interval)
+		},
+		PostRunE: func(cmd *cobra.Command, args []string) error {
+			traceError, _ := cmd.Flags().GetBool(installer.StackTraceFlag)
+			if err == nil && !healthy {
+				err = errors.New("StorageOS is not healthy")
+			}
+			if err := pluginutils.HandleError(status, err, traceError); err != nil {
+				pluginLogger.Error(fmt.Sprintf("%s%s", status, " has failed"))
+				return err
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Bool(installer.StackTraceFlag, false, "print stack trace of error")
+	cmd.Flags().StringP(installer.OutputFlag, "o", logger.OutputText, fmt.Sprintf("output format, one of %q, %q or %q", logger.OutputText, logger.OutputJSON, logger.OutputYAML))
+	cmd.Flags().BoolP(statusWatchFlag, "w", false, "refresh the status until interrupted")
+	cmd.Flags().Duration(statusIntervalFlag, 5*time.Second, "interval between refreshes with --watch")
+	cmd.Flags().String(installer.StosOperatorNSFlag, consts.NewOperatorNamespace, "namespace of storageos operator")
+
+	return cmd
+}
+
+// runStatus writes the status of StorageOS to w in output format, refreshing it every interval until
+// interrupted if watch is set. It returns whether StorageOS is healthy, always true when watching.
+func runStatus(w io.Writer, output string, options installer.StatusOptions, watch bool, interval time.Duration) (bool, error) {
+	config, err := pluginutils.NewClientConfig()
+	if err != nil {
+		return false, err
+	}
+	if !watch {
+		stosStatus, err := installer.CollectStatus(config, options)
+		if err != nil {
+			return false, err
+		}
+		return stosStatus.Healthy(), writeStatus(w, output, stosStatus, logger.IsSmartTerminal(w))
+	}
+	if interval <= 0 {
+		return false, errors.Errorf("--%s must be positive", statusIntervalFlag)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	smartTerminal := logger.IsSmartTerminal(w)
+	for {
+		stosStatus, err := installer.CollectStatus(config, options)
+		if err != nil {
+			return false, err
+		}
+		if smartTerminal && output == logger.OutputText {
+			fmt.Fprint(w, clearScreen)
+		}
+		if err = writeStatus(w, output, stosStatus, smartTerminal); err != nil {
+			return false, err
+		}
+
+		select {
+		case <-interrupt:
+			return true, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeStatus writes stosStatus to w as tables, colored if color is set, or as a json or yaml document.
+func writeStatus(w io.Writer, output string, stosStatus *installer.StorageOSStatus, color bool) error {
+	switch output {
+	case logger.OutputJSON:
+		data, err := json.Marshal(stosStatus)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	case logger.OutputYAML:
+		data, err := yaml.Marshal(stosStatus)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		fmt.Fprintf(w, "---\n%s", data)
+		return nil
+	}
+
+	if stosStatus.Cluster != nil {
+		fmt.Fprintf(w, "StorageOS cluster %s/%s, phase %s\n\n", stosStatus.Cluster.Namespace, stosStatus.Cluster.Name, stosStatus.Cluster.Phase)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tNAMESPACE\tSTATE\tMESSAGE")
+	for _, component := range stosStatus.Components {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", component.Name, valueOrDefault(component.Namespace, "-"), colorState(component.State, color), component.Message)
+	}
+	tw.Flush()
+
+	if len(stosStatus.Nodes) > 0 {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NODE\tPOD\tSTATE\tRESTARTS\tCSI")
+		for _, node := range stosStatus.Nodes {
+			state, csi := installer.ComponentReady, "registered"
+			if !node.Ready {
+				state = installer.ComponentNotReady
+			}
+			if !node.CSIRegistered {
+				csi = "not registered"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", node.Node, node.Pod, colorState(state, color), node.Restarts, csi)
+		}
+		tw.Flush()
+	}
+
+	if stosStatus.Cluster != nil && len(stosStatus.Cluster.Conditions) > 0 {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "CONDITION\tSTATUS\tREASON\tMESSAGE")
+		for _, condition := range stosStatus.Cluster.Conditions {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+		}
+		tw.Flush()
+	}
+
+	return nil
+}
+
+// colorState returns state colored by its severity if color is set. States are padded to the same width
+// and colored with escape codes of the same length, so that tables stay aligned.
+func colorState(state installer.ComponentState, color bool) string {
+	if !color {
+		return string(state)
+	}
+
+	code := 90
+	switch state {
+	case installer.ComponentReady:
+		code = 32
+	case installer.ComponentNotReady:
+		code = 31
+	case installer.ComponentUnknown:
+		code = 33
+	}
+
+	return fmt.Sprintf("\033[%dm%-*s\033[0m", code, len(installer.ComponentNotInstalled), state)
+}
@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/yaml"
+
+	"github.com/storageos/kubectl-storageos/pkg/apiclient"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+const (
+	apiNamespaceFlag     = "namespace"
+	apiAllNamespacesFlag = "all-namespaces"
+	apiLabelsFlag        = "labels"
+
+	// outputWide prints tables with extra columns, like kubectl.
+	outputWide = "wide"
+
+	// apiOutputFormatsAnnotation annotates the output flag of a command with the formats it supports.
+	apiOutputFormatsAnnotation = "storageos_output_formats"
+
+	defaultAPINamespace = "default"
+
+	replicasLabel = "storageos.com/replicas"
+)
+
+// apiCmd wires cmd to run against the StorageOS API of the selected cluster, connecting before run and
+// disconnecting after it, and handling errors like the other commands.
+func apiCmd(cmd *cobra.Command, run func(cmd *cobra.Command, args []string, client *apiclient.Client) error) *cobra.Command {
+	var err error
+	pluginLogger := logger.NewLogger()
+	cmd.SilenceUsage = true
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		defer pluginutils.ConvertPanicToError(func(e error) {
+			err = e
+		})
+
+		err = runAPICommand(cmd, args, run)
+	}
+	cmd.PostRunE = func(cmd *cobra.Command, args []string) error {
+		traceError, _ := cmd.Flags().GetBool(installer.StackTraceFlag)
+		name := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+		if err := pluginutils.HandleError(name, err, traceError); err != nil {
+			pluginLogger.Error(fmt.Sprintf("%s%s", name, " has failed"))
+			return err
+		}
+		return nil
+	}
+	cmd.Flags().Bool(installer.StackTraceFlag, false, "print stack trace of error")
+
+	return cmd
+}
+
+// runAPICommand validates the output flag of cmd, if any, then runs run with a client connected to the
+// StorageOS API.
+func runAPICommand(cmd *cobra.Command, args []string, run func(cmd *cobra.Command, args []string, client *apiclient.Client) error) error {
+	// the output flag of the root command is inherited by commands without one of their own
+	if output := cmd.LocalFlags().Lookup(installer.OutputFlag); output != nil {
+		if err := validateAPIOutput(output); err != nil {
+			return err
+		}
+	}
+
+	config, err := pluginutils.NewClientConfig()
+	if err != nil {
+		return err
+	}
+	client, disconnect, err := apiclient.Connect(config)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	return run(cmd, args, client)
+}
+
+// addAPINamespaceFlag adds the flag of the StorageOS namespace of volumes to cmd.
+func addAPINamespaceFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP(apiNamespaceFlag, "n", defaultAPINamespace, "storageos namespace of the volume")
+}
+
+// addAPIOutputFlag adds the output flag to cmd, with the wide format if wide is set.
+func addAPIOutputFlag(cmd *cobra.Command, wide bool) {
+	formats := []string{logger.OutputJSON, logger.OutputYAML}
+	if wide {
+		formats = append([]string{outputWide}, formats...)
+	}
+	cmd.Flags().StringP(installer.OutputFlag, "o", "", "output format, one of "+quoteFormats(formats))
+	_ = cmd.Flags().SetAnnotation(installer.OutputFlag, apiOutputFormatsAnnotation, formats)
+}
+
+// validateAPIOutput returns an error if the output flag is set to a format it wasn't added with.
+func validateAPIOutput(output *pflag.Flag) error {
+	value := output.Value.String()
+	if value == "" {
+		return nil
+	}
+	formats := output.Annotations[apiOutputFormatsAnnotation]
+	for _, format := range formats {
+		if value == format {
+			return nil
+		}
+	}
+	return errors.Errorf("unknown output format %q, expected %s", value, quoteFormats(formats))
+}
+
+// quoteFormats lists formats quoted, as "a", "b" or "c".
+func quoteFormats(formats []string) string {
+	quoted := make([]string, len(formats))
+	for i, format := range formats {
+		quoted[i] = strconv.Quote(format)
+	}
+	if len(quoted) < 2 {
+		return strings.Join(quoted, "")
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
+// apiNamespace returns the StorageOS namespace set by the namespace flag of cmd.
+func apiNamespace(cmd *cobra.Command, client *apiclient.Client) (*apiclient.Namespace, error) {
+	return client.GetNamespaceByName(cmd.Flags().Lookup(apiNamespaceFlag).Value.String())
+}
+
+// apiNamespaces returns all StorageOS namespaces if the all-namespaces flag of cmd is set, or the one set
+// by its namespace flag otherwise.
+func apiNamespaces(cmd *cobra.Command, client *apiclient.Client) ([]apiclient.Namespace, error) {
+	if all, _ := cmd.Flags().GetBool(apiAllNamespacesFlag); all {
+		return client.ListNamespaces()
+	}
+	namespace, err := apiNamespace(cmd, client)
+	if err != nil {
+		return nil, err
+	}
+	return []apiclient.Namespace{*namespace}, nil
+}
+
+// parseLabels parses labels of the form key=value[,key=value].
+func parseLabels(value string) (map[string]string, error) {
+	labels := map[string]string{}
+	if value == "" {
+		return labels, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, errors.Errorf("invalid label %q, expected key=value", pair)
+		}
+		labels[kv[0]] = kv[1]
+	}
+	return labels, nil
+}
+
+// parseSize parses a size such as 5GiB or 500MB into bytes. Units are powers of 1024.
+func parseSize(value string) (uint64, error) {
+	size, err := units.RAMInBytes(value)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if size <= 0 {
+		return 0, errors.Errorf("invalid size %q, must be positive", value)
+	}
+	return uint64(size), nil
+}
+
+func formatSize(sizeBytes uint64) string {
+	return units.BytesSize(float64(sizeBytes))
+}
+
+func formatAge(t time.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(time.Since(t))
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "<none>"
+	}
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// writeAPIObject writes obj to w as a json or yaml document.
+func writeAPIObject(w io.Writer, output string, obj interface{}) error {
+	if output == logger.OutputYAML {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		fmt.Fprintf(w, "---\n%s", data)
+		return nil
+	}
+
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	fmt.Fprintln(w, string(data))
+	return nil
+}
+
+// volumeRow is a volume as printed in tables, with the names of its namespace and nodes resolved.
+type volumeRow struct {
+	volume     apiclient.Volume
+	namespace  string
+	attachedOn string
+	masterNode string
+}
+
+// volumeRows resolves the namespace and node ids of volumes into names, falling back to ids.
+func volumeRows(volumes []apiclient.Volume, namespaces []apiclient.Namespace, nodes []apiclient.Node) []volumeRow {
+	namespaceNames, nodeNames := map[string]string{}, map[string]string{}
+	for _, namespace := range namespaces {
+		namespaceNames[namespace.ID] = namespace.Name
+	}
+	for _, node := range nodes {
+		nodeNames[node.ID] = node.Name
+	}
+	name := func(names map[string]string, id string) string {
+		if id == "" {
+			return ""
+		}
+		return valueOrDefault(names[id], id)
+	}
+
+	rows := make([]volumeRow, 0, len(volumes))
+	for _, volume := range volumes {
+		row := volumeRow{
+			volume:     volume,
+			namespace:  name(namespaceNames, volume.NamespaceID),
+			attachedOn: name(nodeNames, volume.AttachedOn),
+		}
+		if volume.Master != nil {
+			row.masterNode = name(nodeNames, volume.Master.NodeID)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// replicasSummary returns the number of ready replicas of volume over the number requested.
+func replicasSummary(volume apiclient.Volume) string {
+	ready := 0
+	for _, replica := range volume.Replicas {
+		if replica.Health == "ready" {
+			ready++
+		}
+	}
+	requested, err := strconv.Atoi(volume.Labels[replicasLabel])
+	if err != nil {
+		requested = len(volume.Replicas)
+	}
+	return fmt.Sprintf("%d/%d", ready, requested)
+}
+
+func writeVolumes(w io.Writer, output string, rows []volumeRow) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprint(tw, "NAMESPACE\tNAME\tSIZE\tATTACHED ON\tREPLICAS\tAGE")
+	if output == outputWide {
+		fmt.Fprint(tw, "\tID\tFS TYPE\tMASTER NODE\tLABELS")
+	}
+	fmt.Fprintln(tw)
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s", row.namespace, row.volume.Name, formatSize(row.volume.SizeBytes), valueOrDefault(row.attachedOn, "-"), replicasSummary(row.volume), formatAge(row.volume.CreatedAt))
+		if output == outputWide {
+			fmt.Fprintf(tw, "\t%s\t%s\t%s\t%s", row.volume.ID, row.volume.FsType, valueOrDefault(row.masterNode, "-"), formatLabels(row.volume.Labels))
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
+
+func writeNodes(w io.Writer, output string, nodes []apiclient.Node) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprint(tw, "NAME\tHEALTH\tAGE")
+	if output == outputWide {
+		fmt.Fprint(tw, "\tID\tIO ENDPOINT\tCAPACITY\tFREE\tLABELS")
+	}
+	fmt.Fprintln(tw)
+	for _, node := range nodes {
+		fmt.Fprintf(tw, "%s\t%s\t%s", node.Name, node.Health, formatAge(node.CreatedAt))
+		if output == outputWide {
+			capacity, free := "-", "-"
+			if node.Capacity != nil {
+				capacity, free = formatSize(node.Capacity.Total), formatSize(node.Capacity.Free)
+			}
+			fmt.Fprintf(tw, "\t%s\t%s\t%s\t%s\t%s", node.ID, valueOrDefault(node.IOEndpoint, "-"), capacity, free, formatLabels(node.Labels))
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
+
+func writeNamespaces(w io.Writer, output string, namespaces []apiclient.Namespace) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprint(tw, "NAME\tAGE")
+	if output == outputWide {
+		fmt.Fprint(tw, "\tID\tLABELS")
+	}
+	fmt.Fprintln(tw)
+	for _, namespace := range namespaces {
+		fmt.Fprintf(tw, "%s\t%s", namespace.Name, formatAge(namespace.CreatedAt))
+		if output == outputWide {
+			fmt.Fprintf(tw, "\t%s\t%s", namespace.ID, formatLabels(namespace.Labels))
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
+
+func writeLicence(w io.Writer, licence *apiclient.Licence) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Cluster ID:\t%s\n", licence.ClusterID)
+	fmt.Fprintf(tw, "Kind:\t%s\n", licence.Kind)
+	fmt.Fprintf(tw, "Customer:\t%s\n", valueOrDefault(licence.CustomerName, "-"))
+	fmt.Fprintf(tw, "Expires:\t%s\n", licence.ExpiresAt.Format(time.RFC3339))
+	fmt.Fprintf(tw, "Capacity:\t%s\n", formatSize(licence.ClusterCapacityBytes))
+	fmt.Fprintf(tw, "Used:\t%s\n", formatSize(licence.UsedBytes))
+	fmt.Fprintf(tw, "Features:\t%s\n", valueOrDefault(strings.Join(licence.Features, ", "), "-"))
+	tw.Flush()
+}
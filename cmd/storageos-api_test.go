@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+)
+
+func TestValidateAPIOutput(t *testing.T) {
+	tcases := []struct {
+		name   string
+		wide   bool
+		output string
+		expErr string
+	}{
+		{
+			name: "default format",
+		},
+		{
+			name:   "wide where registered",
+			wide:   true,
+			output: "wide",
+		},
+		{
+			name:   "json without wide",
+			output: "json",
+		},
+		{
+			name:   "wide where not registered",
+			output: "wide",
+			expErr: `unknown output format "wide", expected "json" or "yaml"`,
+		},
+		{
+			name:   "unknown format",
+			wide:   true,
+			output: "table",
+			expErr: `unknown output format "table", expected "wide", "json" or "yaml"`,
+		},
+	}
+
+	for _, tc := range tcases {
+		cmd := &cobra.Command{}
+		addAPIOutputFlag(cmd, tc.wide)
+		if err := cmd.Flags().Set(installer.OutputFlag, tc.output); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		errMsg := ""
+		if err := validateAPIOutput(cmd.Flags().Lookup(installer.OutputFlag)); err != nil {
+			errMsg = err.Error()
+		}
+		if errMsg != tc.expErr {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expErr, errMsg)
+		}
+	}
+}
@@ -1,25 +1,29 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 
-	"github.com/storageos/kubectl-storageos/pkg/forwarder"
-	"github.com/storageos/kubectl-storageos/pkg/logger"
+	"github.com/storageos/kubectl-storageos/pkg/apiclient"
 )
 
 func UncordonCmd() *cobra.Command {
-	cmd := &cobra.Command{
-		Use:                "uncordon",
-		Short:              "Uncordons a node",
-		Args:               cobra.MinimumNArgs(0),
-		SilenceUsage:       true,
-		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
-		DisableFlagParsing: true,
-
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return forwarder.ForwardToCLIPod(logger.NewLogger(), storageosCommand(cmd, args))
-		},
-	}
-
-	return cmd
+	return apiCmd(&cobra.Command{
+		Use:   "uncordon NODE...",
+		Args:  cobra.MinimumNArgs(1),
+		Short: "Uncordons a node",
+	}, func(cmd *cobra.Command, args []string, client *apiclient.Client) error {
+		for _, name := range args {
+			node, err := client.GetNodeByName(name)
+			if err != nil {
+				return err
+			}
+			if err = client.UncordonNode(node); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "node/%s uncordoned\n", node.Name)
+		}
+		return nil
+	})
 }
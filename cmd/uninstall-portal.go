@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"k8s.io/client-go/util/retry"
@@ -19,7 +17,7 @@ const uninstallPortal = "uninstall-portal"
 
 func UninstallPortalCmd() *cobra.Command {
 	var err error
-	var traceError bool
+	var traceError, configShown bool
 	pluginLogger := logger.NewLogger()
 	cmd := &cobra.Command{
 		Use:          uninstallPortal,
@@ -34,21 +32,30 @@ func UninstallPortalCmd() *cobra.Command {
 			})
 
 			config := &apiv1.KubectlStorageOSConfig{}
-			if err = setUninstallPortalValues(cmd, config); err != nil {
+			var fields []configField
+			if fields, err = setUninstallPortalValues(cmd, config); err != nil {
+				return
+			}
+			if configShown = showConfig(cmd, fields); configShown {
 				return
 			}
 
 			traceError = config.Spec.StackTrace
+			if err = pluginLogger.SetOutput(config.Spec.Output); err != nil {
+				return
+			}
 
 			err = uninstallPortalCmd(config, pluginLogger)
 		},
 		PostRunE: func(cmd *cobra.Command, args []string) error {
+			if configShown {
+				return nil
+			}
 			if err := pluginutils.HandleError(uninstallPortal, err, traceError); err != nil {
-				pluginLogger.Error(fmt.Sprintf("%s%s", uninstallPortal, " has failed"))
-
+				pluginLogger.Result(uninstallPortal, err, "Portal Manager uninstalled successfully.")
 				return err
 			}
-			pluginLogger.Success("Portal Manager uninstalled successfully.")
+			pluginLogger.Result(uninstallPortal, nil, "Portal Manager uninstalled successfully.")
 			return nil
 		},
 	}
@@ -60,6 +67,7 @@ func UninstallPortalCmd() *cobra.Command {
 	cmd.Flags().String(installer.StosPortalConfigYamlFlag, "", "storageos-portal-manager-configmap.yaml path or url")
 	cmd.Flags().String(installer.StosPortalClientSecretYamlFlag, "", "storageos-portal-manager-client-secret.yaml path or url")
 	cmd.Flags().Bool(installer.AirGapFlag, false, "uninstall portal manger in an air gapped environment")
+	addShowConfigFlag(cmd)
 
 	viper.BindPFlags(cmd.Flags())
 
@@ -74,7 +82,7 @@ func uninstallPortalCmd(config *apiv1.KubectlStorageOSConfig, log *logger.Logger
 		return err
 	}
 
-	if err := versionSupportsFeature(existingOperatorVersion, consts.PortalManagerFirstSupportedVersion); err != nil {
+	if err := operatorSupportsFeature(existingOperatorVersion, version.FeaturePortalManager); err != nil {
 		return err
 	}
 
@@ -109,45 +117,20 @@ func uninstallPortalCmd(config *apiv1.KubectlStorageOSConfig, log *logger.Logger
 	})
 }
 
-func setUninstallPortalValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) error {
-	viper.BindPFlag(installer.StosConfigPathFlag, cmd.Flags().Lookup(installer.StosConfigPathFlag))
-	v := viper.GetViper()
-	viper.SetConfigName("kubectl-storageos-config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(v.GetString(installer.StosConfigPathFlag))
-
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			// Config file was found but another error was produced
-			return fmt.Errorf("error discovered in config file: %v", err)
-		}
-		// Config file not found; set fields in new config object directly
-		config.Spec.StackTrace, err = cmd.Flags().GetBool(installer.StackTraceFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Verbose, err = cmd.Flags().GetBool(installer.VerboseFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.AirGap, err = cmd.Flags().GetBool(installer.AirGapFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Uninstall.StorageOSOperatorNamespace = cmd.Flags().Lookup(installer.StosOperatorNSFlag).Value.String()
-		config.Spec.Uninstall.PortalManagerVersion = cmd.Flags().Lookup(installer.PortalManagerVersionFlag).Value.String()
-		config.Spec.Uninstall.StorageOSPortalConfigYaml = cmd.Flags().Lookup(installer.StosPortalConfigYamlFlag).Value.String()
-		config.Spec.Uninstall.StorageOSPortalClientSecretYaml = cmd.Flags().Lookup(installer.StosPortalClientSecretYamlFlag).Value.String()
-		return nil
+func setUninstallPortalValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) ([]configField, error) {
+	layers, err := newConfigLayers(cmd)
+	if err != nil {
+		return nil, err
 	}
-	// config file read without error, set fields in new config object
-	config.Spec.StackTrace = viper.GetBool(installer.StackTraceConfig)
-	config.Spec.Verbose = viper.GetBool(installer.VerboseConfig)
-	config.Spec.AirGap = viper.GetBool(installer.AirGapConfig)
-	config.Spec.Uninstall.StorageOSOperatorNamespace = viper.GetString(installer.UninstallStosOperatorNSConfig)
-	config.Spec.Uninstall.PortalManagerVersion = viper.GetString(installer.UninstallPortalManagerVersionConfig)
-	config.Spec.Uninstall.StorageOSPortalConfigYaml = viper.GetString(installer.UninstallStosPortalConfigYamlConfig)
-	config.Spec.Uninstall.StorageOSPortalClientSecretYaml = viper.GetString(installer.UninstallStosPortalClientSecretYamlConfig)
-
-	return nil
+
+	config.Spec.StackTrace = layers.Bool(installer.StackTraceFlag, installer.StackTraceConfig)
+	config.Spec.Verbose = layers.Bool(installer.VerboseFlag, installer.VerboseConfig)
+	config.Spec.Output = layers.String(installer.OutputFlag, installer.OutputConfig)
+	config.Spec.AirGap = layers.Bool(installer.AirGapFlag, installer.AirGapConfig)
+	config.Spec.Uninstall.StorageOSOperatorNamespace = layers.String(installer.StosOperatorNSFlag, installer.UninstallStosOperatorNSConfig)
+	config.Spec.Uninstall.PortalManagerVersion = layers.String(installer.PortalManagerVersionFlag, installer.UninstallPortalManagerVersionConfig)
+	config.Spec.Uninstall.StorageOSPortalConfigYaml = layers.String(installer.StosPortalConfigYamlFlag, installer.UninstallStosPortalConfigYamlConfig)
+	config.Spec.Uninstall.StorageOSPortalClientSecretYaml = layers.String(installer.StosPortalClientSecretYamlFlag, installer.UninstallStosPortalClientSecretYamlConfig)
+
+	return layers.fields, layers.Err()
 }
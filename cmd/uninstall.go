@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
@@ -18,7 +16,7 @@ const uninstall = "uninstall"
 
 func UninstallCmd() *cobra.Command {
 	var err error
-	var traceError bool
+	var traceError, configShown bool
 	pluginLogger := logger.NewLogger()
 	cmd := &cobra.Command{
 		Use:          uninstall,
@@ -32,21 +30,30 @@ func UninstallCmd() *cobra.Command {
 				err = e
 			})
 			config := &apiv1.KubectlStorageOSConfig{}
-			err = setUninstallValues(cmd, config)
-			if err != nil {
+			var fields []configField
+			if fields, err = setUninstallValues(cmd, config); err != nil {
+				return
+			}
+			if configShown = showConfig(cmd, fields); configShown {
 				return
 			}
 
 			traceError = config.Spec.StackTrace
+			if err = pluginLogger.SetOutput(config.Spec.Output); err != nil {
+				return
+			}
 
 			err = uninstallCmd(config, pluginutils.HasFlagSet(installer.SkipNamespaceDeletionFlag), pluginLogger)
 		},
 		PostRunE: func(cmd *cobra.Command, args []string) error {
+			if configShown {
+				return nil
+			}
 			if err = pluginutils.HandleError(uninstall, err, traceError); err != nil {
-				pluginLogger.Error(fmt.Sprintf("%s%s", uninstall, " has failed"))
+				pluginLogger.Result(uninstall, err, "StorageOS uninstalled successfully.")
 				return err
 			}
-			pluginLogger.Success("StorageOS uninstalled successfully.")
+			pluginLogger.Result(uninstall, nil, "StorageOS uninstalled successfully.")
 			return nil
 		},
 	}
@@ -73,6 +80,7 @@ func UninstallCmd() *cobra.Command {
 	cmd.Flags().String(installer.StosVersionFlag, "", "version of storageos operator to uninstall")
 	cmd.Flags().String(installer.EtcdOperatorVersionFlag, "", "version of etcd operator to uninstall")
 	cmd.Flags().String(installer.PortalManagerVersionFlag, "", "version of portal manager to uninstall")
+	addShowConfigFlag(cmd)
 
 	viper.BindPFlags(cmd.Flags())
 
@@ -92,6 +100,12 @@ func uninstallCmd(config *apiv1.KubectlStorageOSConfig, skipNamespaceDeletionHas
 		}
 	}
 
+	state := recordedInstallState(log)
+
+	if config.Spec.Uninstall.StorageOSVersion == "" && state.StorageOSVersion != "" {
+		config.Spec.Uninstall.StorageOSVersion = state.StorageOSVersion
+		log.Successf("Found recorded StorageOS cluster and operator version '%s'.", config.Spec.Uninstall.StorageOSVersion)
+	}
 	if config.Spec.Uninstall.StorageOSVersion == "" {
 		config.Spec.Uninstall.StorageOSVersion, err = pluginversion.GetExistingOperatorVersion(config.Spec.Uninstall.StorageOSOperatorNamespace)
 		if err != nil {
@@ -100,6 +114,10 @@ func uninstallCmd(config *apiv1.KubectlStorageOSConfig, skipNamespaceDeletionHas
 		log.Successf("Discovered StorageOS cluster and operator version '%s'.", config.Spec.Uninstall.StorageOSVersion)
 	}
 
+	if config.Spec.Uninstall.PortalManagerVersion == "" && state.PortalManagerVersion != "" {
+		config.Spec.Uninstall.PortalManagerVersion = state.PortalManagerVersion
+		log.Successf("Found recorded Portal Manager version '%s'.", config.Spec.Uninstall.PortalManagerVersion)
+	}
 	if config.Spec.Uninstall.PortalManagerVersion == "" {
 		config.Spec.Uninstall.PortalManagerVersion, err = pluginversion.GetExistingPortalManagerVersion()
 		if err != nil {
@@ -111,6 +129,10 @@ func uninstallCmd(config *apiv1.KubectlStorageOSConfig, skipNamespaceDeletionHas
 	}
 
 	if config.Spec.IncludeEtcd {
+		if config.Spec.Uninstall.EtcdOperatorVersion == "" && state.EtcdOperatorVersion != "" {
+			config.Spec.Uninstall.EtcdOperatorVersion = state.EtcdOperatorVersion
+			log.Successf("Found recorded ETCD cluster and operator version '%s'.", config.Spec.Uninstall.EtcdOperatorVersion)
+		}
 		if config.Spec.Uninstall.EtcdOperatorVersion == "" {
 			config.Spec.Uninstall.EtcdOperatorVersion, err = pluginversion.GetExistingEtcdOperatorVersion(config.Spec.Uninstall.EtcdNamespace)
 			if err != nil {
@@ -134,100 +156,44 @@ func uninstallCmd(config *apiv1.KubectlStorageOSConfig, skipNamespaceDeletionHas
 	}
 
 	log.Commencing(uninstall)
-	return cliInstaller.Uninstall(false)
-}
+	cliInstaller.StartOperation(installer.UninstallOperation)
+	err = cliInstaller.Uninstall(false)
+	cliInstaller.FinishOperation(installer.UninstallOperation, err)
 
-func setUninstallValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) error {
-	viper.BindPFlag(installer.StosConfigPathFlag, cmd.Flags().Lookup(installer.StosConfigPathFlag))
-	v := viper.GetViper()
-	viper.SetConfigName("kubectl-storageos-config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(v.GetString(installer.StosConfigPathFlag))
-
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			// Config file was found but another error was produced
-			return fmt.Errorf("error discovered in config file: %v", err)
-		}
-		// Config file not found; set fields in new config object directly
-		config.Spec.StackTrace, err = cmd.Flags().GetBool(installer.StackTraceFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Verbose, err = cmd.Flags().GetBool(installer.VerboseFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.SkipNamespaceDeletion, err = cmd.Flags().GetBool(installer.SkipNamespaceDeletionFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.SkipExistingWorkloadCheck, err = cmd.Flags().GetBool(installer.SkipExistingWorkloadCheckFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.IncludeEtcd, err = cmd.Flags().GetBool(installer.IncludeEtcdFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.SkipStorageOSCluster, err = cmd.Flags().GetBool(installer.SkipStosClusterFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.IncludeLocalPathProvisioner, err = cmd.Flags().GetBool(installer.IncludeLocalPathProvisionerFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Serial, err = cmd.Flags().GetBool(installer.SerialFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.AirGap, err = cmd.Flags().GetBool(installer.AirGapFlag)
-		if err != nil {
-			return err
-		}
+	return err
+}
 
-		config.Spec.Uninstall.StorageOSOperatorNamespace = cmd.Flags().Lookup(installer.StosOperatorNSFlag).Value.String()
-		config.Spec.Uninstall.EtcdNamespace = cmd.Flags().Lookup(installer.EtcdNamespaceFlag).Value.String()
-		config.Spec.Uninstall.StorageOSOperatorYaml = cmd.Flags().Lookup(installer.StosOperatorYamlFlag).Value.String()
-		config.Spec.Uninstall.StorageOSClusterYaml = cmd.Flags().Lookup(installer.StosClusterYamlFlag).Value.String()
-		config.Spec.Uninstall.StorageOSPortalConfigYaml = cmd.Flags().Lookup(installer.StosPortalConfigYamlFlag).Value.String()
-		config.Spec.Uninstall.StorageOSPortalClientSecretYaml = cmd.Flags().Lookup(installer.StosPortalClientSecretYamlFlag).Value.String()
-		config.Spec.Uninstall.EtcdOperatorYaml = cmd.Flags().Lookup(installer.EtcdOperatorYamlFlag).Value.String()
-		config.Spec.Uninstall.EtcdClusterYaml = cmd.Flags().Lookup(installer.EtcdClusterYamlFlag).Value.String()
-		config.Spec.Uninstall.ResourceQuotaYaml = cmd.Flags().Lookup(installer.ResourceQuotaYamlFlag).Value.String()
-		config.Spec.Uninstall.LocalPathProvisionerYaml = cmd.Flags().Lookup(installer.LocalPathProvisionerYamlFlag).Value.String()
-		config.Spec.Uninstall.StorageOSVersion = cmd.Flags().Lookup(installer.StosVersionFlag).Value.String()
-		config.Spec.Uninstall.EtcdOperatorVersion = cmd.Flags().Lookup(installer.EtcdOperatorVersionFlag).Value.String()
-		config.Spec.Uninstall.PortalManagerVersion = cmd.Flags().Lookup(installer.PortalManagerVersionFlag).Value.String()
-
-		return nil
+func setUninstallValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) ([]configField, error) {
+	layers, err := newConfigLayers(cmd)
+	if err != nil {
+		return nil, err
 	}
-	// config file read without error, set fields in new config object
-	config.Spec.StackTrace = viper.GetBool(installer.StackTraceConfig)
-	config.Spec.Verbose = viper.GetBool(installer.VerboseConfig)
-	config.Spec.SkipNamespaceDeletion = viper.GetBool(installer.SkipNamespaceDeletionConfig)
-	config.Spec.SkipExistingWorkloadCheck = viper.GetBool(installer.SkipExistingWorkloadCheckConfig)
-	config.Spec.SkipStorageOSCluster = viper.GetBool(installer.SkipStosClusterConfig)
-	config.Spec.IncludeEtcd = viper.GetBool(installer.IncludeEtcdConfig)
-	config.Spec.Serial = viper.GetBool(installer.SerialConfig)
-	config.Spec.AirGap = viper.GetBool(installer.AirGapConfig)
-	config.Spec.Uninstall.StorageOSOperatorNamespace = viper.GetString(installer.UninstallStosOperatorNSConfig)
-	config.Spec.Uninstall.EtcdNamespace = valueOrDefault(viper.GetString(installer.UninstallEtcdNSConfig), consts.EtcdOperatorNamespace)
-	config.Spec.Uninstall.StorageOSOperatorYaml = viper.GetString(installer.UninstallStosOperatorYamlConfig)
-	config.Spec.Uninstall.StorageOSClusterYaml = viper.GetString(installer.UninstallStosClusterYamlConfig)
-	config.Spec.Uninstall.StorageOSPortalConfigYaml = viper.GetString(installer.UninstallStosPortalConfigYamlConfig)
-	config.Spec.Uninstall.StorageOSPortalClientSecretYaml = viper.GetString(installer.UninstallStosPortalClientSecretYamlConfig)
-	config.Spec.Uninstall.EtcdOperatorYaml = viper.GetString(installer.UninstallEtcdOperatorYamlConfig)
-	config.Spec.Uninstall.EtcdClusterYaml = viper.GetString(installer.UninstallEtcdClusterYamlConfig)
-	config.Spec.Uninstall.ResourceQuotaYaml = viper.GetString(installer.UninstallResourceQuotaYamlConfig)
-	config.Spec.IncludeLocalPathProvisioner = viper.GetBool(installer.IncludeLocalPathProvisionerConfig)
-	config.Spec.Uninstall.LocalPathProvisionerYaml = viper.GetString(installer.UninstallLocalPathProvisionerYamlConfig)
-	config.Spec.Uninstall.StorageOSVersion = viper.GetString(installer.UninstallStosVersionConfig)
-	config.Spec.Uninstall.EtcdOperatorVersion = viper.GetString(installer.UninstallEtcdOperatorVersionConfig)
-	config.Spec.Uninstall.PortalManagerVersion = viper.GetString(installer.UninstallPortalManagerVersionConfig)
-
-	return nil
+
+	config.Spec.StackTrace = layers.Bool(installer.StackTraceFlag, installer.StackTraceConfig)
+	config.Spec.Verbose = layers.Bool(installer.VerboseFlag, installer.VerboseConfig)
+	config.Spec.Output = layers.String(installer.OutputFlag, installer.OutputConfig)
+	config.Spec.SkipNamespaceDeletion = layers.Bool(installer.SkipNamespaceDeletionFlag, installer.SkipNamespaceDeletionConfig)
+	config.Spec.SkipExistingWorkloadCheck = layers.Bool(installer.SkipExistingWorkloadCheckFlag, installer.SkipExistingWorkloadCheckConfig)
+	config.Spec.SkipStorageOSCluster = layers.Bool(installer.SkipStosClusterFlag, installer.SkipStosClusterConfig)
+	config.Spec.IncludeEtcd = layers.Bool(installer.IncludeEtcdFlag, installer.IncludeEtcdConfig)
+	config.Spec.IncludeLocalPathProvisioner = layers.Bool(installer.IncludeLocalPathProvisionerFlag, installer.IncludeLocalPathProvisionerConfig)
+	config.Spec.Serial = layers.Bool(installer.SerialFlag, installer.SerialConfig)
+	config.Spec.AirGap = layers.Bool(installer.AirGapFlag, installer.AirGapConfig)
+	config.Spec.Uninstall.StorageOSOperatorNamespace = layers.String(installer.StosOperatorNSFlag, installer.UninstallStosOperatorNSConfig)
+	config.Spec.Uninstall.EtcdNamespace = layers.String(installer.EtcdNamespaceFlag, installer.UninstallEtcdNSConfig)
+	config.Spec.Uninstall.StorageOSOperatorYaml = layers.String(installer.StosOperatorYamlFlag, installer.UninstallStosOperatorYamlConfig)
+	config.Spec.Uninstall.StorageOSClusterYaml = layers.String(installer.StosClusterYamlFlag, installer.UninstallStosClusterYamlConfig)
+	config.Spec.Uninstall.StorageOSPortalConfigYaml = layers.String(installer.StosPortalConfigYamlFlag, installer.UninstallStosPortalConfigYamlConfig)
+	config.Spec.Uninstall.StorageOSPortalClientSecretYaml = layers.String(installer.StosPortalClientSecretYamlFlag, installer.UninstallStosPortalClientSecretYamlConfig)
+	config.Spec.Uninstall.EtcdOperatorYaml = layers.String(installer.EtcdOperatorYamlFlag, installer.UninstallEtcdOperatorYamlConfig)
+	config.Spec.Uninstall.EtcdClusterYaml = layers.String(installer.EtcdClusterYamlFlag, installer.UninstallEtcdClusterYamlConfig)
+	config.Spec.Uninstall.ResourceQuotaYaml = layers.String(installer.ResourceQuotaYamlFlag, installer.UninstallResourceQuotaYamlConfig)
+	config.Spec.Uninstall.LocalPathProvisionerYaml = layers.String(installer.LocalPathProvisionerYamlFlag, installer.UninstallLocalPathProvisionerYamlConfig)
+	config.Spec.Uninstall.StorageOSVersion = layers.String(installer.StosVersionFlag, installer.UninstallStosVersionConfig)
+	config.Spec.Uninstall.EtcdOperatorVersion = layers.String(installer.EtcdOperatorVersionFlag, installer.UninstallEtcdOperatorVersionConfig)
+	config.Spec.Uninstall.PortalManagerVersion = layers.String(installer.PortalManagerVersionFlag, installer.UninstallPortalManagerVersionConfig)
+
+	return layers.fields, layers.Err()
 }
 
 func setVersionSpecificValues(config *apiv1.KubectlStorageOSConfig) (err error) {
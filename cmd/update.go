@@ -1,16 +1,23 @@
 package cmd
 
 import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/storageos/kubectl-storageos/pkg/apiclient"
 	"github.com/storageos/kubectl-storageos/pkg/forwarder"
 	"github.com/storageos/kubectl-storageos/pkg/logger"
 )
 
 func UpdateCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:                "update",
-		Short:              "Make changes to existing resources",
+		Use:   "update",
+		Short: "Make changes to existing resources",
+		Long: `Update the replicas, description, labels and size of volumes through the StorageOS API. Other resources are
+updated by the StorageOS CLI pod.`,
 		Args:               cobra.MinimumNArgs(0),
 		SilenceUsage:       true,
 		FParseErrWhitelist: cobra.FParseErrWhitelist{UnknownFlags: true},
@@ -21,5 +28,66 @@ func UpdateCmd() *cobra.Command {
 		},
 	}
 
+	volumeCmd := &cobra.Command{
+		Use:     "volume",
+		Aliases: []string{"vol"},
+		Short:   "Make changes to existing volumes",
+	}
+	volumeCmd.AddCommand(updateVolumeCmd("replicas NAME COUNT", "Set the number of replicas of a volume", func(client *apiclient.Client, volume *apiclient.Volume, value string) error {
+		replicas, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return errors.Errorf("invalid number of replicas %q", value)
+		}
+		return client.SetReplicas(volume, replicas)
+	}))
+	volumeCmd.AddCommand(updateVolumeCmd("description NAME DESCRIPTION", "Set the description of a volume", func(client *apiclient.Client, volume *apiclient.Volume, value string) error {
+		_, err := client.UpdateVolume(volume, value, volume.Labels)
+		return err
+	}))
+	volumeCmd.AddCommand(updateVolumeCmd("labels NAME KEY=VALUE[,KEY=VALUE]", "Replace the labels of a volume", func(client *apiclient.Client, volume *apiclient.Volume, value string) error {
+		labels, err := parseLabels(value)
+		if err != nil {
+			return err
+		}
+		_, err = client.UpdateVolume(volume, volume.Description, labels)
+		return err
+	}))
+	volumeCmd.AddCommand(updateVolumeCmd("size NAME SIZE", "Grow a volume to a new size", func(client *apiclient.Client, volume *apiclient.Volume, value string) error {
+		size, err := parseSize(value)
+		if err != nil {
+			return err
+		}
+		_, err = client.ResizeVolume(volume, size)
+		return err
+	}))
+	cmd.AddCommand(volumeCmd)
+
+	return cmd
+}
+
+// updateVolumeCmd returns a command which applies update to the volume named by its first argument with
+// its second argument.
+func updateVolumeCmd(use, short string, update func(client *apiclient.Client, volume *apiclient.Volume, value string) error) *cobra.Command {
+	cmd := apiCmd(&cobra.Command{
+		Use:   use,
+		Args:  cobra.ExactArgs(2),
+		Short: short,
+	}, func(cmd *cobra.Command, args []string, client *apiclient.Client) error {
+		namespace, err := apiNamespace(cmd, client)
+		if err != nil {
+			return err
+		}
+		volume, err := client.GetVolumeByName(namespace.ID, args[0])
+		if err != nil {
+			return err
+		}
+		if err = update(client, volume, args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "volume/%s updated\n", volume.Name)
+		return nil
+	})
+	addAPINamespaceFlag(cmd)
+
 	return cmd
 }
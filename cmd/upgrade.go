@@ -46,7 +46,7 @@ const (
 
 func UpgradeCmd() *cobra.Command {
 	var err error
-	var traceError bool
+	var traceError, configShown bool
 	pluginLogger := logger.NewLogger()
 	cmd := &cobra.Command{
 		Use:          upgrade,
@@ -60,28 +60,53 @@ func UpgradeCmd() *cobra.Command {
 			})
 
 			uninstallConfig := &apiv1.KubectlStorageOSConfig{}
-			if err = setUpgradeUninstallValues(cmd, uninstallConfig); err != nil {
+			var uninstallFields, installFields []configField
+			if uninstallFields, err = setUpgradeUninstallValues(cmd, uninstallConfig); err != nil {
 				return
 			}
 
 			installConfig := &apiv1.KubectlStorageOSConfig{}
-			if err = setUpgradeInstallValues(cmd, installConfig); err != nil {
+			if installFields, err = setUpgradeInstallValues(cmd, installConfig); err != nil {
+				return
+			}
+			if configShown = showConfig(cmd, uninstallFields, installFields); configShown {
 				return
 			}
 
 			traceError = installConfig.Spec.StackTrace
+			if err = pluginLogger.SetOutput(installConfig.Spec.Output); err != nil {
+				return
+			}
+
+			var resume bool
+			if resume, err = cmd.Flags().GetBool(installer.ResumeFlag); err != nil {
+				return
+			}
 
-			err = upgradeCmd(uninstallConfig, installConfig, pluginutils.HasFlagSet(installer.SkipNamespaceDeletionFlag), pluginLogger)
+			err = upgradeCmd(uninstallConfig, installConfig, pluginutils.HasFlagSet(installer.SkipNamespaceDeletionFlag), resume, pluginLogger)
 		},
 		PostRunE: func(cmd *cobra.Command, args []string) error {
+			if configShown {
+				return nil
+			}
 			if err := pluginutils.HandleError(upgrade, err, traceError); err != nil {
-				pluginLogger.Error(fmt.Sprintf("%s%s", upgrade, " has failed"))
+				pluginLogger.Result(upgrade, err, "StorageOS upgraded successfully.")
 				return err
 			}
-			pluginLogger.Success("StorageOS upgraded successfully.")
+			pluginLogger.Result(upgrade, nil, "StorageOS upgraded successfully.")
 			return nil
 		},
 	}
+	addUpgradeFlags(cmd)
+	addShowConfigFlag(cmd)
+
+	viper.BindPFlags(cmd.Flags())
+
+	return cmd
+}
+
+// addUpgradeFlags adds the flags of upgrade to cmd.
+func addUpgradeFlags(cmd *cobra.Command) {
 	cmd.Flags().Bool(installer.StackTraceFlag, false, "print stack trace of error")
 	cmd.Flags().BoolP(installer.VerboseFlag, "v", false, "verbose logging")
 	cmd.Flags().Bool(installer.WaitFlag, false, "wait for storageos cluster to enter running phase")
@@ -111,12 +136,13 @@ func UpgradeCmd() *cobra.Command {
 	cmd.Flags().String(installer.EtcdEndpointsFlag, "", "endpoints of pre-existing etcd backend for storageos (implies not --include-etcd)")
 	cmd.Flags().String(installer.EtcdSecretNameFlag, consts.EtcdSecretName, "name of etcd secret in storageos cluster namespace")
 	cmd.Flags().Bool(installer.SkipEtcdEndpointsValFlag, false, "skip validation of etcd endpoints")
+	cmd.Flags().String(installer.EtcdValidationModeFlag, installer.EtcdValidationShellPod, fmt.Sprintf("etcd endpoints validation mode, %q execs etcdctl in a shell pod, %q uses a native etcd client through a port-forward", installer.EtcdValidationShellPod, installer.EtcdValidationClient))
 	cmd.Flags().Bool(installer.SkipStosClusterFlag, false, "skip storageos cluster during upgrade")
 	cmd.Flags().Bool(installer.EtcdTLSEnabledFlag, false, "etcd cluster is tls enabled")
-	cmd.Flags().String(installer.AdminUsernameFlag, "", "storageos admin username (plaintext)")
-	cmd.Flags().String(installer.AdminPasswordFlag, "", "storageos admin password (plaintext)")
-	cmd.Flags().String(installer.PortalClientIDFlag, "", "storageos portal client id (plaintext)")
-	cmd.Flags().String(installer.PortalSecretFlag, "", "storageos portal secret (plaintext)")
+	cmd.Flags().String(installer.AdminUsernameFlag, "", "storageos admin username, "+credentialReferenceHelp)
+	cmd.Flags().String(installer.AdminPasswordFlag, "", "storageos admin password, "+credentialReferenceHelp)
+	cmd.Flags().String(installer.PortalClientIDFlag, "", "storageos portal client id, "+credentialReferenceHelp)
+	cmd.Flags().String(installer.PortalSecretFlag, "", "storageos portal secret, "+credentialReferenceHelp)
 	cmd.Flags().String(installer.PortalAPIURLFlag, "", "storageos portal api url")
 	cmd.Flags().String(installer.PortalTenantIDFlag, "", "storageos portal tenant id")
 	cmd.Flags().Bool(installer.EnableMetricsFlag, false, "enable metrics exporter")
@@ -124,13 +150,17 @@ func UpgradeCmd() *cobra.Command {
 	cmd.Flags().Bool(installer.AirGapFlag, false, "upgrade in an air gapped environment")
 	cmd.Flags().Bool(installer.EnableNodeGuardFlag, false, "enable node guard")
 	cmd.Flags().String(installer.NodeGuardEnvFlag, "", "comma delimited string of environment variables for node guard - eg: \"MINIMUM_REPLICAS=2,WATCH_ALL_VOLUMES=true\"")
-
-	viper.BindPFlags(cmd.Flags())
-
-	return cmd
+	cmd.Flags().Bool(installer.ResumeFlag, false, "resume an interrupted upgrade from its last completed step")
+	cmd.Flags().Bool(installer.SkipRollbackFlag, false, "do not reinstall the previous version if the new version fails to install")
+	cmd.Flags().Duration(installer.ReadinessTimeoutFlag, installer.DefaultReadinessTimeout, "time to wait for installed CRDs and deployments to become ready")
+	cmd.Flags().Bool(installer.ServerSideApplyFlag, false, "apply manifests with server-side apply, as field manager \"kubectl-storageos\"")
+	cmd.Flags().Bool(installer.ForceConflictsFlag, false, "take ownership of fields owned by other field managers on server-side apply")
+	cmd.Flags().String(installer.RegistryMirrorFlag, "", "registry to pull all images of the new version from, keeping their repositories")
+	cmd.Flags().String(installer.ImageMappingFlag, "", "path of a yaml file mapping image names, or prefixes of them, to the names to pull them as")
+	cmd.Flags().Duration(installer.RemovalTimeoutFlag, installer.DefaultRemovalTimeout, "time to wait for uninstalled CRDs and operator-owned objects to be removed")
 }
 
-func upgradeCmd(uninstallConfig *apiv1.KubectlStorageOSConfig, installConfig *apiv1.KubectlStorageOSConfig, skipNamespaceDeletionHasSet bool, log *logger.Logger) error {
+func upgradeCmd(uninstallConfig *apiv1.KubectlStorageOSConfig, installConfig *apiv1.KubectlStorageOSConfig, skipNamespaceDeletionHasSet, resume bool, log *logger.Logger) error {
 	log.Verbose = uninstallConfig.Spec.Verbose
 
 	if installConfig.Spec.AirGap {
@@ -141,24 +171,45 @@ func upgradeCmd(uninstallConfig *apiv1.KubectlStorageOSConfig, installConfig *ap
 		return err
 	}
 
-	if err := setStorageOSVersionsInConfigs(uninstallConfig, installConfig, log); err != nil {
+	if err := validateServerSideApply(installConfig.Spec.Install); err != nil {
 		return err
 	}
 
-	if err := setPortalManagerVersionsInConfigs(uninstallConfig, installConfig, log); err != nil {
+	if err := validateImageRewrite(installConfig.Spec.Install); err != nil {
 		return err
 	}
 
-	if installConfig.Spec.Install.AdminPassword != "" {
-		if err := validatePassword(installConfig.Spec.Install.AdminPassword); err != nil {
+	// backed up manifests are re-applied by the uninstaller, with the apply options of the install
+	uninstallConfig.Spec.Install.ServerSideApply = installConfig.Spec.Install.ServerSideApply
+	uninstallConfig.Spec.Install.ForceConflicts = installConfig.Spec.Install.ForceConflicts
+
+	state := recordedInstallState(log)
+
+	// an interrupted upgrade is resumed between the versions it was started with, as the
+	// previous version may no longer be discoverable in the cluster.
+	if resume {
+		checkpoint, err := installer.ResumableUpgrade(state)
+		if err != nil {
 			return err
 		}
+		installConfig.Spec.Install.StorageOSVersion = valueOrDefault(installConfig.Spec.Install.StorageOSVersion, checkpoint.ToVersion)
+		uninstallConfig.Spec.Uninstall.StorageOSVersion = valueOrDefault(uninstallConfig.Spec.Uninstall.StorageOSVersion, checkpoint.FromVersion)
 	}
 
-	if installConfig.Spec.Install.EnableMetrics != nil && *installConfig.Spec.Install.EnableMetrics {
-		if err := versionSupportsFeature(installConfig.Spec.Install.StorageOSVersion, consts.MetricsExporterFirstSupportedVersion); err != nil {
-			return fmt.Errorf("failed to enable metrics exporter: %w", err)
-		}
+	if err := setStorageOSVersionsInConfigs(uninstallConfig, installConfig, state, log); err != nil {
+		return err
+	}
+
+	if err := setPortalManagerVersionsInConfigs(uninstallConfig, installConfig, state, log); err != nil {
+		return err
+	}
+
+	if err := completeCredentials(&installConfig.Spec.Install); err != nil {
+		return err
+	}
+
+	if err := checkCompatibility(installConfig.Spec.Install); err != nil {
+		return err
 	}
 
 	// if node guard env vars have been set, set enable flag implicitly
@@ -181,206 +232,107 @@ func upgradeCmd(uninstallConfig *apiv1.KubectlStorageOSConfig, installConfig *ap
 	}
 
 	log.Commencing(upgrade)
-	return installer.Upgrade(uninstallConfig, installConfig, log)
+	return installer.Upgrade(uninstallConfig, installConfig, resume, log)
 }
 
-func setUpgradeInstallValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) error {
-	viper.BindPFlag(installer.StosConfigPathFlag, cmd.Flags().Lookup(installer.StosConfigPathFlag))
-	v := viper.GetViper()
-	viper.SetConfigName("kubectl-storageos-config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(v.GetString(installer.StosConfigPathFlag))
-
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			// Config file was found but another error was produced
-			return fmt.Errorf("error discovered in config file: %v", err)
-		}
-		// Config file not found; set fields in new config object directly
-		config.Spec.IncludeEtcd = false
-		config.Spec.StackTrace, err = cmd.Flags().GetBool(installer.StackTraceFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Verbose, err = cmd.Flags().GetBool(installer.VerboseFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Install.Wait, err = cmd.Flags().GetBool(installer.WaitFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Install.EnablePortalManager, err = cmd.Flags().GetBool(installer.EnablePortalManagerFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.SkipExistingWorkloadCheck, err = cmd.Flags().GetBool(installer.SkipExistingWorkloadCheckFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.SkipStorageOSCluster, err = cmd.Flags().GetBool(installer.SkipStosClusterFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Serial, err = cmd.Flags().GetBool(installer.SerialFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.AirGap, err = cmd.Flags().GetBool(installer.AirGapFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Install.SkipEtcdEndpointsValidation, err = cmd.Flags().GetBool(installer.SkipEtcdEndpointsValFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Install.EtcdTLSEnabled, err = cmd.Flags().GetBool(installer.EtcdTLSEnabledFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Install.EnableMetrics, err = GetBoolIfFlagSet(cmd.Flags(), installer.EnableMetricsFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Install.EnableNodeGuard, err = cmd.Flags().GetBool(installer.EnableNodeGuardFlag)
-		if err != nil {
-			return err
-		}
-
-		config.Spec.Install.StorageOSVersion = cmd.Flags().Lookup(installStosVersionFlag).Value.String()
-		config.Spec.Install.PortalManagerVersion = cmd.Flags().Lookup(installPortalManagerVersionFlag).Value.String()
-		config.Spec.Install.StorageOSOperatorYaml = cmd.Flags().Lookup(installStosOperatorYamlFlag).Value.String()
-		config.Spec.Install.StorageOSClusterYaml = cmd.Flags().Lookup(installStosClusterYamlFlag).Value.String()
-		config.Spec.Install.StorageOSPortalConfigYaml = cmd.Flags().Lookup(installStosPortalConfigYamlFlag).Value.String()
-		config.Spec.Install.StorageOSPortalClientSecretYaml = cmd.Flags().Lookup(installStosPortalClientSecretYamlFlag).Value.String()
-		config.Spec.Install.ResourceQuotaYaml = cmd.Flags().Lookup(installResourceQuotaYamlFlag).Value.String()
-		config.Spec.Install.StorageOSOperatorNamespace = cmd.Flags().Lookup(installStosOperatorNSFlag).Value.String()
-		config.Spec.Install.StorageOSClusterNamespace = cmd.Flags().Lookup(installStosClusterNSFlag).Value.String()
-		config.Spec.Install.EtcdEndpoints = cmd.Flags().Lookup(installer.EtcdEndpointsFlag).Value.String()
-		config.Spec.Install.EtcdSecretName = cmd.Flags().Lookup(installer.EtcdSecretNameFlag).Value.String()
-		config.Spec.Install.AdminUsername = cmd.Flags().Lookup(installer.AdminUsernameFlag).Value.String()
-		config.Spec.Install.AdminPassword = cmd.Flags().Lookup(installer.AdminPasswordFlag).Value.String()
-		config.Spec.Install.PortalClientID = cmd.Flags().Lookup(installer.PortalClientIDFlag).Value.String()
-		config.Spec.Install.PortalSecret = cmd.Flags().Lookup(installer.PortalSecretFlag).Value.String()
-		config.Spec.Install.PortalAPIURL = cmd.Flags().Lookup(installer.PortalAPIURLFlag).Value.String()
-		config.Spec.Install.PortalTenantID = cmd.Flags().Lookup(installer.PortalTenantIDFlag).Value.String()
-		config.Spec.Install.NodeGuardEnv = cmd.Flags().Lookup(installer.NodeGuardEnvFlag).Value.String()
-		config.InstallerMeta.StorageOSSecretYaml = ""
-		return nil
+// setUpgradeInstallValues sets the version and options to upgrade to in config, returning the fields
+// resolved.
+func setUpgradeInstallValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) ([]configField, error) {
+	layers, err := newConfigLayers(cmd)
+	if err != nil {
+		return nil, err
 	}
-	// config file read without error, set fields in new config object
-	config.Spec.StackTrace = viper.GetBool(installer.StackTraceConfig)
-	config.Spec.Verbose = viper.GetBool(installer.VerboseConfig)
+
+	config.Spec.StackTrace = layers.Bool(installer.StackTraceFlag, installer.StackTraceConfig)
+	config.Spec.Verbose = layers.Bool(installer.VerboseFlag, installer.VerboseConfig)
+	config.Spec.Output = layers.String(installer.OutputFlag, installer.OutputConfig)
 	config.Spec.IncludeEtcd = false
-	config.Spec.SkipExistingWorkloadCheck = viper.GetBool(installer.SkipExistingWorkloadCheckConfig)
-	config.Spec.SkipStorageOSCluster = viper.GetBool(installer.SkipStosClusterConfig)
-	config.Spec.Serial = viper.GetBool(installer.SerialConfig)
-	config.Spec.AirGap = viper.GetBool(installer.AirGapConfig)
-	config.Spec.Install.EnablePortalManager = viper.GetBool(installer.EnablePortalManagerConfig)
-	config.Spec.Install.EnableMetrics = GetBoolIfConfigSet(installer.EnableMetricsConfig)
-	config.Spec.Install.Wait = viper.GetBool(installer.WaitConfig)
-	config.Spec.Install.StorageOSVersion = viper.GetString(installer.InstallStosVersionConfig)
-	config.Spec.Install.PortalManagerVersion = viper.GetString(installer.InstallPortalManagerVersionConfig)
-	config.Spec.Install.StorageOSOperatorYaml = viper.GetString(installer.InstallStosOperatorYamlConfig)
-	config.Spec.Install.StorageOSClusterYaml = viper.GetString(installer.InstallStosClusterYamlConfig)
-	config.Spec.Install.StorageOSPortalConfigYaml = viper.GetString(installer.InstallStosPortalConfigYamlConfig)
-	config.Spec.Install.StorageOSPortalClientSecretYaml = viper.GetString(installer.InstallStosPortalClientSecretYamlConfig)
-	config.Spec.Install.ResourceQuotaYaml = viper.GetString(installer.InstallResourceQuotaYamlConfig)
-	config.Spec.Install.EtcdEndpoints = viper.GetString(installer.EtcdEndpointsConfig)
-	config.Spec.Install.SkipEtcdEndpointsValidation = viper.GetBool(installer.SkipEtcdEndpointsValConfig)
-	config.Spec.Install.EtcdTLSEnabled = viper.GetBool(installer.EtcdTLSEnabledConfig)
-	config.Spec.Install.EtcdSecretName = viper.GetString(installer.EtcdSecretNameConfig)
-	config.Spec.Install.StorageOSOperatorNamespace = valueOrDefault(viper.GetString(installer.InstallStosOperatorNSConfig), consts.NewOperatorNamespace)
-	config.Spec.Install.StorageOSClusterNamespace = viper.GetString(installer.StosClusterNSConfig)
-	config.Spec.Install.AdminUsername = viper.GetString(installer.AdminUsernameConfig)
-	config.Spec.Install.AdminPassword = viper.GetString(installer.AdminPasswordConfig)
-	config.Spec.Install.PortalClientID = viper.GetString(installer.PortalClientIDConfig)
-	config.Spec.Install.PortalSecret = viper.GetString(installer.PortalSecretConfig)
-	config.Spec.Install.PortalAPIURL = viper.GetString(installer.PortalAPIURLConfig)
-	config.Spec.Install.PortalTenantID = viper.GetString(installer.PortalTenantIDConfig)
-	config.Spec.Install.EnableNodeGuard = viper.GetBool(installer.EnableNodeGuardConfig)
-	config.Spec.Install.NodeGuardEnv = viper.GetString(installer.NodeGuardEnvConfig)
+	config.Spec.SkipExistingWorkloadCheck = layers.Bool(installer.SkipExistingWorkloadCheckFlag, installer.SkipExistingWorkloadCheckConfig)
+	config.Spec.SkipStorageOSCluster = layers.Bool(installer.SkipStosClusterFlag, installer.SkipStosClusterConfig)
+	config.Spec.Serial = layers.Bool(installer.SerialFlag, installer.SerialConfig)
+	config.Spec.AirGap = layers.Bool(installer.AirGapFlag, installer.AirGapConfig)
+	config.Spec.Install.EnablePortalManager = layers.Bool(installer.EnablePortalManagerFlag, installer.EnablePortalManagerConfig)
+	config.Spec.Install.EnableMetrics = layers.BoolIfSet(installer.EnableMetricsFlag, installer.EnableMetricsConfig)
+	config.Spec.Install.Wait = layers.Bool(installer.WaitFlag, installer.WaitConfig)
+	config.Spec.Install.StorageOSVersion = layers.String(installStosVersionFlag, installer.InstallStosVersionConfig)
+	config.Spec.Install.PortalManagerVersion = layers.String(installPortalManagerVersionFlag, installer.InstallPortalManagerVersionConfig)
+	config.Spec.Install.StorageOSOperatorYaml = layers.String(installStosOperatorYamlFlag, installer.InstallStosOperatorYamlConfig)
+	config.Spec.Install.StorageOSClusterYaml = layers.String(installStosClusterYamlFlag, installer.InstallStosClusterYamlConfig)
+	config.Spec.Install.StorageOSPortalConfigYaml = layers.String(installStosPortalConfigYamlFlag, installer.InstallStosPortalConfigYamlConfig)
+	config.Spec.Install.StorageOSPortalClientSecretYaml = layers.String(installStosPortalClientSecretYamlFlag, installer.InstallStosPortalClientSecretYamlConfig)
+	config.Spec.Install.ResourceQuotaYaml = layers.String(installResourceQuotaYamlFlag, installer.InstallResourceQuotaYamlConfig)
+	config.Spec.Install.StorageOSOperatorNamespace = layers.String(installStosOperatorNSFlag, installer.InstallStosOperatorNSConfig)
+	config.Spec.Install.StorageOSClusterNamespace = layers.String(installStosClusterNSFlag, installer.StosClusterNSConfig)
+	config.Spec.Install.EtcdEndpoints = layers.String(installer.EtcdEndpointsFlag, installer.EtcdEndpointsConfig)
+	config.Spec.Install.SkipEtcdEndpointsValidation = layers.Bool(installer.SkipEtcdEndpointsValFlag, installer.SkipEtcdEndpointsValConfig)
+	config.Spec.Install.EtcdTLSEnabled = layers.Bool(installer.EtcdTLSEnabledFlag, installer.EtcdTLSEnabledConfig)
+	config.Spec.Install.EtcdSecretName = layers.String(installer.EtcdSecretNameFlag, installer.EtcdSecretNameConfig)
+	config.Spec.Install.EtcdValidationMode = layers.String(installer.EtcdValidationModeFlag, installer.EtcdValidationModeConfig)
+	config.Spec.Install.AdminUsername = layers.String(installer.AdminUsernameFlag, installer.AdminUsernameConfig)
+	config.Spec.Install.AdminPassword = layers.String(installer.AdminPasswordFlag, installer.AdminPasswordConfig)
+	config.Spec.Install.PortalClientID = layers.String(installer.PortalClientIDFlag, installer.PortalClientIDConfig)
+	config.Spec.Install.PortalSecret = layers.String(installer.PortalSecretFlag, installer.PortalSecretConfig)
+	config.Spec.Install.PortalAPIURL = layers.String(installer.PortalAPIURLFlag, installer.PortalAPIURLConfig)
+	config.Spec.Install.PortalTenantID = layers.String(installer.PortalTenantIDFlag, installer.PortalTenantIDConfig)
+	config.Spec.Install.EnableNodeGuard = layers.Bool(installer.EnableNodeGuardFlag, installer.EnableNodeGuardConfig)
+	config.Spec.Install.NodeGuardEnv = layers.String(installer.NodeGuardEnvFlag, installer.NodeGuardEnvConfig)
+	config.Spec.Install.SkipRollback = layers.Bool(installer.SkipRollbackFlag, installer.SkipRollbackConfig)
+	config.Spec.Install.ReadinessTimeout = layers.Duration(installer.ReadinessTimeoutFlag, installer.ReadinessTimeoutConfig)
+	config.Spec.Install.ServerSideApply = layers.Bool(installer.ServerSideApplyFlag, installer.ServerSideApplyConfig)
+	config.Spec.Install.ForceConflicts = layers.Bool(installer.ForceConflictsFlag, installer.ForceConflictsConfig)
+	config.Spec.Install.RegistryMirror = layers.String(installer.RegistryMirrorFlag, installer.RegistryMirrorConfig)
+	config.Spec.Install.ImageMapping = layers.String(installer.ImageMappingFlag, installer.ImageMappingConfig)
 	config.InstallerMeta.StorageOSSecretYaml = ""
-	return nil
-}
-
-func setUpgradeUninstallValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) error {
-	viper.BindPFlag(installer.StosConfigPathFlag, cmd.Flags().Lookup(installer.StosConfigPathFlag))
-	v := viper.GetViper()
-	viper.SetConfigName("kubectl-storageos-config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(v.GetString(installer.StosConfigPathFlag))
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			// Config file was found but another error was produced
-			return fmt.Errorf("error discovered in config file: %v", err)
-		}
-
-		// Config file not found; set fields in new config object directly
-		config.Spec.Verbose, err = cmd.Flags().GetBool(installer.VerboseFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.SkipNamespaceDeletion, err = cmd.Flags().GetBool(installer.SkipNamespaceDeletionFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.SkipStorageOSCluster, err = cmd.Flags().GetBool(installer.SkipStosClusterFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.Serial, err = cmd.Flags().GetBool(installer.SerialFlag)
-		if err != nil {
-			return err
-		}
-		config.Spec.AirGap, err = cmd.Flags().GetBool(installer.AirGapFlag)
-		if err != nil {
-			return err
-		}
 
-		config.Spec.IncludeEtcd = false
-		config.Spec.Uninstall.StorageOSVersion = cmd.Flags().Lookup(uninstallStosVersionFlag).Value.String()
-		config.Spec.Uninstall.PortalManagerVersion = cmd.Flags().Lookup(uninstallPortalManagerVersionFlag).Value.String()
-		config.Spec.Uninstall.StorageOSOperatorNamespace = cmd.Flags().Lookup(uninstallStosOperatorNSFlag).Value.String()
-		config.Spec.Uninstall.StorageOSOperatorYaml = cmd.Flags().Lookup(uninstallStosOperatorYamlFlag).Value.String()
-		config.Spec.Uninstall.StorageOSClusterYaml = cmd.Flags().Lookup(uninstallStosClusterYamlFlag).Value.String()
-		config.Spec.Uninstall.StorageOSPortalConfigYaml = cmd.Flags().Lookup(uninstallStosPortalConfigYamlFlag).Value.String()
-		config.Spec.Uninstall.StorageOSPortalClientSecretYaml = cmd.Flags().Lookup(uninstallStosPortalClientSecretYamlFlag).Value.String()
-		config.Spec.Uninstall.ResourceQuotaYaml = cmd.Flags().Lookup(uninstallResourceQuotaYamlFlag).Value.String()
+	return layers.fields, layers.Err()
+}
 
-		return nil
+// setUpgradeUninstallValues sets the version and options to upgrade from in config, returning the fields
+// resolved.
+func setUpgradeUninstallValues(cmd *cobra.Command, config *apiv1.KubectlStorageOSConfig) ([]configField, error) {
+	layers, err := newConfigLayers(cmd)
+	if err != nil {
+		return nil, err
 	}
-	// config file read without error, set fields in new config object
-	config.Spec.Verbose = viper.GetBool(installer.VerboseConfig)
-	config.Spec.SkipNamespaceDeletion = viper.GetBool(installer.SkipNamespaceDeletionConfig)
-	config.Spec.IncludeEtcd = false
-	config.Spec.SkipStorageOSCluster = viper.GetBool(installer.SkipStosClusterConfig)
-	config.Spec.Serial = viper.GetBool(installer.SerialConfig)
-	config.Spec.AirGap = viper.GetBool(installer.AirGapConfig)
-	config.Spec.Uninstall.StorageOSVersion = viper.GetString(installer.UninstallStosVersionConfig)
-	config.Spec.Uninstall.PortalManagerVersion = viper.GetString(installer.UninstallPortalManagerVersionConfig)
-	config.Spec.Uninstall.StorageOSOperatorNamespace = viper.GetString(installer.UninstallStosOperatorNSConfig)
-	config.Spec.Uninstall.StorageOSOperatorYaml = viper.GetString(installer.UninstallStosOperatorYamlConfig)
-	config.Spec.Uninstall.StorageOSClusterYaml = viper.GetString(installer.UninstallStosClusterYamlConfig)
-	config.Spec.Uninstall.StorageOSPortalConfigYaml = viper.GetString(installer.UninstallStosPortalConfigYamlConfig)
-	config.Spec.Uninstall.StorageOSPortalClientSecretYaml = viper.GetString(installer.UninstallStosPortalClientSecretYamlConfig)
-	config.Spec.Uninstall.ResourceQuotaYaml = viper.GetString(installer.UninstallResourceQuotaYamlConfig)
 
-	return nil
+	config.Spec.Verbose = layers.Bool(installer.VerboseFlag, installer.VerboseConfig)
+	config.Spec.SkipNamespaceDeletion = layers.Bool(installer.SkipNamespaceDeletionFlag, installer.SkipNamespaceDeletionConfig)
+	config.Spec.IncludeEtcd = false
+	config.Spec.SkipStorageOSCluster = layers.Bool(installer.SkipStosClusterFlag, installer.SkipStosClusterConfig)
+	config.Spec.Serial = layers.Bool(installer.SerialFlag, installer.SerialConfig)
+	config.Spec.AirGap = layers.Bool(installer.AirGapFlag, installer.AirGapConfig)
+	config.Spec.Uninstall.StorageOSVersion = layers.String(uninstallStosVersionFlag, installer.UninstallStosVersionConfig)
+	config.Spec.Uninstall.PortalManagerVersion = layers.String(uninstallPortalManagerVersionFlag, installer.UninstallPortalManagerVersionConfig)
+	config.Spec.Uninstall.StorageOSOperatorNamespace = layers.String(uninstallStosOperatorNSFlag, installer.UninstallStosOperatorNSConfig)
+	config.Spec.Uninstall.StorageOSOperatorYaml = layers.String(uninstallStosOperatorYamlFlag, installer.UninstallStosOperatorYamlConfig)
+	config.Spec.Uninstall.StorageOSClusterYaml = layers.String(uninstallStosClusterYamlFlag, installer.UninstallStosClusterYamlConfig)
+	config.Spec.Uninstall.StorageOSPortalConfigYaml = layers.String(uninstallStosPortalConfigYamlFlag, installer.UninstallStosPortalConfigYamlConfig)
+	config.Spec.Uninstall.StorageOSPortalClientSecretYaml = layers.String(uninstallStosPortalClientSecretYamlFlag, installer.UninstallStosPortalClientSecretYamlConfig)
+	config.Spec.Uninstall.ResourceQuotaYaml = layers.String(uninstallResourceQuotaYamlFlag, installer.UninstallResourceQuotaYamlConfig)
+	config.Spec.Uninstall.RemovalTimeout = layers.Duration(installer.RemovalTimeoutFlag, installer.RemovalTimeoutConfig)
+
+	return layers.fields, layers.Err()
 }
 
 // setStorageOSVersionsInConfigs:
 // 1. Gets the version to be installed from github releases if it has not been specified by --install-stos-version.
 // 2. Sets this version in installConfig.
-// 3. Gets the existing version to be uninstalled if it has not been specified by --uninstall-stos-version.
+// 3. Gets the existing version to be uninstalled if it has not been specified by --uninstall-stos-version,
+// preferring the version recorded in the install state over discovery.
 // 4. Sets this version in uninstallConfig
 // 5. Ensures that install version is not less than or equal to uninstall version.
-func setStorageOSVersionsInConfigs(uninstallConfig *apiv1.KubectlStorageOSConfig, installConfig *apiv1.KubectlStorageOSConfig, log *logger.Logger) error {
+func setStorageOSVersionsInConfigs(uninstallConfig *apiv1.KubectlStorageOSConfig, installConfig *apiv1.KubectlStorageOSConfig, state *apiv1.KubectlStorageOSConfigStatus, log *logger.Logger) error {
+	var err error
 	if installConfig.Spec.Install.StorageOSVersion == "" {
-		installConfig.Spec.Install.StorageOSVersion = version.OperatorLatestSupportedVersion()
+		if installConfig.Spec.Install.StorageOSVersion, err = version.OperatorLatestSupportedVersion(); err != nil {
+			return err
+		}
 	}
 
-	var err error
+	if uninstallConfig.Spec.Uninstall.StorageOSVersion == "" && state.StorageOSVersion != "" {
+		uninstallConfig.Spec.Uninstall.StorageOSVersion = state.StorageOSVersion
+		log.Successf("Found recorded StorageOS cluster and operator version %s.", uninstallConfig.Spec.Uninstall.StorageOSVersion)
+	}
 	if uninstallConfig.Spec.Uninstall.StorageOSVersion == "" {
 		uninstallConfig.Spec.Uninstall.StorageOSVersion, err = pluginversion.GetExistingOperatorVersion(uninstallConfig.Spec.Uninstall.StorageOSOperatorNamespace)
 		if err != nil {
@@ -403,17 +355,29 @@ func setStorageOSVersionsInConfigs(uninstallConfig *apiv1.KubectlStorageOSConfig
 // 1. Ensures that portal manager is supported in the storageos version to be installed.
 // 2. Gets the portal manager version to be installed from github releases if it has not been specified by --install-portal-manager-version.
 // 3. Sets this portal manager version in installConfig.
-// 3. Gets the existing portal manager version to be uninstalled if it has not been specified by --uninstall-portal-manager-version.
+// 3. Gets the existing portal manager version to be uninstalled if it has not been specified by --uninstall-portal-manager-version,
+// preferring the version recorded in the install state over discovery.
 // 4. Sets this portal manager version in uninstallConfig
 // 5. Sets enable portal manager if it already exists.
-func setPortalManagerVersionsInConfigs(uninstallConfig *apiv1.KubectlStorageOSConfig, installConfig *apiv1.KubectlStorageOSConfig, log *logger.Logger) error {
+func setPortalManagerVersionsInConfigs(uninstallConfig *apiv1.KubectlStorageOSConfig, installConfig *apiv1.KubectlStorageOSConfig, state *apiv1.KubectlStorageOSConfigStatus, log *logger.Logger) error {
 	// ensure that the storageos version we are upgrading to supports portal manager.
-	if err := versionSupportsFeature(installConfig.Spec.Install.StorageOSVersion, consts.PortalManagerFirstSupportedVersion); err != nil {
+	if err := operatorSupportsFeature(installConfig.Spec.Install.StorageOSVersion, version.FeaturePortalManager); err != nil {
 		return fmt.Errorf("failed to enable portal manager: %w", err)
 	}
 
 	if installConfig.Spec.Install.PortalManagerVersion == "" {
-		installConfig.Spec.Install.PortalManagerVersion = version.PortalManagerLatestSupportedVersion()
+		var err error
+		if installConfig.Spec.Install.PortalManagerVersion, err = version.PortalManagerLatestSupportedVersion(); err != nil {
+			return err
+		}
+	}
+
+	if uninstallConfig.Spec.Uninstall.PortalManagerVersion == "" && state.PortalManagerVersion != "" {
+		uninstallConfig.Spec.Uninstall.PortalManagerVersion = state.PortalManagerVersion
+		log.Successf("Found recorded Portal Manager version '%s'.", uninstallConfig.Spec.Uninstall.PortalManagerVersion)
+		// the portal manager already exists, so it should be re-installed during the upgrade.
+		installConfig.Spec.Install.EnablePortalManager = true
+		return nil
 	}
 
 	var err error
@@ -0,0 +1,12 @@
+// Package crd embeds the CustomResourceDefinitions generated from the api types,
+// so that the plugin can register them in the cluster at runtime.
+package crd
+
+import (
+	_ "embed"
+)
+
+// KubectlStorageOSConfig is the kubectlstorageosconfigs.storageos.com CRD manifest.
+//
+//go:embed bases/storageos.com_kubectlstorageosconfigs.yaml
+var KubectlStorageOSConfig string
@@ -1,18 +1,27 @@
 package main
 
 import (
+	"errors"
 	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	utilexec "k8s.io/client-go/util/exec"
 
 	"github.com/storageos/kubectl-storageos/cmd"
+	"github.com/storageos/kubectl-storageos/pkg/installer"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
 )
 
 func main() {
 	if err := RootCmd().Execute(); err != nil {
+		// commands forwarded to the storageos cli exit with its exit code, diff with those of kubectl diff
+		var exitErr utilexec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitStatus())
+		}
 		os.Exit(1)
 	}
 }
@@ -23,6 +32,18 @@ func RootCmd() *cobra.Command {
 		Aliases: []string{"kubectl storageos"},
 		Short:   "StorageOS",
 		Long:    `StorageOS kubectl plugin`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			flag := cmd.Flags().Lookup(installer.ClusterFlag)
+			if flag == nil {
+				return nil
+			}
+			selector, err := pluginutils.ParseClusterSelector(flag.Value.String())
+			if err != nil {
+				return err
+			}
+			pluginutils.SetClusterSelector(selector)
+			return nil
+		},
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			return viper.BindPFlags(cmd.Flags())
 		},
@@ -34,14 +55,29 @@ func RootCmd() *cobra.Command {
 		},
 	}
 
+	cobracmd.PersistentFlags().String(installer.ClusterFlag, "", "storageos cluster to target as name or name/namespace, required when more than one exists")
+	cmd.AddOutputFlag(cobracmd.PersistentFlags())
+
 	viper.AutomaticEnv()
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 
 	cobracmd.AddCommand(cmd.PreflightCmd())
 	cobracmd.AddCommand(cmd.BundleCmd())
+	cobracmd.AddCommand(cmd.BundleReleaseCmd())
+	cobracmd.AddCommand(cmd.ImagesCmd())
+	cobracmd.AddCommand(cmd.CacheCmd())
+	cobracmd.AddCommand(cmd.CompatCmd())
+	cobracmd.AddCommand(cmd.ConfigCmd())
+	cobracmd.AddCommand(cmd.StatusCmd())
 	cobracmd.AddCommand(cmd.InstallCmd())
 	cobracmd.AddCommand(cmd.UninstallCmd())
 	cobracmd.AddCommand(cmd.UpgradeCmd())
+	cobracmd.AddCommand(cmd.DiffCmd())
+	cobracmd.AddCommand(cmd.ExportCmd())
+	cobracmd.AddCommand(cmd.RenderCmd())
+	cobracmd.AddCommand(cmd.BackupCmd())
+	cobracmd.AddCommand(cmd.RestoreCmd())
+	cobracmd.AddCommand(cmd.EtcdCmd())
 	cobracmd.AddCommand(cmd.VersionCmd())
 	cobracmd.AddCommand(cmd.InstallPortalCmd())
 	cobracmd.AddCommand(cmd.UninstallPortalCmd())
@@ -52,6 +88,7 @@ func RootCmd() *cobra.Command {
 	cobracmd.AddCommand(cmd.CreateCmd())
 	cobracmd.AddCommand(cmd.GetCmd())
 	cobracmd.AddCommand(cmd.DescribeCmd())
+	cobracmd.AddCommand(cmd.DeleteCmd())
 	cobracmd.AddCommand(cmd.AttachCmd())
 	cobracmd.AddCommand(cmd.DetachCmd())
 	cobracmd.AddCommand(cmd.NfsCmd())
@@ -0,0 +1,180 @@
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/rest"
+
+	"github.com/storageos/kubectl-storageos/pkg/consts"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+const (
+	apiBasePath = "/v2"
+
+	requestTimeout = time.Minute
+
+	errAPISecretMissingKey = "storageos api secret %s/%s has no %s"
+)
+
+// Client is a client of the REST API of StorageOS.
+type Client struct {
+	endpoint   string
+	httpClient *http.Client
+	token      string
+}
+
+// APIError is an error returned by the StorageOS API.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("storageos api: %s (%d)", e.Message, e.StatusCode)
+}
+
+// IsNotFound returns true if err is an APIError for a missing resource.
+func IsNotFound(err error) bool {
+	apiErr, ok := errors.Cause(err).(*APIError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// NotFound returns an APIError for the missing resource of kind called name.
+func NotFound(kind, name string) error {
+	return errors.WithStack(&APIError{StatusCode: http.StatusNotFound, Message: fmt.Sprintf("%s %q not found", kind, name)})
+}
+
+// NewClient returns a client of the StorageOS API served at endpoint, such as http://127.0.0.1:5705.
+func NewClient(endpoint string) *Client {
+	return &Client{
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Connect returns a client of the API of the selected storageos cluster, logged in with the credentials
+// of its api secret, and a function which closes the connection. The API is reached through a
+// port-forward to the storageos service, so the cluster network does not need to be reachable.
+func Connect(config *rest.Config) (*Client, func(), error) {
+	stosCluster, err := pluginutils.GetStorageOSCluster(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secret, err := pluginutils.GetSecret(config, pluginutils.GetAPISecretName(stosCluster), stosCluster.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, key := range []string{consts.APISecretUsernameKey, consts.APISecretPasswordKey} {
+		if len(secret.Data[key]) == 0 {
+			return nil, nil, errors.Errorf(errAPISecretMissingKey, secret.Namespace, secret.Name, key)
+		}
+	}
+
+	podName, podPort, err := pluginutils.GetServiceBackendPod(config, consts.APIService, stosCluster.Namespace, consts.APIPort)
+	if err != nil {
+		return nil, nil, err
+	}
+	localPort, stop, err := pluginutils.PortForwardToPod(config, podName, stosCluster.Namespace, podPort)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client := NewClient(fmt.Sprintf("http://127.0.0.1:%d", localPort))
+	if err = client.Login(string(secret.Data[consts.APISecretUsernameKey]), string(secret.Data[consts.APISecretPasswordKey])); err != nil {
+		stop()
+		return nil, nil, err
+	}
+
+	return client, stop, nil
+}
+
+// Login authenticates the client, which is required before any other request.
+func (c *Client) Login(username, password string) error {
+	body := map[string]string{"username": username, "password": password}
+	resp, err := c.request(http.MethodPost, "/auth/login", nil, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	c.token = resp.Header.Get("Authorization")
+	if c.token == "" {
+		return errors.New("storageos api: no token returned by login")
+	}
+
+	return nil
+}
+
+// do sends a request with a json body, if any, and decodes the json response into out, if set.
+func (c *Client) do(method, path string, query url.Values, body, out interface{}) error {
+	resp, err := c.request(method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+
+	return errors.WithStack(json.NewDecoder(resp.Body).Decode(out))
+}
+
+// request sends a request and returns the response if successful, or an APIError.
+func (c *Client) request(method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	reqURL := c.endpoint + apiBasePath + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(context.TODO(), method, reqURL, reqBody)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	errBody := struct {
+		Error string `json:"error"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&errBody); err == nil && errBody.Error != "" {
+		apiErr.Message = errBody.Error
+	}
+
+	return nil, errors.WithStack(apiErr)
+}
+
+// versioned returns the query of a request which must match version of the resource.
+func versioned(version string) url.Values {
+	return url.Values{"version": []string{version}}
+}
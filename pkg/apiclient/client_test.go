@@ -0,0 +1,82 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient(t *testing.T) {
+	const token = "Bearer token"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		credentials := map[string]string{}
+		if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil || credentials["password"] != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid credentials"})
+			return
+		}
+		w.Header().Set("Authorization", token)
+	})
+	mux.HandleFunc("/v2/namespaces/ns-1/volumes", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]Volume{{ID: "vol-1", Name: "pvc-1", NamespaceID: "ns-1", Version: "1"}})
+	})
+	mux.HandleFunc("/v2/namespaces/ns-1/volumes/vol-1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Query().Get("version") != "1" {
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "version mismatch"})
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tcases := []struct {
+		name          string
+		password      string
+		volume        string
+		expectedError bool
+		notFound      bool
+	}{
+		{
+			name:     "existing volume",
+			password: "secret",
+			volume:   "pvc-1",
+		},
+		{
+			name:          "missing volume",
+			password:      "secret",
+			volume:        "pvc-2",
+			expectedError: true,
+			notFound:      true,
+		},
+		{
+			name:          "invalid credentials",
+			password:      "wrong",
+			volume:        "pvc-1",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tcases {
+		client := NewClient(server.URL)
+		err := client.Login("storageos", tc.password)
+		var volume *Volume
+		if err == nil {
+			volume, err = client.GetVolumeByName("ns-1", tc.volume)
+		}
+		if err == nil {
+			err = client.DeleteVolume(volume)
+		}
+		if tc.expectedError != (err != nil) {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectedError, err)
+		}
+		if IsNotFound(err) != tc.notFound {
+			t.Errorf("%s: expected not found %v, got %v", tc.name, tc.notFound, err)
+		}
+	}
+}
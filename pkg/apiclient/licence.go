@@ -0,0 +1,30 @@
+package apiclient
+
+import (
+	"net/http"
+)
+
+// GetLicence returns the licence of the StorageOS cluster.
+func (c *Client) GetLicence() (*Licence, error) {
+	licence := &Licence{}
+	err := c.do(http.MethodGet, "/cluster/licence", nil, nil, licence)
+
+	return licence, err
+}
+
+// UpdateLicence replaces the licence of the StorageOS cluster with the licence key.
+func (c *Client) UpdateLicence(key string) (*Licence, error) {
+	current, err := c.GetLicence()
+	if err != nil {
+		return nil, err
+	}
+
+	body := struct {
+		Key     string `json:"key"`
+		Version string `json:"version"`
+	}{Key: key, Version: current.Version}
+	licence := &Licence{}
+	err = c.do(http.MethodPut, "/cluster/licence", nil, body, licence)
+
+	return licence, err
+}
@@ -0,0 +1,45 @@
+package apiclient
+
+import (
+	"net/http"
+)
+
+// ListNamespaces returns all StorageOS namespaces.
+func (c *Client) ListNamespaces() ([]Namespace, error) {
+	namespaces := []Namespace{}
+	err := c.do(http.MethodGet, "/namespaces", nil, nil, &namespaces)
+
+	return namespaces, err
+}
+
+// GetNamespaceByName returns the StorageOS namespace called name.
+func (c *Client) GetNamespaceByName(name string) (*Namespace, error) {
+	namespaces, err := c.ListNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	for i := range namespaces {
+		if namespaces[i].Name == name {
+			return &namespaces[i], nil
+		}
+	}
+
+	return nil, NotFound("namespace", name)
+}
+
+// CreateNamespace creates a StorageOS namespace.
+func (c *Client) CreateNamespace(name string, labels map[string]string) (*Namespace, error) {
+	body := struct {
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels,omitempty"`
+	}{Name: name, Labels: labels}
+	namespace := &Namespace{}
+	err := c.do(http.MethodPost, "/namespaces", nil, body, namespace)
+
+	return namespace, err
+}
+
+// DeleteNamespace deletes a StorageOS namespace, which must not contain volumes.
+func (c *Client) DeleteNamespace(namespace *Namespace) error {
+	return c.do(http.MethodDelete, "/namespaces/"+namespace.ID, versioned(namespace.Version), nil, nil)
+}
@@ -0,0 +1,46 @@
+package apiclient
+
+import (
+	"net/http"
+)
+
+// ListNodes returns all StorageOS nodes.
+func (c *Client) ListNodes() ([]Node, error) {
+	nodes := []Node{}
+	err := c.do(http.MethodGet, "/nodes", nil, nil, &nodes)
+
+	return nodes, err
+}
+
+// GetNodeByName returns the StorageOS node called name, which is the name of its k8s node.
+func (c *Client) GetNodeByName(name string) (*Node, error) {
+	nodes, err := c.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+	for i := range nodes {
+		if nodes[i].Name == name {
+			return &nodes[i], nil
+		}
+	}
+
+	return nil, NotFound("node", name)
+}
+
+// CordonNode stops StorageOS from placing new volume deployments on a node.
+func (c *Client) CordonNode(node *Node) error {
+	return c.setNodeCordon(node, "cordon")
+}
+
+// UncordonNode allows StorageOS to place new volume deployments on a node again.
+func (c *Client) UncordonNode(node *Node) error {
+	return c.setNodeCordon(node, "uncordon")
+}
+
+func (c *Client) setNodeCordon(node *Node, action string) error {
+	body := struct {
+		Version string `json:"version"`
+	}{Version: node.Version}
+
+	return c.do(http.MethodPut, "/nodes/"+node.ID+"/"+action, nil, body, nil)
+}
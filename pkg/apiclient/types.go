@@ -0,0 +1,113 @@
+package apiclient
+
+import (
+	"time"
+)
+
+// Namespace is a StorageOS namespace, which scopes volumes. It is not a k8s namespace.
+type Namespace struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+	Version   string            `json:"version"`
+}
+
+// Volume is a StorageOS volume.
+type Volume struct {
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	Description    string            `json:"description,omitempty"`
+	AttachedOn     string            `json:"attachedOn,omitempty"`
+	AttachmentType string            `json:"attachmentType,omitempty"`
+	NamespaceID    string            `json:"namespaceID"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	FsType         string            `json:"fsType"`
+	SizeBytes      uint64            `json:"sizeBytes"`
+	Master         *Deployment       `json:"master,omitempty"`
+	Replicas       []Deployment      `json:"replicas,omitempty"`
+	NFS            *NFSConfig        `json:"nfs,omitempty"`
+	CreatedAt      time.Time         `json:"createdAt"`
+	UpdatedAt      time.Time         `json:"updatedAt"`
+	Version        string            `json:"version"`
+}
+
+// Deployment is the master or a replica of a volume on a node.
+type Deployment struct {
+	ID         string `json:"id"`
+	NodeID     string `json:"nodeID"`
+	Health     string `json:"health,omitempty"`
+	Promotable bool   `json:"promotable,omitempty"`
+}
+
+// NFSConfig is the configuration of a volume shared over NFS.
+type NFSConfig struct {
+	Exports         []NFSExport `json:"exports,omitempty"`
+	ServiceEndpoint string      `json:"serviceEndpoint,omitempty"`
+}
+
+// NFSExport is an NFS export of a volume.
+type NFSExport struct {
+	ExportID   uint64   `json:"exportID"`
+	Path       string   `json:"path"`
+	PseudoPath string   `json:"pseudoPath"`
+	ACLs       []NFSACL `json:"acls,omitempty"`
+}
+
+// NFSACL grants access to an NFS export.
+type NFSACL struct {
+	Identity struct {
+		IdentityType string `json:"identityType"`
+		Matcher      string `json:"matcher"`
+	} `json:"identity"`
+	SquashConfig struct {
+		UID    int64  `json:"uid"`
+		GID    int64  `json:"gid"`
+		Squash string `json:"squash"`
+	} `json:"squashConfig"`
+	AccessLevel string `json:"accessLevel"`
+}
+
+// Node is a StorageOS node.
+type Node struct {
+	ID                 string            `json:"id"`
+	Name               string            `json:"name"`
+	Health             string            `json:"health"`
+	IOEndpoint         string            `json:"ioEndpoint,omitempty"`
+	SupervisorEndpoint string            `json:"supervisorEndpoint,omitempty"`
+	GossipEndpoint     string            `json:"gossipEndpoint,omitempty"`
+	ClusteringEndpoint string            `json:"clusteringEndpoint,omitempty"`
+	Labels             map[string]string `json:"labels,omitempty"`
+	Capacity           *CapacityStats    `json:"capacity,omitempty"`
+	CreatedAt          time.Time         `json:"createdAt"`
+	UpdatedAt          time.Time         `json:"updatedAt"`
+	Version            string            `json:"version"`
+}
+
+// CapacityStats is the storage capacity of a node.
+type CapacityStats struct {
+	Total uint64 `json:"total"`
+	Free  uint64 `json:"free"`
+}
+
+// Licence is the licence of the StorageOS cluster.
+type Licence struct {
+	ClusterID            string    `json:"clusterID"`
+	ExpiresAt            time.Time `json:"expiresAt"`
+	ClusterCapacityBytes uint64    `json:"clusterCapacityBytes"`
+	UsedBytes            uint64    `json:"usedBytes"`
+	Kind                 string    `json:"kind"`
+	CustomerName         string    `json:"customerName"`
+	Features             []string  `json:"features,omitempty"`
+	Version              string    `json:"version"`
+}
+
+// CreateVolumeRequest holds the fields of a volume to create.
+type CreateVolumeRequest struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	FsType      string            `json:"fsType"`
+	SizeBytes   uint64            `json:"sizeBytes"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
@@ -0,0 +1,124 @@
+package apiclient
+
+import (
+	"fmt"
+	"net/http"
+)
+
+func volumesPath(namespaceID string) string {
+	return fmt.Sprintf("/namespaces/%s/volumes", namespaceID)
+}
+
+func volumePath(volume *Volume) string {
+	return fmt.Sprintf("/namespaces/%s/volumes/%s", volume.NamespaceID, volume.ID)
+}
+
+// ListVolumes returns the volumes of a StorageOS namespace.
+func (c *Client) ListVolumes(namespaceID string) ([]Volume, error) {
+	volumes := []Volume{}
+	err := c.do(http.MethodGet, volumesPath(namespaceID), nil, nil, &volumes)
+
+	return volumes, err
+}
+
+// GetVolumeByName returns the volume called name in a StorageOS namespace.
+func (c *Client) GetVolumeByName(namespaceID, name string) (*Volume, error) {
+	volumes, err := c.ListVolumes(namespaceID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range volumes {
+		if volumes[i].Name == name {
+			return &volumes[i], nil
+		}
+	}
+
+	return nil, NotFound("volume", name)
+}
+
+// CreateVolume creates a volume in a StorageOS namespace.
+func (c *Client) CreateVolume(namespaceID string, request CreateVolumeRequest) (*Volume, error) {
+	body := struct {
+		CreateVolumeRequest
+		NamespaceID string `json:"namespaceID"`
+	}{CreateVolumeRequest: request, NamespaceID: namespaceID}
+	volume := &Volume{}
+	err := c.do(http.MethodPost, volumesPath(namespaceID), nil, body, volume)
+
+	return volume, err
+}
+
+// DeleteVolume deletes a volume, which must be detached.
+func (c *Client) DeleteVolume(volume *Volume) error {
+	return c.do(http.MethodDelete, volumePath(volume), versioned(volume.Version), nil, nil)
+}
+
+// UpdateVolume sets the description and labels of a volume.
+func (c *Client) UpdateVolume(volume *Volume, description string, labels map[string]string) (*Volume, error) {
+	body := struct {
+		Description string            `json:"description"`
+		Labels      map[string]string `json:"labels"`
+		Version     string            `json:"version"`
+	}{Description: description, Labels: labels, Version: volume.Version}
+	updated := &Volume{}
+	err := c.do(http.MethodPut, volumePath(volume), nil, body, updated)
+
+	return updated, err
+}
+
+// SetReplicas sets the number of replicas of a volume. Replicas are added or removed asynchronously.
+func (c *Client) SetReplicas(volume *Volume, replicas uint64) error {
+	body := struct {
+		Replicas uint64 `json:"replicas"`
+		Version  string `json:"version"`
+	}{Replicas: replicas, Version: volume.Version}
+
+	return c.do(http.MethodPut, volumePath(volume)+"/replicas", nil, body, nil)
+}
+
+// ResizeVolume sets the size of a volume, which can only grow.
+func (c *Client) ResizeVolume(volume *Volume, sizeBytes uint64) (*Volume, error) {
+	body := struct {
+		SizeBytes uint64 `json:"sizeBytes"`
+		Version   string `json:"version"`
+	}{SizeBytes: sizeBytes, Version: volume.Version}
+	updated := &Volume{}
+	err := c.do(http.MethodPut, volumePath(volume)+"/size", nil, body, updated)
+
+	return updated, err
+}
+
+// AttachVolume attaches a volume to a node.
+func (c *Client) AttachVolume(volume *Volume, nodeID string) error {
+	body := struct {
+		NodeID  string `json:"nodeID"`
+		Version string `json:"version"`
+	}{NodeID: nodeID, Version: volume.Version}
+
+	return c.do(http.MethodPost, volumePath(volume)+"/attach", nil, body, nil)
+}
+
+// DetachVolume detaches a volume from the node it is attached on.
+func (c *Client) DetachVolume(volume *Volume) error {
+	return c.do(http.MethodDelete, volumePath(volume)+"/attach", versioned(volume.Version), nil, nil)
+}
+
+// AttachNFSVolume shares a volume over NFS from a node.
+func (c *Client) AttachNFSVolume(volume *Volume, nodeID string) error {
+	body := struct {
+		NodeID  string `json:"nodeID"`
+		Version string `json:"version"`
+	}{NodeID: nodeID, Version: volume.Version}
+
+	return c.do(http.MethodPut, volumePath(volume)+"/nfs/attach", nil, body, nil)
+}
+
+// SetNFSMountEndpoint sets the endpoint NFS clients mount a volume from.
+func (c *Client) SetNFSMountEndpoint(volume *Volume, endpoint string) error {
+	body := struct {
+		MountEndpoint string `json:"mountEndpoint"`
+		Version       string `json:"version"`
+	}{MountEndpoint: endpoint, Version: volume.Version}
+
+	return c.do(http.MethodPut, volumePath(volume)+"/nfs/mount-endpoint", nil, body, nil)
+}
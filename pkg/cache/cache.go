@@ -0,0 +1,292 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Kind is the kind of content cached, which determines its time to live.
+type Kind string
+
+const (
+	// KindManifest is a manifest of a release fetched from a url.
+	KindManifest Kind = "manifest"
+	// KindImageFile is a file extracted from an image, keyed by the image digest.
+	KindImageFile Kind = "image-file"
+	// KindRelease is the release metadata of a GitHub repository.
+	KindRelease Kind = "release"
+)
+
+const (
+	// DirEnvVar overrides the directory of the default cache.
+	DirEnvVar = "KUBECTL_STORAGEOS_CACHE_DIR"
+
+	entriesDir = "entries"
+	objectsDir = "objects"
+)
+
+// TTLs are the times to live of each kind of content, zero never expires. Image files are keyed by
+// image digest, so their content never changes.
+var TTLs = map[Kind]time.Duration{
+	KindManifest:  24 * time.Hour,
+	KindImageFile: 0,
+	KindRelease:   time.Hour,
+}
+
+// Entry is a cached key, referencing its content by digest.
+type Entry struct {
+	Kind      Kind       `json:"kind"`
+	Key       string     `json:"key"`
+	Digest    string     `json:"digest"`
+	Size      int64      `json:"size"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Expired returns true if the entry expired at now.
+func (e *Entry) Expired(now time.Time) bool {
+	return e.ExpiresAt != nil && !now.Before(*e.ExpiresAt)
+}
+
+// Cache is a content-addressed cache on disk. Content is stored once by its digest, under objects/,
+// and referenced by an entry of its kind and key, under entries/.
+type Cache struct {
+	dir string
+	now func() time.Time
+}
+
+// New returns a cache stored in dir.
+func New(dir string) *Cache {
+	return &Cache{dir: dir, now: time.Now}
+}
+
+// DefaultDir returns the directory of the default cache, $HOME/.kube/storageos/cache unless overridden
+// by DirEnvVar.
+func DefaultDir() (string, error) {
+	if dir := os.Getenv(DirEnvVar); dir != "" {
+		return dir, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return filepath.Join(homeDir, ".kube", "storageos", "cache"), nil
+}
+
+// Default returns the default cache.
+func Default() (*Cache, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(dir), nil
+}
+
+// Fetch returns the content of kind and key from the default cache, unless missing or expired, in which
+// case it is fetched and cached. Content is always fetched if the cache is unusable.
+func Fetch(kind Kind, key string, fetch func() ([]byte, error)) ([]byte, error) {
+	c, err := Default()
+	if err != nil {
+		return fetch()
+	}
+	if data, ok := c.Get(kind, key); ok {
+		return data, nil
+	}
+
+	data, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	// failing to cache only costs a fetch next time
+	_ = c.Put(kind, key, data)
+
+	return data, nil
+}
+
+// Get returns the content of kind and key, and false if it is missing, expired or unreadable.
+func (c *Cache) Get(kind Kind, key string) ([]byte, bool) {
+	return c.get(kind, key, false)
+}
+
+// GetStale returns the content of kind and key even if expired, and false if it is missing or
+// unreadable. It serves as a fallback when content can't be fetched.
+func (c *Cache) GetStale(kind Kind, key string) ([]byte, bool) {
+	return c.get(kind, key, true)
+}
+
+func (c *Cache) get(kind Kind, key string, allowExpired bool) ([]byte, bool) {
+	entry, err := c.readEntry(c.entryPath(kind, key))
+	if err != nil || !allowExpired && entry.Expired(c.now()) {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.objectPath(entry.Digest))
+	if err != nil || digest(data) != entry.Digest {
+		return nil, false
+	}
+
+	return data, true
+}
+
+// Put caches data as the content of kind and key, expiring after the TTL of kind.
+func (c *Cache) Put(kind Kind, key string, data []byte) error {
+	now := c.now()
+	entry := &Entry{
+		Kind:      kind,
+		Key:       key,
+		Digest:    digest(data),
+		Size:      int64(len(data)),
+		CreatedAt: now,
+	}
+	if ttl := TTLs[kind]; ttl > 0 {
+		expiresAt := now.Add(ttl)
+		entry.ExpiresAt = &expiresAt
+	}
+
+	if err := writeFile(c.objectPath(entry.Digest), data); err != nil {
+		return err
+	}
+	entryData, err := json.Marshal(entry)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return writeFile(c.entryPath(kind, key), entryData)
+}
+
+// List returns the entries of the cache, sorted by kind and key.
+func (c *Cache) List() ([]Entry, error) {
+	paths, err := filepath.Glob(filepath.Join(c.dir, entriesDir, "*.json"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	entries := []Entry{}
+	for _, path := range paths {
+		entry, err := c.readEntry(path)
+		if err != nil {
+			// written by an incompatible version or partially written, left for prune
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Kind != entries[j].Kind {
+			return entries[i].Kind < entries[j].Kind
+		}
+		return entries[i].Key < entries[j].Key
+	})
+
+	return entries, nil
+}
+
+// Prune removes expired and unreadable entries, or all entries if all is set, and the content no
+// longer referenced by any entry. The number of entries removed and the bytes of content freed are
+// returned.
+func (c *Cache) Prune(all bool) (int, int64, error) {
+	entryPaths, err := filepath.Glob(filepath.Join(c.dir, entriesDir, "*.json"))
+	if err != nil {
+		return 0, 0, errors.WithStack(err)
+	}
+
+	removed := 0
+	referenced := map[string]bool{}
+	now := c.now()
+	for _, path := range entryPaths {
+		entry, err := c.readEntry(path)
+		if err == nil && !all && !entry.Expired(now) {
+			referenced[entry.Digest] = true
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return removed, 0, errors.WithStack(err)
+		}
+		removed++
+	}
+
+	objectPaths, err := filepath.Glob(filepath.Join(c.dir, objectsDir, "*"))
+	if err != nil {
+		return removed, 0, errors.WithStack(err)
+	}
+	var freed int64
+	for _, path := range objectPaths {
+		// temporary files are being written
+		if referenced[filepath.Base(path)] || strings.HasPrefix(filepath.Base(path), ".") {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return removed, freed, errors.WithStack(err)
+		}
+		freed += info.Size()
+	}
+
+	return removed, freed, nil
+}
+
+// Dir returns the directory of the cache.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+func (c *Cache) readEntry(path string) (*Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	entry := &Entry{}
+	if err = json.Unmarshal(data, entry); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return entry, nil
+}
+
+func (c *Cache) entryPath(kind Kind, key string) string {
+	return filepath.Join(c.dir, entriesDir, digest([]byte(string(kind)+"\n"+key))+".json")
+}
+
+func (c *Cache) objectPath(digest string) string {
+	return filepath.Join(c.dir, objectsDir, digest)
+}
+
+// digest returns the hex encoded sha256 of data.
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeFile writes data to path through a temporary file, so that concurrent readers never see a
+// partially written file.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.WithStack(err)
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(os.Rename(tmp.Name(), path))
+}
@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCachePrune(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	manifest := []byte("kind: Deployment\n")
+
+	tcases := []struct {
+		name       string
+		age        time.Duration
+		all        bool
+		expRemoved int
+		expFreed   int64
+		expHits    map[Kind]bool
+	}{
+		{
+			name:       "nothing expired",
+			age:        time.Minute,
+			expRemoved: 0,
+			expHits:    map[Kind]bool{KindManifest: true, KindRelease: true, KindImageFile: true},
+		},
+		{
+			name:       "release expired",
+			age:        2 * time.Hour,
+			expRemoved: 1,
+			expFreed:   int64(len("[]")),
+			expHits:    map[Kind]bool{KindManifest: true, KindRelease: false, KindImageFile: true},
+		},
+		{
+			name:       "manifest shares its content with an image file",
+			age:        48 * time.Hour,
+			expRemoved: 2,
+			expFreed:   int64(len("[]")),
+			expHits:    map[Kind]bool{KindManifest: false, KindRelease: false, KindImageFile: true},
+		},
+		{
+			name:       "all",
+			all:        true,
+			expRemoved: 3,
+			expFreed:   int64(len("[]") + len(manifest)),
+			expHits:    map[Kind]bool{KindManifest: false, KindRelease: false, KindImageFile: false},
+		},
+	}
+
+	for _, tc := range tcases {
+		c := New(t.TempDir())
+		c.now = func() time.Time { return now }
+		if err := c.Put(KindManifest, "https://example.com/storageos-operator.yaml", manifest); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Put(KindImageFile, "sha256:abc/storageos-operator.yaml", manifest); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Put(KindRelease, "https://api.github.com/repos/storageos/operator/releases", []byte("[]")); err != nil {
+			t.Fatal(err)
+		}
+		// a partially written file is kept
+		if err := os.WriteFile(filepath.Join(c.dir, objectsDir, ".tmp-1"), []byte("partial"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		c.now = func() time.Time { return now.Add(tc.age) }
+		removed, freed, err := c.Prune(tc.all)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+			continue
+		}
+		if removed != tc.expRemoved {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expRemoved, removed)
+		}
+		if freed != tc.expFreed {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expFreed, freed)
+		}
+
+		c.now = func() time.Time { return now }
+		keys := map[Kind]string{
+			KindManifest:  "https://example.com/storageos-operator.yaml",
+			KindImageFile: "sha256:abc/storageos-operator.yaml",
+			KindRelease:   "https://api.github.com/repos/storageos/operator/releases",
+		}
+		for kind, key := range keys {
+			if _, hit := c.Get(kind, key); hit != tc.expHits[kind] {
+				t.Errorf("%s: expected %s hit %v, got %v", tc.name, kind, tc.expHits[kind], hit)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(c.dir, objectsDir, ".tmp-1")); err != nil {
+			t.Errorf("%s: expected temporary file to be kept, got %v", tc.name, err)
+		}
+	}
+}
+
+func TestCacheGet(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	key := "https://example.com/storageos-operator.yaml"
+
+	tcases := []struct {
+		name     string
+		age      time.Duration
+		corrupt  bool
+		expected bool
+	}{
+		{
+			name:     "fresh",
+			age:      time.Hour,
+			expected: true,
+		},
+		{
+			name:     "expired",
+			age:      24 * time.Hour,
+			expected: false,
+		},
+		{
+			name:     "corrupted content",
+			corrupt:  true,
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcases {
+		c := New(t.TempDir())
+		c.now = func() time.Time { return now }
+		data := []byte("kind: Deployment\n")
+		if err := c.Put(KindManifest, key, data); err != nil {
+			t.Fatal(err)
+		}
+		if tc.corrupt {
+			if err := os.WriteFile(c.objectPath(digest(data)), []byte("kind: Pod\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		c.now = func() time.Time { return now.Add(tc.age) }
+		got, hit := c.Get(KindManifest, key)
+		if hit != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, hit)
+		}
+		if hit && string(got) != string(data) {
+			t.Errorf("%s: expected %v, got %v", tc.name, string(data), string(got))
+		}
+	}
+}
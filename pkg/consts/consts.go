@@ -1,12 +1,6 @@
 package consts
 
 const (
-	OperatorOldestSupportedVersion = "v2.2.0"
-	ClusterOperatorLastVersion     = "v2.4.4"
-
-	PortalManagerFirstSupportedVersion   = "v2.6.0"
-	MetricsExporterFirstSupportedVersion = "v2.8.0"
-
 	OldOperatorName = "storageos-cluster-operator"
 	NewOperatorName = "storageos-operator"
 
@@ -19,8 +13,17 @@ const (
 
 	EtcdSecretName = "storageos-etcd-secret"
 
+	APISecretName        = "storageos-api"
+	APISecretUsernameKey = "username"
+	APISecretPasswordKey = "password"
+	APIService           = "storageos"
+	APIPort              = 5705
+
 	PortalManagerName = "storageos-portal-manager"
 
+	InstallStateName      = "kubectl-storageos"
+	InstallStateNamespace = "kube-system"
+
 	VersionRegex    = "v?([0-9]+.[0-9]+.[0-9]+)"
 	ShaVersionRegex = "^[a-fA-F0-9]+$"
 )
@@ -0,0 +1,219 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+// Schemes of the references which are resolved by default. A value without one of them, or the scheme
+// of a registered provider, is a plaintext credential.
+const (
+	// FileScheme references the content of a file, eg file:/path/to/password.
+	FileScheme = "file"
+	// EnvScheme references an env var, eg env:STORAGEOS_PASSWORD.
+	EnvScheme = "env"
+	// SecretScheme references a key of a Kubernetes secret, eg secret:storageos/credentials#password.
+	SecretScheme = "secret"
+	// ExecScheme references a credential of an exec plugin, eg exec:vault:secret/storageos#password.
+	ExecScheme = "exec"
+)
+
+const (
+	// PluginPrefix prefixes the name of the executable of an exec plugin, which is looked up in $PATH, eg
+	// kubectl-storageos-credential-vault for exec:vault:<reference>.
+	PluginPrefix = "kubectl-storageos-credential-"
+
+	// PluginAPIVersion is the version of the messages exchanged with exec plugins.
+	PluginAPIVersion = "credentials.storageos.com/v1"
+
+	requestKind  = "CredentialRequest"
+	responseKind = "CredentialResponse"
+
+	// pluginTimeout is the time an exec plugin has to respond.
+	pluginTimeout = 30 * time.Second
+)
+
+// Provider resolves the part of a reference following its scheme to a credential.
+type Provider interface {
+	Resolve(ctx context.Context, reference string) (string, error)
+}
+
+// ProviderFunc is a Provider implemented by a func.
+type ProviderFunc func(ctx context.Context, reference string) (string, error)
+
+// Resolve calls f.
+func (f ProviderFunc) Resolve(ctx context.Context, reference string) (string, error) {
+	return f(ctx, reference)
+}
+
+// SecretGetter returns the secret called name in namespace.
+type SecretGetter func(ctx context.Context, namespace, name string) (*corev1.Secret, error)
+
+// Resolver resolves credential references with the providers of their schemes.
+type Resolver struct {
+	providers map[string]Provider
+}
+
+// NewResolver returns a resolver of the default schemes, reading secrets with getSecret.
+func NewResolver(getSecret SecretGetter) *Resolver {
+	r := &Resolver{providers: map[string]Provider{}}
+	r.Register(FileScheme, ProviderFunc(resolveFile))
+	r.Register(EnvScheme, ProviderFunc(resolveEnv))
+	r.Register(SecretScheme, secretProvider(getSecret))
+	r.Register(ExecScheme, ProviderFunc(resolveExec))
+
+	return r
+}
+
+// Register sets the provider of scheme, replacing any previous one.
+func (r *Resolver) Register(scheme string, provider Provider) {
+	r.providers[scheme] = provider
+}
+
+// IsReference returns true if value is a reference to a credential, rather than the credential itself.
+func (r *Resolver) IsReference(value string) bool {
+	_, _, ok := r.provider(value)
+	return ok
+}
+
+// Resolve returns the credential referenced by value, or value if it isn't a reference. A reference to
+// an empty credential is an error.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	provider, reference, ok := r.provider(value)
+	if !ok {
+		return value, nil
+	}
+	credential, err := provider.Resolve(ctx, reference)
+	if err != nil {
+		return "", err
+	}
+	if credential == "" {
+		return "", errors.Errorf("%s references an empty value", value)
+	}
+
+	return credential, nil
+}
+
+// provider returns the provider of the scheme of value, and the reference following it.
+func (r *Resolver) provider(value string) (Provider, string, bool) {
+	scheme, reference, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, "", false
+	}
+	provider, ok := r.providers[scheme]
+
+	return provider, reference, ok
+}
+
+// DefaultSecretGetter reads secrets from the current cluster.
+func DefaultSecretGetter(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
+	config, err := pluginutils.NewClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return pluginutils.GetSecret(config, name, namespace)
+}
+
+// resolveFile returns the content of a file, without its trailing newline.
+func resolveFile(_ context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolveEnv returns the value of an env var, which must be set.
+func resolveEnv(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", errors.Errorf("env var %s is not set", name)
+	}
+
+	return value, nil
+}
+
+// secretProvider resolves references in the form namespace/name#key to the value of key of a secret.
+func secretProvider(getSecret SecretGetter) Provider {
+	return ProviderFunc(func(ctx context.Context, reference string) (string, error) {
+		path, key, ok := strings.Cut(reference, "#")
+		namespace, name, hasNamespace := strings.Cut(path, "/")
+		if !ok || !hasNamespace || namespace == "" || name == "" || key == "" {
+			return "", errors.Errorf("invalid secret reference %q, expected %s:namespace/name#key", reference, SecretScheme)
+		}
+		secret, err := getSecret(ctx, namespace, name)
+		if err != nil {
+			return "", err
+		}
+		if value, ok := secret.Data[key]; ok {
+			return string(value), nil
+		}
+		if value, ok := secret.StringData[key]; ok {
+			return value, nil
+		}
+
+		return "", errors.Errorf("secret %s/%s has no key %s", namespace, name, key)
+	})
+}
+
+// pluginMessage is a request written to the stdin of an exec plugin, or its response read from stdout.
+type pluginMessage struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// Reference is the reference to resolve, following the name of the plugin, set on requests.
+	Reference string `json:"reference,omitempty"`
+	// Value is the resolved credential, set on responses.
+	Value string `json:"value,omitempty"`
+}
+
+// resolveExec resolves references in the form plugin:reference by running the executable of the plugin,
+// which is sent a CredentialRequest on stdin and responds with a CredentialResponse on stdout. A plugin
+// failing to resolve the reference exits non-zero, with the reason on stderr.
+func resolveExec(ctx context.Context, reference string) (string, error) {
+	plugin, pluginReference, ok := strings.Cut(reference, ":")
+	if !ok || plugin == "" {
+		return "", errors.Errorf("invalid exec reference %q, expected %s:plugin:reference", reference, ExecScheme)
+	}
+	path, err := exec.LookPath(PluginPrefix + plugin)
+	if err != nil {
+		return "", errors.Wrapf(err, "credential plugin %s not found", plugin)
+	}
+
+	request, err := json.Marshal(pluginMessage{APIVersion: PluginAPIVersion, Kind: requestKind, Reference: pluginReference})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, pluginTimeout)
+	defer cancel()
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err = cmd.Run(); err != nil {
+		return "", errors.Wrapf(err, "credential plugin %s failed: %s", plugin, strings.TrimSpace(stderr.String()))
+	}
+
+	response := pluginMessage{}
+	if err = json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return "", errors.Wrapf(err, "invalid response of credential plugin %s", plugin)
+	}
+	if response.APIVersion != PluginAPIVersion || response.Kind != responseKind {
+		return "", errors.Errorf("invalid response of credential plugin %s, expected %s %s, got %s %s",
+			plugin, PluginAPIVersion, responseKind, response.APIVersion, response.Kind)
+	}
+
+	return response.Value, nil
+}
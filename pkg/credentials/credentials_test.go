@@ -0,0 +1,88 @@
+package credentials
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// testPlugin resolves "password", fails to resolve "fail" and responds with an unknown kind otherwise.
+const testPlugin = `#!/bin/sh
+request=$(cat)
+case "$request" in
+*'"reference":"fail"'*) echo "no such credential" >&2; exit 1 ;;
+*'"reference":"password"'*) echo '{"apiVersion":"credentials.storageos.com/v1","kind":"CredentialResponse","value":"drowssap"}' ;;
+*) echo '{"apiVersion":"v0","kind":"Unknown"}' ;;
+esac
+`
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "password"), []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "empty"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, PluginPrefix+"test"), []byte(testPlugin), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("TEST_CREDENTIAL", "from-env")
+
+	getSecret := func(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
+		if namespace != "storageos" || name != "credentials" {
+			return nil, errors.Errorf("secret %s/%s not found", namespace, name)
+		}
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       map[string][]byte{"password": []byte("from-secret")},
+		}, nil
+	}
+	resolver := NewResolver(getSecret)
+	resolver.Register("static", ProviderFunc(func(ctx context.Context, reference string) (string, error) {
+		return "static-" + reference, nil
+	}))
+
+	tcases := []struct {
+		name   string
+		value  string
+		expVal string
+		expErr bool
+	}{
+		{name: "plaintext", value: "password", expVal: "password"},
+		{name: "plaintext with colon", value: "pass:word", expVal: "pass:word"},
+		{name: "empty", value: "", expVal: ""},
+		{name: "file", value: "file:" + filepath.Join(dir, "password"), expVal: "from-file"},
+		{name: "missing file", value: "file:" + filepath.Join(dir, "missing"), expErr: true},
+		{name: "empty file", value: "file:" + filepath.Join(dir, "empty"), expErr: true},
+		{name: "env", value: "env:TEST_CREDENTIAL", expVal: "from-env"},
+		{name: "unset env", value: "env:TEST_CREDENTIAL_UNSET", expErr: true},
+		{name: "secret", value: "secret:storageos/credentials#password", expVal: "from-secret"},
+		{name: "missing secret key", value: "secret:storageos/credentials#username", expErr: true},
+		{name: "missing secret", value: "secret:default/credentials#password", expErr: true},
+		{name: "invalid secret reference", value: "secret:credentials#password", expErr: true},
+		{name: "exec", value: "exec:test:password", expVal: "drowssap"},
+		{name: "exec failure", value: "exec:test:fail", expErr: true},
+		{name: "exec invalid response", value: "exec:test:other", expErr: true},
+		{name: "exec missing plugin", value: "exec:missing:password", expErr: true},
+		{name: "exec invalid reference", value: "exec:test", expErr: true},
+		{name: "registered provider", value: "static:password", expVal: "static-password"},
+	}
+
+	for _, tc := range tcases {
+		value, err := resolver.Resolve(context.Background(), tc.value)
+		if (err != nil) != tc.expErr {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.expErr, err)
+			continue
+		}
+		if value != tc.expVal {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expVal, value)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package forwarder
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/storageos/kubectl-storageos/pkg/consts"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+	operatorapi "github.com/storageos/operator/api/v1"
+)
+
+const (
+	cliImageName       = "cli"
+	cliImageRepository = "storageos/" + cliImageName
+	stosNodeDaemonSet  = "storageos-node"
+
+	ephemeralCLIPodPrefix = "kubectl-storageos-cli-"
+	ephemeralCLILabel     = "app.kubernetes.io/managed-by"
+	// ephemeralCLIDeadline bounds the life of an ephemeral cli pod left behind by a killed plugin.
+	ephemeralCLIDeadline = int64(3600)
+)
+
+// launchEphemeralCLIPod runs a cli pod matching the version of stosCluster, logged in with the
+// credentials of its api secret, and returns its name and a function which deletes it.
+func launchEphemeralCLIPod(config *rest.Config, stosCluster *operatorapi.StorageOSCluster, log *logger.Logger) (string, func(), error) {
+	nodeImage := stosCluster.Spec.Images.NodeContainer
+	if nodeImage == "" {
+		daemonSet, err := pluginutils.GetDaemonSet(config, stosNodeDaemonSet, stosCluster.Namespace)
+		if err != nil {
+			return "", nil, err
+		}
+		nodeImage = daemonSet.Spec.Template.Spec.Containers[0].Image
+	}
+	image := cliImage(nodeImage)
+
+	log.Infof("Launching ephemeral storageos cli pod %s in namespace %s", image, stosCluster.Namespace)
+	pod, deletePod, err := pluginutils.CreatePodAndWaitForRunning(config, ephemeralCLIPod(stosCluster, image))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return pod.Name, deletePod, nil
+}
+
+// cliImage returns the image of the cli released along with the storageos node image, pulled from the
+// same registry and repository path as the node image, so that registry mirrors and image mappings
+// applied to the node image also apply to the cli.
+func cliImage(nodeImage string) string {
+	nodeImage = strings.SplitN(nodeImage, "@", 2)[0]
+	tag := "latest"
+	if i := strings.LastIndex(nodeImage, ":"); i > strings.LastIndex(nodeImage, "/") {
+		tag = nodeImage[i+1:]
+		nodeImage = nodeImage[:i]
+	}
+	repository := cliImageRepository
+	if i := strings.LastIndex(nodeImage, "/"); i >= 0 {
+		repository = nodeImage[:i+1] + cliImageName
+	}
+
+	return repository + ":" + tag
+}
+
+// ephemeralCLIPod returns a pod which runs the cli image until deleted, configured to reach the api of
+// stosCluster. It does not carry the label of the storageos-cli deployment, so that other invocations
+// do not pick it.
+func ephemeralCLIPod(stosCluster *operatorapi.StorageOSCluster, image string) *corev1.Pod {
+	secretKey := func(key string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: pluginutils.GetAPISecretName(stosCluster)},
+				Key:                  key,
+			},
+		}
+	}
+	deadline := ephemeralCLIDeadline
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: ephemeralCLIPodPrefix,
+			Namespace:    stosCluster.Namespace,
+			Labels:       map[string]string{ephemeralCLILabel: "kubectl-storageos"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:    "cli",
+					Image:   image,
+					Command: []string{"/bin/sh", "-c", "while true; do sleep 3600; done"},
+					Env: []corev1.EnvVar{
+						{Name: "STORAGEOS_ENDPOINTS", Value: fmt.Sprintf("http://%s.%s.svc:%d", consts.APIService, stosCluster.Namespace, consts.APIPort)},
+						{Name: "STORAGEOS_USERNAME", ValueFrom: secretKey(consts.APISecretUsernameKey)},
+						{Name: "STORAGEOS_PASSWORD", ValueFrom: secretKey(consts.APISecretPasswordKey)},
+					},
+				},
+			},
+			RestartPolicy:         corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds: &deadline,
+		},
+	}
+}
@@ -2,54 +2,124 @@ package forwarder
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/kubectl/pkg/util/interrupt"
+	"k8s.io/kubectl/pkg/util/term"
 
 	"github.com/storageos/kubectl-storageos/pkg/logger"
 	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
 )
 
 const (
-	errCLIPodUnavailable = "storageos cli pod unavailable for request - please ensure storageos-cli deployment is running"
+	errCLIPodUnavailable = "storageos cli pod unavailable for request - please ensure storageos-cli deployment is running, or pass " + ephemeralCLIFlag
 	cliLabel             = "app.kubernetes.io/component=storageos-cli"
+	clusterFlag          = "--cluster"
+	ephemeralCLIFlag     = "--ephemeral-cli"
 )
 
+// forwardOptions are the flags of the plugin passed along with a forwarded command.
+type forwardOptions struct {
+	selector pluginutils.ClusterSelector
+	// ephemeralCLI launches a cli pod for the command if the storageos-cli deployment is unavailable.
+	ephemeralCLI bool
+}
+
+// ForwardToCLIPod runs command in the storageos cli pod, streaming the stdin, stdout and stderr of the
+// plugin to it. A TTY is allocated when both stdin and stdout are terminals, so that prompts work and the
+// remote terminal follows the size of the local one. A non-zero exit code of command is returned as an
+// error implementing k8s.io/client-go/util/exec.ExitError.
 func ForwardToCLIPod(log *logger.Logger, command []string) error {
 	clientConfig, err := pluginutils.NewClientConfig()
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	log.Verbose = true
 
-	cliPodNamespace, err := getCLIPodNamespace(clientConfig)
+	command, options, err := extractForwardOptions(command)
 	if err != nil {
 		return err
 	}
 
+	stosCluster, err := pluginutils.GetSelectedStorageOSCluster(clientConfig, options.selector)
+	if err != nil {
+		return errors.Wrap(err, "failed to discover storageos namespace for storageos-cli deployment")
+	}
+	cliPodNamespace := stosCluster.GetNamespace()
+
+	deletePod := func() {}
 	cliPodName, err := getCLIPodName(clientConfig, cliPodNamespace)
 	if err != nil {
-		return err
+		if !options.ephemeralCLI {
+			return err
+		}
+		var deleteEphemeralPod func()
+		if cliPodName, deleteEphemeralPod, err = launchEphemeralCLIPod(clientConfig, stosCluster, log); err != nil {
+			return err
+		}
+		// deleted on return, or by the interrupt handler if the plugin is interrupted first
+		var once sync.Once
+		deletePod = func() { once.Do(deleteEphemeralPod) }
+		defer deletePod()
 	}
+	log.Infof("Forwarding %q to pod %s/%s", strings.Join(command, " "), cliPodNamespace, cliPodName)
 
-	stdout, stderr, err := pluginutils.ExecToPod(clientConfig, command, "", cliPodName, cliPodNamespace, nil)
-	if stdout != "" {
-		log.Info(stdout)
+	// tty.Safe only runs the interrupt handler when stdin is a terminal, so also delete the ephemeral cli
+	// pod on a signal here, for piped stdin
+	handler := interrupt.New(nil, deletePod)
+	tty := term.TTY{In: os.Stdin, Out: os.Stdout, Parent: handler}
+	tty.Raw = tty.IsTerminalIn() && tty.IsTerminalOut()
+	streams := pluginutils.ExecStreams{
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		TTY:    tty.Raw,
+	}
+	if streams.TTY {
+		streams.TerminalSizeQueue = tty.MonitorSize(tty.GetSize())
 	}
-	if stderr != "" {
-		log.Info(stderr)
+
+	if tty.IsTerminalIn() {
+		return tty.Safe(func() error {
+			return pluginutils.StreamExecToPod(clientConfig, command, "", cliPodName, cliPodNamespace, streams)
+		})
 	}
 
-	return err
+	return handler.Run(func() error {
+		return pluginutils.StreamExecToPod(clientConfig, command, "", cliPodName, cliPodNamespace, streams)
+	})
 }
 
-func getCLIPodNamespace(clientConfig *rest.Config) (string, error) {
-	stosCluster, err := pluginutils.GetFirstStorageOSCluster(clientConfig)
-	if err != nil {
-		return "", errors.Wrap(err, "failed to discover storageos namespace for storageos-cli deployment")
+// extractForwardOptions removes the flags of the plugin from command, as flag parsing is disabled for
+// forwarded commands and the storageos cli does not know them, and returns the options they set.
+func extractForwardOptions(command []string) ([]string, forwardOptions, error) {
+	forwarded := make([]string, 0, len(command))
+	options := forwardOptions{}
+	cluster := ""
+	for i := 0; i < len(command); i++ {
+		switch {
+		case command[i] == clusterFlag:
+			if i+1 == len(command) {
+				return nil, options, errors.Errorf("flag needs an argument: %s", clusterFlag)
+			}
+			cluster = command[i+1]
+			i++
+		case strings.HasPrefix(command[i], clusterFlag+"="):
+			cluster = strings.TrimPrefix(command[i], clusterFlag+"=")
+		case command[i] == ephemeralCLIFlag:
+			options.ephemeralCLI = true
+		default:
+			forwarded = append(forwarded, command[i])
+		}
 	}
-	return stosCluster.GetNamespace(), nil
+
+	var err error
+	options.selector, err = pluginutils.ParseClusterSelector(cluster)
+	return forwarded, options, err
 }
 
 func getCLIPodName(clientConfig *rest.Config, namespace string) (string, error) {
@@ -0,0 +1,71 @@
+package forwarder
+
+import (
+	"reflect"
+	"testing"
+
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+func TestExtractForwardOptions(t *testing.T) {
+	tcases := []struct {
+		name          string
+		command       []string
+		expCommand    []string
+		expOptions    forwardOptions
+		expectedError bool
+	}{
+		{
+			name:       "no plugin flags",
+			command:    []string{"storageos", "get", "users", "-o", "yaml"},
+			expCommand: []string{"storageos", "get", "users", "-o", "yaml"},
+		},
+		{
+			name:       "cluster and ephemeral cli",
+			command:    []string{"storageos", "get", "users", "--cluster", "storageos/team-a", "--ephemeral-cli"},
+			expCommand: []string{"storageos", "get", "users"},
+			expOptions: forwardOptions{selector: pluginutils.ClusterSelector{Name: "storageos", Namespace: "team-a"}, ephemeralCLI: true},
+		},
+		{
+			name:          "cluster without value",
+			command:       []string{"storageos", "get", "users", "--cluster"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tcases {
+		command, options, err := extractForwardOptions(tc.command)
+		if tc.expectedError != (err != nil) {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectedError, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(command, tc.expCommand) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expCommand, command)
+		}
+		if options != tc.expOptions {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expOptions, options)
+		}
+	}
+}
+
+func TestCLIImage(t *testing.T) {
+	tcases := []struct {
+		nodeImage string
+		expImage  string
+	}{
+		{nodeImage: "storageos/node:v2.7.0", expImage: "storageos/cli:v2.7.0"},
+		{nodeImage: "registry.example.com:5000/storageos/node:v2.8.0@sha256:abc", expImage: "registry.example.com:5000/storageos/cli:v2.8.0"},
+		{nodeImage: "registry.example.com:5000/storageos/node", expImage: "registry.example.com:5000/storageos/cli:latest"},
+		{nodeImage: "mirror.example.com/docker.io/storageos/node:v2.9.0", expImage: "mirror.example.com/docker.io/storageos/cli:v2.9.0"},
+		{nodeImage: "node:v2.9.0", expImage: "storageos/cli:v2.9.0"},
+	}
+
+	for _, tc := range tcases {
+		if image := cliImage(tc.nodeImage); image != tc.expImage {
+			t.Errorf("%s: expected %v, got %v", tc.nodeImage, tc.expImage, image)
+		}
+	}
+}
@@ -0,0 +1,375 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	gyaml "github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+	pluginversion "github.com/storageos/kubectl-storageos/pkg/version"
+)
+
+const (
+	// BackupFormatVersion is the version of the backup layout written by Backup and read by Restore.
+	BackupFormatVersion = "v1"
+
+	backupsDir          = "backups"
+	backupMetadataFile  = "backup.yaml"
+	backupArchiveSuffix = ".tar.gz"
+	backupTimeFormat    = "20060102-150405"
+	etcdSnapshotFile    = "etcd-snapshot.db"
+	etcdShellSnapshot   = "/tmp/etcd-snapshot.db"
+	stosClusterCRDName  = "storageosclusters.storageos.com"
+
+	errNoStorageOSClusterToBackup = "no storageos cluster was found to back up"
+	errBackupPassphraseRequired   = "set %s to the passphrase to encrypt the backed up secrets with, so that the backup can be restored once the cluster is lost or to another cluster"
+	errBackupExists               = "backup %s already exists"
+	errNoBackupFound              = "no backup was found in %s"
+	errUnsupportedBackupFormat    = "backup format %q is not supported, expected %q"
+	errStosClusterCRDNotFound     = `
+	The StorageOSCluster CRD is not established in the cluster, the storageos operator must be installed
+	before the storageos cluster can be restored.
+
+	To restore the remaining objects only, set restore flag --%s`
+
+	backupFromOtherClusterMessage = "Backup was taken from kubernetes cluster %s, restoring to kubernetes cluster %s."
+	etcdSnapshotNotRestored       = `Backup contains an etcd snapshot (%s) which is not restored automatically,
+	use 'kubectl storageos etcd snapshot restore' to restore it to an etcd data directory.`
+)
+
+// BackupMetadata describes a backup written by Backup, it is stored alongside the backed up manifests.
+type BackupMetadata struct {
+	FormatVersion             string      `json:"formatVersion"`
+	CreationTimestamp         metav1.Time `json:"creationTimestamp"`
+	KubeClusterID             string      `json:"kubeClusterID"`
+	StorageOSVersion          string      `json:"storageOSVersion,omitempty"`
+	StorageOSClusterName      string      `json:"storageOSClusterName"`
+	StorageOSClusterNamespace string      `json:"storageOSClusterNamespace"`
+	EtcdSnapshot              string      `json:"etcdSnapshot,omitempty"`
+}
+
+// NewBackupInstaller returns a lightweight Installer used for backup and restore commands
+func NewBackupInstaller(config *apiv1.KubectlStorageOSConfig, log *logger.Logger) (*Installer, error) {
+	installer := &Installer{}
+
+	clientConfig, err := pluginutils.NewClientConfig()
+	if err != nil {
+		return installer, errors.WithStack(err)
+	}
+
+	kubesystemNS, err := pluginutils.GetNamespace(clientConfig, "kube-system")
+	if err != nil {
+		return installer, errors.WithStack(err)
+	}
+
+	stosCluster, err := pluginutils.GetStorageOSCluster(clientConfig)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return installer, errors.WithStack(err)
+		}
+	}
+
+	installer = &Installer{
+		kubectlClient:    kubectlNew(log),
+		clientConfig:     clientConfig,
+		kubeClusterID:    kubesystemNS.GetUID(),
+		stosConfig:       config,
+		onDiskFileSys:    filesys.MakeFsOnDisk(),
+		storageOSCluster: stosCluster,
+		log:              log,
+	}
+
+	return installer, nil
+}
+
+// Backup writes a timestamped backup of the storageos cluster, secrets, configmaps and storageclasses
+// (and optionally a snapshot of the etcd keyspace) to the backup directory, returning its path.
+func (in *Installer) Backup() (string, error) {
+	// the key held in the cluster is lost along with it, and is not found in other clusters
+	if backupKeySource() != keySourcePassphrase {
+		return "", errors.Errorf(errBackupPassphraseRequired, BackupPassphraseEnvVar)
+	}
+	if in.storageOSCluster == nil {
+		return "", errors.New(errNoStorageOSClusterToBackup)
+	}
+
+	backupDir, err := in.getBackupDir()
+	if err != nil {
+		return "", err
+	}
+
+	now := metav1.Now()
+	backupPath := filepath.Join(backupDir, now.UTC().Format(backupTimeFormat))
+	if in.onDiskFileSys.Exists(backupPath) || in.onDiskFileSys.Exists(backupPath+backupArchiveSuffix) {
+		return "", fmt.Errorf(errBackupExists, backupPath)
+	}
+
+	if err = in.writeBackupToPath(backupPath, in.storageOSCluster); err != nil {
+		return "", err
+	}
+
+	metadata := BackupMetadata{
+		FormatVersion:             BackupFormatVersion,
+		CreationTimestamp:         now,
+		KubeClusterID:             string(in.kubeClusterID),
+		StorageOSVersion:          in.existingStorageOSVersion(),
+		StorageOSClusterName:      in.storageOSCluster.Name,
+		StorageOSClusterNamespace: in.storageOSCluster.Namespace,
+	}
+
+	if in.stosConfig.Spec.Backup.EtcdSnapshot {
+		if err = in.writeEtcdSnapshot(filepath.Join(backupPath, etcdSnapshotFile)); err != nil {
+			return "", err
+		}
+		metadata.EtcdSnapshot = etcdSnapshotFile
+	}
+
+	if err = in.writeBackupMetadata(backupPath, metadata); err != nil {
+		return "", err
+	}
+
+	if !in.stosConfig.Spec.Backup.Archive {
+		return backupPath, nil
+	}
+
+	archivePath := backupPath + backupArchiveSuffix
+	if err = archiveDir(backupPath, archivePath); err != nil {
+		return "", err
+	}
+
+	return archivePath, errors.WithStack(os.RemoveAll(backupPath))
+}
+
+// Restore re-applies the objects of the backup at source, which is either a backup directory or archive.
+// If source is empty, the latest backup of the backup directory is restored. Storageclasses and CSI
+// secrets are applied with the storageos finalizer, as during upgrade. The storageos cluster is applied
+// last, once its CRD has been established by the operator.
+func (in *Installer) Restore(source string) error {
+	var err error
+	if source == "" {
+		if source, err = in.latestBackupPath(); err != nil {
+			return err
+		}
+	}
+	in.log.Infof("Restoring backup %s.", source)
+
+	backupPath := source
+	if strings.HasSuffix(source, backupArchiveSuffix) {
+		backupPath, err = os.MkdirTemp("", "kubectl-storageos-restore-")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer os.RemoveAll(backupPath)
+
+		if err = unarchiveDir(source, backupPath); err != nil {
+			return err
+		}
+	}
+
+	metadata, err := in.readBackupMetadata(backupPath)
+	if err != nil {
+		return err
+	}
+	if metadata.FormatVersion != BackupFormatVersion {
+		return fmt.Errorf(errUnsupportedBackupFormat, metadata.FormatVersion, BackupFormatVersion)
+	}
+	if metadata.KubeClusterID != string(in.kubeClusterID) {
+		in.log.Warnf(backupFromOtherClusterMessage, metadata.KubeClusterID, in.kubeClusterID)
+	}
+
+	for _, file := range []string{stosSecretsFile, stosConfigMapsFile} {
+		if err = in.restoreBackupFile(backupPath, file, false); err != nil {
+			return err
+		}
+	}
+	for _, file := range []string{csiSecretsFile, stosStorageClassFile} {
+		if err = in.restoreBackupFile(backupPath, file, true); err != nil {
+			return err
+		}
+	}
+
+	if !in.stosConfig.Spec.SkipStorageOSCluster {
+		if err = pluginutils.IsCRDEstablished(in.clientConfig, stosClusterCRDName); err != nil {
+			return errors.Wrap(err, fmt.Sprintf(errStosClusterCRDNotFound, SkipStosClusterFlag))
+		}
+		if err = in.restoreBackupFile(backupPath, stosClusterFile, false); err != nil {
+			return err
+		}
+	}
+
+	if metadata.EtcdSnapshot != "" {
+		in.log.Warnf(etcdSnapshotNotRestored, metadata.EtcdSnapshot)
+	}
+
+	return nil
+}
+
+// restoreBackupFile applies the manifests of file in backupPath, with the storageos finalizer if
+// withFinalizer is set. Files are only written by a backup for objects which existed, so a missing file
+// is not an error.
+func (in *Installer) restoreBackupFile(backupPath, file string, withFinalizer bool) error {
+	path := filepath.Join(backupPath, file)
+	if !in.onDiskFileSys.Exists(path) {
+		return nil
+	}
+
+	multidoc, err := in.readBackupFile(path)
+	if err != nil {
+		return err
+	}
+
+	manifests := make([]string, 0)
+	for _, manifest := range splitMultiDoc(string(multidoc)) {
+		restorable, err := restorableManifest(manifest)
+		if err != nil {
+			return err
+		}
+		manifests = append(manifests, restorable)
+	}
+
+	if withFinalizer {
+		return in.applyManifestsWithFinalizer(manifests)
+	}
+
+	return errors.WithStack(in.apply("", makeMultiDoc(manifests...)))
+}
+
+// restorableManifest returns manifest without the resource version and finalizers recorded at backup
+// time, so that it can be applied whether or not the object still exists.
+func restorableManifest(manifest string) (string, error) {
+	data, err := gyaml.YAMLToJSON([]byte(manifest))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	obj := &unstructured.Unstructured{}
+	if err = obj.UnmarshalJSON(data); err != nil {
+		return "", errors.WithStack(err)
+	}
+	obj.SetResourceVersion("")
+	obj.SetFinalizers(nil)
+
+	data, err = obj.MarshalJSON()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	data, err = gyaml.JSONToYAML(data)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return string(data), nil
+}
+
+// writeEtcdSnapshot saves a snapshot of the etcd keyspace of the storageos cluster through the etcd
+// shell pod to path of the on-disk filesystem.
+func (in *Installer) writeEtcdSnapshot(path string) error {
+	in.setEtcdDefaultsFromCluster()
+
+	return in.EtcdSnapshotSave(path)
+}
+
+// existingStorageOSVersion returns the version of storageos recorded in the install state, or discovered
+// from the operator deployment. An empty string is returned if neither is available.
+func (in *Installer) existingStorageOSVersion() string {
+	if state, err := ReadInstallState(in.clientConfig); err == nil && state.StorageOSVersion != "" {
+		return state.StorageOSVersion
+	}
+	version, err := pluginversion.GetExistingOperatorVersion(in.stosConfig.Spec.GetOperatorNamespace())
+	if err != nil {
+		return ""
+	}
+	return version
+}
+
+// writeBackupMetadata writes metadata to the backup at backupPath.
+func (in *Installer) writeBackupMetadata(backupPath string, metadata BackupMetadata) error {
+	data, err := gyaml.Marshal(metadata)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(in.onDiskFileSys.WriteFile(filepath.Join(backupPath, backupMetadataFile), data))
+}
+
+// readBackupMetadata returns the metadata of the backup at backupPath.
+func (in *Installer) readBackupMetadata(backupPath string) (*BackupMetadata, error) {
+	data, err := in.onDiskFileSys.ReadFile(filepath.Join(backupPath, backupMetadataFile))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	metadata := &BackupMetadata{}
+	if err = gyaml.Unmarshal(data, metadata); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return metadata, nil
+}
+
+// getBackupDir returns the directory backups are written to and restored from.
+func (in *Installer) getBackupDir() (string, error) {
+	if in.stosConfig.Spec.Backup.BackupDir != "" {
+		return in.stosConfig.Spec.Backup.BackupDir, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return filepath.Join(homeDir, kubeDir, stosDir, backupsDir, string(in.kubeClusterID)), nil
+}
+
+// latestBackupPath returns the path to the most recent backup of the backup directory.
+func (in *Installer) latestBackupPath() (string, error) {
+	backupDir, err := in.getBackupDir()
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := os.ReadDir(backupDir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", errors.WithStack(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	latest := latestBackup(names)
+	if latest == "" {
+		return "", fmt.Errorf(errNoBackupFound, backupDir)
+	}
+
+	return filepath.Join(backupDir, latest), nil
+}
+
+// latestBackup returns the most recent of the backup directory and archive names, which sort by their
+// timestamp. An empty string is returned if names contains no backups.
+func latestBackup(names []string) string {
+	backups := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, err := time.Parse(backupTimeFormat, strings.TrimSuffix(name, backupArchiveSuffix)); err != nil {
+			continue
+		}
+		backups = append(backups, name)
+	}
+	if len(backups) == 0 {
+		return ""
+	}
+
+	sort.Strings(backups)
+
+	return backups[len(backups)-1]
+}
@@ -0,0 +1,111 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	operatorapi "github.com/storageos/operator/api/v1"
+)
+
+func TestLatestBackup(t *testing.T) {
+	tcases := []struct {
+		name      string
+		names     []string
+		expLatest string
+	}{
+		{
+			name:      "no backups",
+			names:     []string{},
+			expLatest: "",
+		},
+		{
+			name:      "directories",
+			names:     []string{"20220101-100000", "20220301-090000", "20220201-110000"},
+			expLatest: "20220301-090000",
+		},
+		{
+			name:      "directories and archives",
+			names:     []string{"20220101-100000", "20220301-090000.tar.gz", "20220201-110000"},
+			expLatest: "20220301-090000.tar.gz",
+		},
+		{
+			name:      "unrelated files are ignored",
+			names:     []string{"20220101-100000", "notes.txt", "zzz.tar.gz"},
+			expLatest: "20220101-100000",
+		},
+	}
+	for _, tc := range tcases {
+		latest := latestBackup(tc.names)
+		if latest != tc.expLatest {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expLatest, latest)
+		}
+	}
+}
+
+func TestRestorableManifest(t *testing.T) {
+	manifest := `apiVersion: storage.k8s.io/v1
+kind: StorageClass
+metadata:
+  finalizers:
+  - storageos.com/finalizer
+  labels:
+    app: storageos
+  name: storageos
+  resourceVersion: "1234"
+provisioner: csi.storageos.com
+`
+	expManifest := `apiVersion: storage.k8s.io/v1
+kind: StorageClass
+metadata:
+  labels:
+    app: storageos
+  name: storageos
+provisioner: csi.storageos.com
+`
+	restorable, err := restorableManifest(manifest)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if restorable != expManifest {
+		t.Errorf("expected %v, got %v", expManifest, restorable)
+	}
+}
+
+func TestBackupRequiresPassphrase(t *testing.T) {
+	t.Setenv(BackupPassphraseEnvVar, "")
+	in := &Installer{storageOSCluster: &operatorapi.StorageOSCluster{}}
+	if _, err := in.Backup(); err == nil || !strings.Contains(err.Error(), BackupPassphraseEnvVar) {
+		t.Errorf("expected error requiring %s, got %v", BackupPassphraseEnvVar, err)
+	}
+}
+
+func TestReadPlaintextBackupFile(t *testing.T) {
+	t.Setenv(BackupPassphraseEnvVar, "")
+	path := filepath.Join(t.TempDir(), stosSecretsFile)
+	plaintext := "apiVersion: v1\nkind: Secret\n"
+	if err := os.WriteFile(path, []byte(plaintext), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// without a cluster, encrypting the file would fail rather than create a key
+	in := &Installer{onDiskFileSys: filesys.MakeFsOnDisk(), log: logger.NewLogger()}
+	data, err := in.readBackupFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != plaintext {
+		t.Errorf("expected %v, got %v", plaintext, string(data))
+	}
+	onDisk, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(onDisk) != plaintext {
+		t.Errorf("expected %s to be left in plaintext, got %v", path, string(onDisk))
+	}
+}
@@ -0,0 +1,64 @@
+package installer
+
+import (
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+)
+
+// componentNames holds the name reported for the components applied and deleted from each manifest file.
+var componentNames = map[string]string{
+	stosOperatorFile:         "storageos-operator",
+	stosClusterFile:          "storageos-cluster",
+	resourceQuotaFile:        "resource-quota",
+	stosPortalConfigFile:     "portal-manager-config",
+	stosPortalClientFile:     "portal-manager-client",
+	etcdOperatorFile:         "etcd-operator",
+	etcdClusterFile:          "etcd-cluster",
+	localPathProvisionerFile: "local-path-provisioner",
+}
+
+// recordComponent reports the component of manifest file to the logger, with the version and namespace
+// it is installed with, or was installed with for deleted components.
+func (in *Installer) recordComponent(file, action string) {
+	name, ok := componentNames[file]
+	if !ok {
+		name = file
+	}
+	component := logger.Component{
+		Name:   name,
+		Action: action,
+	}
+
+	spec := in.stosConfig.Spec
+	deleted := action == logger.ComponentDeleted
+	stosClusterNamespace := spec.Install.StorageOSClusterNamespace
+	if deleted && in.storageOSCluster != nil {
+		stosClusterNamespace = in.storageOSCluster.Namespace
+	}
+
+	switch file {
+	case stosOperatorFile:
+		component.Version, component.Namespace = spec.Install.StorageOSVersion, spec.Install.StorageOSOperatorNamespace
+		if deleted {
+			component.Version, component.Namespace = spec.Uninstall.StorageOSVersion, spec.Uninstall.StorageOSOperatorNamespace
+		}
+	case stosClusterFile:
+		component.Version, component.Namespace = spec.Install.StorageOSVersion, stosClusterNamespace
+		if deleted {
+			component.Version = spec.Uninstall.StorageOSVersion
+		}
+	case resourceQuotaFile:
+		component.Namespace = stosClusterNamespace
+	case stosPortalConfigFile, stosPortalClientFile:
+		component.Version, component.Namespace = spec.Install.PortalManagerVersion, stosClusterNamespace
+		if deleted {
+			component.Version = spec.Uninstall.PortalManagerVersion
+		}
+	case etcdOperatorFile, etcdClusterFile:
+		component.Version, component.Namespace = spec.Install.EtcdOperatorVersion, spec.Install.EtcdNamespace
+		if deleted {
+			component.Version, component.Namespace = spec.Uninstall.EtcdOperatorVersion, spec.Uninstall.EtcdNamespace
+		}
+	}
+
+	in.log.Component(component)
+}
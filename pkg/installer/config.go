@@ -0,0 +1,105 @@
+package installer
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	fieldpath "k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/config/crd"
+)
+
+// ConfigKind is the kind of the config file.
+const ConfigKind = "KubectlStorageOSConfig"
+
+// configSchema returns the schema of the KubectlStorageOSConfig CRD.
+func configSchema() (*apiextensionsv1.CustomResourceValidation, error) {
+	configCRD := &apiextensionsv1.CustomResourceDefinition{}
+	if err := yaml.Unmarshal([]byte(crd.KubectlStorageOSConfig), configCRD); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, version := range configCRD.Spec.Versions {
+		if version.Name == apiv1.GroupVersion.Version && version.Schema != nil {
+			return version.Schema, nil
+		}
+	}
+
+	return nil, errors.Errorf("no schema for version %s in the %s CRD", apiv1.GroupVersion.Version, configCRD.Name)
+}
+
+// ValidateConfig decodes data as a KubectlStorageOSConfig strictly against the schema of its CRD. It
+// returns the fields which are unknown to the schema or whose values don't match it, and an error only
+// if data can't be decoded at all.
+func ValidateConfig(data []byte) (fieldpath.ErrorList, error) {
+	config := map[string]interface{}{}
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, errors.Wrap(err, "unable to decode config")
+	}
+	schema, err := configSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	allErrs := fieldpath.ErrorList{}
+	if apiVersion := config["apiVersion"]; apiVersion != apiv1.GroupVersion.String() {
+		allErrs = append(allErrs, fieldpath.NotSupported(fieldpath.NewPath("apiVersion"), apiVersion, []string{apiv1.GroupVersion.String()}))
+	}
+	if kind := config["kind"]; kind != ConfigKind {
+		allErrs = append(allErrs, fieldpath.NotSupported(fieldpath.NewPath("kind"), kind, []string{ConfigKind}))
+	}
+	allErrs = append(allErrs, unknownFields(nil, config, schema.OpenAPIV3Schema)...)
+
+	internal := &apiextensions.CustomResourceValidation{}
+	if err := apiextensionsv1.Convert_v1_CustomResourceValidation_To_apiextensions_CustomResourceValidation(schema, internal, nil); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	validator, _, err := validation.NewSchemaValidator(internal)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// the validator walks maps in random order
+	schemaErrs := validation.ValidateCustomResource(nil, config, validator)
+	sort.SliceStable(schemaErrs, func(i, j int) bool { return schemaErrs[i].Field < schemaErrs[j].Field })
+
+	return append(allErrs, schemaErrs...), nil
+}
+
+// unknownFields returns the fields of value under path which are not properties of schema. Objects
+// without properties, such as metadata, are not checked.
+func unknownFields(path *fieldpath.Path, value interface{}, schema *apiextensionsv1.JSONSchemaProps) fieldpath.ErrorList {
+	allErrs := fieldpath.ErrorList{}
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if len(schema.Properties) == 0 || schema.XPreserveUnknownFields != nil && *schema.XPreserveUnknownFields {
+			return allErrs
+		}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			property, ok := schema.Properties[key]
+			if !ok {
+				allErrs = append(allErrs, fieldpath.Forbidden(path.Child(key), "unknown field"))
+				continue
+			}
+			allErrs = append(allErrs, unknownFields(path.Child(key), value[key], &property)...)
+		}
+	case []interface{}:
+		if schema.Items == nil || schema.Items.Schema == nil {
+			return allErrs
+		}
+		for i, item := range value {
+			allErrs = append(allErrs, unknownFields(path.Index(i), item, schema.Items.Schema)...)
+		}
+	}
+
+	return allErrs
+}
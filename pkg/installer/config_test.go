@@ -0,0 +1,121 @@
+package installer
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	sample, err := os.ReadFile("../../config/samples/_v1_kubectlstorageosconfig.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tcases := []struct {
+		name      string
+		config    string
+		expFields []string
+		expErr    bool
+	}{
+		{
+			name:   "sample",
+			config: string(sample),
+		},
+		{
+			name: "unknown fields",
+			config: `apiVersion: storageos.com/v1
+kind: KubectlStorageOSConfig
+spec:
+  install:
+    skipK8sVerisonCheck: true
+    storageOSVersion: v2.8.0
+  uninstall:
+    stosVersion: v2.8.0
+`,
+			expFields: []string{"spec.install.skipK8sVerisonCheck", "spec.uninstall.stosVersion"},
+		},
+		{
+			name: "wrong type",
+			config: `apiVersion: storageos.com/v1
+kind: KubectlStorageOSConfig
+spec:
+  includeEtcd: "yes"
+  install:
+    etcdSecretName: false
+`,
+			expFields: []string{"spec.includeEtcd", "spec.install.etcdSecretName"},
+		},
+		{
+			name: "wrong kind",
+			config: `apiVersion: storageos.com/v2
+kind: StorageOSCluster
+spec: {}
+`,
+			expFields: []string{"apiVersion", "kind"},
+		},
+		{
+			name:   "duplicate field",
+			config: "kind: KubectlStorageOSConfig\nkind: KubectlStorageOSConfig\n",
+			expErr: true,
+		},
+	}
+
+	for _, tc := range tcases {
+		errs, err := ValidateConfig([]byte(tc.config))
+		if (err != nil) != tc.expErr {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.expErr, err)
+			continue
+		}
+		fields := []string{}
+		for _, fieldErr := range errs {
+			fields = append(fields, fieldErr.Field)
+		}
+		if strings.Join(fields, ",") != strings.Join(tc.expFields, ",") {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expFields, errs)
+		}
+	}
+}
+
+// TestConfigPaths checks that the paths read from the config file are fields of the CRD, as a typo
+// would otherwise be silently ignored.
+func TestConfigPaths(t *testing.T) {
+	schema, err := configSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []string{
+		StackTraceConfig, VerboseConfig, OutputConfig, SkipNamespaceDeletionConfig, SkipExistingWorkloadCheckConfig,
+		SkipStosClusterConfig, IncludeEtcdConfig, SerialConfig, AirGapConfig, WaitConfig, DryRunConfig,
+		InstallStosVersionConfig, InstallEtcdOperatorVersionConfig, InstallEtcdNamespaceConfig,
+		InstallStosOperatorNSConfig, StosClusterNSConfig, InstallStosOperatorYamlConfig, InstallStosClusterYamlConfig,
+		InstallStosPortalConfigYamlConfig, InstallStosPortalClientSecretYamlConfig, InstallEtcdOperatorYamlConfig,
+		InstallEtcdClusterYamlConfig, InstallResourceQuotaYamlConfig, EtcdEndpointsConfig, SkipEtcdEndpointsValConfig,
+		EtcdTLSEnabledConfig, EtcdValidationModeConfig, EtcdSecretNameConfig, EtcdStorageClassConfig,
+		AdminUsernameConfig, AdminPasswordConfig, PortalClientIDConfig, PortalSecretConfig, PortalTenantIDConfig,
+		PortalAPIURLConfig, PortalHTTPSProxyConfig, InstallPortalManagerVersionConfig,
+		UninstallPortalManagerVersionConfig, EnablePortalManagerConfig, UninstallStosVersionConfig,
+		UninstallEtcdOperatorVersionConfig, UninstallEtcdNSConfig, UninstallStosOperatorNSConfig,
+		UninstallStosOperatorYamlConfig, UninstallStosClusterYamlConfig, UninstallStosPortalConfigYamlConfig,
+		UninstallStosPortalClientSecretYamlConfig, UninstallEtcdOperatorYamlConfig, UninstallEtcdClusterYamlConfig,
+		UninstallResourceQuotaYamlConfig, IncludeLocalPathProvisionerConfig, InstallLocalPathProvisionerYamlConfig,
+		UninstallLocalPathProvisionerYamlConfig, EtcdVersionTagConfig, EtcdDockerRepositoryConfig,
+		EtcdTopologyKeyConfig, EtcdCPULimitConfig, EtcdMemoryLimitConfig, EtcdReplicasConfig, EnableMetricsConfig,
+		TestClusterConfig, EnableNodeGuardConfig, NodeGuardEnvConfig, SkipRollbackConfig, ReadinessTimeoutConfig,
+		ServerSideApplyConfig, ForceConflictsConfig, FromBundleConfig, RegistryMirrorConfig, ImageMappingConfig,
+		RemovalTimeoutConfig, BackupDirConfig, ArchiveConfig, EtcdSnapshotConfig, K8sVersionConfig,
+		SkipK8sVersionCheckConfig,
+	}
+	for _, path := range paths {
+		props := schema.OpenAPIV3Schema
+		for _, key := range strings.Split(path, ".") {
+			prop, ok := props.Properties[key]
+			if !ok {
+				t.Errorf("%s: expected field of the CRD, got no field %s", path, key)
+				break
+			}
+			props = &prop
+		}
+	}
+}
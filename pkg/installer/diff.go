@@ -0,0 +1,216 @@
+package installer
+
+import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+// EnablePortalOperation is the enable-portal operation, which can be diffed along with install and upgrade.
+const EnablePortalOperation = "enable-portal"
+
+const (
+	errNoStorageOSClusterToDiff = "no storageos cluster found to diff %s against"
+	errNoUninstallConfigToDiff  = "no uninstall config to diff %s with"
+)
+
+// NewDiffInstaller returns a dry-run Installer for operation, one of install, upgrade or enable-portal,
+// which renders the manifests of the operation to memory for Diff. Nothing is written to the cluster.
+// The uninstallConfig of an upgrade renders the objects its uninstall phase deletes, it is ignored
+// otherwise.
+func NewDiffInstaller(config, uninstallConfig *apiv1.KubectlStorageOSConfig, operation string, log *logger.Logger) (*Installer, error) {
+	setVersionsForOperation(config)
+
+	installer := &Installer{}
+
+	clientConfig, err := pluginutils.NewClientConfig()
+	if err != nil {
+		return installer, errors.WithStack(err)
+	}
+
+	stosCluster, err := pluginutils.GetStorageOSCluster(clientConfig)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return installer, errors.WithStack(err)
+		}
+		stosCluster = nil
+	}
+	if stosCluster == nil && operation != InstallOperation {
+		return installer, errors.Errorf(errNoStorageOSClusterToDiff, operation)
+	}
+
+	if config.Spec.Install.KubernetesVersion == "" {
+		currentVersion, err := pluginutils.GetKubernetesVersion(clientConfig)
+		if err != nil {
+			return installer, errors.WithStack(err)
+		}
+		config.Spec.Install.KubernetesVersion = currentVersion.String()
+	}
+	config.Spec.Install.DryRun = true
+	config.Spec.Install.SkipEtcdEndpointsValidation = true
+	distribution := pluginutils.DetermineDistribution(config.Spec.Install.KubernetesVersion)
+
+	// enable-portal re-applies the existing storageos cluster only
+	options := &installerOptions{}
+	if operation != EnablePortalOperation {
+		options = &installerOptions{
+			storageosOperator:    true,
+			storageosCluster:     !config.Spec.SkipStorageOSCluster,
+			portalClient:         config.Spec.Install.EnablePortalManager,
+			portalConfig:         config.Spec.Install.EnablePortalManager,
+			resourceQuota:        (distribution == pluginutils.DistributionGKE),
+			etcdOperator:         config.Spec.IncludeEtcd,
+			etcdCluster:          config.Spec.IncludeEtcd,
+			localPathProvisioner: config.Spec.IncludeLocalPathProvisioner,
+		}
+	}
+
+	fileSys, err := options.buildInstallerFileSys(config, clientConfig)
+	if err != nil {
+		return installer, errors.WithStack(err)
+	}
+
+	installer = &Installer{
+		distribution:     distribution,
+		clientConfig:     clientConfig,
+		stosConfig:       config,
+		fileSys:          fileSys,
+		onDiskFileSys:    filesys.MakeFsOnDisk(),
+		installerOptions: options,
+		storageOSCluster: stosCluster,
+		log:              log,
+		renderToMemory:   true,
+	}
+
+	if operation != UpgradeOperation {
+		return installer, nil
+	}
+	if uninstallConfig == nil {
+		return installer, errors.Errorf(errNoUninstallConfigToDiff, operation)
+	}
+	// created after the installer, as for an upgrade
+	if installer.diffUninstaller, err = NewUninstaller(uninstallConfig, log); err != nil {
+		return installer, err
+	}
+	installer.diffUninstaller.renderToMemory = true
+
+	return installer, nil
+}
+
+// Diff renders the manifests of operation and returns the changes that applying them would make to the
+// live objects of the cluster. Objects that would be unchanged are omitted. For an upgrade, the live
+// objects deleted by its uninstall phase which the new version doesn't recreate are returned as deleted.
+func (in *Installer) Diff(operation string) ([]pluginutils.ObjectDiff, error) {
+	var err error
+	switch operation {
+	case InstallOperation:
+		err = in.Install(false)
+	case UpgradeOperation:
+		err = in.renderUpgrade()
+	case EnablePortalOperation:
+		err = in.EnablePortalManager(true)
+	default:
+		err = errors.Errorf("unable to diff unknown operation %s", operation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := []pluginutils.ObjectDiff{}
+	for _, manifest := range in.renderedManifests {
+		manifestDiffs, err := pluginutils.DiffManifest(in.clientConfig, "", manifest, FieldManager)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, manifestDiffs...)
+	}
+
+	if in.diffUninstaller == nil {
+		return diffs, nil
+	}
+	if err = in.diffUninstaller.renderUpgradeUninstall(); err != nil {
+		return nil, err
+	}
+	removals, err := pluginutils.DiffManifestRemovals(in.clientConfig, "", in.diffUninstaller.renderedManifests, in.renderedManifests)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(diffs, removals...), nil
+}
+
+// renderUpgrade renders the install phase of an upgrade. As for an upgrade, the existing storageos cluster
+// and its credentials are installed with the new version, unless a storageos cluster has been passed.
+func (in *Installer) renderUpgrade() error {
+	install := &in.stosConfig.Spec.Install
+	if install.EtcdEndpoints == "" {
+		install.EtcdEndpoints = in.storageOSCluster.Spec.KVBackend.Address
+	}
+	if install.StorageOSClusterNamespace == "" {
+		install.StorageOSClusterNamespace = getStringWithDefault(in.storageOSCluster.Spec.Namespace, in.storageOSCluster.Namespace)
+	}
+	if err := in.handleEndpointsInput(in.stosConfig.Spec); err != nil {
+		return err
+	}
+
+	if in.stosConfig.Spec.Install.StorageOSClusterYaml == "" && !in.stosConfig.Spec.SkipStorageOSCluster {
+		stosClusterManifest, err := storageOSClusterToManifest(in.storageOSCluster)
+		if err != nil {
+			return err
+		}
+		inMemStosClusterManifest, err := in.fileSys.ReadFile(filepath.Join(stosDir, clusterDir, stosClusterFile))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		inMemStosAPISecret, err := pluginutils.GetManifestFromMultiDocByKind(string(inMemStosClusterManifest), "Secret")
+		if err != nil {
+			return err
+		}
+		if err = in.useStorageOSClusterManifest(string(stosClusterManifest), inMemStosAPISecret); err != nil {
+			return err
+		}
+	}
+
+	stosSecret, err := pluginutils.GetSecret(in.clientConfig, in.storageOSCluster.Spec.SecretRefName, in.storageOSCluster.Namespace)
+	if err != nil {
+		return err
+	}
+	stosSecretManifest, err := secretToManifest(stosSecret)
+	if err != nil {
+		return err
+	}
+	if err = in.copyStorageOSAPIData(in.stosConfig, string(stosSecretManifest)); err != nil {
+		return err
+	}
+
+	return in.Install(true)
+}
+
+// renderUpgradeUninstall renders the manifests of the existing version which the uninstall phase of an
+// upgrade deletes, see uninstallStorageOS. Namespaces aren't deleted and nothing is waited for.
+func (in *Installer) renderUpgradeUninstall() error {
+	if !in.stosConfig.Spec.SkipStorageOSCluster && in.storageOSCluster != nil {
+		if err := in.uninstallStorageOSCluster(true); err != nil {
+			return err
+		}
+	}
+
+	storageOSClusterNamespace := in.stosConfig.Spec.GetOperatorNamespace()
+	if in.storageOSCluster != nil && in.storageOSCluster.Namespace != "" {
+		storageOSClusterNamespace = in.storageOSCluster.Namespace
+	}
+	if err := in.uninstallPortalManagerConfig(storageOSClusterNamespace); err != nil {
+		return err
+	}
+	if err := in.uninstallPortalManagerClient(storageOSClusterNamespace); err != nil {
+		return err
+	}
+
+	return in.uninstallStorageOSOperator()
+}
@@ -0,0 +1,227 @@
+package installer
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	"github.com/storageos/kubectl-storageos/pkg/consts"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+const (
+	// BackupPassphraseEnvVar is the environment variable holding the passphrase used to encrypt
+	// secrets backed up to disk. It is required by backup. If unset, the backups taken on uninstall and
+	// upgrade use a key generated and held in the k8s cluster, with a warning as they can't be decrypted
+	// once the cluster is lost.
+	BackupPassphraseEnvVar = "STORAGEOS_BACKUP_PASSPHRASE"
+
+	keySourcePassphrase = "passphrase"
+	keySourceCluster    = "cluster"
+
+	backupKeySecretName = "kubectl-storageos-backup-key"
+	backupKeySecretKey  = "key"
+	backupKeySize       = 32
+
+	errBackupPassphraseNotSet = "%s was encrypted with a passphrase, set %s to decrypt it"
+	errUnknownKeySource       = "%s was encrypted with an unknown key source %q"
+	errNoClusterForBackupKey  = "unable to read the backup key held in the k8s cluster"
+	errBackupKeyNotFound      = "%s was encrypted with the key held in secret %s/%s of the k8s cluster it was backed up from, which does not exist in this cluster"
+
+	backupKeyCreatedMessage  = "Secrets backed up to disk are encrypted with a key held in secret %s/%s, set %s to use a passphrase instead."
+	clusterBackupKeyWarning  = "%s is encrypted with the key held in secret %s/%s of this cluster: it can't be decrypted once the cluster is lost, and anyone able to read secrets of %s can decrypt it. Set %s to encrypt backups with a passphrase instead."
+	plaintextBackupWarning   = "%s holds secrets in plaintext, run kubectl storageos backup encrypt-existing to encrypt it."
+	plaintextBackupEncrypted = "Encrypted plaintext backup %s."
+)
+
+// secretBackupFiles holds the names of backup files which contain secrets, and are encrypted at rest.
+var secretBackupFiles = map[string]bool{
+	stosSecretsFile: true,
+	csiSecretsFile:  true,
+}
+
+// NewBackupFileInstaller returns an installer able to encrypt and decrypt backup files. The k8s cluster
+// is only required for files encrypted with the key held in it, so that backups encrypted with a
+// passphrase can still be read once the cluster is lost.
+func NewBackupFileInstaller(log *logger.Logger) *Installer {
+	installer := &Installer{
+		onDiskFileSys: filesys.MakeFsOnDisk(),
+		log:           log,
+	}
+
+	clientConfig, err := pluginutils.NewClientConfig()
+	if err == nil {
+		installer.clientConfig = clientConfig
+	}
+
+	return installer
+}
+
+// writeEncryptedBackupFile encrypts data and writes it to path of the on-disk filesystem.
+func (in *Installer) writeEncryptedBackupFile(path string, data []byte) error {
+	keySource := backupKeySource()
+	passphrase, err := in.backupPassphrase(path, keySource, true)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := pluginutils.Encrypt(data, passphrase, keySource)
+	if err != nil {
+		return err
+	}
+	if keySource == keySourceCluster {
+		in.log.Warnf(clusterBackupKeyWarning, path, consts.InstallStateNamespace, backupKeySecretName, consts.InstallStateNamespace, BackupPassphraseEnvVar)
+	}
+
+	return errors.WithStack(in.onDiskFileSys.WriteFile(path, encrypted))
+}
+
+// readBackupFile returns the contents of path of the on-disk filesystem, decrypting them if the file is
+// encrypted. Nothing is written, plaintext secret backups written by earlier versions of the plugin are
+// only encrypted by EncryptPlaintextBackups.
+func (in *Installer) readBackupFile(path string) ([]byte, error) {
+	data, err := in.onDiskFileSys.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if !pluginutils.IsEncrypted(data) {
+		if secretBackupFiles[filepath.Base(path)] {
+			in.log.Warnf(plaintextBackupWarning, path)
+		}
+		return data, nil
+	}
+
+	keySource, err := pluginutils.EncryptedKeySource(data)
+	if err != nil {
+		return nil, err
+	}
+	passphrase, err := in.backupPassphrase(path, keySource, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return pluginutils.Decrypt(data, passphrase)
+}
+
+// EncryptPlaintextBackups encrypts the plaintext secret backups found under the plugin's directory of
+// $HOME/.kube, returning the paths of the files encrypted.
+func (in *Installer) EncryptPlaintextBackups() ([]string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	root := filepath.Join(homeDir, kubeDir, stosDir)
+
+	encrypted := make([]string, 0)
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() || !secretBackupFiles[entry.Name()] {
+			return nil
+		}
+
+		data, err := in.onDiskFileSys.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if pluginutils.IsEncrypted(data) {
+			return nil
+		}
+		if err = in.writeEncryptedBackupFile(path, data); err != nil {
+			return err
+		}
+		in.log.Successf(plaintextBackupEncrypted, path)
+		encrypted = append(encrypted, path)
+
+		return nil
+	})
+
+	return encrypted, errors.WithStack(err)
+}
+
+// DecryptBackupFile returns the plaintext contents of the backup file at path.
+func (in *Installer) DecryptBackupFile(path string) ([]byte, error) {
+	return in.readBackupFile(path)
+}
+
+// backupKeySource returns the key source used to encrypt new backups.
+func backupKeySource() string {
+	if os.Getenv(BackupPassphraseEnvVar) != "" {
+		return keySourcePassphrase
+	}
+	return keySourceCluster
+}
+
+// backupPassphrase returns the passphrase of keySource used to encrypt or decrypt path. The key held in
+// the k8s cluster is generated if it does not exist and create is set.
+func (in *Installer) backupPassphrase(path, keySource string, create bool) ([]byte, error) {
+	switch keySource {
+	case keySourcePassphrase:
+		passphrase := os.Getenv(BackupPassphraseEnvVar)
+		if passphrase == "" {
+			return nil, errors.Errorf(errBackupPassphraseNotSet, path, BackupPassphraseEnvVar)
+		}
+		return []byte(passphrase), nil
+	case keySourceCluster:
+		key, err := in.clusterBackupKey(create)
+		if err != nil && !create && kerrors.IsNotFound(errors.Cause(err)) {
+			return nil, errors.Errorf(errBackupKeyNotFound, path, consts.InstallStateNamespace, backupKeySecretName)
+		}
+		return key, err
+	}
+
+	return nil, errors.Errorf(errUnknownKeySource, path, keySource)
+}
+
+// clusterBackupKey returns the backup encryption key held in the k8s cluster, generating it first if it
+// does not exist and create is set.
+func (in *Installer) clusterBackupKey(create bool) ([]byte, error) {
+	if in.clientConfig == nil {
+		return nil, errors.New(errNoClusterForBackupKey)
+	}
+	secret, err := pluginutils.GetSecret(in.clientConfig, backupKeySecretName, consts.InstallStateNamespace)
+	if err == nil {
+		return secret.Data[backupKeySecretKey], nil
+	}
+	if !kerrors.IsNotFound(errors.Cause(err)) || !create {
+		return nil, err
+	}
+
+	key := make([]byte, backupKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backupKeySecretName,
+			Namespace: consts.InstallStateNamespace,
+		},
+		Data: map[string][]byte{
+			backupKeySecretKey: []byte(base64.StdEncoding.EncodeToString(key)),
+		},
+	}
+	if err = pluginutils.CreateSecret(in.clientConfig, secret, consts.InstallStateNamespace); err != nil {
+		if kerrors.IsAlreadyExists(err) {
+			// created concurrently, use the existing key.
+			return in.clusterBackupKey(false)
+		}
+		return nil, errors.WithStack(err)
+	}
+	in.log.Warnf(backupKeyCreatedMessage, consts.InstallStateNamespace, backupKeySecretName, BackupPassphraseEnvVar)
+
+	return secret.Data[backupKeySecretKey], nil
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 
 	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
 	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
@@ -27,6 +28,7 @@ const (
 	If you have uninstalled StorageOS using kubectl-storageos since last creating your secret, check 
 	
 	$HOME/.kube/storageos/uninstall-<cluster-id>/storageos-secrets.yaml for a local backup.
+	The backup is encrypted, print it with 'kubectl storageos backup decrypt <file>'.
 	
 
 
@@ -60,6 +62,9 @@ const (
 	Failed to cleanup etcd shell pod with error %v, 
 	please delete pod manually after installaion is complete.`
 
+	// etcdShellImage is the image of the etcd shell pod.
+	etcdShellImage = "gcr.io/etcd-development/etcd:v3.5.0"
+
 	etcdShellPod = `apiVersion: v1
 kind: Pod
 metadata:
@@ -69,7 +74,7 @@ spec:
   restartPolicy: OnFailure      
   containers:
     - name: storageos-etcd-shell
-      image: gcr.io/etcd-development/etcd:v3.5.0
+      image: ` + etcdShellImage + `
       # pod completes and is not restarted after 3m, this is in case
       # the plugin crashes and is unable to delete this pod after health check
       command: [ "sleep" ]
@@ -84,7 +89,7 @@ spec:
   restartPolicy: OnFailure
   containers:
     - name: storageos-etcd-shell
-      image: gcr.io/etcd-development/etcd:v3.5.0
+      image: ` + etcdShellImage + `
       # pod completes and is not restarted after 3m, this is in case
       # the plugin crashes and is unable to delete this pod after health check
       command: [ "sleep" ]
@@ -125,12 +130,21 @@ func (in *Installer) handleEndpointsInput(configInstall apiv1.KubectlStorageOSCo
 	return in.addPatchesToFSKustomize(filepath.Join(stosDir, clusterDir, kustomizationFile), stosClusterKind, fsClusterName, []pluginutils.KustomizePatch{endpointPatch})
 }
 
-// validateEtcd:
+// validateEtcd validates the endpoints with a native etcd client for the client validation mode,
+// otherwise it:
 // - creates the etcd-shell pod (TLS or non-TLS)
 // - deletes the etcd-shell pod (deferred)
 // - prompts the user for endpoints input if required
 // - validates the endpoints using the etcd-shell-pod
 func (in *Installer) validateEtcd(configSpec apiv1.KubectlStorageOSConfigSpec) error {
+	switch mode := configSpec.Install.EtcdValidationMode; mode {
+	case EtcdValidationClient:
+		return in.validateEtcdWithClient(configSpec)
+	case "", EtcdValidationShellPod:
+	default:
+		return fmt.Errorf(errUnknownEtcdValidationMode, mode, EtcdValidationShellPod, EtcdValidationClient)
+	}
+
 	var err error
 	etcdShell := etcdShellPod
 
@@ -146,8 +160,11 @@ func (in *Installer) validateEtcd(configSpec apiv1.KubectlStorageOSConfigSpec) e
 			return err
 		}
 	}
+	if etcdShell, err = in.rewriteEtcdShellImage(etcdShell); err != nil {
+		return err
+	}
 
-	if err = in.kubectlClient.Apply(context.TODO(), "", string(etcdShell), true); err != nil {
+	if err = in.apply("", string(etcdShell)); err != nil {
 		return errors.WithStack(err)
 	}
 
@@ -168,35 +185,47 @@ func (in *Installer) validateEtcd(configSpec apiv1.KubectlStorageOSConfigSpec) e
 // - applies app=storageos label to secret
 // - returns the tls equipped etcd-shell pod with storageos cluster namespace and secret name
 func (in *Installer) tlsValidationPrep(namespace string, configInstall apiv1.Install) (string, error) {
+	if _, err := in.labelEtcdSecret(namespace, configInstall); err != nil {
+		return "", err
+	}
+
+	etcdShell, err := pluginutils.SetFieldInManifest(etcdShellPodTLS, namespace, "namespace", "metadata")
+	if err != nil {
+		return "", err
+	}
+	etcdShell, err = pluginutils.SetFieldInManifest(etcdShell, configInstall.EtcdSecretName, "secretName", "spec", "volumes", "[name=etcd-certs]", "secret")
+	if err != nil {
+		return "", err
+	}
+
+	return etcdShell, nil
+}
+
+// labelEtcdSecret searches for the etcd-secret and applies the app=storageos label to it, returning
+// the secret
+func (in *Installer) labelEtcdSecret(namespace string, configInstall apiv1.Install) (*corev1.Secret, error) {
 	etcdSecret, err := pluginutils.GetSecret(in.clientConfig, configInstall.EtcdSecretName, namespace)
 	if err != nil {
-		return "", fmt.Errorf(errSecretNotFound, configInstall.EtcdSecretName, namespace, SkipEtcdEndpointsValFlag)
+		return nil, fmt.Errorf(errSecretNotFound, configInstall.EtcdSecretName, namespace, SkipEtcdEndpointsValFlag)
 	}
 
 	// apply app=storageos label to secret, this way it will be backed up locally during uninstall
 	secretLabels := etcdSecret.GetLabels()
+	if secretLabels == nil {
+		secretLabels = map[string]string{}
+	}
 	secretLabels["app"] = "storageos"
 	etcdSecret.SetLabels(secretLabels)
 	etcdSecretManifest, err := secretToManifest(etcdSecret)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	if err = in.kubectlClient.Apply(context.TODO(), namespace, string(etcdSecretManifest), true); err != nil {
-		return "", errors.WithStack(err)
+	if err = in.apply(namespace, string(etcdSecretManifest)); err != nil {
+		return nil, errors.WithStack(err)
 	}
 
-	etcdShell := etcdShellPodTLS
-	etcdShell, err = pluginutils.SetFieldInManifest(etcdShell, namespace, "namespace", "metadata")
-	if err != nil {
-		return "", err
-	}
-	etcdShell, err = pluginutils.SetFieldInManifest(etcdShell, configInstall.EtcdSecretName, "secretName", "spec", "volumes", "[name=etcd-certs]", "secret")
-	if err != nil {
-		return "", err
-	}
-
-	return etcdShell, nil
+	return etcdSecret, nil
 }
 
 // validateEndpoints:
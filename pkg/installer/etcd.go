@@ -0,0 +1,369 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/consts"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+const (
+	etcdShellRestoreSnapshot = "/tmp/etcd-restore.db"
+	etcdShellRestoreDataDir  = "/tmp/etcd-restore"
+
+	errNoEtcdEndpoints     = "no etcd endpoints found, set --%s or install a storageos cluster"
+	errNoRevisionInStatus  = "unable to find the revision of etcd endpoint %s in its status"
+	errEtcdSnapshotNoHash  = "etcd snapshot %s is incomplete, it doesn't end with its sha256 hash"
+	errEtcdSnapshotHash    = "etcd snapshot %s is corrupt, its content doesn't match its sha256 hash"
+	etcdCompactedMessage   = "ETCD keyspace compacted at revision %d."
+	etcdDataDirRestored    = "ETCD data directory restored from %s to %s."
+	etcdSnapshotSavedToMsg = "ETCD snapshot saved to %s."
+)
+
+// EtcdRestoreOptions holds the member options passed to etcdutl when restoring a snapshot to a data
+// directory. Options left empty take the etcdutl defaults, which suit a single member cluster.
+type EtcdRestoreOptions struct {
+	Name                     string
+	InitialCluster           string
+	InitialAdvertisePeerURLs string
+}
+
+// etcdShellSession is a running etcd shell pod through which etcdctl commands are exec'd.
+type etcdShellSession struct {
+	name      string
+	namespace string
+}
+
+// NewEtcdInstaller returns an installer for day-2 operations on the etcd cluster of storageos. The etcd
+// endpoints, TLS and secret of config default to those of the storageos cluster.
+func NewEtcdInstaller(config *apiv1.KubectlStorageOSConfig, log *logger.Logger) (*Installer, error) {
+	installer := &Installer{}
+
+	clientConfig, err := pluginutils.NewClientConfig()
+	if err != nil {
+		return installer, errors.WithStack(err)
+	}
+
+	stosCluster, err := pluginutils.GetStorageOSCluster(clientConfig)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return installer, errors.WithStack(err)
+		}
+	}
+
+	installer = &Installer{
+		kubectlClient:    kubectlNew(log),
+		clientConfig:     clientConfig,
+		stosConfig:       config,
+		onDiskFileSys:    filesys.MakeFsOnDisk(),
+		storageOSCluster: stosCluster,
+		log:              log,
+	}
+	installer.setEtcdDefaultsFromCluster()
+
+	if config.Spec.Install.EtcdEndpoints == "" {
+		return installer, errors.Errorf(errNoEtcdEndpoints, EtcdEndpointsFlag)
+	}
+
+	return installer, nil
+}
+
+// setEtcdDefaultsFromCluster sets the etcd endpoints, TLS, secret and shell namespace left unset in the
+// installer's config to those of the storageos cluster.
+func (in *Installer) setEtcdDefaultsFromCluster() {
+	install := &in.stosConfig.Spec.Install
+	if in.storageOSCluster != nil {
+		if install.EtcdEndpoints == "" {
+			install.EtcdEndpoints = in.storageOSCluster.Spec.KVBackend.Address
+			install.EtcdTLSEnabled = in.storageOSCluster.Spec.TLSEtcdSecretRefName != ""
+		}
+		if install.EtcdSecretName == "" {
+			install.EtcdSecretName = in.storageOSCluster.Spec.TLSEtcdSecretRefName
+		}
+		if install.StorageOSClusterNamespace == "" {
+			install.StorageOSClusterNamespace = getStringWithDefault(in.storageOSCluster.Spec.TLSEtcdSecretRefNamespace, in.storageOSCluster.Namespace)
+		}
+	}
+	install.EtcdSecretName = getStringWithDefault(install.EtcdSecretName, consts.EtcdSecretName)
+	install.StorageOSClusterNamespace = getStringWithDefault(install.StorageOSClusterNamespace, consts.NewOperatorNamespace)
+}
+
+// EtcdStatus returns the status of each etcd endpoint as a table.
+func (in *Installer) EtcdStatus() (string, error) {
+	return in.runEtcdctl("endpoint", "status", "-w", "table")
+}
+
+// EtcdMemberList returns the members of the etcd cluster as a table.
+func (in *Installer) EtcdMemberList() (string, error) {
+	return in.runEtcdctl("member", "list", "-w", "table")
+}
+
+// EtcdEndpointHealth returns the health of each etcd endpoint as a table.
+func (in *Installer) EtcdEndpointHealth() (string, error) {
+	return in.runEtcdctl("endpoint", "health", "-w", "table")
+}
+
+// EtcdDefrag defragments the backend database of each etcd endpoint.
+func (in *Installer) EtcdDefrag() (string, error) {
+	return in.runEtcdctl("defrag")
+}
+
+// EtcdCompact compacts the etcd keyspace at revision, or at the current revision if revision is 0,
+// returning the revision compacted at.
+func (in *Installer) EtcdCompact(revision int64) (int64, error) {
+	shell, stop, err := in.startEtcdShell()
+	if err != nil {
+		return 0, err
+	}
+	defer stop()
+
+	if revision == 0 {
+		endpoint := in.etcdEndpoints()[0]
+		status, err := in.execEtcdShell(shell, etcdctlCmd(endpoint, in.stosConfig.Spec.Install.EtcdTLSEnabled, "endpoint", "status", "-w", "json"), nil)
+		if err != nil {
+			return 0, err
+		}
+		if revision, err = etcdRevisionFromStatus(status); err != nil {
+			return 0, errors.Wrapf(err, errNoRevisionInStatus, endpoint)
+		}
+	}
+
+	if _, err = in.execEtcdShell(shell, in.etcdctlCmd("compact", strconv.FormatInt(revision, 10)), nil); err != nil {
+		return 0, err
+	}
+	in.log.Successf(etcdCompactedMessage, revision)
+
+	return revision, nil
+}
+
+// EtcdSnapshotSave saves a snapshot of the etcd keyspace to path of the on-disk filesystem. The snapshot
+// is streamed from the etcd shell pod and verified against its hash, it is removed if either fails.
+func (in *Installer) EtcdSnapshotSave(path string) error {
+	shell, stop, err := in.startEtcdShell()
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	// etcdctl snapshot save accepts a single endpoint only
+	endpoint := in.etcdEndpoints()[0]
+	if _, err = in.execEtcdShell(shell, etcdctlSnapshotSaveCmd(endpoint, etcdShellSnapshot, in.stosConfig.Spec.Install.EtcdTLSEnabled), nil); err != nil {
+		return err
+	}
+	if err = in.streamEtcdShellToFile(shell, []string{"cat", etcdShellSnapshot}, path); err != nil {
+		return err
+	}
+	if err = verifyEtcdSnapshot(in.onDiskFileSys, path); err != nil {
+		_ = in.onDiskFileSys.RemoveAll(path)
+		return err
+	}
+	in.log.Successf(etcdSnapshotSavedToMsg, path)
+
+	return nil
+}
+
+// EtcdSnapshotRestore restores the snapshot at snapshotPath of the on-disk filesystem to an etcd data
+// directory, written as a tar.gz archive to outputPath. The etcd cluster itself is left untouched, the
+// data directory has to be put in place of each member's.
+func (in *Installer) EtcdSnapshotRestore(snapshotPath, outputPath string, options EtcdRestoreOptions) error {
+	snapshot, err := in.onDiskFileSys.Open(snapshotPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer snapshot.Close()
+
+	shell, stop, err := in.startEtcdShell()
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	if _, err = in.execEtcdShell(shell, []string{"sh", "-c", "cat > " + etcdShellRestoreSnapshot}, snapshot); err != nil {
+		return err
+	}
+	if _, err = in.execEtcdShell(shell, etcdutlSnapshotRestoreCmd(etcdShellRestoreSnapshot, etcdShellRestoreDataDir, options), nil); err != nil {
+		return err
+	}
+	if err = in.streamEtcdShellToFile(shell, []string{"tar", "-czf", "-", "-C", etcdShellRestoreDataDir, "."}, outputPath); err != nil {
+		return err
+	}
+	in.log.Successf(etcdDataDirRestored, snapshotPath, outputPath)
+
+	return nil
+}
+
+// runEtcdctl runs etcdctl with args against the etcd endpoints through an etcd shell pod, returning
+// its output.
+func (in *Installer) runEtcdctl(args ...string) (string, error) {
+	shell, stop, err := in.startEtcdShell()
+	if err != nil {
+		return "", err
+	}
+	defer stop()
+
+	return in.execEtcdShell(shell, in.etcdctlCmd(args...), nil)
+}
+
+// etcdEndpoints returns the etcd endpoints of the installer's config, prefixed for etcdctl.
+func (in *Installer) etcdEndpoints() []string {
+	install := in.stosConfig.Spec.Install
+	return endpointsSplitter(install.EtcdEndpoints, install.EtcdTLSEnabled)
+}
+
+// etcdctlCmd returns the etcdctl command with args against all etcd endpoints of the installer's config.
+func (in *Installer) etcdctlCmd(args ...string) []string {
+	return etcdctlCmd(strings.Join(in.etcdEndpoints(), ","), in.stosConfig.Spec.Install.EtcdTLSEnabled, args...)
+}
+
+// startEtcdShell applies the etcd shell pod to the storageos cluster namespace of the installer's
+// config, equipped with the etcd secret if TLS is enabled, and waits for it to run. The returned
+// function deletes the pod.
+func (in *Installer) startEtcdShell() (*etcdShellSession, func(), error) {
+	install := in.stosConfig.Spec.Install
+	namespace := install.StorageOSClusterNamespace
+
+	etcdShell, err := pluginutils.SetFieldInManifest(etcdShellPod, namespace, "namespace", "metadata")
+	if err != nil {
+		return nil, nil, err
+	}
+	if install.EtcdTLSEnabled {
+		if etcdShell, err = in.tlsValidationPrep(namespace, install); err != nil {
+			return nil, nil, err
+		}
+	}
+	if etcdShell, err = in.rewriteEtcdShellImage(etcdShell); err != nil {
+		return nil, nil, err
+	}
+
+	if err = in.apply("", etcdShell); err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	stop := func() {
+		if err := in.kubectlClient.Delete(context.TODO(), "", etcdShell, true); err != nil {
+			// do nothing, etcd shell pod runs to completion even in unlikely event that delete fails
+			in.log.Warnf(etcdShellPodDeletionFailMessage, err)
+		}
+	}
+
+	name, err := pluginutils.GetFieldInManifest(etcdShell, "metadata", "name")
+	if err != nil {
+		stop()
+		return nil, nil, err
+	}
+	if err = pluginutils.WaitFor(func() error {
+		return pluginutils.IsPodRunning(in.clientConfig, name, namespace)
+	}, 60, 5); err != nil {
+		stop()
+		return nil, nil, err
+	}
+
+	return &etcdShellSession{name: name, namespace: namespace}, stop, nil
+}
+
+// execEtcdShell execs cmd in the etcd shell pod, returning its stdout. stderr is only returned as part
+// of the error of a failed command, as etcdctl logs to it on success too.
+func (in *Installer) execEtcdShell(shell *etcdShellSession, cmd []string, stdin io.Reader) (string, error) {
+	stdout := &strings.Builder{}
+	err := in.streamEtcdShell(shell, cmd, stdin, stdout)
+
+	return stdout.String(), err
+}
+
+// streamEtcdShell execs cmd in the etcd shell pod, streaming stdin to it and its stdout to stdout.
+// stderr is only returned as part of the error of a failed command.
+func (in *Installer) streamEtcdShell(shell *etcdShellSession, cmd []string, stdin io.Reader, stdout io.Writer) error {
+	stderr := &strings.Builder{}
+	if err := pluginutils.StreamExecToPod(in.clientConfig, cmd, "", shell.name, shell.namespace, pluginutils.ExecStreams{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	}); err != nil {
+		return errors.Wrap(err, stderr.String())
+	}
+
+	return nil
+}
+
+// streamEtcdShellToFile execs cmd in the etcd shell pod, streaming its stdout to path of the on-disk
+// filesystem. The file is removed if cmd fails.
+func (in *Installer) streamEtcdShellToFile(shell *etcdShellSession, cmd []string, path string) error {
+	file, err := in.onDiskFileSys.Create(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = in.streamEtcdShell(shell, cmd, nil, file)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = errors.WithStack(closeErr)
+	}
+	if err != nil {
+		_ = in.onDiskFileSys.RemoveAll(path)
+	}
+
+	return err
+}
+
+// verifyEtcdSnapshot checks the snapshot at path of fSys against the sha256 hash etcd appends to it, as
+// etcdutl snapshot restore does. The snapshot is read as a stream.
+func verifyEtcdSnapshot(fSys filesys.FileSystem, path string) error {
+	snapshot, err := fSys.Open(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer snapshot.Close()
+
+	info, err := snapshot.Stat()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// the bolt database of the snapshot is a multiple of 512 bytes
+	if info.Size()%512 != sha256.Size {
+		return errors.Errorf(errEtcdSnapshotNoHash, path)
+	}
+
+	hash := sha256.New()
+	if _, err = io.CopyN(hash, snapshot, info.Size()-sha256.Size); err != nil {
+		return errors.WithStack(err)
+	}
+	expected := make([]byte, sha256.Size)
+	if _, err = io.ReadFull(snapshot, expected); err != nil {
+		return errors.WithStack(err)
+	}
+	if !bytes.Equal(hash.Sum(nil), expected) {
+		return errors.Errorf(errEtcdSnapshotHash, path)
+	}
+
+	return nil
+}
+
+// etcdRevisionFromStatus returns the revision of the first endpoint of the json output of
+// etcdctl endpoint status.
+func etcdRevisionFromStatus(status string) (int64, error) {
+	endpointStatuses := []struct {
+		Endpoint string `json:"Endpoint"`
+		Status   struct {
+			Header struct {
+				Revision int64 `json:"revision"`
+			} `json:"header"`
+		} `json:"Status"`
+	}{}
+	if err := json.Unmarshal([]byte(status), &endpointStatuses); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if len(endpointStatuses) == 0 || endpointStatuses[0].Status.Header.Revision == 0 {
+		return 0, errors.New("no revision in endpoint status")
+	}
+
+	return endpointStatuses[0].Status.Header.Revision, nil
+}
@@ -0,0 +1,93 @@
+package installer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+func TestEtcdRevisionFromStatus(t *testing.T) {
+	tcases := []struct {
+		name        string
+		status      string
+		expRevision int64
+		expErr      bool
+	}{
+		{
+			name:        "single endpoint",
+			status:      `[{"Endpoint":"http://1.2.3.4:2379","Status":{"header":{"cluster_id":1,"member_id":2,"revision":42,"raft_term":3},"version":"3.5.0"}}]`,
+			expRevision: 42,
+		},
+		{
+			name:        "multiple endpoints",
+			status:      `[{"Endpoint":"http://1.2.3.4:2379","Status":{"header":{"revision":7}}},{"Endpoint":"http://5.6.7.8:2379","Status":{"header":{"revision":8}}}]`,
+			expRevision: 7,
+		},
+		{
+			name:   "no endpoints",
+			status: `[]`,
+			expErr: true,
+		},
+		{
+			name:   "not json",
+			status: `http://1.2.3.4:2379, 8e9e05c52164694d, 3.5.0`,
+			expErr: true,
+		},
+	}
+	for _, tc := range tcases {
+		revision, err := etcdRevisionFromStatus(tc.status)
+		if (err != nil) != tc.expErr {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.expErr, err)
+		}
+		if revision != tc.expRevision {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expRevision, revision)
+		}
+	}
+}
+
+func TestVerifyEtcdSnapshot(t *testing.T) {
+	db := bytes.Repeat([]byte("etcd"), 1024)
+	hash := sha256.Sum256(db)
+	snapshot := append(append([]byte{}, db...), hash[:]...)
+	corrupt := append([]byte{}, snapshot...)
+	corrupt[0] = 'E'
+
+	tcases := []struct {
+		name     string
+		snapshot []byte
+		expErr   bool
+	}{
+		{
+			name:     "snapshot with hash",
+			snapshot: snapshot,
+		},
+		{
+			name:     "snapshot without hash",
+			snapshot: db,
+			expErr:   true,
+		},
+		{
+			name:     "truncated snapshot",
+			snapshot: snapshot[:len(snapshot)-1],
+			expErr:   true,
+		},
+		{
+			name:     "corrupt snapshot",
+			snapshot: corrupt,
+			expErr:   true,
+		},
+	}
+
+	for _, tc := range tcases {
+		fSys := filesys.MakeFsInMemory()
+		if err := fSys.WriteFile("etcd-snapshot.db", tc.snapshot); err != nil {
+			t.Fatal(err)
+		}
+		err := verifyEtcdSnapshot(fSys, "etcd-snapshot.db")
+		if (err != nil) != tc.expErr {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.expErr, err)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package installer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/rest"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+const (
+	// EtcdValidationShellPod validates etcd endpoints by exec'ing etcdctl in an etcd shell pod.
+	EtcdValidationShellPod = "shell-pod"
+	// EtcdValidationClient validates etcd endpoints with a native etcd client, port-forwarding to
+	// endpoints served by pods of the k8s cluster.
+	EtcdValidationClient = "client"
+
+	defaultEtcdPort   = "2379"
+	etcdClientTimeout = 10 * time.Second
+
+	etcdClientCAFile   = "etcd-client-ca.crt"
+	etcdClientCertFile = "etcd-client.crt"
+	etcdClientKeyFile  = "etcd-client.key"
+
+	errUnknownEtcdValidationMode = "unknown etcd validation mode %q, expected %q or %q"
+	errEtcdSecretMissingKey      = "etcd secret %s is missing key %s"
+	errInvalidEtcdCA             = "unable to parse CA certificate %s of etcd secret %s"
+
+	errFailedToValidateEndpointWithClient = `
+	Unable to validate ETCD endpoint %s: %v
+
+	If ETCD endpoints are TLS-enabled, please set install flag --%s
+
+	To skip ETCD endpoints validation during installation, set install flag --%s`
+)
+
+// validateEtcdWithClient validates the endpoints with a native etcd client, using the TLS material of
+// the etcd secret if TLS is enabled. No pod is created, so images need not be pulled.
+func (in *Installer) validateEtcdWithClient(configSpec apiv1.KubectlStorageOSConfigSpec) error {
+	var tlsConfig *tls.Config
+	if configSpec.Install.EtcdTLSEnabled {
+		etcdSecret, err := in.labelEtcdSecret(configSpec.GetETCDValidationNamespace(), configSpec.Install)
+		if err != nil {
+			return err
+		}
+		if tlsConfig, err = etcdClientTLSConfig(etcdSecret); err != nil {
+			return err
+		}
+	}
+
+	endpoints := endpointsSplitter(configSpec.Install.EtcdEndpoints, configSpec.Install.EtcdTLSEnabled)
+	for _, endpoint := range endpoints {
+		if err := in.etcdClientHealthCheck(endpoint, tlsConfig); err != nil {
+			return fmt.Errorf(errFailedToValidateEndpointWithClient, endpoint, err, EtcdTLSEnabledFlag, SkipEtcdEndpointsValFlag)
+		}
+	}
+	in.log.Successf(endpointsValidatedMessage, strings.Join(endpoints, ","))
+
+	return nil
+}
+
+// etcdClientHealthCheck performs write, read, delete of key/value to the etcd endpoint with a native
+// etcd client, returning an error if any step fails.
+func (in *Installer) etcdClientHealthCheck(endpoint string, tlsConfig *tls.Config) error {
+	client, _, closeClient, err := newEtcdClient(in.clientConfig, endpoint, tlsConfig)
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	ctx, cancel := context.WithTimeout(context.Background(), etcdClientTimeout)
+	defer cancel()
+
+	// use dummy key/value pair 'foo'/'bar' to write to, read from & delete from etcd
+	// in order to validate the endpoint
+	key, value := "foo", "bar"
+	if _, err = client.Put(ctx, key, value); err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err = client.Get(ctx, key); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = client.Delete(ctx, key)
+
+	return errors.WithStack(err)
+}
+
+// etcdEndpointHealth checks the health of the etcd endpoint with a native etcd client, as etcdctl
+// endpoint health does, with a read which needs the quorum of the cluster. Nothing is written. It
+// also returns whether the endpoint is served by the k8s cluster, rather than dialled directly.
+func etcdEndpointHealth(config *rest.Config, endpoint string, tlsConfig *tls.Config) (bool, error) {
+	client, forwarded, closeClient, err := newEtcdClient(config, endpoint, tlsConfig)
+	if err != nil {
+		return forwarded, err
+	}
+	defer closeClient()
+
+	ctx, cancel := context.WithTimeout(context.Background(), etcdClientTimeout)
+	defer cancel()
+
+	// a denied read is served by a healthy member, which is enough
+	if _, err = client.Get(ctx, "health"); err != nil && err != rpctypes.ErrPermissionDenied {
+		return forwarded, errors.WithStack(err)
+	}
+
+	return forwarded, nil
+}
+
+// newEtcdClient returns a native etcd client of endpoint and a function closing it. It also returns
+// whether the client dials the endpoint through a port-forward.
+func newEtcdClient(config *rest.Config, endpoint string, tlsConfig *tls.Config) (*clientv3.Client, bool, func(), error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, false, nil, errors.WithStack(err)
+	}
+	host, port := endpointURL.Hostname(), endpointURL.Port()
+	if port == "" {
+		port = defaultEtcdPort
+	}
+
+	address, stop, err := etcdClientAddress(config, host, port)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	forwarded := address != net.JoinHostPort(host, port)
+
+	if tlsConfig != nil {
+		// the client dials the local end of a port-forward, verify the certificate against the
+		// endpoint's own host instead.
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.ServerName = host
+	}
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{fmt.Sprintf("%s://%s", endpointURL.Scheme, address)},
+		DialTimeout: etcdClientTimeout,
+		TLS:         tlsConfig,
+	})
+	if err != nil {
+		stop()
+		return nil, forwarded, nil, errors.WithStack(err)
+	}
+
+	return client, forwarded, func() {
+		client.Close()
+		stop()
+	}, nil
+}
+
+// etcdClientAddress returns the address the etcd client dials to reach host:port, along with a function
+// releasing it. Endpoints served by a service or pod of the k8s cluster are reached through a
+// port-forward, any other endpoint is dialled directly.
+func etcdClientAddress(config *rest.Config, host, port string) (string, func(), error) {
+	direct := net.JoinHostPort(host, port)
+	portNumber, err := strconv.ParseInt(port, 10, 32)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+
+	podName, podNamespace, podPort := "", "", int32(portNumber)
+	if net.ParseIP(host) != nil {
+		pod, err := pluginutils.FindPodByIP(config, host)
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				return direct, func() {}, nil
+			}
+			return "", nil, err
+		}
+		podName, podNamespace = pod.Name, pod.Namespace
+	} else {
+		name, namespace, ok := serviceFromHost(host)
+		if !ok {
+			return direct, func() {}, nil
+		}
+		podName, podPort, err = pluginutils.GetServiceBackendPod(config, name, namespace, podPort)
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				return direct, func() {}, nil
+			}
+			return "", nil, err
+		}
+		podNamespace = namespace
+	}
+
+	localPort, stop, err := pluginutils.PortForwardToPod(config, podName, podNamespace, podPort)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(int(localPort))), stop, nil
+}
+
+// serviceFromHost returns the name and namespace of the k8s service addressed by host, if host is of the
+// form <service>.<namespace>[.svc[.<cluster-domain>]].
+func serviceFromHost(host string) (string, string, bool) {
+	parts := strings.Split(host, ".")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	if len(parts) > 2 && parts[2] != "svc" {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+// etcdClientTLSConfig returns the client TLS config built from the CA, certificate and key of the etcd
+// secret.
+func etcdClientTLSConfig(etcdSecret *corev1.Secret) (*tls.Config, error) {
+	for _, key := range []string{etcdClientCAFile, etcdClientCertFile, etcdClientKeyFile} {
+		if len(etcdSecret.Data[key]) == 0 {
+			return nil, errors.Errorf(errEtcdSecretMissingKey, etcdSecret.Name, key)
+		}
+	}
+
+	cert, err := tls.X509KeyPair(etcdSecret.Data[etcdClientCertFile], etcdSecret.Data[etcdClientKeyFile])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM(etcdSecret.Data[etcdClientCAFile]) {
+		return nil, errors.Errorf(errInvalidEtcdCA, etcdClientCAFile, etcdSecret.Name)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      caPool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
@@ -0,0 +1,56 @@
+package installer
+
+import (
+	"testing"
+)
+
+func TestServiceFromHost(t *testing.T) {
+	tcases := []struct {
+		name         string
+		host         string
+		expName      string
+		expNamespace string
+		expOK        bool
+	}{
+		{
+			name:         "service and namespace",
+			host:         "storageos-etcd.storageos-etcd",
+			expName:      "storageos-etcd",
+			expNamespace: "storageos-etcd",
+			expOK:        true,
+		},
+		{
+			name:         "svc suffix",
+			host:         "storageos-etcd.storageos-etcd.svc",
+			expName:      "storageos-etcd",
+			expNamespace: "storageos-etcd",
+			expOK:        true,
+		},
+		{
+			name:         "cluster domain",
+			host:         "storageos-etcd.storageos-etcd.svc.cluster.local",
+			expName:      "storageos-etcd",
+			expNamespace: "storageos-etcd",
+			expOK:        true,
+		},
+		{
+			name:  "single label",
+			host:  "storageos-etcd",
+			expOK: false,
+		},
+		{
+			name:  "external domain",
+			host:  "etcd.example.com",
+			expOK: false,
+		},
+	}
+	for _, tc := range tcases {
+		name, namespace, ok := serviceFromHost(tc.host)
+		if ok != tc.expOK {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expOK, ok)
+		}
+		if name != tc.expName || namespace != tc.expNamespace {
+			t.Errorf("%s: expected %s/%s, got %s/%s", tc.name, tc.expNamespace, tc.expName, namespace, name)
+		}
+	}
+}
@@ -13,117 +13,87 @@ const (
 	caCertPath  = "/run/storageos/pki/etcd-client-ca.crt"
 )
 
-// etcdctlMemberList returns a slice of strings representing the etcdctl command for members list to
-// be interpreted by the pod exec:
-// {`/bin/bash`, `-c`, `etcdctl --endpoints "http://<endpoints>" member list`}
-func etcdctlMemberListCmd(endpoints string, tls bool) []string {
+// etcdctlCmd returns a slice of strings representing the etcdctl command with args against endpoints
+// to be interpreted by the pod exec, passing the client certificates of the etcd shell pod if tls is set:
+// {`etcdctl`, `--endpoints`, `http://<endpoints>`, <args>...}
+func etcdctlCmd(endpoints string, tls bool, args ...string) []string {
+	cmd := []string{
+		"etcdctl",
+		"--endpoints",
+		endpoints,
+	}
 	if tls {
-		return []string{
-			"etcdctl",
-			"--endpoints",
-			endpoints,
+		cmd = append(cmd,
 			"--key",
 			keyPath,
 			"--cert",
 			certPath,
 			"--cacert",
 			caCertPath,
-			"member",
-			"list",
-		}
-	}
-	return []string{
-		"etcdctl",
-		"--endpoints",
-		endpoints,
-		"member",
-		"list",
+		)
 	}
+
+	return append(cmd, args...)
+}
+
+// etcdctlMemberList returns a slice of strings representing the etcdctl command for members list to
+// be interpreted by the pod exec:
+// {`/bin/bash`, `-c`, `etcdctl --endpoints "http://<endpoints>" member list`}
+func etcdctlMemberListCmd(endpoints string, tls bool) []string {
+	return etcdctlCmd(endpoints, tls, "member", "list")
 }
 
 // etcdctlPutCmd returns a slice of strings representing the etcdctl command for a simple write to
 // be interpreted by the pod exec:
 // {`/bin/bash`, `-c`, `etcdctl --endpoints "http://<endpoints>" put foo bar`}
 func etcdctlPutCmd(endpoints, key, value string, tls bool) []string {
-	if tls {
-		return []string{
-			"etcdctl",
-			"--endpoints",
-			endpoints,
-			"--key",
-			keyPath,
-			"--cert",
-			certPath,
-			"--cacert",
-			caCertPath,
-			"put",
-			key,
-			value,
-		}
-	}
-	return []string{
-		"etcdctl",
-		"--endpoints",
-		endpoints,
-		"put",
-		key,
-		value,
-	}
+	return etcdctlCmd(endpoints, tls, "put", key, value)
 }
 
 // etcdctlGetCmd returns a slice of strings representing the etcdctl command for a simple read to
 // be interpreted by the pod exec:
 // {`/bin/bash`, `-c`, `etcdctl --endpoints "http://<endpoints>" get foo`}
 func etcdctlGetCmd(endpoints, key string, tls bool) []string {
-	if tls {
-		return []string{
-			"etcdctl",
-			"--endpoints",
-			endpoints,
-			"--key",
-			keyPath,
-			"--cert",
-			certPath,
-			"--cacert",
-			caCertPath,
-			"get",
-			key,
-		}
-	}
-	return []string{
-		"etcdctl",
-		"--endpoints",
-		endpoints,
-		"get",
-		key,
-	}
+	return etcdctlCmd(endpoints, tls, "get", key)
 }
 
 // etcdctlDelCmd returns a slice of strings representing the etcdctl command for a simple delete to
 // be interpreted by the pod exec:
 // {`/bin/bash`, `-c`, `etcdctl --endpoints "http://<endpoints>" del foo`}
 func etcdctlDelCmd(endpoints, key string, tls bool) []string {
-	if tls {
-		return []string{"etcdctl",
-			"--endpoints",
-			endpoints,
-			"--key",
-			keyPath,
-			"--cert",
-			certPath,
-			"--cacert",
-			caCertPath,
-			"del",
-			key,
-		}
-	}
+	return etcdctlCmd(endpoints, tls, "del", key)
+}
 
-	return []string{
-		"etcdctl",
-		"--endpoints",
-		endpoints, "del",
-		key,
+// etcdctlSnapshotSaveCmd returns a slice of strings representing the etcdctl command for saving a
+// snapshot of the keyspace to path on the pod to be interpreted by the pod exec:
+// {`/bin/bash`, `-c`, `etcdctl --endpoints "http://<endpoint>" snapshot save <path>`}
+func etcdctlSnapshotSaveCmd(endpoint, path string, tls bool) []string {
+	return etcdctlCmd(endpoint, tls, "snapshot", "save", path)
+}
+
+// etcdutlSnapshotRestoreCmd returns a slice of strings representing the etcdutl command for restoring
+// the snapshot at path on the pod to dataDir to be interpreted by the pod exec:
+// {`etcdutl`, `snapshot`, `restore`, `<path>`, `--data-dir`, `<dataDir>`}
+func etcdutlSnapshotRestoreCmd(path, dataDir string, options EtcdRestoreOptions) []string {
+	cmd := []string{
+		"etcdutl",
+		"snapshot",
+		"restore",
+		path,
+		"--data-dir",
+		dataDir,
 	}
+	if options.Name != "" {
+		cmd = append(cmd, "--name", options.Name)
+	}
+	if options.InitialCluster != "" {
+		cmd = append(cmd, "--initial-cluster", options.InitialCluster)
+	}
+	if options.InitialAdvertisePeerURLs != "" {
+		cmd = append(cmd, "--initial-advertise-peer-urls", options.InitialAdvertisePeerURLs)
+	}
+
+	return cmd
 }
 
 // endpointsSplitter takes endpoints input from user prompt and returns digestable string for etcdctl
@@ -408,3 +408,102 @@ func TestEtcdctlDelCmd(t *testing.T) {
 		}
 	}
 }
+
+func TestEtcdctlSnapshotSaveCmd(t *testing.T) {
+	tcases := []struct {
+		name     string
+		endpoint string
+		tls      bool
+		path     string
+		cmd      []string
+	}{
+		{
+			name:     "snapshot save",
+			endpoint: "http://1.2.3.4:2379",
+			tls:      false,
+			path:     "/tmp/snapshot.db",
+			cmd: []string{
+				"etcdctl",
+				"--endpoints",
+				"http://1.2.3.4:2379",
+				"snapshot",
+				"save",
+				"/tmp/snapshot.db",
+			},
+		},
+		{
+			name:     "snapshot save tls",
+			endpoint: "https://1.2.3.4:2379",
+			tls:      true,
+			path:     "/tmp/snapshot.db",
+			cmd: []string{
+				"etcdctl",
+				"--endpoints",
+				"https://1.2.3.4:2379",
+				"--key",
+				keyPath,
+				"--cert",
+				certPath,
+				"--cacert",
+				caCertPath,
+				"snapshot",
+				"save",
+				"/tmp/snapshot.db",
+			},
+		},
+	}
+	for _, tc := range tcases {
+		cmd := etcdctlSnapshotSaveCmd(tc.endpoint, tc.path, tc.tls)
+		if !reflect.DeepEqual(cmd, tc.cmd) {
+			t.Errorf("expected %v, got %v", tc.cmd, cmd)
+		}
+	}
+}
+
+func TestEtcdutlSnapshotRestoreCmd(t *testing.T) {
+	tcases := []struct {
+		name    string
+		options EtcdRestoreOptions
+		cmd     []string
+	}{
+		{
+			name: "snapshot restore defaults",
+			cmd: []string{
+				"etcdutl",
+				"snapshot",
+				"restore",
+				"/tmp/snapshot.db",
+				"--data-dir",
+				"/tmp/restore",
+			},
+		},
+		{
+			name: "snapshot restore member",
+			options: EtcdRestoreOptions{
+				Name:                     "etcd-0",
+				InitialCluster:           "etcd-0=http://10.0.0.1:2380",
+				InitialAdvertisePeerURLs: "http://10.0.0.1:2380",
+			},
+			cmd: []string{
+				"etcdutl",
+				"snapshot",
+				"restore",
+				"/tmp/snapshot.db",
+				"--data-dir",
+				"/tmp/restore",
+				"--name",
+				"etcd-0",
+				"--initial-cluster",
+				"etcd-0=http://10.0.0.1:2380",
+				"--initial-advertise-peer-urls",
+				"http://10.0.0.1:2380",
+			},
+		},
+	}
+	for _, tc := range tcases {
+		cmd := etcdutlSnapshotRestoreCmd("/tmp/snapshot.db", "/tmp/restore", tc.options)
+		if !reflect.DeepEqual(cmd, tc.cmd) {
+			t.Errorf("expected %v, got %v", tc.cmd, cmd)
+		}
+	}
+}
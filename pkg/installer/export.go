@@ -0,0 +1,157 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+// exportNamespacesFile holds the namespaces which install applies itself rather than from the manifests
+// of a component, such as a storageos cluster namespace other than the operator's.
+const exportNamespacesFile = "namespaces.yaml"
+
+// NewRenderInstaller returns a dry-run Installer which renders the manifests of install to memory, for
+// Render. Nothing is written to the cluster.
+func NewRenderInstaller(config *apiv1.KubectlStorageOSConfig, log *logger.Logger) (*Installer, error) {
+	config.Spec.Install.DryRun = true
+	config.Spec.Install.SkipEtcdEndpointsValidation = true
+
+	installer, err := NewDryRunInstaller(config, log)
+	if err != nil {
+		return installer, err
+	}
+	installer.renderToMemory = true
+
+	return installer, nil
+}
+
+// Render returns the manifests of install, in install order.
+func (in *Installer) Render() ([]string, error) {
+	if err := in.Install(false); err != nil {
+		return nil, err
+	}
+
+	return in.renderedManifests, nil
+}
+
+// NewExportInstaller returns a dry-run Installer which keeps the kustomize layout of each component
+// installed, with the patches of the install flags, for Export. Nothing is written to the cluster.
+func NewExportInstaller(config *apiv1.KubectlStorageOSConfig, log *logger.Logger) (*Installer, error) {
+	installer, err := NewRenderInstaller(config, log)
+	if err != nil {
+		return installer, err
+	}
+	installer.exportFileSys = filesys.MakeFsInMemory()
+
+	return installer, nil
+}
+
+// Export renders the install and writes the kustomize directory of each component to dir, with a
+// top-level kustomization.yaml including them in install order, which Argo CD or Flux can reconcile.
+func (in *Installer) Export(dir string) error {
+	if err := in.Install(false); err != nil {
+		return err
+	}
+
+	return in.writeExport(dir)
+}
+
+// writeExport writes the exported components to dir, with the top-level kustomization including them.
+func (in *Installer) writeExport(dir string) error {
+	if err := in.writeExportKustomization(); err != nil {
+		return err
+	}
+
+	// check the exported tree builds before writing it, as components may not be combinable
+	kustomizer := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	if _, err := kustomizer.Run(in.exportFileSys, "."); err != nil {
+		return errors.Wrap(err, "exported manifests do not build")
+	}
+
+	return copyFileSysDir(in.exportFileSys, in.onDiskFileSys, ".", dir)
+}
+
+// exportComponent copies the kustomize directory dir of a component to the export file system, before
+// it is overwritten by its output. It is a no-op if not exporting or if dir has already been exported.
+func (in *Installer) exportComponent(dir string) error {
+	if in.exportFileSys == nil {
+		return nil
+	}
+	for _, exported := range in.exportedDirs {
+		if exported == dir {
+			return nil
+		}
+	}
+	if err := copyFileSysDir(in.fileSys, in.exportFileSys, dir, dir); err != nil {
+		return err
+	}
+	in.exportedDirs = append(in.exportedDirs, dir)
+
+	return nil
+}
+
+// exportNamespace records namespace, which a dry-run does not apply, to be exported along with the
+// components.
+func (in *Installer) exportNamespace(namespace string) {
+	in.exportNamespaces = append(in.exportNamespaces, namespace)
+}
+
+// writeExportKustomization writes the exported namespaces, if any, and the top-level kustomization to
+// the export file system.
+func (in *Installer) writeExportKustomization() error {
+	resources := make([]string, 0, len(in.exportedDirs)+1)
+	if len(in.exportNamespaces) > 0 {
+		manifests := make([]string, 0, len(in.exportNamespaces))
+		for _, namespace := range in.exportNamespaces {
+			manifests = append(manifests, pluginutils.NamespaceYaml(namespace))
+		}
+		if err := in.exportFileSys.WriteFile(exportNamespacesFile, []byte(makeMultiDoc(manifests...))); err != nil {
+			return errors.WithStack(err)
+		}
+		resources = append(resources, exportNamespacesFile)
+	}
+	for _, dir := range in.exportedDirs {
+		resources = append(resources, filepath.ToSlash(dir))
+	}
+
+	kustomization := kustTemp
+	for _, resource := range resources {
+		kustomization += "\n- " + resource
+	}
+
+	return errors.WithStack(in.exportFileSys.WriteFile(kustomizationFile, []byte(kustomization+"\n")))
+}
+
+// copyFileSysDir copies the files under dir of src to the directory to of dst.
+func copyFileSysDir(src, dst filesys.FileSystem, dir, to string) error {
+	// the walked paths may be absolute, the first one being dir itself
+	root := ""
+	return errors.WithStack(src.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if root == "" {
+			root = path
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, rel)
+		if info.IsDir() {
+			return dst.MkdirAll(target)
+		}
+		data, err := src.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return dst.WriteFile(target, data)
+	}))
+}
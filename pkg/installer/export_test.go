@@ -0,0 +1,133 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+const (
+	exportTestOperator = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: storageos-operator
+  namespace: storageos
+spec:
+  template:
+    spec:
+      containers:
+      - name: operator
+        image: storageos/operator:v2.7.0
+`
+	exportTestCluster = `apiVersion: storageos.com/v1
+kind: StorageOSCluster
+metadata:
+  name: storageos-cluster
+  namespace: storageos
+spec:
+  kvBackend:
+    address: ""
+`
+)
+
+func TestExport(t *testing.T) {
+	tcases := []struct {
+		name             string
+		clusterNamespace string
+		expResources     []string
+	}{
+		{
+			name:             "operator namespace",
+			clusterNamespace: "storageos",
+			expResources:     []string{"storageos/operator", "storageos/cluster"},
+		},
+		{
+			name:             "other cluster namespace",
+			clusterNamespace: "ondat",
+			expResources:     []string{exportNamespacesFile, "storageos/operator", "storageos/cluster"},
+		},
+	}
+
+	for _, tc := range tcases {
+		fileSys, err := createDirAndFiles(filesys.MakeFsInMemory(), fsData{
+			stosDir: {
+				operatorDir: {
+					stosOperatorFile:  []byte(exportTestOperator),
+					kustomizationFile: []byte(kustTemp + "\n- " + stosOperatorFile + "\n"),
+				},
+				clusterDir: {
+					stosClusterFile:   []byte(exportTestCluster),
+					kustomizationFile: []byte(kustTemp + "\n- " + stosClusterFile + "\n"),
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		in := &Installer{
+			fileSys:        fileSys,
+			onDiskFileSys:  filesys.MakeFsOnDisk(),
+			stosConfig:     &apiv1.KubectlStorageOSConfig{Spec: apiv1.KubectlStorageOSConfigSpec{Install: apiv1.Install{DryRun: true}}},
+			log:            logger.NewLogger(),
+			renderToMemory: true,
+			exportFileSys:  filesys.MakeFsInMemory(),
+		}
+
+		endpointsPatch := pluginutils.KustomizePatch{Op: "replace", Path: "/spec/kvBackend/address", Value: "etcd.example.com:2379"}
+		if err = in.addPatchesToFSKustomize(filepath.Join(stosDir, clusterDir, kustomizationFile), stosClusterKind, "storageos-cluster", []pluginutils.KustomizePatch{endpointsPatch}); err != nil {
+			t.Fatal(err)
+		}
+		if err = in.setFieldInFsManifest(filepath.Join(stosDir, clusterDir, kustomizationFile), tc.clusterNamespace, "namespace", ""); err != nil {
+			t.Fatal(err)
+		}
+		if tc.clusterNamespace != "storageos" {
+			in.exportNamespace(tc.clusterNamespace)
+		}
+		for _, component := range [][]string{{operatorDir, stosOperatorFile}, {clusterDir, stosClusterFile}, {clusterDir, stosClusterFile}} {
+			if err = in.kustomizeAndApply(filepath.Join(stosDir, component[0]), component[1]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		dir := t.TempDir()
+		if err = in.writeExport(dir); err != nil {
+			t.Fatal(err)
+		}
+		kustomization, err := os.ReadFile(filepath.Join(dir, kustomizationFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "resources:\n- " + strings.Join(tc.expResources, "\n- ") + "\n"; !strings.HasSuffix(string(kustomization), expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, expected, string(kustomization))
+		}
+
+		// the exported tree builds from disk to the rendered manifests, with the patches applied once
+		resMap, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(filesys.MakeFsOnDisk(), dir)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+			continue
+		}
+		resources := []string{}
+		for _, res := range resMap.Resources() {
+			resources = append(resources, res.GetKind()+"/"+res.GetNamespace()+"/"+res.GetName())
+		}
+		expected := "Deployment/storageos/storageos-operator,StorageOSCluster/" + tc.clusterNamespace + "/storageos-cluster"
+		if tc.clusterNamespace != "storageos" {
+			expected = "Namespace//" + tc.clusterNamespace + "," + expected
+		}
+		if got := strings.Join(resources, ","); got != expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, expected, got)
+		}
+		address, err := resMap.Resources()[len(resources)-1].GetString("spec.kvBackend.address")
+		if err != nil || address != endpointsPatch.Value {
+			t.Errorf("%s: expected %v, got %v (%v)", tc.name, endpointsPatch.Value, address, err)
+		}
+	}
+}
@@ -101,7 +101,11 @@ func (o *installerOptions) buildInstallerFileSys(config *apiv1.KubectlStorageOSC
 	fs := filesys.MakeFsInMemory()
 	fsData := make(fsData)
 	stosSubDirs := make(map[string]map[string][]byte)
-	var err error
+
+	versions, err := o.resolveVersions(config)
+	if err != nil {
+		return fs, err
+	}
 
 	// build storageos/operator
 	if o.storageosOperator {
@@ -109,9 +113,9 @@ func (o *installerOptions) buildInstallerFileSys(config *apiv1.KubectlStorageOSC
 			// path to storageos-operator.yaml as passed by --stos-operator-yaml can be a local path, a docker image or url.
 			getStringWithDefault(config.Spec.Install.StorageOSOperatorYaml, config.Spec.Uninstall.StorageOSOperatorYaml),
 			// url for latest storageos-operator.yaml
-			pluginversion.OperatorLatestSupportedURL(),
+			pluginversion.OperatorManifestsURL(versions.operator),
 			// latest docker image docker.io/storageos/operator-manifests
-			pluginversion.OperatorLatestSupportedImageURL(),
+			pluginversion.OperatorManifestsImageURL(versions.operator),
 			// filename storageos-operator.yaml
 			stosOperatorFile,
 			// storageos operator namespace
@@ -130,7 +134,7 @@ func (o *installerOptions) buildInstallerFileSys(config *apiv1.KubectlStorageOSC
 			// url for latest storageos-cluster.yaml
 			pluginversion.ClusterLatestSupportedURL(),
 			// latest docker image docker.io/storageos/operator-manifests
-			pluginversion.OperatorLatestSupportedImageURL(),
+			pluginversion.OperatorManifestsImageURL(versions.operator),
 			// filename storageos-cluster.yaml
 			stosClusterFile,
 			// storageos operator namespace
@@ -181,7 +185,7 @@ func (o *installerOptions) buildInstallerFileSys(config *apiv1.KubectlStorageOSC
 			// url for latest storageos-portal-client.yaml
 			pluginversion.PortalClientLatestSupportedURL(),
 			// latest docker image docker.io/storageos/portal-manager-manifests
-			pluginversion.PortalManagerLatestSupportedImageURL(),
+			pluginversion.PortalManagerManifestsImageURL(versions.portalManager),
 			// filename storageos-portal-client.yaml
 			stosPortalClientFile,
 			// namespace not applicable
@@ -201,7 +205,7 @@ func (o *installerOptions) buildInstallerFileSys(config *apiv1.KubectlStorageOSC
 			// url for latest storageos-portal-configmap.yaml
 			pluginversion.PortalConfigLatestSupportedURL(),
 			// latest docker image docker.io/storageos/portal-manager-manifests
-			pluginversion.PortalManagerLatestSupportedImageURL(),
+			pluginversion.PortalManagerManifestsImageURL(versions.portalManager),
 			// filename storageos-portal-configmap.yaml
 			stosPortalConfigFile,
 			// storageos operator namespace
@@ -253,7 +257,7 @@ func (o *installerOptions) buildInstallerFileSys(config *apiv1.KubectlStorageOSC
 			// no url for latest etcd-operator.yaml
 			"",
 			// latest docker image docker.io/storageos/etcd-cluster-operator-manifests
-			pluginversion.EtcdOperatorLatestSupportedImageURL(),
+			pluginversion.EtcdOperatorManifestsImageURL(versions.etcdOperator),
 			// filename etcd-operator.yaml
 			etcdOperatorFile,
 			// storageos operator namespace
@@ -270,9 +274,9 @@ func (o *installerOptions) buildInstallerFileSys(config *apiv1.KubectlStorageOSC
 			// path to etcd-cluster.yaml as passed by --etcd-cluster-yaml can be a local path, a docker image or url.
 			getStringWithDefault(config.Spec.Install.EtcdClusterYaml, config.Spec.Uninstall.EtcdClusterYaml),
 			// url for latest etcd-cluster.yaml
-			pluginversion.EtcdClusterLatestSupportedURL(),
+			pluginversion.EtcdClusterManifestsURL(versions.etcdOperator),
 			// latest docker image docker.io/storageos/etcd-cluster-operator-manifests
-			pluginversion.EtcdOperatorLatestSupportedImageURL(),
+			pluginversion.EtcdOperatorManifestsImageURL(versions.etcdOperator),
 			// filename etcd-cluster.yaml
 			etcdClusterFile,
 			config.Spec.GetOperatorNamespace()).createFileWithKustPair(config)
@@ -291,6 +295,37 @@ func (o *installerOptions) buildInstallerFileSys(config *apiv1.KubectlStorageOSC
 	return fs, nil
 }
 
+// installerVersions are the versions of the manifests of the components built by installerOptions.
+type installerVersions struct {
+	operator      string
+	portalManager string
+	etcdOperator  string
+}
+
+// resolveVersions resolves the versions of the components built by o, the versions of config unless
+// they have not been set.
+func (o *installerOptions) resolveVersions(config *apiv1.KubectlStorageOSConfig) (*installerVersions, error) {
+	versions := &installerVersions{}
+	var err error
+	if o.storageosOperator || o.storageosCluster {
+		if versions.operator, err = pluginversion.OperatorLatestSupportedVersion(); err != nil {
+			return nil, err
+		}
+	}
+	if o.portalClient || o.portalConfig {
+		if versions.portalManager, err = pluginversion.PortalManagerLatestSupportedVersion(); err != nil {
+			return nil, err
+		}
+	}
+	if config.Spec.IncludeEtcd && (o.etcdOperator || o.etcdCluster) {
+		if versions.etcdOperator, err = pluginversion.EtcdOperatorLatestSupportedVersion(); err != nil {
+			return nil, err
+		}
+	}
+
+	return versions, nil
+}
+
 // createFileWithKustPair creates a map of two files (file name to file data).
 //
 // The first file is that which has its address stored in fileBuilder as a
@@ -422,6 +457,8 @@ func (fb *fileBuilder) getManifestFromImage(airGap bool, location string) (strin
 }
 
 func (fb *fileBuilder) buildManifestNotFoundFromImageErr(manifestLocation string) string {
+	// resolved to build manifestLocation
+	etcdOperatorVersion, _ := pluginversion.EtcdOperatorLatestSupportedVersion()
 	errStr := `
    An error occurred attempting to fetch the manifest image ` + manifestLocation + ` with manifest ` + fb.fileName + ` from the docker daemon. 
    Possible causes:
@@ -443,7 +480,7 @@ func (fb *fileBuilder) buildManifestNotFoundFromImageErr(manifestLocation string
 
    Alternatively, download ` + fb.fileName + ` from URL:
       
-      "wget ` + pluginversion.OperatorLatestSupportedURL() + ` -O ./` + fb.fileName + `"`
+      "wget ` + fb.yamlUrl + ` -O ./` + fb.fileName + `"`
 
 	case stosClusterFile:
 		errStr += `
@@ -463,7 +500,7 @@ func (fb *fileBuilder) buildManifestNotFoundFromImageErr(manifestLocation string
 
    Alternatively, download ` + fb.fileName + ` from URL:
       
-      "wget ` + pluginversion.EtcdOperatorLatestSupportedURL() + ` -O ./` + fb.fileName + `"`
+      "wget ` + pluginversion.EtcdOperatorManifestsURL(etcdOperatorVersion) + ` -O ./` + fb.fileName + `"`
 
 	case etcdClusterFile:
 		errStr += `
@@ -473,7 +510,7 @@ func (fb *fileBuilder) buildManifestNotFoundFromImageErr(manifestLocation string
 
    Alternatively, download ` + fb.fileName + ` from URL:
       
-      "wget ` + pluginversion.EtcdClusterLatestSupportedURL() + ` -O ./` + fb.fileName + `"`
+      "wget ` + fb.yamlUrl + ` -O ./` + fb.fileName + `"`
 
 	case stosPortalClientFile:
 		errStr += `
@@ -0,0 +1,387 @@
+package installer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+const (
+	helmChartName        = "storageos"
+	helmChartFile        = "Chart.yaml"
+	helmValuesFile       = "values.yaml"
+	helmTemplatesDir     = "templates"
+	helmCRDsDir          = "crds"
+	helmCRDsFile         = "storageos-crds.yaml"
+	helmNamespacesFile   = "namespaces.yaml"
+	helmFallbackVersion  = "0.1.0"
+	helmPlaceholderAffix = "kubectl-storageos-helm-"
+	helmPortalCondition  = ".Values.portalManager.enabled"
+)
+
+// helmValues are the values.yaml parameters of the chart, defaulting to the flags it is rendered with.
+// Sections which do not apply to the rendered install are omitted.
+type helmValues struct {
+	Operator      helmNamespaceValues  `json:"operator"`
+	Cluster       helmNamespaceValues  `json:"cluster"`
+	Etcd          helmEtcdValues       `json:"etcd"`
+	PortalManager *helmPortalValues    `json:"portalManager,omitempty"`
+	NodeGuard     *helmNodeGuardValues `json:"nodeGuard,omitempty"`
+	Metrics       *helmMetricsValues   `json:"metrics,omitempty"`
+}
+
+type helmNamespaceValues struct {
+	Namespace string `json:"namespace"`
+}
+
+// helmEtcdValues holds the namespace of the etcd cluster installed with --include-etcd, or the
+// endpoints and TLS secret of an existing one.
+type helmEtcdValues struct {
+	Namespace string         `json:"namespace,omitempty"`
+	Endpoints string         `json:"endpoints,omitempty"`
+	TLS       *helmTLSValues `json:"tls,omitempty"`
+}
+
+type helmTLSValues struct {
+	Enabled    bool   `json:"enabled"`
+	SecretName string `json:"secretName"`
+}
+
+type helmPortalValues struct {
+	Enabled    bool   `json:"enabled"`
+	ClientID   string `json:"clientID"`
+	Secret     string `json:"secret"`
+	TenantID   string `json:"tenantID"`
+	APIURL     string `json:"apiURL"`
+	HTTPSProxy string `json:"httpsProxy"`
+}
+
+type helmNodeGuardValues struct {
+	Enabled bool   `json:"enabled"`
+	Env     string `json:"env"`
+}
+
+type helmMetricsValues struct {
+	Enabled bool `json:"enabled"`
+}
+
+// helmPlaceholder is a value of the chart, which is set as rendered for the installer and then replaced
+// by a template of the value where it is found as placeholder in the manifests.
+type helmPlaceholder struct {
+	rendered    string
+	placeholder string
+	// value is the default of the value, which is rendered where it cannot be templated
+	value string
+	// expression templates the value where it is a whole yaml value
+	expression string
+	// embedded templates the value where it is part of a yaml value
+	embedded string
+	// condition, if set, keeps the lines holding the value only if true
+	condition string
+}
+
+// helmChart is a chart being rendered from the manifests of install.
+type helmChart struct {
+	values       helmValues
+	placeholders []helmPlaceholder
+	version      string
+}
+
+// stringValue returns the placeholder of the string value at path of the values.
+func stringValue(name, path, value string) helmPlaceholder {
+	return helmPlaceholder{
+		rendered:    helmPlaceholderAffix + name,
+		placeholder: helmPlaceholderAffix + name,
+		value:       value,
+		expression:  fmt.Sprintf("{{ .Values.%s | quote }}", path),
+		embedded:    fmt.Sprintf("{{ .Values.%s }}", path),
+	}
+}
+
+// boolValue returns the placeholder of the boolean value at path of the values.
+func boolValue(name, path string, value bool) helmPlaceholder {
+	return helmPlaceholder{
+		rendered:    helmPlaceholderAffix + name,
+		placeholder: helmPlaceholderAffix + name,
+		value:       fmt.Sprint(value),
+		expression:  fmt.Sprintf("{{ .Values.%s }}", path),
+		embedded:    fmt.Sprintf("{{ .Values.%s }}", path),
+	}
+}
+
+// base64Value returns the placeholder of the string value at path of the values, as encoded in the data
+// of a secret.
+func base64Value(name, path, value string) helmPlaceholder {
+	return helmPlaceholder{
+		rendered:    helmPlaceholderAffix + name,
+		placeholder: base64.StdEncoding.EncodeToString([]byte(helmPlaceholderAffix + name)),
+		value:       base64.StdEncoding.EncodeToString([]byte(value)),
+		expression:  fmt.Sprintf("{{ .Values.%s | b64enc | quote }}", path),
+		embedded:    fmt.Sprintf("{{ .Values.%s | b64enc }}", path),
+	}
+}
+
+// when returns p, keeping the lines holding it only if condition is true.
+func (p helmPlaceholder) when(condition string) helmPlaceholder {
+	p.condition = condition
+	return p
+}
+
+// newHelmChart returns the chart of the install of config, setting the placeholders of the values of
+// the chart in config, so that they are rendered by the installer.
+func newHelmChart(config *apiv1.KubectlStorageOSConfig) *helmChart {
+	install := &config.Spec.Install
+	chart := &helmChart{version: helmChartVersion(install.StorageOSVersion)}
+	add := func(placeholder helmPlaceholder) string {
+		chart.placeholders = append(chart.placeholders, placeholder)
+		return placeholder.rendered
+	}
+
+	chart.values.Operator.Namespace = install.StorageOSOperatorNamespace
+	install.StorageOSOperatorNamespace = add(stringValue("operator-namespace", "operator.namespace", install.StorageOSOperatorNamespace))
+	chart.values.Cluster.Namespace = install.StorageOSClusterNamespace
+	install.StorageOSClusterNamespace = add(stringValue("cluster-namespace", "cluster.namespace", install.StorageOSClusterNamespace))
+
+	if config.Spec.IncludeEtcd {
+		chart.values.Etcd.Namespace = install.EtcdNamespace
+		install.EtcdNamespace = add(stringValue("etcd-namespace", "etcd.namespace", install.EtcdNamespace))
+	} else {
+		chart.values.Etcd.Endpoints = install.EtcdEndpoints
+		install.EtcdEndpoints = add(stringValue("etcd-endpoints", "etcd.endpoints", install.EtcdEndpoints))
+		chart.values.Etcd.TLS = &helmTLSValues{Enabled: install.EtcdTLSEnabled, SecretName: install.EtcdSecretName}
+		install.EtcdSecretName = add(stringValue("etcd-secret-name", "etcd.tls.secretName", install.EtcdSecretName).when(".Values.etcd.tls.enabled"))
+		install.EtcdTLSEnabled = true
+	}
+
+	if install.EnablePortalManager {
+		chart.values.PortalManager = &helmPortalValues{
+			Enabled:    true,
+			ClientID:   install.PortalClientID,
+			Secret:     install.PortalSecret,
+			TenantID:   install.PortalTenantID,
+			APIURL:     install.PortalAPIURL,
+			HTTPSProxy: install.PortalHTTPSProxy,
+		}
+		install.PortalClientID = add(base64Value("portal-client-id", "portalManager.clientID", install.PortalClientID))
+		install.PortalSecret = add(base64Value("portal-secret", "portalManager.secret", install.PortalSecret))
+		install.PortalTenantID = add(base64Value("portal-tenant-id", "portalManager.tenantID", install.PortalTenantID))
+		install.PortalAPIURL = add(base64Value("portal-api-url", "portalManager.apiURL", install.PortalAPIURL))
+		install.PortalHTTPSProxy = add(stringValue("portal-https-proxy", "portalManager.httpsProxy", install.PortalHTTPSProxy).when(".Values.portalManager.httpsProxy"))
+	}
+
+	if !config.Spec.SkipStorageOSCluster {
+		chart.values.NodeGuard = &helmNodeGuardValues{Enabled: install.EnableNodeGuard, Env: install.NodeGuardEnv}
+		install.NodeGuardEnv = add(stringValue("node-guard-env", "nodeGuard.env", install.NodeGuardEnv).when(".Values.nodeGuard.enabled"))
+		install.EnableNodeGuard = true
+
+		// metrics are templated in the rendered storageos cluster
+		chart.values.Metrics = &helmMetricsValues{}
+	}
+
+	return chart
+}
+
+// helmChartVersion returns the semver version of the chart of storageos version, or a fallback for
+// versions which are not semver, like develop.
+func helmChartVersion(version string) string {
+	chartVersion := strings.TrimPrefix(version, "v")
+	if _, err := semver.NewVersion(chartVersion); err != nil {
+		return helmFallbackVersion
+	}
+	return chartVersion
+}
+
+// NewHelmInstaller returns a dry-run Installer which renders the manifests of install as a Helm chart, for
+// HelmChart. Nothing is written to the cluster.
+func NewHelmInstaller(config *apiv1.KubectlStorageOSConfig, log *logger.Logger) (*Installer, error) {
+	chart := newHelmChart(config)
+
+	installer, err := NewRenderInstaller(config, log)
+	if err != nil {
+		return installer, err
+	}
+	installer.helmChart = chart
+
+	return installer, nil
+}
+
+// HelmChart renders the install and writes it to dir as a Helm chart. The namespaces, etcd endpoints and
+// TLS secret, portal manager settings, node guard env and metrics are values.yaml parameters, defaulting
+// to the flags of install. CRDs are written to the crds directory, so that they are installed first.
+func (in *Installer) HelmChart(dir string) error {
+	if err := in.Install(false); err != nil {
+		return err
+	}
+
+	return in.writeHelmChart(dir)
+}
+
+// writeHelmChart writes the rendered manifests to dir as a Helm chart.
+func (in *Installer) writeHelmChart(dir string) error {
+	chart := in.helmChart
+	sort.SliceStable(chart.placeholders, func(i, j int) bool {
+		// longer placeholders first, in case one is a prefix of another
+		return len(chart.placeholders[i].placeholder) > len(chart.placeholders[j].placeholder)
+	})
+
+	files := map[string]string{}
+	crds := []string{}
+	for i, manifest := range in.renderedManifests {
+		file := in.renderedFiles[i]
+		if file == stosClusterFile {
+			var err error
+			if manifest, err = chart.templateStorageOSCluster(manifest); err != nil {
+				return err
+			}
+		}
+
+		manifest, manifestCRDs, err := pluginutils.OmitAndReturnKindFromMultiDoc(manifest, crdKind)
+		if err != nil {
+			return err
+		}
+		for _, crd := range manifestCRDs {
+			crds = append(crds, chart.untemplate(crd))
+		}
+
+		template := chart.template(manifest)
+		if file == stosPortalClientFile || file == stosPortalConfigFile {
+			template = fmt.Sprintf("{{- if %s }}\n%s\n{{- end }}\n", helmPortalCondition, template)
+		}
+		files[filepath.Join(helmTemplatesDir, file)] = template
+	}
+	if len(crds) > 0 {
+		files[filepath.Join(helmCRDsDir, helmCRDsFile)] = makeMultiDoc(crds...)
+	}
+	if len(in.exportNamespaces) > 0 {
+		// the storageos cluster namespace, the only one applied outside of the manifests, is only created
+		// if it is not the operator's
+		namespaces := make([]string, 0, len(in.exportNamespaces))
+		for _, namespace := range in.exportNamespaces {
+			namespaces = append(namespaces, chart.template(pluginutils.NamespaceYaml(namespace)))
+		}
+		files[filepath.Join(helmTemplatesDir, helmNamespacesFile)] = fmt.Sprintf("{{- if ne .Values.cluster.namespace .Values.operator.namespace }}\n%s\n{{- end }}\n", makeMultiDoc(namespaces...))
+	}
+
+	values, err := yaml.Marshal(chart.values)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	files[helmValuesFile] = string(values)
+	files[helmChartFile] = fmt.Sprintf(`apiVersion: v2
+name: %s
+description: StorageOS, rendered by kubectl-storageos
+type: application
+version: %s
+appVersion: %q
+`, helmChartName, chart.version, in.stosConfig.Spec.Install.StorageOSVersion)
+
+	for _, subDir := range []string{"", helmTemplatesDir, helmCRDsDir} {
+		if err := in.onDiskFileSys.MkdirAll(filepath.Join(dir, subDir)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	for file, data := range files {
+		if err := in.onDiskFileSys.WriteFile(filepath.Join(dir, file), []byte(data)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// helmClusterField is a value of the chart which is set in the rendered storageos cluster at path.
+type helmClusterField struct {
+	placeholder helmPlaceholder
+	path        []string
+}
+
+// templateStorageOSCluster sets the placeholders of the values of the chart which are set in the
+// rendered storageos cluster of manifest, rather than by the installer.
+func (c *helmChart) templateStorageOSCluster(manifest string) (string, error) {
+	others, clusters, err := pluginutils.OmitAndReturnKindFromMultiDoc(manifest, stosClusterKind)
+	if err != nil {
+		return "", err
+	}
+
+	for i, cluster := range clusters {
+		fields := []helmClusterField{}
+		if c.values.Metrics != nil {
+			// metrics default to the rendered value, set by flag or by the manifest
+			enabled, err := pluginutils.GetFieldInManifest(cluster, "spec", "metrics", "enabled")
+			c.values.Metrics.Enabled = err == nil && enabled == "true"
+			fields = append(fields, helmClusterField{boolValue("metrics-enabled", "metrics.enabled", c.values.Metrics.Enabled), []string{"spec", "metrics", "enabled"}})
+		}
+		if c.values.PortalManager != nil {
+			fields = append(fields, helmClusterField{boolValue("portal-enabled", "portalManager.enabled", true), []string{"spec", "enablePortalManager"}})
+		}
+		if c.values.Etcd.TLS != nil {
+			tlsNamespace := stringValue("etcd-tls-namespace", "cluster.namespace", c.values.Cluster.Namespace).when(".Values.etcd.tls.enabled")
+			fields = append(fields, helmClusterField{tlsNamespace, []string{"spec", "tlsEtcdSecretRefNamespace"}})
+		}
+
+		for _, field := range fields {
+			last := len(field.path) - 1
+			if cluster, err = pluginutils.SetFieldInManifest(cluster, field.placeholder.rendered, field.path[last], field.path[:last]...); err != nil {
+				return "", err
+			}
+			if i == 0 {
+				c.placeholders = append(c.placeholders, field.placeholder)
+			}
+		}
+		clusters[i] = cluster
+	}
+
+	return makeMultiDoc(append([]string{others}, clusters...)...), nil
+}
+
+// template returns manifest as a Helm template, escaping existing template delimiters and replacing
+// the placeholders of the values of the chart by templates of them.
+func (c *helmChart) template(manifest string) string {
+	lines := strings.Split(strings.ReplaceAll(manifest, "{{", `{{ "{{" }}`), "\n")
+	templated := make([]string, 0, len(lines))
+	for _, line := range lines {
+		conditions := []string{}
+		for _, p := range c.placeholders {
+			if !strings.Contains(line, p.placeholder) {
+				continue
+			}
+			if p.condition != "" {
+				conditions = append(conditions, p.condition)
+			}
+			trimmed := strings.TrimSpace(line)
+			if trimmed == p.placeholder || strings.HasSuffix(trimmed, ": "+p.placeholder) || strings.HasSuffix(trimmed, "- "+p.placeholder) {
+				line = strings.TrimSuffix(line, p.placeholder) + p.expression
+				continue
+			}
+			line = strings.ReplaceAll(line, p.placeholder, p.embedded)
+		}
+		for _, condition := range conditions {
+			templated = append(templated, "{{- if "+condition+" }}")
+		}
+		templated = append(templated, line)
+		for range conditions {
+			templated = append(templated, "{{- end }}")
+		}
+	}
+
+	return strings.Join(templated, "\n")
+}
+
+// untemplate returns manifest with the placeholders of the values of the chart replaced by their
+// defaults, for manifests which are not templated by Helm.
+func (c *helmChart) untemplate(manifest string) string {
+	for _, p := range c.placeholders {
+		manifest = strings.ReplaceAll(manifest, p.placeholder, p.value)
+	}
+	return manifest
+}
@@ -0,0 +1,312 @@
+package installer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/coreos/go-semver/semver"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+)
+
+// helmTemplate lints the chart in dir and renders its templates with helm, setting overrides over
+// values.yaml. It returns the rendered objects by kind/namespace/name.
+func helmTemplate(t *testing.T, helm, dir string, overrides map[string]interface{}) map[string]map[string]interface{} {
+	args := []string{dir}
+	for path, value := range overrides {
+		args = append(args, "--set", fmt.Sprintf("%s=%v", path, value))
+	}
+	if out, err := exec.Command(helm, append([]string{"lint", "--strict"}, args...)...).CombinedOutput(); err != nil {
+		t.Fatalf("helm lint: %v\n%s", err, out)
+	}
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command(helm, append([]string{"template"}, args...)...)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("helm template: %v\n%s", err, stderr.String())
+	}
+
+	objects := map[string]map[string]interface{}{}
+	for _, doc := range regexp.MustCompile("(?m)^---$").Split(string(out), -1) {
+		object := map[string]interface{}{}
+		if err = yaml.Unmarshal([]byte(doc), &object); err != nil {
+			t.Fatalf("%v\n%s", err, doc)
+		}
+		if len(object) == 0 {
+			continue
+		}
+		metadata := object["metadata"].(map[string]interface{})
+		objects[fmt.Sprintf("%s/%v/%v", object["kind"], valueOrEmpty(metadata["namespace"]), metadata["name"])] = object
+	}
+	return objects
+}
+
+// helmBinary returns the path of helm, skipping the test if it isn't installed, unless running in CI.
+func helmBinary(t *testing.T) string {
+	helm, err := exec.LookPath("helm")
+	if err != nil {
+		if os.Getenv("CI") != "" {
+			t.Fatalf("helm is required to render the chart in CI: %v", err)
+		}
+		t.Skipf("skipping rendering of the chart: %v", err)
+	}
+	return helm
+}
+
+func valueOrEmpty(value interface{}) interface{} {
+	if value == nil {
+		return ""
+	}
+	return value
+}
+
+// field returns the value at the dot separated path of object, or nil.
+func field(object map[string]interface{}, path string) interface{} {
+	var value interface{} = object
+	for _, key := range strings.Split(path, ".") {
+		parent, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = parent[key]
+	}
+	return value
+}
+
+func TestHelmChart(t *testing.T) {
+	config := &apiv1.KubectlStorageOSConfig{Spec: apiv1.KubectlStorageOSConfigSpec{Install: apiv1.Install{
+		StorageOSVersion:           "v2.7.0",
+		StorageOSOperatorNamespace: "storageos",
+		StorageOSClusterNamespace:  "ondat",
+		EtcdEndpoints:              "10.0.0.1:2379,10.0.0.2:2379",
+		EtcdSecretName:             "storageos-etcd-secret",
+		EnablePortalManager:        true,
+		PortalClientID:             "client",
+		PortalSecret:               "secret",
+		PortalTenantID:             "tenant",
+		PortalAPIURL:               "https://portal.example.com",
+		NodeGuardEnv:               "MINIMUM_REPLICAS=2",
+	}}}
+	chart := newHelmChart(config)
+	install := config.Spec.Install
+	if !install.EtcdTLSEnabled || !install.EnableNodeGuard {
+		t.Fatalf("expected tls and node guard to be rendered, got %v and %v", install.EtcdTLSEnabled, install.EnableNodeGuard)
+	}
+
+	// manifests as rendered by the installer with the placeholders of the chart
+	operator := fmt.Sprintf(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: storageosclusters.storageos.com
+spec:
+  conversion:
+    webhook:
+      clientConfig:
+        service:
+          namespace: %[1]s
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: %[1]s
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: storageos-operator
+  namespace: %[1]s
+spec:
+  template:
+    spec:
+      containers:
+      - args:
+        - --leader-election-cm-namespace=%[1]s
+        name: operator
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: storageos-templates
+  namespace: %[1]s
+data:
+  template: '{{ .Name }}'
+`, install.StorageOSOperatorNamespace)
+	cluster := fmt.Sprintf(`apiVersion: v1
+kind: Secret
+metadata:
+  name: storageos-api
+  namespace: %[1]s
+---
+apiVersion: storageos.com/v1
+kind: StorageOSCluster
+metadata:
+  name: storageos-cluster
+  namespace: %[1]s
+spec:
+  enablePortalManager: true
+  kvBackend:
+    address: %[2]s
+  metrics:
+    enabled: false
+  nodeManagerFeatures:
+    nodeGuard: %[3]s
+  tlsEtcdSecretRefName: %[4]s
+  tlsEtcdSecretRefNamespace: %[1]s
+`, install.StorageOSClusterNamespace, install.EtcdEndpoints, install.NodeGuardEnv, install.EtcdSecretName)
+	portalClient := fmt.Sprintf(`apiVersion: v1
+kind: Secret
+metadata:
+  name: storageos-portal-client
+  namespace: %s
+data:
+  CLIENT_ID: %s
+  PASSWORD: %s
+`, install.StorageOSClusterNamespace, base64.StdEncoding.EncodeToString([]byte(install.PortalClientID)), base64.StdEncoding.EncodeToString([]byte(install.PortalSecret)))
+	portalConfig := fmt.Sprintf(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: storageos-portal-manager
+  namespace: %s
+data:
+  portal_config: |
+    apiVersion: storageos.com/v1
+    httpsProxy: %s
+`, install.StorageOSClusterNamespace, install.PortalHTTPSProxy)
+
+	in := &Installer{
+		stosConfig:        config,
+		onDiskFileSys:     filesys.MakeFsOnDisk(),
+		helmChart:         chart,
+		renderedManifests: []string{operator, portalClient, portalConfig, cluster},
+		renderedFiles:     []string{stosOperatorFile, stosPortalClientFile, stosPortalConfigFile, stosClusterFile},
+		exportNamespaces:  []string{install.StorageOSClusterNamespace},
+	}
+	dir := t.TempDir()
+	if err := in.writeHelmChart(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// lint the chart metadata and crds, which are not templated
+	chartFile, err := os.ReadFile(filepath.Join(dir, helmChartFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chartMetadata := map[string]string{}
+	if err = yaml.Unmarshal(chartFile, &chartMetadata); err != nil {
+		t.Fatal(err)
+	}
+	if chartMetadata["apiVersion"] != "v2" || chartMetadata["name"] != helmChartName || chartMetadata["appVersion"] != "v2.7.0" {
+		t.Errorf("expected %v, got %v", "apiVersion v2, name storageos and appVersion v2.7.0", chartMetadata)
+	}
+	if _, err = semver.NewVersion(chartMetadata["version"]); err != nil {
+		t.Errorf("expected semver chart version, got %v", chartMetadata["version"])
+	}
+	crds, err := os.ReadFile(filepath.Join(dir, helmCRDsDir, helmCRDsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(crds), "namespace: storageos\n") || strings.Contains(string(crds), helmPlaceholderAffix) {
+		t.Errorf("expected crds with default values, got %v", string(crds))
+	}
+
+	const stosCluster = "StorageOSCluster/ondat/storageos-cluster"
+	tcases := []struct {
+		name       string
+		overrides  map[string]interface{}
+		expObjects []string
+		expFields  map[string]interface{}
+	}{
+		{
+			name: "default values",
+			expObjects: []string{
+				"ConfigMap/ondat/storageos-portal-manager",
+				"ConfigMap/storageos/storageos-templates",
+				"Deployment/storageos/storageos-operator",
+				"Namespace//ondat",
+				"Namespace//storageos",
+				"Secret/ondat/storageos-api",
+				"Secret/ondat/storageos-portal-client",
+				stosCluster,
+			},
+			expFields: map[string]interface{}{
+				stosCluster + ":spec.kvBackend.address":                       "10.0.0.1:2379,10.0.0.2:2379",
+				stosCluster + ":spec.tlsEtcdSecretRefName":                    nil,
+				stosCluster + ":spec.tlsEtcdSecretRefNamespace":               nil,
+				stosCluster + ":spec.nodeManagerFeatures":                     nil,
+				stosCluster + ":spec.metrics.enabled":                         false,
+				stosCluster + ":spec.enablePortalManager":                     true,
+				"Secret/ondat/storageos-portal-client:data.CLIENT_ID":         base64.StdEncoding.EncodeToString([]byte("client")),
+				"ConfigMap/ondat/storageos-portal-manager:data.portal_config": "apiVersion: storageos.com/v1\n",
+				"ConfigMap/storageos/storageos-templates:data.template":       "{{ .Name }}",
+				"Namespace//ondat:metadata.name":                              "ondat",
+			},
+		},
+		{
+			name: "set values",
+			overrides: map[string]interface{}{
+				"cluster.namespace":     "storageos",
+				"etcd.tls.enabled":      true,
+				"nodeGuard.enabled":     true,
+				"metrics.enabled":       true,
+				"portalManager.enabled": false,
+			},
+			expObjects: []string{
+				"ConfigMap/storageos/storageos-templates",
+				"Deployment/storageos/storageos-operator",
+				"Namespace//storageos",
+				"Secret/storageos/storageos-api",
+				"StorageOSCluster/storageos/storageos-cluster",
+			},
+			expFields: map[string]interface{}{
+				"StorageOSCluster/storageos/storageos-cluster:spec.tlsEtcdSecretRefName":          "storageos-etcd-secret",
+				"StorageOSCluster/storageos/storageos-cluster:spec.tlsEtcdSecretRefNamespace":     "storageos",
+				"StorageOSCluster/storageos/storageos-cluster:spec.nodeManagerFeatures.nodeGuard": "MINIMUM_REPLICAS=2",
+				"StorageOSCluster/storageos/storageos-cluster:spec.metrics.enabled":               true,
+				"StorageOSCluster/storageos/storageos-cluster:spec.enablePortalManager":           false,
+			},
+		},
+		{
+			name:      "other cluster namespace",
+			overrides: map[string]interface{}{"cluster.namespace": "prod", "portalManager.enabled": false},
+			expObjects: []string{
+				"ConfigMap/storageos/storageos-templates",
+				"Deployment/storageos/storageos-operator",
+				"Namespace//prod",
+				"Namespace//storageos",
+				"Secret/prod/storageos-api",
+				"StorageOSCluster/prod/storageos-cluster",
+			},
+		},
+	}
+
+	helm := helmBinary(t)
+	for _, tc := range tcases {
+		objects := helmTemplate(t, helm, dir, tc.overrides)
+		names := []string{}
+		for name := range objects {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, tc.expObjects) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expObjects, names)
+		}
+		for key, expected := range tc.expFields {
+			parts := strings.SplitN(key, ":", 2)
+			if value := field(objects[parts[0]], parts[1]); !reflect.DeepEqual(value, expected) {
+				t.Errorf("%s: %s: expected %v, got %v", tc.name, key, expected, value)
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package installer
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/kustomize/api/krusty"
+
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+// imageFieldsFile is the kustomize transformer configuration, written alongside a kustomization, of the
+// image fields rewritten to a registry mirror.
+const imageFieldsFile = "image-fields.yaml"
+
+// getImageRewriter returns the image rewriter of the registry mirror and image mapping of the installer's
+// config, the mapping file is only read once.
+func (in *Installer) getImageRewriter() (*pluginutils.ImageRewriter, error) {
+	if in.imageRewriter != nil {
+		return in.imageRewriter, nil
+	}
+
+	rewriter, err := pluginutils.NewImageRewriter(in.stosConfig.Spec.Install.RegistryMirror, in.stosConfig.Spec.Install.ImageMapping)
+	if err != nil {
+		return nil, err
+	}
+	in.imageRewriter = rewriter
+
+	return rewriter, nil
+}
+
+// rewriteImages adds the images of resYaml, the kustomized output of dir, which are rewritten to a
+// registry mirror or mapped to the kustomization of dir. The output of kustomizing dir again is returned,
+// or resYaml if no image is rewritten.
+func (in *Installer) rewriteImages(dir string, resYaml []byte) ([]byte, error) {
+	rewriter, err := in.getImageRewriter()
+	if err != nil || !rewriter.Enabled() {
+		return resYaml, err
+	}
+
+	images, err := pluginutils.ManifestImages(string(resYaml))
+	if err != nil {
+		return nil, err
+	}
+	rewrites := rewriter.NameRewrites(images)
+	if len(rewrites) == 0 {
+		return resYaml, nil
+	}
+
+	imageFields, err := pluginutils.ImageTransformerConfig(string(resYaml))
+	if err != nil {
+		return nil, err
+	}
+	if err = in.fileSys.WriteFile(filepath.Join(dir, imageFieldsFile), []byte(imageFields)); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	kustomizationPath := filepath.Join(dir, kustomizationFile)
+	kustFile, err := in.fileSys.ReadFile(kustomizationPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	kustFileWithImages, err := pluginutils.AddImagesToKustomize(string(kustFile), rewrites, imageFieldsFile)
+	if err != nil {
+		return nil, err
+	}
+	if err = in.fileSys.WriteFile(kustomizationPath, []byte(kustFileWithImages)); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	kustomizer := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	resMap, err := kustomizer.Run(in.fileSys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return resMap.AsYaml()
+}
+
+// rewriteEtcdShellImage returns etcd shell pod manifest etcdShell with its image rewritten to a registry
+// mirror or as mapped.
+func (in *Installer) rewriteEtcdShellImage(etcdShell string) (string, error) {
+	rewriter, err := in.getImageRewriter()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.ReplaceAll(etcdShell, etcdShellImage, rewriter.Rewrite(etcdShellImage)), nil
+}
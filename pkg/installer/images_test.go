@@ -0,0 +1,84 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+func TestRewriteImages(t *testing.T) {
+	tcases := []struct {
+		name     string
+		mirror   string
+		expected []string
+	}{
+		{
+			name: "no mirror",
+			expected: []string{
+				"quay.io/brancz/kube-rbac-proxy:v0.10.0",
+				"storageos/api-manager:v1.2.7",
+				"storageos/node:v2.7.0",
+				"storageos/operator:v2.7.0",
+				"storageos/portal-manager:v1.0.2",
+			},
+		},
+		{
+			name:   "mirror",
+			mirror: "registry.example.com",
+			expected: []string{
+				"registry.example.com/brancz/kube-rbac-proxy:v0.10.0",
+				"registry.example.com/storageos/api-manager:v1.2.7",
+				"registry.example.com/storageos/node:v2.7.0",
+				"registry.example.com/storageos/operator:v2.7.0",
+				"registry.example.com/storageos/portal-manager:v1.0.2",
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		manifest, err := os.ReadFile(filepath.Join("test-data", "images-operator.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fsData := fsData{
+			stosDir: {
+				operatorDir: {
+					stosOperatorFile:  manifest,
+					kustomizationFile: []byte(kustTemp + "\n- " + stosOperatorFile + "\n"),
+				},
+			},
+		}
+		fileSys, err := createDirAndFiles(filesys.MakeFsInMemory(), fsData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		in := &Installer{
+			fileSys:    fileSys,
+			stosConfig: &apiv1.KubectlStorageOSConfig{Spec: apiv1.KubectlStorageOSConfigSpec{Install: apiv1.Install{RegistryMirror: tc.mirror}}},
+		}
+
+		dir := filepath.Join(stosDir, operatorDir)
+		resYaml, err := in.rewriteImages(dir, manifest)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+			continue
+		}
+		images, err := pluginutils.ManifestImages(string(resYaml))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(images, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, images)
+		}
+		// values of environment variables which are not images are kept
+		if !strings.Contains(string(resYaml), "value: storageos\n") {
+			t.Errorf("%s: expected %v, got %v", tc.name, "value: storageos", string(resYaml))
+		}
+	}
+}
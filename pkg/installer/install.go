@@ -1,18 +1,22 @@
 package installer
 
 import (
-	"context"
 	"encoding/base64"
 	"fmt"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/pkg/errors"
 
 	"sigs.k8s.io/kustomize/api/krusty"
 
 	"github.com/storageos/kubectl-storageos/pkg/consts"
+	"github.com/storageos/kubectl-storageos/pkg/logger"
 	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+	operatorapi "github.com/storageos/operator/api/v1"
 )
 
 // Install performs storageos operator and etcd operator installation for kubectl-storageos
@@ -56,7 +60,7 @@ func (in *Installer) Install(upgrade bool) error {
 	if in.stosConfig.Spec.Install.Wait {
 		once := sync.Once{}
 		errChan <- pluginutils.WaitFor(func() error {
-			cluster, err := pluginutils.GetFirstStorageOSCluster(in.clientConfig)
+			cluster, err := in.getInstalledStorageOSCluster()
 			if err != nil {
 				return err
 			}
@@ -78,6 +82,23 @@ func (in *Installer) Install(upgrade bool) error {
 	return collectErrors(errChan)
 }
 
+// getInstalledStorageOSCluster returns the storageos cluster being installed, rather than any other
+// storageos cluster of the k8s cluster. It falls back to the selected one if no cluster is installed.
+func (in *Installer) getInstalledStorageOSCluster() (*operatorapi.StorageOSCluster, error) {
+	if in.stosConfig.Spec.SkipStorageOSCluster {
+		return pluginutils.GetStorageOSCluster(in.clientConfig)
+	}
+	fsClusterName, err := in.getFieldInFsMultiDocByKind(filepath.Join(stosDir, clusterDir, stosClusterFile), stosClusterKind, "metadata", "name")
+	if err != nil {
+		return nil, err
+	}
+
+	return pluginutils.GetSelectedStorageOSCluster(in.clientConfig, pluginutils.ClusterSelector{
+		Name:      fsClusterName,
+		Namespace: in.stosConfig.Spec.Install.StorageOSClusterNamespace,
+	})
+}
+
 func (in *Installer) installLocalPathStorageClass() error {
 	return in.kustomizeAndApply(filepath.Join(localPathProvisionerDir, storageclassDir), localPathProvisionerFile)
 }
@@ -283,6 +304,9 @@ func (in *Installer) installStorageOS() error {
 	if err := in.installStorageOSOperator(); err != nil {
 		return err
 	}
+	if err := in.operatorCRDsAreEstablished(); err != nil {
+		return err
+	}
 	if err := in.operatorDeploymentsAreReady(filepath.Join(stosDir, operatorDir, stosOperatorFile)); err != nil {
 		return err
 	}
@@ -368,9 +392,11 @@ func (in *Installer) installStorageOSCluster() error {
 	// to storageos/cluster/kustomization.yaml based on flags (or cli in.stosConfig file)
 	if in.stosConfig.Spec.Install.StorageOSClusterNamespace != consts.NewOperatorNamespace {
 		if !in.stosConfig.Spec.Install.DryRun {
-			if err = in.kubectlClient.Apply(context.TODO(), "", pluginutils.NamespaceYaml(in.stosConfig.Spec.Install.StorageOSClusterNamespace), true); err != nil {
+			if err = in.apply("", pluginutils.NamespaceYaml(in.stosConfig.Spec.Install.StorageOSClusterNamespace)); err != nil {
 				return err
 			}
+		} else {
+			in.exportNamespace(in.stosConfig.Spec.Install.StorageOSClusterNamespace)
 		}
 		if err = in.setFieldInFsManifest(filepath.Join(stosDir, clusterDir, kustomizationFile), in.stosConfig.Spec.Install.StorageOSClusterNamespace, "namespace", ""); err != nil {
 			return err
@@ -464,6 +490,7 @@ func (in *Installer) operatorDeploymentsAreReady(path string) error {
 		return err
 	}
 
+	gates := make([]pluginutils.Gate, 0, len(operatorDeployments))
 	for _, deployment := range operatorDeployments {
 		deploymentName, err := pluginutils.GetFieldInManifest(deployment, "metadata", "name")
 		if err != nil {
@@ -473,11 +500,13 @@ func (in *Installer) operatorDeploymentsAreReady(path string) error {
 		if err != nil {
 			return err
 		}
-		if err = pluginutils.WaitFor(func() error {
+		gates = append(gates, func() error {
 			return pluginutils.IsDeploymentReady(in.clientConfig, deploymentName, deploymentNamespace)
-		}, 120, 5); err != nil {
-			return err
-		}
+		})
+	}
+
+	if err = pluginutils.WaitForGates(in.readinessTimeout()/time.Second, gateInterval, gates...); err != nil {
+		return errors.Wrap(err, errDeploymentsNotReady)
 	}
 	return nil
 }
@@ -514,6 +543,7 @@ func (in *Installer) operatorServicesAreReady(path string) error {
 
 // kustomizeAndApply performs the following in the order described:
 // - kustomize run (build) on the provided 'dir'.
+// - copy dir, with its kustomization, to the export fs if exporting.
 // - write the resulting kustomized manifest to dir/file of in-mem fs.
 // - remove any namespaces from dir/file of in-mem fs.
 // - safely apply the removed namespaces.
@@ -528,15 +558,25 @@ func (in *Installer) kustomizeAndApply(dir, file string) error {
 	if err != nil {
 		return err
 	}
+	if resYaml, err = in.rewriteImages(dir, resYaml); err != nil {
+		return err
+	}
+	if err = in.exportComponent(dir); err != nil {
+		return err
+	}
 	if err = in.fileSys.WriteFile(filepath.Join(dir, file), resYaml); err != nil {
 		return err
 	}
 
 	if in.stosConfig.Spec.Install.DryRun {
-		if err := pluginutils.WriteDryRunManifests(fmt.Sprintf("%s%s%s", strconv.Itoa(in.dryRunFileCounter), "-", file), resYaml); err != nil {
+		if in.renderToMemory {
+			in.renderedManifests = append(in.renderedManifests, string(resYaml))
+			in.renderedFiles = append(in.renderedFiles, file)
+		} else if err := pluginutils.WriteDryRunManifests(fmt.Sprintf("%s%s%s", strconv.Itoa(in.dryRunFileCounter), "-", file), resYaml); err != nil {
 			return err
 		}
 		in.dryRunFileCounter++
+		in.recordComponent(file, logger.ComponentRendered)
 		// return early for dry-run without applying manifest
 		return nil
 	}
@@ -556,13 +596,18 @@ func (in *Installer) kustomizeAndApply(dir, file string) error {
 		return err
 	}
 
-	return in.kubectlClient.Apply(context.TODO(), "", string(manifest), true)
+	if err = in.apply("", string(manifest)); err != nil {
+		return err
+	}
+	in.recordComponent(file, logger.ComponentApplied)
+
+	return nil
 }
 
 // gracefullyApplyNS applies a namespace and then waits until it has been applied successfully before
 // returning no error
 func (in *Installer) gracefullyApplyNS(namespaceManifest string) error {
-	if err := in.kubectlClient.Apply(context.TODO(), "", namespaceManifest, true); err != nil {
+	if err := in.apply("", namespaceManifest); err != nil {
 		return err
 	}
 
@@ -1,10 +1,12 @@
 package installer
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/ondat/operator-toolkit/declarative/applier"
 	"github.com/ondat/operator-toolkit/declarative/deleter"
@@ -31,6 +33,7 @@ const (
 	// CLI flags
 	StackTraceFlag                  = "stack-trace"
 	VerboseFlag                     = "verbose"
+	OutputFlag                      = "output"
 	SkipNamespaceDeletionFlag       = "skip-namespace-deletion"
 	SkipExistingWorkloadCheckFlag   = "skip-existing-workload-check"
 	StosVersionFlag                 = "stos-version"
@@ -52,6 +55,7 @@ const (
 	SkipEtcdEndpointsValFlag        = "skip-etcd-endpoints-validation"
 	SkipStosClusterFlag             = "skip-stos-cluster"
 	EtcdTLSEnabledFlag              = "etcd-tls-enabled"
+	EtcdValidationModeFlag          = "etcd-validation-mode"
 	EtcdSecretNameFlag              = "etcd-secret-name"
 	StosConfigPathFlag              = "stos-config-path"
 	EtcdNamespaceFlag               = "etcd-namespace"
@@ -80,10 +84,25 @@ const (
 	AirGapFlag                      = "air-gap"
 	EnableNodeGuardFlag             = "enable-node-guard"
 	NodeGuardEnvFlag                = "node-guard-env"
+	ResumeFlag                      = "resume"
+	SkipRollbackFlag                = "skip-rollback"
+	ReadinessTimeoutFlag            = "readiness-timeout"
+	ServerSideApplyFlag             = "server-side"
+	ForceConflictsFlag              = "force-conflicts"
+	FromBundleFlag                  = "from-bundle"
+	RegistryMirrorFlag              = "registry-mirror"
+	ImageMappingFlag                = "image-mapping"
+	RemovalTimeoutFlag              = "removal-timeout"
+	BackupDirFlag                   = "backup-dir"
+	ArchiveFlag                     = "archive"
+	EtcdSnapshotFlag                = "etcd-snapshot"
+	ClusterFlag                     = "cluster"
+	ShowConfigFlag                  = "show-config"
 
 	// config file fields - contain path delimiters for plugin interpretation of config manifest
 	StackTraceConfig                          = "spec.stackTrace"
 	VerboseConfig                             = "spec.verbose"
+	OutputConfig                              = "spec.output"
 	SkipNamespaceDeletionConfig               = "spec.skipNamespaceDeletion"
 	SkipExistingWorkloadCheckConfig           = "spec.skipExistingWorkloadCheck"
 	SkipStosClusterConfig                     = "spec.skipStorageOSCluster"
@@ -92,7 +111,7 @@ const (
 	AirGapConfig                              = "spec.airGap"
 	WaitConfig                                = "spec.install.wait"
 	DryRunConfig                              = "spec.install.dryRun"
-	K8sVersionConfig                          = "spec.install.kubernetesVersion"
+	K8sVersionConfig                          = "spec.install.k8sVersion"
 	InstallStosVersionConfig                  = "spec.install.storageOSVersion"
 	InstallEtcdOperatorVersionConfig          = "spec.install.etcdOperatorVersion"
 	InstallEtcdNamespaceConfig                = "spec.install.etcdNamespace"
@@ -108,6 +127,7 @@ const (
 	EtcdEndpointsConfig                       = "spec.install.etcdEndpoints"
 	SkipEtcdEndpointsValConfig                = "spec.install.skipEtcdEndpointsValidation"
 	EtcdTLSEnabledConfig                      = "spec.install.etcdTLSEnabled"
+	EtcdValidationModeConfig                  = "spec.install.etcdValidationMode"
 	EtcdSecretNameConfig                      = "spec.install.etcdSecretName"
 	EtcdStorageClassConfig                    = "spec.install.etcdStorageClassName"
 	AdminUsernameConfig                       = "spec.install.adminUsername"
@@ -116,6 +136,7 @@ const (
 	PortalSecretConfig                        = "spec.install.portalSecret"
 	PortalTenantIDConfig                      = "spec.install.portalTenantID"
 	PortalAPIURLConfig                        = "spec.install.portalAPIURL"
+	PortalHTTPSProxyConfig                    = "spec.install.portalHttpsProxy"
 	InstallPortalManagerVersionConfig         = "spec.install.portalManagerVersion"
 	UninstallPortalManagerVersionConfig       = "spec.uninstall.portalManagerVersion"
 	EnablePortalManagerConfig                 = "spec.install.enablePortalManager"
@@ -131,8 +152,8 @@ const (
 	UninstallEtcdClusterYamlConfig            = "spec.uninstall.etcdClusterYaml"
 	UninstallResourceQuotaYamlConfig          = "spec.uninstall.resourceQuotaYaml"
 	IncludeLocalPathProvisionerConfig         = "spec.includeLocalPathProvisioner"
-	InstallLocalPathProvisionerYamlConfig     = "spec.install.localPathProvisionerYamlConfig"
-	UninstallLocalPathProvisionerYamlConfig   = "spec.uninstall.localPathProvisionerYamlConfig"
+	InstallLocalPathProvisionerYamlConfig     = "spec.install.localPathProvisionerYaml"
+	UninstallLocalPathProvisionerYamlConfig   = "spec.uninstall.localPathProvisionerYaml"
 	EtcdVersionTagConfig                      = "spec.install.etcdVersionTag"
 	EtcdDockerRepositoryConfig                = "spec.install.etcdDockerRepository"
 	EtcdTopologyKeyConfig                     = "spec.install.etcdTopologyKey"
@@ -140,10 +161,21 @@ const (
 	EtcdMemoryLimitConfig                     = "spec.install.etcdMemoryLimit"
 	EtcdReplicasConfig                        = "spec.install.etcdReplicas"
 	EnableMetricsConfig                       = "spec.install.enableMetrics"
-	TestClusterConfig                         = "spec.install.enableTestClusterTaint"
-	SkipK8sVersionCheckConfig                 = "spec.install.skipK8sVerisonCheck"
+	TestClusterConfig                         = "spec.install.markTestCluster"
+	SkipK8sVersionCheckConfig                 = "spec.install.skipK8sVersionCheck"
 	EnableNodeGuardConfig                     = "spec.install.enableNodeGuard"
 	NodeGuardEnvConfig                        = "spec.install.nodeGuardEnv"
+	SkipRollbackConfig                        = "spec.install.skipRollback"
+	ReadinessTimeoutConfig                    = "spec.install.readinessTimeout"
+	ServerSideApplyConfig                     = "spec.install.serverSideApply"
+	ForceConflictsConfig                      = "spec.install.forceConflicts"
+	FromBundleConfig                          = "spec.install.fromBundle"
+	RegistryMirrorConfig                      = "spec.install.registryMirror"
+	ImageMappingConfig                        = "spec.install.imageMapping"
+	RemovalTimeoutConfig                      = "spec.uninstall.removalTimeout"
+	BackupDirConfig                           = "spec.backup.backupDir"
+	ArchiveConfig                             = "spec.backup.archive"
+	EtcdSnapshotConfig                        = "spec.backup.etcdSnapshot"
 
 	// dir and file names for in memory fs
 	etcdDir                  = "etcd"
@@ -185,6 +217,16 @@ resources:`
 	stosFinalizer     = "storageos.com/finalizer"
 	stosSCProvisioner = "csi.storageos.com"
 	stosAppLabel      = "app=storageos"
+
+	// FieldManager is the field manager of the fields set by server-side apply.
+	FieldManager = "kubectl-storageos"
+
+	errApplyConflicts = "fields are owned by other managers, re-run with --%s to take ownership of them"
+
+	// DefaultReadinessTimeout is the default time to wait for installed CRDs and deployments to become ready.
+	DefaultReadinessTimeout = 5 * time.Minute
+	// DefaultRemovalTimeout is the default time to wait for uninstalled CRDs and operator-owned objects to be removed.
+	DefaultRemovalTimeout = 5 * time.Minute
 )
 
 var (
@@ -224,6 +266,28 @@ type Installer struct {
 	dryRunFileCounter int
 	storageOSCluster  *operatorapi.StorageOSCluster
 	log               *logger.Logger
+
+	// renderToMemory collects the manifests rendered by a dry-run to renderedManifests, and their file
+	// names to renderedFiles, instead of writing them to disk. Manifests to uninstall are collected
+	// instead of being deleted.
+	renderToMemory    bool
+	renderedManifests []string
+	renderedFiles     []string
+
+	// diffUninstaller renders the manifests deleted by the uninstall phase of an upgrade diff.
+	diffUninstaller *Installer
+
+	// imageRewriter rewrites images to a registry mirror, see getImageRewriter.
+	imageRewriter *pluginutils.ImageRewriter
+
+	// exportFileSys collects the kustomize directories of the components installed, in exportedDirs
+	// order, and the namespaces installed outside of them, for Export.
+	exportFileSys    filesys.FileSystem
+	exportedDirs     []string
+	exportNamespaces []string
+
+	// helmChart holds the values of the chart rendered by HelmChart.
+	helmChart *helmChart
 }
 
 // NewInstaller returns an Installer used for install command
@@ -283,7 +347,7 @@ func NewPortalManagerInstaller(config *apiv1.KubectlStorageOSConfig, manifestsRe
 
 	in.fileSys = fileSys
 
-	stosCluster, err := pluginutils.GetFirstStorageOSCluster(in.clientConfig)
+	stosCluster, err := pluginutils.GetStorageOSCluster(in.clientConfig)
 	if err != nil {
 		return in, errors.WithStack(err)
 	}
@@ -326,10 +390,20 @@ func newCommonInstaller(config *apiv1.KubectlStorageOSConfig, log *logger.Logger
 	distribution := pluginutils.DetermineDistribution(currentVersionStr)
 
 	if !config.Spec.Install.SkipK8sVersionCheck {
-		minVersion, err := fetchImageAndExtractFileFromTarball(pluginversion.OperatorLatestSupportedImageURL(), "MIN_KUBE_VERSION")
+		// the minimum version is known without fetching the operator manifests image when installing from a bundle
+		minVersion := config.InstallerMeta.MinKubernetesVersion
+		var err error
+		if minVersion == "" {
+			var operatorVersion string
+			if operatorVersion, err = pluginversion.OperatorLatestSupportedVersion(); err != nil {
+				return installer, err
+			}
+			minVersion, err = OperatorMinKubernetesVersion(operatorVersion)
+		}
 		// Version 2.5.0-beta.1 doesn't contains the version file. After 2.5.0 has released error handling needs here.
 		if err == nil && minVersion != "" {
-			supported, err := pluginversion.IsSupported(currentVersionStr, minVersion)
+			requirement := pluginversion.Requirement{Component: pluginversion.KubernetesComponent, MinVersion: minVersion}
+			supported, err := requirement.Satisfied(currentVersionStr)
 			if err != nil {
 				return installer, errors.WithStack(err)
 			} else if !supported {
@@ -424,7 +498,7 @@ func NewUninstaller(config *apiv1.KubectlStorageOSConfig, log *logger.Logger) (*
 		return uninstaller, errors.WithStack(err)
 	}
 
-	stosCluster, err := pluginutils.GetFirstStorageOSCluster(clientConfig)
+	stosCluster, err := pluginutils.GetStorageOSCluster(clientConfig)
 	if err != nil {
 		if !kerrors.IsNotFound(err) {
 			return uninstaller, errors.WithStack(err)
@@ -476,9 +550,9 @@ func setVersionsForOperation(config *apiv1.KubectlStorageOSConfig) {
 }
 
 // kubectlNew returns a new KubectlClient. The client is based on silent direct applier and deleter if verbose
-// flag has not been set.
+// flag has not been set, or if the logger emits structured output.
 func kubectlNew(log *logger.Logger) *otkkubectl.DefaultKubectl {
-	if !log.Verbose {
+	if !log.Verbose || log.Structured() {
 		return &otkkubectl.DefaultKubectl{
 			DirectApplier: applier.NewDirectApplier().IOStreams(genericclioptions.NewTestIOStreamsDiscard()),
 			DirectDeleter: deleter.NewDirectDeleter().IOStreams(genericclioptions.NewTestIOStreamsDiscard()),
@@ -497,6 +571,22 @@ func kubectlNew(log *logger.Logger) *otkkubectl.DefaultKubectl {
 	}
 }
 
+// apply applies manifest to namespace. Server-side apply is used as the kubectl-storageos field manager if
+// enabled in the installer's config, client-side apply otherwise.
+func (in *Installer) apply(namespace, manifest string) error {
+	install := in.stosConfig.Spec.Install
+	if !install.ServerSideApply {
+		return in.kubectlClient.Apply(context.TODO(), namespace, manifest, true)
+	}
+
+	err := pluginutils.ServerSideApply(in.clientConfig, namespace, manifest, FieldManager, install.ForceConflicts)
+	if errors.As(err, &pluginutils.ApplyConflictError{}) {
+		return errors.Wrapf(err, errApplyConflicts, ForceConflictsFlag)
+	}
+
+	return err
+}
+
 // addPatchesToFSKustomize uses AddPatchesToKustomize internally to add a list of patches to a kustomization file
 // at path of in-memory fs.
 func (in *Installer) addPatchesToFSKustomize(path, targetKind, targetName string, patches []pluginutils.KustomizePatch) error {
@@ -617,7 +707,14 @@ func (in *Installer) writeBackupFileSystem(storageOSCluster *operatorapi.Storage
 	if err != nil {
 		return err
 	}
-	if err = in.onDiskFileSys.MkdirAll(backupPath); err != nil {
+
+	return in.writeBackupToPath(backupPath, storageOSCluster)
+}
+
+// writeBackupToPath writes manifests of secrets, configmaps, storageoscluster and storageclass to backupPath
+// of the on-disk filesystem
+func (in *Installer) writeBackupToPath(backupPath string, storageOSCluster *operatorapi.StorageOSCluster) error {
+	if err := in.onDiskFileSys.MkdirAll(backupPath); err != nil {
 		return errors.WithStack(err)
 	}
 
@@ -674,7 +771,7 @@ func (in *Installer) listStorageOSStorageClasses() (*kstoragev1.StorageClassList
 	return stosStorageClassList, nil
 }
 
-// writeSecretsToDisk writes multidoc manifest of SecretList.Items to path of on-disk filesystem
+// writeSecretsToDisk writes encrypted multidoc manifest of SecretList.Items to path of on-disk filesystem
 func (in *Installer) writeSecretsToDisk(secretList *corev1.SecretList, path string) error {
 	if len(secretList.Items) == 0 {
 		return nil
@@ -683,9 +780,8 @@ func (in *Installer) writeSecretsToDisk(secretList *corev1.SecretList, path stri
 	if err != nil {
 		return err
 	}
-	err = in.onDiskFileSys.WriteFile(path, secretsMultiDoc)
 
-	return errors.WithStack(err)
+	return in.writeEncryptedBackupFile(path, secretsMultiDoc)
 }
 
 // writeConfigMapsToDisk writes multidoc manifest of ConfigMapList.Items to path of on-disk filesystem
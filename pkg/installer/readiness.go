@@ -0,0 +1,141 @@
+package installer
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+const (
+	crdKind = "CustomResourceDefinition"
+
+	// gateInterval is the interval in seconds between evaluations of readiness gates.
+	gateInterval = 5
+
+	errCRDsNotEstablished  = "timeout waiting for storageos CRDs to be established"
+	errDeploymentsNotReady = "timeout waiting for operator deployments to become ready"
+	errStosNotRemoved      = "timeout waiting for storageos CRDs and operator-owned objects to be removed"
+)
+
+// readinessTimeout returns the configured time to wait for installed objects to become ready.
+func (in *Installer) readinessTimeout() time.Duration {
+	if timeout := in.stosConfig.Spec.Install.ReadinessTimeout.Duration; timeout > 0 {
+		return timeout
+	}
+	return DefaultReadinessTimeout
+}
+
+// removalTimeout returns the configured time to wait for uninstalled objects to be removed.
+func (in *Installer) removalTimeout() time.Duration {
+	if timeout := in.stosConfig.Spec.Uninstall.RemovalTimeout.Duration; timeout > 0 {
+		return timeout
+	}
+	return DefaultRemovalTimeout
+}
+
+// operatorCRDNames returns the names of the CRDs in the storageos operator manifest of the in-memory fs.
+func (in *Installer) operatorCRDNames() ([]string, error) {
+	crds, err := in.getAllManifestsOfKindFromFsMultiDoc(filepath.Join(stosDir, operatorDir, stosOperatorFile), crdKind)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(crds))
+	for _, crd := range crds {
+		name, err := pluginutils.GetFieldInManifest(crd, "metadata", "name")
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// operatorCRDsAreEstablished returns no error once every CRD of the storageos operator manifest has been
+// established by the API server, so that custom resources can be applied straight after.
+func (in *Installer) operatorCRDsAreEstablished() error {
+	// return early for dry-run
+	if in.stosConfig.Spec.Install.DryRun {
+		return nil
+	}
+	crdNames, err := in.operatorCRDNames()
+	if err != nil {
+		return err
+	}
+
+	gates := make([]pluginutils.Gate, 0, len(crdNames))
+	for _, name := range crdNames {
+		name := name
+		gates = append(gates, func() error {
+			return pluginutils.IsCRDEstablished(in.clientConfig, name)
+		})
+	}
+
+	if err = pluginutils.WaitForGates(in.readinessTimeout()/time.Second, gateInterval, gates...); err != nil {
+		return errors.Wrap(err, errCRDsNotEstablished)
+	}
+	return nil
+}
+
+// storageOSRemovalGates returns the gates which pass once the storageos cluster, the operator CRDs and
+// deployments and the storageos pods have been removed from the k8s cluster.
+func (in *Installer) storageOSRemovalGates() ([]pluginutils.Gate, error) {
+	gates := []pluginutils.Gate{
+		func() error {
+			return pluginutils.StorageOSClusterDoesNotExist(in.clientConfig)
+		},
+	}
+
+	crdNames, err := in.operatorCRDNames()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range crdNames {
+		name := name
+		gates = append(gates, func() error {
+			return pluginutils.CRDDoesNotExist(in.clientConfig, name)
+		})
+	}
+
+	deployments, err := in.getAllManifestsOfKindFromFsMultiDoc(filepath.Join(stosDir, operatorDir, stosOperatorFile), "Deployment")
+	if err != nil {
+		return nil, err
+	}
+	operatorNamespace := in.stosConfig.Spec.GetOperatorNamespace()
+	for _, deployment := range deployments {
+		name, err := pluginutils.GetFieldInManifest(deployment, "metadata", "name")
+		if err != nil {
+			return nil, err
+		}
+		gates = append(gates, func() error {
+			return pluginutils.DeploymentDoesNotExist(in.clientConfig, name, operatorNamespace)
+		})
+	}
+
+	if in.storageOSCluster != nil {
+		clusterNamespace := getStringWithDefault(in.storageOSCluster.Spec.Namespace, in.storageOSCluster.Namespace)
+		gates = append(gates, func() error {
+			return pluginutils.PodsDoNotExist(in.clientConfig, clusterNamespace, stosAppLabel)
+		})
+	}
+
+	return gates, nil
+}
+
+// waitForStorageOSRemoval returns no error once the storageos objects uninstalled by the installer have
+// been removed from the k8s cluster, so that a new version can be installed in their place.
+func (in *Installer) waitForStorageOSRemoval() error {
+	gates, err := in.storageOSRemovalGates()
+	if err != nil {
+		return err
+	}
+
+	if err = pluginutils.WaitForGates(in.removalTimeout()/time.Second, gateInterval, gates...); err != nil {
+		return errors.Wrap(err, errStosNotRemoved)
+	}
+	return nil
+}
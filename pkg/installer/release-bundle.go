@@ -0,0 +1,320 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gyaml "github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+	pluginversion "github.com/storageos/kubectl-storageos/pkg/version"
+)
+
+const (
+	// ReleaseBundleFormatVersion is the version of the bundle layout written by BuildReleaseBundle and read
+	// by UseReleaseBundle.
+	ReleaseBundleFormatVersion = "v1"
+
+	releaseBundleMetadataFile = "release.yaml"
+	releaseBundleImagesFile   = "images.txt"
+	releaseBundleManifestsDir = "manifests"
+
+	errUnsupportedReleaseBundleFormat = "release bundle format %q is not supported, expected %q"
+	errReleaseBundleVersionMismatch   = "release bundle %s contains version %s, not version %s passed to --%s"
+	errReleaseBundleMissingManifest   = "release bundle %s does not contain %s, create the bundle with --%s"
+)
+
+// ReleaseBundle describes a release bundle written by BuildReleaseBundle, it is stored alongside the
+// manifests of the release.
+type ReleaseBundle struct {
+	FormatVersion        string      `json:"formatVersion"`
+	CreationTimestamp    metav1.Time `json:"creationTimestamp"`
+	PluginVersion        string      `json:"pluginVersion,omitempty"`
+	StorageOSVersion     string      `json:"storageOSVersion"`
+	EtcdOperatorVersion  string      `json:"etcdOperatorVersion,omitempty"`
+	PortalManagerVersion string      `json:"portalManagerVersion,omitempty"`
+	MinKubernetesVersion string      `json:"minKubernetesVersion,omitempty"`
+	Manifests            []string    `json:"manifests"`
+	Images               []string    `json:"images"`
+}
+
+// releaseBundleManifests are the paths of manifests in the installer's in-memory fs, by their file name
+// in a release bundle. The portal client manifest is a kustomization of its own.
+var releaseBundleManifests = map[string]string{
+	stosOperatorFile:         filepath.Join(stosDir, operatorDir, stosOperatorFile),
+	stosClusterFile:          filepath.Join(stosDir, clusterDir, stosClusterFile),
+	resourceQuotaFile:        filepath.Join(stosDir, resourceQuotaDir, resourceQuotaFile),
+	stosPortalClientFile:     filepath.Join(stosDir, portalClientDir, kustomizationFile),
+	stosPortalConfigFile:     filepath.Join(stosDir, portalConfigDir, stosPortalConfigFile),
+	etcdOperatorFile:         filepath.Join(etcdDir, operatorDir, etcdOperatorFile),
+	etcdClusterFile:          filepath.Join(etcdDir, clusterDir, etcdClusterFile),
+	localPathProvisionerFile: filepath.Join(localPathProvisionerDir, storageclassDir, localPathProvisionerFile),
+}
+
+// BuildReleaseBundle writes a tar.gz release bundle to bundlePath, containing the manifests of the
+// versions of config, the images they reference and the versions themselves. Every component that
+// can be installed is bundled, etcd, portal manager and the local path provisioner only if enabled.
+func BuildReleaseBundle(config *apiv1.KubectlStorageOSConfig, bundlePath string) (*ReleaseBundle, error) {
+	fileSys, err := buildReleaseFileSys(config)
+	if err != nil {
+		return nil, err
+	}
+
+	bundleDir, err := os.MkdirTemp("", "storageos-release-bundle-")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer os.RemoveAll(bundleDir)
+	if err = os.Mkdir(filepath.Join(bundleDir, releaseBundleManifestsDir), 0755); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	bundle := &ReleaseBundle{
+		FormatVersion:        ReleaseBundleFormatVersion,
+		CreationTimestamp:    metav1.Now(),
+		PluginVersion:        pluginversion.PluginVersion,
+		StorageOSVersion:     config.Spec.Install.StorageOSVersion,
+		EtcdOperatorVersion:  config.Spec.Install.EtcdOperatorVersion,
+		PortalManagerVersion: config.Spec.Install.PortalManagerVersion,
+		Manifests:            []string{},
+		Images:               []string{},
+	}
+
+	for _, file := range releaseBundleManifestFiles() {
+		if !fileSys.Exists(releaseBundleManifests[file]) {
+			continue
+		}
+		data, err := fileSys.ReadFile(releaseBundleManifests[file])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err = os.WriteFile(filepath.Join(bundleDir, releaseBundleManifestsDir, file), data, 0644); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		bundle.Manifests = append(bundle.Manifests, file)
+	}
+	if bundle.Images, err = releaseImages(fileSys); err != nil {
+		return nil, err
+	}
+
+	// Version 2.5.0-beta.1 doesn't contain the version file, the minimum version check is then skipped
+	// as for an install from the manifests image.
+	operatorVersion, err := pluginversion.OperatorLatestSupportedVersion()
+	if err != nil {
+		return nil, err
+	}
+	if minVersion, err := OperatorMinKubernetesVersion(operatorVersion); err == nil {
+		bundle.MinKubernetesVersion = minVersion
+	}
+
+	metadata, err := gyaml.Marshal(bundle)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = os.WriteFile(filepath.Join(bundleDir, releaseBundleMetadataFile), metadata, 0644); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	imageList := strings.Join(bundle.Images, "\n") + "\n"
+	if err = os.WriteFile(filepath.Join(bundleDir, releaseBundleImagesFile), []byte(imageList), 0644); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return bundle, archiveDir(bundleDir, bundlePath)
+}
+
+// ReleaseImages returns the sorted images referenced by the manifests of the versions of config, which
+// installing them pulls, and the image of the etcd shell pod validating etcd endpoints. Etcd, portal
+// manager and the local path provisioner are only included if enabled.
+func ReleaseImages(config *apiv1.KubectlStorageOSConfig) ([]string, error) {
+	fileSys, err := buildReleaseFileSys(config)
+	if err != nil {
+		return nil, err
+	}
+	images, err := releaseImages(fileSys)
+	if err != nil {
+		return nil, err
+	}
+	images = append(images, etcdShellImage)
+	sort.Strings(images)
+
+	return images, nil
+}
+
+// buildReleaseFileSys returns the in-memory fs of the manifests of the versions of config, of every
+// component that can be installed, etcd, portal manager and the local path provisioner only if enabled.
+func buildReleaseFileSys(config *apiv1.KubectlStorageOSConfig) (filesys.FileSystem, error) {
+	setVersionsForOperation(config)
+
+	options := &installerOptions{
+		storageosOperator:    true,
+		storageosCluster:     true,
+		portalClient:         config.Spec.Install.EnablePortalManager,
+		portalConfig:         config.Spec.Install.EnablePortalManager,
+		resourceQuota:        true,
+		etcdOperator:         config.Spec.IncludeEtcd,
+		etcdCluster:          config.Spec.IncludeEtcd,
+		localPathProvisioner: config.Spec.IncludeLocalPathProvisioner,
+	}
+
+	return options.buildInstallerFileSys(config, nil)
+}
+
+// releaseImages returns the sorted images referenced by the release manifests of fileSys.
+func releaseImages(fileSys filesys.FileSystem) ([]string, error) {
+	images := map[string]bool{}
+	for _, file := range releaseBundleManifestFiles() {
+		// the portal client kustomization only generates a secret
+		if file == stosPortalClientFile || !fileSys.Exists(releaseBundleManifests[file]) {
+			continue
+		}
+		data, err := fileSys.ReadFile(releaseBundleManifests[file])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		manifestImages, err := pluginutils.ManifestImages(string(data))
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range manifestImages {
+			images[image] = true
+		}
+	}
+
+	sorted := []string{}
+	for image := range images {
+		sorted = append(sorted, image)
+	}
+	sort.Strings(sorted)
+
+	return sorted, nil
+}
+
+// UseReleaseBundle extracts the release bundle of config to a temporary directory and sets the versions
+// and manifests of config to those of the bundle, unless a manifest has been passed. Air gapped operation
+// is enabled, so that nothing is fetched from the network. The directory of the extracted bundle is
+// returned, it must be removed by the caller once installed.
+func UseReleaseBundle(config *apiv1.KubectlStorageOSConfig) (string, error) {
+	bundlePath := config.Spec.Install.FromBundle
+	bundleDir, err := os.MkdirTemp("", "storageos-release-bundle-")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if err = unarchiveDir(bundlePath, bundleDir); err != nil {
+		return bundleDir, err
+	}
+
+	bundle, err := readReleaseBundle(bundleDir)
+	if err != nil {
+		return bundleDir, err
+	}
+
+	install := &config.Spec.Install
+	versions := []struct {
+		value   *string
+		bundled string
+		flag    string
+	}{
+		{value: &install.StorageOSVersion, bundled: bundle.StorageOSVersion, flag: StosVersionFlag},
+		{value: &install.EtcdOperatorVersion, bundled: bundle.EtcdOperatorVersion, flag: EtcdOperatorVersionFlag},
+		{value: &install.PortalManagerVersion, bundled: bundle.PortalManagerVersion, flag: PortalManagerVersionFlag},
+	}
+	for _, version := range versions {
+		if *version.value != "" && version.bundled != "" && *version.value != version.bundled {
+			return bundleDir, errors.Errorf(errReleaseBundleVersionMismatch, bundlePath, version.bundled, *version.value, version.flag)
+		}
+		*version.value = getStringWithDefault(*version.value, version.bundled)
+	}
+
+	manifests := []struct {
+		value    *string
+		file     string
+		required bool
+		flag     string
+	}{
+		{value: &install.StorageOSOperatorYaml, file: stosOperatorFile, required: true},
+		{value: &install.StorageOSClusterYaml, file: stosClusterFile, required: !config.Spec.SkipStorageOSCluster},
+		{value: &install.ResourceQuotaYaml, file: resourceQuotaFile},
+		{value: &install.StorageOSPortalClientSecretYaml, file: stosPortalClientFile, required: install.EnablePortalManager, flag: EnablePortalManagerFlag},
+		{value: &install.StorageOSPortalConfigYaml, file: stosPortalConfigFile, required: install.EnablePortalManager, flag: EnablePortalManagerFlag},
+		{value: &install.EtcdOperatorYaml, file: etcdOperatorFile, required: config.Spec.IncludeEtcd, flag: IncludeEtcdFlag},
+		{value: &install.EtcdClusterYaml, file: etcdClusterFile, required: config.Spec.IncludeEtcd, flag: IncludeEtcdFlag},
+		{value: &install.LocalPathProvisionerYaml, file: localPathProvisionerFile, required: config.Spec.IncludeLocalPathProvisioner, flag: IncludeLocalPathProvisionerFlag},
+	}
+	for _, manifest := range manifests {
+		if *manifest.value != "" {
+			continue
+		}
+		if !bundle.containsManifest(manifest.file) {
+			if manifest.required {
+				return bundleDir, errors.Errorf(errReleaseBundleMissingManifest, bundlePath, manifest.file, manifest.flag)
+			}
+			continue
+		}
+		*manifest.value = filepath.Join(bundleDir, releaseBundleManifestsDir, manifest.file)
+	}
+
+	config.Spec.AirGap = true
+	config.InstallerMeta.MinKubernetesVersion = bundle.MinKubernetesVersion
+
+	return bundleDir, nil
+}
+
+// readReleaseBundle returns the metadata of the release bundle extracted to bundleDir.
+func readReleaseBundle(bundleDir string) (*ReleaseBundle, error) {
+	data, err := os.ReadFile(filepath.Join(bundleDir, releaseBundleMetadataFile))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	bundle := &ReleaseBundle{}
+	if err = gyaml.Unmarshal(data, bundle); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if bundle.FormatVersion != ReleaseBundleFormatVersion {
+		return nil, errors.Errorf(errUnsupportedReleaseBundleFormat, bundle.FormatVersion, ReleaseBundleFormatVersion)
+	}
+
+	return bundle, nil
+}
+
+// containsManifest returns true if file is one of the manifests of the bundle.
+func (b *ReleaseBundle) containsManifest(file string) bool {
+	for _, manifest := range b.Manifests {
+		if manifest == file {
+			return true
+		}
+	}
+
+	return false
+}
+
+// releaseBundleManifestFiles returns the file names of releaseBundleManifests, sorted.
+func releaseBundleManifestFiles() []string {
+	files := make([]string, 0, len(releaseBundleManifests))
+	for file := range releaseBundleManifests {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	return files
+}
+
+// String returns a summary of the versions of the bundle.
+func (b *ReleaseBundle) String() string {
+	summary := fmt.Sprintf("StorageOS %s", b.StorageOSVersion)
+	if b.EtcdOperatorVersion != "" {
+		summary += fmt.Sprintf(", etcd operator %s", b.EtcdOperatorVersion)
+	}
+	if b.PortalManagerVersion != "" {
+		summary += fmt.Sprintf(", portal manager %s", b.PortalManagerVersion)
+	}
+
+	return summary
+}
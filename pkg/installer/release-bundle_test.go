@@ -0,0 +1,145 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	gyaml "github.com/ghodss/yaml"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+)
+
+// writeTestReleaseBundle writes a release bundle of bundle, with empty manifests, to a temporary
+// directory and returns its path.
+func writeTestReleaseBundle(t *testing.T, bundle ReleaseBundle) string {
+	bundleDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(bundleDir, releaseBundleManifestsDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range bundle.Manifests {
+		if err := os.WriteFile(filepath.Join(bundleDir, releaseBundleManifestsDir, file), []byte{}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	metadata, err := gyaml.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(bundleDir, releaseBundleMetadataFile), metadata, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bundlePath := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	if err = archiveDir(bundleDir, bundlePath); err != nil {
+		t.Fatal(err)
+	}
+
+	return bundlePath
+}
+
+func TestUseReleaseBundle(t *testing.T) {
+	bundle := ReleaseBundle{
+		FormatVersion:        ReleaseBundleFormatVersion,
+		StorageOSVersion:     "v2.7.0",
+		MinKubernetesVersion: "1.19.0",
+		Manifests:            []string{stosOperatorFile, stosClusterFile, resourceQuotaFile},
+	}
+	etcdBundle := bundle
+	etcdBundle.EtcdOperatorVersion = "v0.3.2"
+	etcdBundle.Manifests = append([]string{etcdOperatorFile, etcdClusterFile}, bundle.Manifests...)
+	oldFormatBundle := bundle
+	oldFormatBundle.FormatVersion = "v0"
+
+	tcases := []struct {
+		name           string
+		bundle         ReleaseBundle
+		config         apiv1.KubectlStorageOSConfig
+		expVersion     string
+		expEtcd        bool
+		expClusterYaml string
+		expErr         bool
+	}{
+		{
+			name:       "storageos",
+			bundle:     bundle,
+			expVersion: "v2.7.0",
+		},
+		{
+			name:       "storageos and etcd",
+			bundle:     etcdBundle,
+			config:     apiv1.KubectlStorageOSConfig{Spec: apiv1.KubectlStorageOSConfigSpec{IncludeEtcd: true}},
+			expVersion: "v2.7.0",
+			expEtcd:    true,
+		},
+		{
+			name:   "manifest passed is kept",
+			bundle: bundle,
+			config: apiv1.KubectlStorageOSConfig{Spec: apiv1.KubectlStorageOSConfigSpec{Install: apiv1.Install{
+				StorageOSClusterYaml: "./my-cluster.yaml",
+			}}},
+			expVersion:     "v2.7.0",
+			expClusterYaml: "./my-cluster.yaml",
+		},
+		{
+			name:   "etcd not bundled",
+			bundle: bundle,
+			config: apiv1.KubectlStorageOSConfig{Spec: apiv1.KubectlStorageOSConfigSpec{IncludeEtcd: true}},
+			expErr: true,
+		},
+		{
+			name:   "version mismatch",
+			bundle: bundle,
+			config: apiv1.KubectlStorageOSConfig{Spec: apiv1.KubectlStorageOSConfigSpec{Install: apiv1.Install{
+				StorageOSVersion: "v2.8.0",
+			}}},
+			expErr: true,
+		},
+		{
+			name:   "unsupported format",
+			bundle: oldFormatBundle,
+			expErr: true,
+		},
+	}
+
+	for _, tc := range tcases {
+		config := tc.config
+		config.Spec.Install.FromBundle = writeTestReleaseBundle(t, tc.bundle)
+		bundleDir, err := UseReleaseBundle(&config)
+		defer os.RemoveAll(bundleDir)
+		if tc.expErr != (err != nil) {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.expErr, err)
+			continue
+		}
+		if tc.expErr {
+			continue
+		}
+
+		install := config.Spec.Install
+		if install.StorageOSVersion != tc.expVersion {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expVersion, install.StorageOSVersion)
+		}
+		if expOperatorYaml := filepath.Join(bundleDir, releaseBundleManifestsDir, stosOperatorFile); install.StorageOSOperatorYaml != expOperatorYaml {
+			t.Errorf("%s: expected %v, got %v", tc.name, expOperatorYaml, install.StorageOSOperatorYaml)
+		}
+		expClusterYaml := tc.expClusterYaml
+		if expClusterYaml == "" {
+			expClusterYaml = filepath.Join(bundleDir, releaseBundleManifestsDir, stosClusterFile)
+		}
+		if install.StorageOSClusterYaml != expClusterYaml {
+			t.Errorf("%s: expected %v, got %v", tc.name, expClusterYaml, install.StorageOSClusterYaml)
+		}
+		if (install.EtcdOperatorYaml != "") != tc.expEtcd {
+			t.Errorf("%s: expected etcd operator yaml %v, got %v", tc.name, tc.expEtcd, install.EtcdOperatorYaml)
+		}
+		if install.StorageOSPortalConfigYaml != "" {
+			t.Errorf("%s: expected no portal config yaml, got %v", tc.name, install.StorageOSPortalConfigYaml)
+		}
+		if !config.Spec.AirGap {
+			t.Errorf("%s: expected %v, got %v", tc.name, true, config.Spec.AirGap)
+		}
+		if config.InstallerMeta.MinKubernetesVersion != tc.bundle.MinKubernetesVersion {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.bundle.MinKubernetesVersion, config.InstallerMeta.MinKubernetesVersion)
+		}
+	}
+}
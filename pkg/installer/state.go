@@ -0,0 +1,243 @@
+package installer
+
+import (
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+	"github.com/storageos/kubectl-storageos/config/crd"
+	"github.com/storageos/kubectl-storageos/pkg/consts"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+)
+
+// Operations recorded in the install state.
+const (
+	InstallOperation   = "install"
+	UpgradeOperation   = "upgrade"
+	UninstallOperation = "uninstall"
+)
+
+const (
+	warnInstallStateNotRecorded = "Unable to record %s state in the cluster: %s"
+	warnCheckpointNotRecorded   = "Unable to record upgrade checkpoint '%s' in the cluster: %s"
+
+	errNoUpgradeToResume = "no interrupted upgrade was found to resume"
+)
+
+// upgradeSteps holds the resumable upgrade steps in the order they are completed.
+var upgradeSteps = []apiv1.UpgradeStep{
+	apiv1.UpgradeStepStarted,
+	apiv1.UpgradeStepBackedUp,
+	apiv1.UpgradeStepUninstalled,
+	apiv1.UpgradeStepCompleted,
+}
+
+// operationPhases holds the in-progress, succeeded and failed phases of each operation.
+var operationPhases = map[string][3]apiv1.InstallPhase{
+	InstallOperation:   {apiv1.PhaseInstalling, apiv1.PhaseInstalled, apiv1.PhaseInstallFailed},
+	UpgradeOperation:   {apiv1.PhaseUpgrading, apiv1.PhaseUpgraded, apiv1.PhaseUpgradeFailed},
+	UninstallOperation: {apiv1.PhaseUninstalling, apiv1.PhaseUninstalled, apiv1.PhaseUninstallFailed},
+}
+
+// ReadInstallState returns the install state recorded in the cluster by a previous run of the plugin.
+// A NotFound error is returned if no state has been recorded yet.
+func ReadInstallState(clientConfig *rest.Config) (*apiv1.KubectlStorageOSConfigStatus, error) {
+	state, err := pluginutils.GetKubectlStorageOSConfig(clientConfig, consts.InstallStateName, consts.InstallStateNamespace)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			// the CRD has not been registered, so nothing has been recorded.
+			return nil, kerrors.NewNotFound(apiv1.GroupVersion.WithResource("kubectlstorageosconfigs").GroupResource(), consts.InstallStateName)
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	return &state.Status, nil
+}
+
+// ResumableUpgrade returns the checkpoint of the interrupted upgrade recorded in state. An error is
+// returned if no upgrade was recorded, or if the last upgrade completed or was rolled back.
+func ResumableUpgrade(state *apiv1.KubectlStorageOSConfigStatus) (*apiv1.UpgradeCheckpoint, error) {
+	if state == nil || state.Upgrade == nil {
+		return nil, errors.New(errNoUpgradeToResume)
+	}
+
+	switch state.Upgrade.Step {
+	case apiv1.UpgradeStepCompleted, apiv1.UpgradeStepRolledBack:
+		return nil, errors.Errorf("%s, last upgrade from %s to %s is %s", errNoUpgradeToResume, state.Upgrade.FromVersion, state.Upgrade.ToVersion, state.Upgrade.Step)
+	}
+
+	return state.Upgrade.DeepCopy(), nil
+}
+
+// upgradeStepReached returns true if checkpoint has completed step.
+func upgradeStepReached(checkpoint *apiv1.UpgradeCheckpoint, step apiv1.UpgradeStep) bool {
+	reached, queried := -1, -1
+	for i, s := range upgradeSteps {
+		if s == checkpoint.Step {
+			reached = i
+		}
+		if s == step {
+			queried = i
+		}
+	}
+	return reached >= 0 && reached >= queried
+}
+
+// recordUpgradeStep sets step as the last step completed by checkpoint and records it in the cluster.
+// Failing to record the checkpoint is logged as a warning only.
+func (in *Installer) recordUpgradeStep(checkpoint *apiv1.UpgradeCheckpoint, step apiv1.UpgradeStep) {
+	now := metav1.Now()
+	checkpoint.Step = step
+	checkpoint.LastTransitionTime = &now
+
+	state, err := in.readOrInitInstallState()
+	if err != nil {
+		in.log.Warnf(warnCheckpointNotRecorded, step, err.Error())
+		return
+	}
+
+	state.Status.Upgrade = checkpoint.DeepCopy()
+
+	if err := in.writeInstallState(state); err != nil {
+		in.log.Warnf(warnCheckpointNotRecorded, step, err.Error())
+	}
+}
+
+// StartOperation records in the cluster that operation has started. Failing to record the state is
+// logged as a warning only, as it must not prevent the operation itself.
+func (in *Installer) StartOperation(operation string) {
+	state, err := in.readOrInitInstallState()
+	if err != nil {
+		in.log.Warnf(warnInstallStateNotRecorded, operation, err.Error())
+		return
+	}
+
+	state.Status = startedState(state.Status, operation, metav1.Now())
+
+	if err := in.writeInstallState(state); err != nil {
+		in.log.Warnf(warnInstallStateNotRecorded, operation, err.Error())
+	}
+}
+
+// FinishOperation records the outcome of operation in the cluster, along with the component
+// versions, namespaces and etcd endpoints it leaves behind. Failing to record the state is logged
+// as a warning only.
+func (in *Installer) FinishOperation(operation string, opErr error) {
+	state, err := in.readOrInitInstallState()
+	if err != nil {
+		in.log.Warnf(warnInstallStateNotRecorded, operation, err.Error())
+		return
+	}
+
+	state.Status = finishedState(state.Status, operation, in.stosConfig.Spec, opErr, metav1.Now())
+
+	// the storageos cluster is the source of truth for etcd endpoints and namespace, as they may have
+	// been generated during the operation (--include-etcd) or inherited from the previous cluster.
+	if opErr == nil && operation != UninstallOperation && !in.stosConfig.Spec.SkipStorageOSCluster {
+		if stosCluster, err := pluginutils.GetStorageOSCluster(in.clientConfig); err == nil {
+			state.Status.EtcdEndpoints = stosCluster.Spec.KVBackend.Address
+			state.Status.StorageOSClusterNamespace = getStringWithDefault(stosCluster.Spec.Namespace, stosCluster.Namespace)
+		}
+	}
+
+	if err := in.writeInstallState(state); err != nil {
+		in.log.Warnf(warnInstallStateNotRecorded, operation, err.Error())
+	}
+}
+
+// readOrInitInstallState returns the install state object from the cluster, or a new one if none
+// has been recorded yet.
+func (in *Installer) readOrInitInstallState() (*apiv1.KubectlStorageOSConfig, error) {
+	state, err := pluginutils.GetKubectlStorageOSConfig(in.clientConfig, consts.InstallStateName, consts.InstallStateNamespace)
+	if err == nil {
+		return state, nil
+	}
+	if !kerrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return nil, errors.WithStack(err)
+	}
+
+	return &apiv1.KubectlStorageOSConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      consts.InstallStateName,
+			Namespace: consts.InstallStateNamespace,
+		},
+	}, nil
+}
+
+// writeInstallState registers the kubectlstorageosconfigs CRD if necessary and writes state to the cluster.
+func (in *Installer) writeInstallState(state *apiv1.KubectlStorageOSConfig) error {
+	if err := in.apply("", crd.KubectlStorageOSConfig); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// the CRD may take a moment to be established after it is first applied.
+	return pluginutils.WaitFor(func() error {
+		return pluginutils.CreateOrUpdateKubectlStorageOSConfigStatus(in.clientConfig, state.DeepCopy())
+	}, 30, 2)
+}
+
+// startedState returns status updated for the start of operation. Versions and namespaces are left
+// untouched, as they still describe what is currently installed.
+func startedState(status apiv1.KubectlStorageOSConfigStatus, operation string, now metav1.Time) apiv1.KubectlStorageOSConfigStatus {
+	status.Phase = operationPhases[operation][0]
+	status.LastOperation = operation
+	status.LastError = ""
+	status.StartTime = &now
+	status.CompletionTime = nil
+
+	return status
+}
+
+// finishedState returns status updated for the outcome of operation performed with spec.
+func finishedState(status apiv1.KubectlStorageOSConfigStatus, operation string, spec apiv1.KubectlStorageOSConfigSpec, opErr error, now metav1.Time) apiv1.KubectlStorageOSConfigStatus {
+	status.LastOperation = operation
+	status.CompletionTime = &now
+	if status.StartTime == nil {
+		status.StartTime = &now
+	}
+
+	if opErr != nil {
+		status.Phase = operationPhases[operation][2]
+		status.LastError = opErr.Error()
+		// a failed install may still have left components behind, record what was attempted so
+		// that they can be uninstalled. Failed upgrades and uninstalls keep the previous record.
+		if operation != InstallOperation {
+			return status
+		}
+	} else {
+		status.Phase = operationPhases[operation][1]
+		status.LastError = ""
+	}
+
+	if operation == UninstallOperation {
+		status.StorageOSVersion = ""
+		status.PortalManagerVersion = ""
+		status.StorageOSOperatorNamespace = ""
+		status.StorageOSClusterNamespace = ""
+		status.EtcdEndpoints = ""
+		if spec.IncludeEtcd {
+			status.EtcdOperatorVersion = ""
+			status.EtcdNamespace = ""
+		}
+		return status
+	}
+
+	status.StorageOSVersion = spec.Install.StorageOSVersion
+	status.StorageOSOperatorNamespace = spec.Install.StorageOSOperatorNamespace
+	status.StorageOSClusterNamespace = spec.Install.StorageOSClusterNamespace
+	status.EtcdEndpoints = spec.Install.EtcdEndpoints
+	if spec.Install.EnablePortalManager {
+		status.PortalManagerVersion = spec.Install.PortalManagerVersion
+	} else if operation == InstallOperation {
+		status.PortalManagerVersion = ""
+	}
+	if spec.IncludeEtcd {
+		status.EtcdOperatorVersion = spec.Install.EtcdOperatorVersion
+		status.EtcdNamespace = spec.Install.EtcdNamespace
+	}
+
+	return status
+}
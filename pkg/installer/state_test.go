@@ -0,0 +1,232 @@
+package installer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
+)
+
+func TestFinishedState(t *testing.T) {
+	start := metav1.NewTime(time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC))
+	now := metav1.NewTime(time.Date(2022, 1, 1, 10, 5, 0, 0, time.UTC))
+
+	installed := apiv1.KubectlStorageOSConfigStatus{
+		Phase:                      apiv1.PhaseInstalled,
+		LastOperation:              InstallOperation,
+		StartTime:                  &start,
+		CompletionTime:             &start,
+		StorageOSVersion:           "v2.8.0",
+		EtcdOperatorVersion:        "v0.3.0",
+		PortalManagerVersion:       "v1.0.0",
+		StorageOSOperatorNamespace: "storageos",
+		StorageOSClusterNamespace:  "storageos",
+		EtcdNamespace:              "storageos-etcd",
+		EtcdEndpoints:              "storageos-etcd.storageos-etcd:2379",
+	}
+
+	upgradeSpec := apiv1.KubectlStorageOSConfigSpec{
+		Install: apiv1.Install{
+			StorageOSVersion:           "v2.9.0",
+			StorageOSOperatorNamespace: "storageos",
+			StorageOSClusterNamespace:  "storageos",
+			EtcdEndpoints:              "storageos-etcd.storageos-etcd:2379",
+			EnablePortalManager:        true,
+			PortalManagerVersion:       "v1.1.0",
+		},
+	}
+
+	tcases := []struct {
+		name      string
+		status    apiv1.KubectlStorageOSConfigStatus
+		operation string
+		spec      apiv1.KubectlStorageOSConfigSpec
+		opErr     error
+		expStatus apiv1.KubectlStorageOSConfigStatus
+	}{
+		{
+			name:      "install",
+			status:    apiv1.KubectlStorageOSConfigStatus{Phase: apiv1.PhaseInstalling, StartTime: &start},
+			operation: InstallOperation,
+			spec: apiv1.KubectlStorageOSConfigSpec{
+				IncludeEtcd: true,
+				Install: apiv1.Install{
+					StorageOSVersion:           "v2.8.0",
+					EtcdOperatorVersion:        "v0.3.0",
+					StorageOSOperatorNamespace: "storageos",
+					StorageOSClusterNamespace:  "storageos",
+					EtcdNamespace:              "storageos-etcd",
+				},
+			},
+			expStatus: apiv1.KubectlStorageOSConfigStatus{
+				Phase:                      apiv1.PhaseInstalled,
+				LastOperation:              InstallOperation,
+				StartTime:                  &start,
+				CompletionTime:             &now,
+				StorageOSVersion:           "v2.8.0",
+				EtcdOperatorVersion:        "v0.3.0",
+				StorageOSOperatorNamespace: "storageos",
+				StorageOSClusterNamespace:  "storageos",
+				EtcdNamespace:              "storageos-etcd",
+			},
+		},
+		{
+			name:      "failed install records attempted versions",
+			status:    apiv1.KubectlStorageOSConfigStatus{},
+			operation: InstallOperation,
+			spec: apiv1.KubectlStorageOSConfigSpec{
+				Install: apiv1.Install{
+					StorageOSVersion:           "v2.8.0",
+					StorageOSOperatorNamespace: "storageos",
+				},
+			},
+			opErr: errors.New("boom"),
+			expStatus: apiv1.KubectlStorageOSConfigStatus{
+				Phase:                      apiv1.PhaseInstallFailed,
+				LastOperation:              InstallOperation,
+				LastError:                  "boom",
+				StartTime:                  &now,
+				CompletionTime:             &now,
+				StorageOSVersion:           "v2.8.0",
+				StorageOSOperatorNamespace: "storageos",
+			},
+		},
+		{
+			name:      "upgrade keeps etcd record",
+			status:    installed,
+			operation: UpgradeOperation,
+			spec:      upgradeSpec,
+			expStatus: apiv1.KubectlStorageOSConfigStatus{
+				Phase:                      apiv1.PhaseUpgraded,
+				LastOperation:              UpgradeOperation,
+				StartTime:                  &start,
+				CompletionTime:             &now,
+				StorageOSVersion:           "v2.9.0",
+				EtcdOperatorVersion:        "v0.3.0",
+				PortalManagerVersion:       "v1.1.0",
+				StorageOSOperatorNamespace: "storageos",
+				StorageOSClusterNamespace:  "storageos",
+				EtcdNamespace:              "storageos-etcd",
+				EtcdEndpoints:              "storageos-etcd.storageos-etcd:2379",
+			},
+		},
+		{
+			name:      "failed upgrade keeps previous record",
+			status:    installed,
+			operation: UpgradeOperation,
+			spec:      upgradeSpec,
+			opErr:     errors.New("boom"),
+			expStatus: func() apiv1.KubectlStorageOSConfigStatus {
+				s := installed
+				s.Phase = apiv1.PhaseUpgradeFailed
+				s.LastOperation = UpgradeOperation
+				s.LastError = "boom"
+				s.CompletionTime = &now
+				return s
+			}(),
+		},
+		{
+			name:      "uninstall without etcd",
+			status:    installed,
+			operation: UninstallOperation,
+			expStatus: apiv1.KubectlStorageOSConfigStatus{
+				Phase:               apiv1.PhaseUninstalled,
+				LastOperation:       UninstallOperation,
+				StartTime:           &start,
+				CompletionTime:      &now,
+				EtcdOperatorVersion: "v0.3.0",
+				EtcdNamespace:       "storageos-etcd",
+			},
+		},
+		{
+			name:      "uninstall with etcd",
+			status:    installed,
+			operation: UninstallOperation,
+			spec:      apiv1.KubectlStorageOSConfigSpec{IncludeEtcd: true},
+			expStatus: apiv1.KubectlStorageOSConfigStatus{
+				Phase:          apiv1.PhaseUninstalled,
+				LastOperation:  UninstallOperation,
+				StartTime:      &start,
+				CompletionTime: &now,
+			},
+		},
+	}
+	for _, tc := range tcases {
+		status := finishedState(tc.status, tc.operation, tc.spec, tc.opErr, now)
+		if !reflect.DeepEqual(status, tc.expStatus) {
+			t.Errorf("%s: expected %+v, got %+v", tc.name, tc.expStatus, status)
+		}
+	}
+}
+
+func TestStartedState(t *testing.T) {
+	start := metav1.NewTime(time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC))
+	status := apiv1.KubectlStorageOSConfigStatus{
+		Phase:            apiv1.PhaseInstallFailed,
+		LastError:        "boom",
+		CompletionTime:   &start,
+		StorageOSVersion: "v2.8.0",
+	}
+
+	status = startedState(status, UninstallOperation, start)
+
+	expStatus := apiv1.KubectlStorageOSConfigStatus{
+		Phase:            apiv1.PhaseUninstalling,
+		LastOperation:    UninstallOperation,
+		StartTime:        &start,
+		StorageOSVersion: "v2.8.0",
+	}
+	if !reflect.DeepEqual(status, expStatus) {
+		t.Errorf("expected %+v, got %+v", expStatus, status)
+	}
+}
+
+func TestUpgradeStepReached(t *testing.T) {
+	tcases := []struct {
+		name  string
+		step  apiv1.UpgradeStep
+		query apiv1.UpgradeStep
+		exp   bool
+	}{
+		{name: "started before backup", step: apiv1.UpgradeStepStarted, query: apiv1.UpgradeStepBackedUp, exp: false},
+		{name: "backed up", step: apiv1.UpgradeStepBackedUp, query: apiv1.UpgradeStepBackedUp, exp: true},
+		{name: "backed up before uninstall", step: apiv1.UpgradeStepBackedUp, query: apiv1.UpgradeStepUninstalled, exp: false},
+		{name: "uninstalled after backup", step: apiv1.UpgradeStepUninstalled, query: apiv1.UpgradeStepBackedUp, exp: true},
+		{name: "no step", step: "", query: apiv1.UpgradeStepStarted, exp: false},
+		{name: "rolled back", step: apiv1.UpgradeStepRolledBack, query: apiv1.UpgradeStepBackedUp, exp: false},
+	}
+	for _, tc := range tcases {
+		reached := upgradeStepReached(&apiv1.UpgradeCheckpoint{Step: tc.step}, tc.query)
+		if reached != tc.exp {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.exp, reached)
+		}
+	}
+}
+
+func TestResumableUpgrade(t *testing.T) {
+	tcases := []struct {
+		name   string
+		state  *apiv1.KubectlStorageOSConfigStatus
+		expErr bool
+	}{
+		{name: "no state", state: nil, expErr: true},
+		{name: "no upgrade", state: &apiv1.KubectlStorageOSConfigStatus{}, expErr: true},
+		{name: "completed", state: &apiv1.KubectlStorageOSConfigStatus{Upgrade: &apiv1.UpgradeCheckpoint{Step: apiv1.UpgradeStepCompleted}}, expErr: true},
+		{name: "rolled back", state: &apiv1.KubectlStorageOSConfigStatus{Upgrade: &apiv1.UpgradeCheckpoint{Step: apiv1.UpgradeStepRolledBack}}, expErr: true},
+		{name: "interrupted", state: &apiv1.KubectlStorageOSConfigStatus{Upgrade: &apiv1.UpgradeCheckpoint{Step: apiv1.UpgradeStepUninstalled, FromVersion: "v2.8.0", ToVersion: "v2.9.0"}}},
+	}
+	for _, tc := range tcases {
+		checkpoint, err := ResumableUpgrade(tc.state)
+		if (err != nil) != tc.expErr {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.expErr, err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(checkpoint, tc.state.Upgrade) {
+			t.Errorf("%s: expected %+v, got %+v", tc.name, tc.state.Upgrade, checkpoint)
+		}
+	}
+}
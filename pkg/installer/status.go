@@ -0,0 +1,375 @@
+package installer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kstoragev1 "k8s.io/api/storage/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/storageos/kubectl-storageos/pkg/consts"
+	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
+	operatorapi "github.com/storageos/operator/api/v1"
+)
+
+// ComponentState is the state of a component of StorageOS.
+type ComponentState string
+
+const (
+	ComponentReady    ComponentState = "Ready"
+	ComponentNotReady ComponentState = "NotReady"
+	// ComponentNotInstalled is the state of optional components which are not enabled.
+	ComponentNotInstalled ComponentState = "NotInstalled"
+	// ComponentUnknown is the state of components which can't be checked, such as an unreachable external etcd.
+	ComponentUnknown ComponentState = "Unknown"
+
+	// StatusOperator, StatusCluster, StatusNodes, StatusCSI, StatusEtcd, StatusPortalManager and
+	// StatusMetricsExporter name the components reported by CollectStatus.
+	StatusOperator        = "operator"
+	StatusCluster         = "cluster"
+	StatusNodes           = "nodes"
+	StatusCSI             = "csi-driver"
+	StatusEtcd            = "etcd"
+	StatusPortalManager   = "portal-manager"
+	StatusMetricsExporter = "metrics-exporter"
+
+	stosClusterRunningPhase = "Running"
+	stosNodeDaemonSet       = "storageos-node"
+	metricsExporterName     = "storageos-metrics-exporter"
+)
+
+// ComponentStatus is the state of a component of StorageOS, with a message explaining it.
+type ComponentStatus struct {
+	Name      string         `json:"name"`
+	Namespace string         `json:"namespace,omitempty"`
+	State     ComponentState `json:"state"`
+	Message   string         `json:"message,omitempty"`
+}
+
+// NodeStatus is the state of the StorageOS pod of a node.
+type NodeStatus struct {
+	Node          string `json:"node"`
+	Pod           string `json:"pod"`
+	Ready         bool   `json:"ready"`
+	Restarts      int32  `json:"restarts"`
+	CSIRegistered bool   `json:"csiRegistered"`
+}
+
+// ClusterStatus is the status of the StorageOSCluster object.
+type ClusterStatus struct {
+	Name       string             `json:"name"`
+	Namespace  string             `json:"namespace"`
+	Phase      string             `json:"phase"`
+	Ready      string             `json:"ready,omitempty"`
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// StorageOSStatus is the health of a StorageOS installation, per component.
+type StorageOSStatus struct {
+	Time       time.Time         `json:"time"`
+	Cluster    *ClusterStatus    `json:"cluster,omitempty"`
+	Components []ComponentStatus `json:"components"`
+	Nodes      []NodeStatus      `json:"nodes,omitempty"`
+}
+
+// Healthy returns true if no component nor node is not ready. Components which are not installed or
+// can't be checked don't affect health.
+func (s *StorageOSStatus) Healthy() bool {
+	for _, component := range s.Components {
+		if component.State == ComponentNotReady {
+			return false
+		}
+	}
+	for _, node := range s.Nodes {
+		if !node.Ready {
+			return false
+		}
+	}
+
+	return true
+}
+
+// StatusOptions are the namespaces of the components which can't be discovered from the StorageOSCluster.
+type StatusOptions struct {
+	OperatorNamespace string
+}
+
+// CollectStatus returns the health of each component of the StorageOS installation of the k8s cluster.
+// Only reads are made, etcd included.
+func CollectStatus(config *rest.Config, options StatusOptions) (*StorageOSStatus, error) {
+	clientset, err := pluginutils.GetClientsetFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	status := &StorageOSStatus{Time: time.Now()}
+
+	operator := ComponentStatus{Name: StatusOperator, Namespace: options.OperatorNamespace}
+	operator.State, operator.Message = deploymentState(pluginutils.IsDeploymentReady(config, consts.NewOperatorName, options.OperatorNamespace))
+	status.Components = append(status.Components, operator)
+
+	cluster, err := pluginutils.GetStorageOSCluster(config)
+	if err != nil {
+		// the StorageOSCluster CRD is missing if the operator has never been installed
+		if !kerrors.IsNotFound(err) && !meta.IsNoMatchError(errors.Cause(err)) {
+			return nil, err
+		}
+		status.Components = append(status.Components, ComponentStatus{Name: StatusCluster, State: ComponentNotReady, Message: "no StorageOSCluster found"})
+		return status, nil
+	}
+	clusterNamespace := getStringWithDefault(cluster.Spec.Namespace, cluster.Namespace)
+	status.Cluster = &ClusterStatus{
+		Name:       cluster.Name,
+		Namespace:  cluster.Namespace,
+		Phase:      cluster.Status.Phase,
+		Ready:      cluster.Status.Ready,
+		Conditions: cluster.Status.Conditions,
+	}
+	status.Components = append(status.Components, clusterComponent(cluster))
+
+	pods, err := pluginutils.ListPods(config, clusterNamespace, stosAppLabel)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	csiNodes, err := clientset.StorageV1().CSINodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	status.Nodes = nodeStatuses(pods.Items, csiNodes.Items)
+	status.Components = append(status.Components, nodesComponent(status.Nodes, clusterNamespace))
+
+	csiDriver := ComponentStatus{Name: StatusCSI, State: ComponentReady}
+	if _, err := clientset.StorageV1().CSIDrivers().Get(context.TODO(), stosSCProvisioner, metav1.GetOptions{}); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return nil, errors.WithStack(err)
+		}
+		csiDriver.State, csiDriver.Message = ComponentNotReady, fmt.Sprintf("CSIDriver %s not found", stosSCProvisioner)
+	} else if csiDriver.Message = csiMessage(status.Nodes); csiDriver.Message != "" {
+		csiDriver.State = ComponentNotReady
+	}
+	status.Components = append(status.Components, csiDriver)
+
+	status.Components = append(status.Components, etcdComponent(config, cluster))
+
+	portalManager := ComponentStatus{Name: StatusPortalManager, Namespace: clusterNamespace}
+	if err := pluginutils.IsDeploymentReady(config, consts.PortalManagerName, clusterNamespace); kerrors.IsNotFound(err) {
+		portalManager.State = ComponentNotInstalled
+	} else {
+		portalManager.State, portalManager.Message = deploymentState(err)
+	}
+	status.Components = append(status.Components, portalManager)
+
+	metricsExporter := ComponentStatus{Name: StatusMetricsExporter, Namespace: clusterNamespace, State: ComponentNotInstalled}
+	if cluster.Spec.Metrics.Enabled {
+		metricsExporter.State, metricsExporter.Message = daemonSetState(clientset, metricsExporterName, clusterNamespace)
+	}
+	status.Components = append(status.Components, metricsExporter)
+
+	return status, nil
+}
+
+// deploymentState returns the state of a deployment from the error of IsDeploymentReady.
+func deploymentState(err error) (ComponentState, string) {
+	if err == nil {
+		return ComponentReady, ""
+	}
+
+	return ComponentNotReady, err.Error()
+}
+
+// daemonSetState returns the state of a daemonset, ready once all its scheduled pods are ready.
+func daemonSetState(clientset kubernetes.Interface, name, namespace string) (ComponentState, string) {
+	daemonSet, err := clientset.AppsV1().DaemonSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return ComponentNotReady, err.Error()
+	}
+
+	return daemonSetReadiness(daemonSet)
+}
+
+func daemonSetReadiness(daemonSet *appsv1.DaemonSet) (ComponentState, string) {
+	message := fmt.Sprintf("%d/%d pods ready", daemonSet.Status.NumberReady, daemonSet.Status.DesiredNumberScheduled)
+	if daemonSet.Status.DesiredNumberScheduled == 0 || daemonSet.Status.NumberReady < daemonSet.Status.DesiredNumberScheduled {
+		return ComponentNotReady, message
+	}
+
+	return ComponentReady, message
+}
+
+// clusterComponent returns the state of the StorageOSCluster, ready in the running phase. Conditions
+// which are not true are reported.
+func clusterComponent(cluster *operatorapi.StorageOSCluster) ComponentStatus {
+	component := ComponentStatus{
+		Name:      StatusCluster,
+		Namespace: cluster.Namespace,
+		State:     ComponentReady,
+		Message:   fmt.Sprintf("phase %s", cluster.Status.Phase),
+	}
+	if cluster.Status.Phase != stosClusterRunningPhase {
+		component.State = ComponentNotReady
+	}
+	failing := []string{}
+	for _, condition := range cluster.Status.Conditions {
+		if condition.Status != metav1.ConditionTrue {
+			failing = append(failing, condition.Type)
+		}
+	}
+	if len(failing) > 0 {
+		component.State = ComponentNotReady
+		component.Message = fmt.Sprintf("%s, conditions not met: %s", component.Message, strings.Join(failing, ", "))
+	}
+
+	return component
+}
+
+// nodeStatuses returns the status of the pod of each node of the storageos node daemonset, sorted by
+// node, and whether the CSI driver is registered on the node.
+func nodeStatuses(pods []corev1.Pod, csiNodes []kstoragev1.CSINode) []NodeStatus {
+	registered := map[string]bool{}
+	for _, csiNode := range csiNodes {
+		for _, driver := range csiNode.Spec.Drivers {
+			if driver.Name == stosSCProvisioner {
+				registered[csiNode.Name] = true
+			}
+		}
+	}
+
+	nodes := []NodeStatus{}
+	for _, pod := range pods {
+		if !ownedByDaemonSet(pod, stosNodeDaemonSet) {
+			continue
+		}
+		node := NodeStatus{
+			Node:          pod.Spec.NodeName,
+			Pod:           pod.Name,
+			CSIRegistered: registered[pod.Spec.NodeName],
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady {
+				node.Ready = condition.Status == corev1.ConditionTrue
+			}
+		}
+		for _, container := range pod.Status.ContainerStatuses {
+			node.Restarts += container.RestartCount
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Node < nodes[j].Node
+	})
+
+	return nodes
+}
+
+func ownedByDaemonSet(pod corev1.Pod, name string) bool {
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind == "DaemonSet" && owner.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nodesComponent returns the state of the storageos pods of the nodes, ready if all of them are.
+func nodesComponent(nodes []NodeStatus, namespace string) ComponentStatus {
+	ready := 0
+	for _, node := range nodes {
+		if node.Ready {
+			ready++
+		}
+	}
+	component := ComponentStatus{
+		Name:      StatusNodes,
+		Namespace: namespace,
+		State:     ComponentReady,
+		Message:   fmt.Sprintf("%d/%d nodes ready", ready, len(nodes)),
+	}
+	if len(nodes) == 0 || ready < len(nodes) {
+		component.State = ComponentNotReady
+	}
+
+	return component
+}
+
+// csiMessage returns the nodes the CSI driver is not registered on, if any.
+func csiMessage(nodes []NodeStatus) string {
+	unregistered := []string{}
+	for _, node := range nodes {
+		if !node.CSIRegistered {
+			unregistered = append(unregistered, node.Node)
+		}
+	}
+	if len(unregistered) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("not registered on %s", strings.Join(unregistered, ", "))
+}
+
+// etcdComponent returns the state of the etcd cluster of the storageos cluster, from the health of each
+// of its endpoints as checked by a native etcd client. Endpoints served by the k8s cluster are reached
+// through a port-forward, an external etcd unreachable from here is reported as unknown.
+func etcdComponent(config *rest.Config, cluster *operatorapi.StorageOSCluster) ComponentStatus {
+	component := ComponentStatus{Name: StatusEtcd, State: ComponentUnknown}
+	tlsEnabled := cluster.Spec.TLSEtcdSecretRefName != ""
+	endpoints := endpointsSplitter(cluster.Spec.KVBackend.Address, tlsEnabled)
+	if endpointURL, err := url.Parse(endpoints[0]); err == nil {
+		if _, namespace, ok := serviceFromHost(endpointURL.Hostname()); ok {
+			component.Namespace = namespace
+		}
+	}
+
+	var tlsConfig *tls.Config
+	if tlsEnabled {
+		secretNamespace := getStringWithDefault(cluster.Spec.TLSEtcdSecretRefNamespace, cluster.Namespace)
+		etcdSecret, err := pluginutils.GetSecret(config, cluster.Spec.TLSEtcdSecretRefName, secretNamespace)
+		if err != nil {
+			component.Message = fmt.Sprintf("etcd TLS secret %s/%s not readable: %v", secretNamespace, cluster.Spec.TLSEtcdSecretRefName, err)
+			return component
+		}
+		if tlsConfig, err = etcdClientTLSConfig(etcdSecret); err != nil {
+			component.Message = err.Error()
+			return component
+		}
+	}
+
+	healthy, forwarded := 0, false
+	unhealthy := []string{}
+	for _, endpoint := range endpoints {
+		endpointForwarded, err := etcdEndpointHealth(config, endpoint, tlsConfig)
+		forwarded = forwarded || endpointForwarded
+		if err != nil {
+			unhealthy = append(unhealthy, fmt.Sprintf("%s: %v", endpoint, errors.Cause(err)))
+			continue
+		}
+		healthy++
+	}
+
+	component.Message = fmt.Sprintf("%d/%d endpoints healthy", healthy, len(endpoints))
+	if len(unhealthy) != 0 {
+		component.Message = fmt.Sprintf("%s, %s", component.Message, strings.Join(unhealthy, ", "))
+	}
+	switch {
+	case healthy == len(endpoints):
+		component.State = ComponentReady
+	case healthy == 0 && !forwarded:
+		// an external etcd may only be reachable from the k8s cluster
+		component.Message = fmt.Sprintf("external etcd %s not reachable from here, %s", cluster.Spec.KVBackend.Address, strings.Join(unhealthy, ", "))
+	default:
+		component.State = ComponentNotReady
+	}
+
+	return component
+}
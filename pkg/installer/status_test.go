@@ -0,0 +1,129 @@
+package installer
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	kstoragev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorapi "github.com/storageos/operator/api/v1"
+)
+
+func TestNodeStatuses(t *testing.T) {
+	nodePod := func(name, node string, ready corev1.ConditionStatus, restarts int32) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: stosNodeDaemonSet}},
+			},
+			Spec: corev1.PodSpec{NodeName: node},
+			Status: corev1.PodStatus{
+				Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+				ContainerStatuses: []corev1.ContainerStatus{{RestartCount: restarts}, {RestartCount: 1}},
+			},
+		}
+	}
+	scheduler := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "storageos-scheduler-abc",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "storageos-scheduler-abc"}},
+		},
+		Spec: corev1.PodSpec{NodeName: "node-a"},
+	}
+	csiNodes := []kstoragev1.CSINode{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+			Spec:       kstoragev1.CSINodeSpec{Drivers: []kstoragev1.CSINodeDriver{{Name: "other.csi.k8s.io"}, {Name: stosSCProvisioner}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-b"},
+			Spec:       kstoragev1.CSINodeSpec{Drivers: []kstoragev1.CSINodeDriver{{Name: "other.csi.k8s.io"}}},
+		},
+	}
+
+	tcases := []struct {
+		name        string
+		pods        []corev1.Pod
+		expNodes    []NodeStatus
+		expState    ComponentState
+		expCSIError string
+	}{
+		{
+			name: "all ready",
+			pods: []corev1.Pod{nodePod("storageos-node-a", "node-a", corev1.ConditionTrue, 0), scheduler},
+			expNodes: []NodeStatus{
+				{Node: "node-a", Pod: "storageos-node-a", Ready: true, Restarts: 1, CSIRegistered: true},
+			},
+			expState: ComponentReady,
+		},
+		{
+			name: "node not ready and csi not registered",
+			pods: []corev1.Pod{nodePod("storageos-node-b", "node-b", corev1.ConditionFalse, 3), nodePod("storageos-node-a", "node-a", corev1.ConditionTrue, 0)},
+			expNodes: []NodeStatus{
+				{Node: "node-a", Pod: "storageos-node-a", Ready: true, Restarts: 1, CSIRegistered: true},
+				{Node: "node-b", Pod: "storageos-node-b", Ready: false, Restarts: 4, CSIRegistered: false},
+			},
+			expState:    ComponentNotReady,
+			expCSIError: "not registered on node-b",
+		},
+		{
+			name:     "no nodes",
+			pods:     []corev1.Pod{scheduler},
+			expNodes: []NodeStatus{},
+			expState: ComponentNotReady,
+		},
+	}
+
+	for _, tc := range tcases {
+		nodes := nodeStatuses(tc.pods, csiNodes)
+		if !reflect.DeepEqual(nodes, tc.expNodes) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expNodes, nodes)
+		}
+		if state := nodesComponent(nodes, "storageos").State; state != tc.expState {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expState, state)
+		}
+		if message := csiMessage(nodes); message != tc.expCSIError {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expCSIError, message)
+		}
+	}
+}
+
+func TestClusterComponent(t *testing.T) {
+	tcases := []struct {
+		name       string
+		status     operatorapi.StorageOSClusterStatus
+		expState   ComponentState
+		expMessage string
+	}{
+		{
+			name:       "running",
+			status:     operatorapi.StorageOSClusterStatus{Phase: "Running", Conditions: []metav1.Condition{{Type: "Ready", Status: metav1.ConditionTrue}}},
+			expState:   ComponentReady,
+			expMessage: "phase Running",
+		},
+		{
+			name:       "creating",
+			status:     operatorapi.StorageOSClusterStatus{Phase: "Creating"},
+			expState:   ComponentNotReady,
+			expMessage: "phase Creating",
+		},
+		{
+			name:       "running with failing conditions",
+			status:     operatorapi.StorageOSClusterStatus{Phase: "Running", Conditions: []metav1.Condition{{Type: "Scheduler", Status: metav1.ConditionFalse}, {Type: "CSI", Status: metav1.ConditionUnknown}}},
+			expState:   ComponentNotReady,
+			expMessage: "phase Running, conditions not met: Scheduler, CSI",
+		},
+	}
+
+	for _, tc := range tcases {
+		component := clusterComponent(&operatorapi.StorageOSCluster{Status: tc.status})
+		if component.State != tc.expState {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expState, component.State)
+		}
+		if component.Message != tc.expMessage {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expMessage, component.Message)
+		}
+	}
+}
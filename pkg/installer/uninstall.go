@@ -12,6 +12,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/kustomize/api/krusty"
 
+	"github.com/storageos/kubectl-storageos/pkg/logger"
 	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
 	"github.com/storageos/kubectl-storageos/pkg/version"
 )
@@ -118,9 +119,13 @@ func (in *Installer) Uninstall(upgrade bool) error {
 }
 
 func (in *Installer) uninstallStorageOS(upgrade bool) error {
-	storageOSClusterNamespace := in.storageOSCluster.Namespace
-	if storageOSClusterNamespace == "" {
-		storageOSClusterNamespace = in.stosConfig.Spec.GetOperatorNamespace()
+	// the storageos cluster may already be gone, eg. when an interrupted upgrade is resumed
+	// or a partially installed version is rolled back.
+	storageOSClusterExists := in.storageOSCluster != nil
+
+	storageOSClusterNamespace := in.stosConfig.Spec.GetOperatorNamespace()
+	if storageOSClusterExists && in.storageOSCluster.Namespace != "" {
+		storageOSClusterNamespace = in.storageOSCluster.Namespace
 	}
 
 	if !in.stosConfig.Spec.SkipNamespaceDeletion && storageOSClusterNamespace != in.stosConfig.Spec.GetOperatorNamespace() {
@@ -128,12 +133,12 @@ func (in *Installer) uninstallStorageOS(upgrade bool) error {
 			return fmt.Errorf("namespace is protected - %s - %w ", errStosUninstallAborted, err)
 		}
 		defer func() {
-			if err := in.gracefullyDeleteNS(in.storageOSCluster.Namespace); err != nil {
+			if err := in.gracefullyDeleteNS(storageOSClusterNamespace); err != nil {
 				println(err.Error())
 			}
 		}()
 	}
-	if !in.stosConfig.Spec.SkipStorageOSCluster {
+	if !in.stosConfig.Spec.SkipStorageOSCluster && storageOSClusterExists {
 		if err := in.uninstallStorageOSCluster(upgrade); err != nil {
 			return errors.WithStack(err)
 		}
@@ -418,6 +423,13 @@ func (in *Installer) kustomizeAndDelete(dir, file string) error {
 		return errors.WithStack(err)
 	}
 
+	// a diff collects the manifest without deleting it
+	if in.renderToMemory {
+		in.renderedManifests = append(in.renderedManifests, string(resYaml))
+		in.renderedFiles = append(in.renderedFiles, file)
+		return nil
+	}
+
 	removedNamespaces, err := in.omitAndReturnKindFromFSMultiDoc(filepath.Join(dir, file), "Namespace")
 	if err != nil {
 		return err
@@ -431,6 +443,7 @@ func (in *Installer) kustomizeAndDelete(dir, file string) error {
 	if err = in.kubectlClient.Delete(context.TODO(), "", string(manifest), true); err != nil {
 		return errors.WithStack(err)
 	}
+	in.recordComponent(file, logger.ComponentDeleted)
 
 	if in.stosConfig.Spec.SkipNamespaceDeletion {
 		return nil
@@ -507,7 +520,7 @@ func (in *Installer) ensureStorageOSClusterRemoved() error {
 	}
 	// storageoscluster still exists at this point, it may be stuck in deleting phase with finalizer. So we
 	// rediscover the object, remove any finlaizers and update (known issue on k8s 1.18)
-	storageOSCluster, err := pluginutils.GetFirstStorageOSCluster(in.clientConfig)
+	storageOSCluster, err := pluginutils.GetStorageOSCluster(in.clientConfig)
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			return nil
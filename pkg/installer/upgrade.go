@@ -1,11 +1,10 @@
 package installer
 
 import (
-	"context"
 	"path/filepath"
-	"time"
 
 	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 
 	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
 	"github.com/storageos/kubectl-storageos/pkg/logger"
@@ -25,34 +24,105 @@ const (
 	--portal-api-url
 	--portal-tenant-id
 	`
+
+	errResumeVersionMismatch = "interrupted upgrade from %s to %s cannot be resumed to version %s"
+
+	errUpgradeInstallFailed = `
+	Upgrade failed while installing the new version and rollback is disabled.
+	Manifests of the previous cluster are backed up at %s.
+	Re-run the upgrade with --%s to retry the install.`
+
+	errUpgradeRolledBack = `
+	Upgrade failed while installing the new version. StorageOS %s has been reinstalled`
+
+	errRollbackFailed = `
+	Upgrade failed while installing the new version and rollback failed: %s.
+	Manifests of the previous cluster are backed up at %s.
+	Re-run the upgrade with --%s to retry the install`
 )
 
-func Upgrade(uninstallConfig *apiv1.KubectlStorageOSConfig, installConfig *apiv1.KubectlStorageOSConfig, log *logger.Logger) error {
+// Upgrade uninstalls the existing storageos operator and cluster and installs the new version. Each
+// step completed is recorded as a checkpoint in the install state, so that an interrupted upgrade can
+// be continued by setting resume. Should the new version fail to install, the previous version is
+// reinstalled from the local backup unless rollback has been disabled.
+func Upgrade(uninstallConfig *apiv1.KubectlStorageOSConfig, installConfig *apiv1.KubectlStorageOSConfig, resume bool, log *logger.Logger) error {
 	// create new installer with in-mem fs of operator and cluster to be installed
 	// use installer to validate etcd-endpoints before going any further
 	installer, err := NewInstaller(installConfig, log)
 	if err != nil {
 		return err
 	}
-	storageOSCluster, err := pluginutils.GetFirstStorageOSCluster(installer.clientConfig)
+
+	installer.StartOperation(UpgradeOperation)
+	err = upgrade(installer, uninstallConfig, installConfig, resume, log)
+	installer.FinishOperation(UpgradeOperation, err)
+
+	return err
+}
+
+func upgrade(installer *Installer, uninstallConfig *apiv1.KubectlStorageOSConfig, installConfig *apiv1.KubectlStorageOSConfig, resume bool, log *logger.Logger) error {
+	backupPath, err := installer.getBackupPath()
 	if err != nil {
 		return err
 	}
 
-	// if etcdEndpoints was not passed via config, use that of existing cluster
-	if installConfig.Spec.Install.EtcdEndpoints == "" {
-		installConfig.Spec.Install.EtcdEndpoints = storageOSCluster.Spec.KVBackend.Address
+	checkpoint := &apiv1.UpgradeCheckpoint{
+		FromVersion: uninstallConfig.Spec.Uninstall.StorageOSVersion,
+		ToVersion:   installConfig.Spec.Install.StorageOSVersion,
+		BackupPath:  backupPath,
+	}
+
+	// prefer etcd endpoints and storageos cluster namespace recorded by a previous run of the plugin
+	state, stateErr := ReadInstallState(installer.clientConfig)
+	if stateErr == nil {
+		if installConfig.Spec.Install.EtcdEndpoints == "" {
+			installConfig.Spec.Install.EtcdEndpoints = state.EtcdEndpoints
+		}
+		if installConfig.Spec.Install.StorageOSClusterNamespace == "" {
+			installConfig.Spec.Install.StorageOSClusterNamespace = state.StorageOSClusterNamespace
+		}
 	}
 
-	// if storageOSClusterNamespace was not passed via config, use that of existing cluster
-	if installConfig.Spec.Install.StorageOSClusterNamespace == "" {
-		// First, check spec.namespace which defines the namespace for storageos installation by storageos/cluster-operator,
-		// (this field is deprecated in storageos/operator). Otherwise, use metadata.Namespace for storageos installation
-		// (default behaviour for storageos/operator).
-		if storageOSCluster.Spec.Namespace != "" {
-			installConfig.Spec.Install.StorageOSClusterNamespace = storageOSCluster.Spec.Namespace
-		} else {
-			installConfig.Spec.Install.StorageOSClusterNamespace = storageOSCluster.Namespace
+	if resume {
+		if stateErr != nil {
+			return errors.Wrap(stateErr, errNoUpgradeToResume)
+		}
+		if checkpoint, err = ResumableUpgrade(state); err != nil {
+			return err
+		}
+		if checkpoint.ToVersion != installConfig.Spec.Install.StorageOSVersion {
+			return errors.Errorf(errResumeVersionMismatch, checkpoint.FromVersion, checkpoint.ToVersion, installConfig.Spec.Install.StorageOSVersion)
+		}
+		log.Warnf("Resuming upgrade from %s to %s after step '%s'.", checkpoint.FromVersion, checkpoint.ToVersion, checkpoint.Step)
+	} else {
+		installer.recordUpgradeStep(checkpoint, apiv1.UpgradeStepStarted)
+	}
+
+	storageOSCluster, err := pluginutils.GetStorageOSCluster(installer.clientConfig)
+	if err != nil {
+		// the storageos cluster is expected to be gone if the upgrade is resumed after it was uninstalled.
+		if !kerrors.IsNotFound(err) || !upgradeStepReached(checkpoint, apiv1.UpgradeStepBackedUp) {
+			return err
+		}
+		storageOSCluster = nil
+	}
+
+	if storageOSCluster != nil {
+		// if etcdEndpoints was not passed via config, use that of existing cluster
+		if installConfig.Spec.Install.EtcdEndpoints == "" {
+			installConfig.Spec.Install.EtcdEndpoints = storageOSCluster.Spec.KVBackend.Address
+		}
+
+		// if storageOSClusterNamespace was not passed via config, use that of existing cluster
+		if installConfig.Spec.Install.StorageOSClusterNamespace == "" {
+			// First, check spec.namespace which defines the namespace for storageos installation by storageos/cluster-operator,
+			// (this field is deprecated in storageos/operator). Otherwise, use metadata.Namespace for storageos installation
+			// (default behaviour for storageos/operator).
+			if storageOSCluster.Spec.Namespace != "" {
+				installConfig.Spec.Install.StorageOSClusterNamespace = storageOSCluster.Spec.Namespace
+			} else {
+				installConfig.Spec.Install.StorageOSClusterNamespace = storageOSCluster.Namespace
+			}
 		}
 	}
 
@@ -66,27 +136,127 @@ func Upgrade(uninstallConfig *apiv1.KubectlStorageOSConfig, installConfig *apiv1
 		return err
 	}
 
-	if err = uninstaller.prepareForUpgrade(installConfig, uninstallConfig.Spec.Uninstall.StorageOSVersion, installer); err != nil {
-		return err
+	if !upgradeStepReached(checkpoint, apiv1.UpgradeStepBackedUp) {
+		if err = uninstaller.prepareForUpgrade(uninstallConfig.Spec.Uninstall.StorageOSVersion); err != nil {
+			return err
+		}
+		installer.recordUpgradeStep(checkpoint, apiv1.UpgradeStepBackedUp)
 	}
 
-	// uninstall existing storageos operator and cluster
-	if err = uninstaller.Uninstall(true); err != nil {
+	if err = uninstaller.loadUpgradeBackup(installConfig, installer); err != nil {
 		return err
 	}
 
-	// sleep to allow CRDs to be removed
-	// TODO: Add specific check instead of sleep
-	time.Sleep(30 * time.Second)
+	if !upgradeStepReached(checkpoint, apiv1.UpgradeStepUninstalled) {
+		// uninstall existing storageos operator and cluster
+		if err = uninstaller.Uninstall(true); err != nil {
+			return err
+		}
+
+		// wait for CRDs and operator-owned objects to be removed before installing the new version
+		if err = uninstaller.waitForStorageOSRemoval(); err != nil {
+			return err
+		}
+
+		installer.recordUpgradeStep(checkpoint, apiv1.UpgradeStepUninstalled)
+	}
 
 	// install new storageos operator and cluster
-	err = installer.Install(true)
+	if err = installer.Install(true); err != nil {
+		if installConfig.Spec.Install.SkipRollback {
+			return errors.Wrapf(err, errUpgradeInstallFailed, backupPath, ResumeFlag)
+		}
 
-	return err
+		log.Warnf("Failed to install StorageOS %s, rolling back to %s.", checkpoint.ToVersion, checkpoint.FromVersion)
+		if rollbackErr := rollback(uninstaller, uninstallConfig, installConfig, log); rollbackErr != nil {
+			return errors.Wrapf(err, errRollbackFailed, rollbackErr.Error(), backupPath, ResumeFlag)
+		}
+		installer.recordUpgradeStep(checkpoint, apiv1.UpgradeStepRolledBack)
+
+		return errors.Wrapf(err, errUpgradeRolledBack, checkpoint.FromVersion)
+	}
+
+	installer.recordUpgradeStep(checkpoint, apiv1.UpgradeStepCompleted)
+
+	return nil
 }
 
-// prepareForUpgrade performs necessary steps before upgrade commences
-func (in *Installer) prepareForUpgrade(installConfig *apiv1.KubectlStorageOSConfig, versionToUninstall string, installer *Installer) error {
+// rollback uninstalls what has been installed of the new version and reinstalls the previous version
+// from the manifests backed up by the uninstaller before the upgrade.
+func rollback(uninstaller *Installer, uninstallConfig *apiv1.KubectlStorageOSConfig, installConfig *apiv1.KubectlStorageOSConfig, log *logger.Logger) error {
+	newVersionUninstaller, err := NewUninstaller(rollbackUninstallConfig(uninstallConfig, installConfig), log)
+	if err != nil {
+		return err
+	}
+	if err = newVersionUninstaller.Uninstall(true); err != nil {
+		return err
+	}
+
+	if err = newVersionUninstaller.waitForStorageOSRemoval(); err != nil {
+		return err
+	}
+
+	previousInstallConfig := rollbackInstallConfig(uninstallConfig, installConfig)
+	previousVersionInstaller, err := NewInstaller(previousInstallConfig, log)
+	if err != nil {
+		return err
+	}
+	if err = uninstaller.loadUpgradeBackup(previousInstallConfig, previousVersionInstaller); err != nil {
+		return err
+	}
+
+	return previousVersionInstaller.Install(true)
+}
+
+// rollbackUninstallConfig returns the config used to uninstall the new version during a rollback.
+// Workloads are expected to be running and namespaces are kept, as the previous version is reinstalled
+// straight after.
+func rollbackUninstallConfig(uninstallConfig *apiv1.KubectlStorageOSConfig, installConfig *apiv1.KubectlStorageOSConfig) *apiv1.KubectlStorageOSConfig {
+	config := &apiv1.KubectlStorageOSConfig{}
+	config.Spec.Verbose = installConfig.Spec.Verbose
+	config.Spec.Serial = installConfig.Spec.Serial
+	config.Spec.AirGap = installConfig.Spec.AirGap
+	config.Spec.SkipStorageOSCluster = installConfig.Spec.SkipStorageOSCluster
+	config.Spec.SkipNamespaceDeletion = true
+	config.Spec.SkipExistingWorkloadCheck = true
+	config.Spec.Uninstall.StorageOSVersion = installConfig.Spec.Install.StorageOSVersion
+	config.Spec.Uninstall.PortalManagerVersion = installConfig.Spec.Install.PortalManagerVersion
+	config.Spec.Uninstall.StorageOSOperatorNamespace = installConfig.Spec.Install.StorageOSOperatorNamespace
+	config.Spec.Uninstall.StorageOSOperatorYaml = installConfig.Spec.Install.StorageOSOperatorYaml
+	config.Spec.Uninstall.StorageOSClusterYaml = installConfig.Spec.Install.StorageOSClusterYaml
+	config.Spec.Uninstall.StorageOSPortalConfigYaml = installConfig.Spec.Install.StorageOSPortalConfigYaml
+	config.Spec.Uninstall.StorageOSPortalClientSecretYaml = installConfig.Spec.Install.StorageOSPortalClientSecretYaml
+	config.Spec.Uninstall.ResourceQuotaYaml = installConfig.Spec.Install.ResourceQuotaYaml
+	config.Spec.Uninstall.RemovalTimeout = uninstallConfig.Spec.Uninstall.RemovalTimeout
+
+	return config
+}
+
+// rollbackInstallConfig returns the config used to reinstall the previous version during a rollback.
+func rollbackInstallConfig(uninstallConfig *apiv1.KubectlStorageOSConfig, installConfig *apiv1.KubectlStorageOSConfig) *apiv1.KubectlStorageOSConfig {
+	config := installConfig.DeepCopy()
+	config.Spec.Install.StorageOSVersion = uninstallConfig.Spec.Uninstall.StorageOSVersion
+	config.Spec.Install.StorageOSOperatorNamespace = uninstallConfig.Spec.Uninstall.StorageOSOperatorNamespace
+	config.Spec.Install.StorageOSOperatorYaml = uninstallConfig.Spec.Uninstall.StorageOSOperatorYaml
+	config.Spec.Install.StorageOSPortalConfigYaml = uninstallConfig.Spec.Uninstall.StorageOSPortalConfigYaml
+	config.Spec.Install.StorageOSPortalClientSecretYaml = uninstallConfig.Spec.Uninstall.StorageOSPortalClientSecretYaml
+	config.Spec.Install.ResourceQuotaYaml = uninstallConfig.Spec.Uninstall.ResourceQuotaYaml
+	config.Spec.Install.PortalManagerVersion = uninstallConfig.Spec.Uninstall.PortalManagerVersion
+	config.Spec.Install.EnablePortalManager = uninstallConfig.Spec.Uninstall.PortalManagerVersion != ""
+	// the previous cluster is restored from backup, along with its credentials
+	config.Spec.Install.StorageOSClusterYaml = ""
+	config.Spec.Install.AdminUsername = ""
+	config.Spec.Install.AdminPassword = ""
+	// endpoints have already been validated by the upgrade
+	config.Spec.Install.SkipEtcdEndpointsValidation = true
+	config.InstallerMeta.StorageOSSecretYaml = uninstallConfig.InstallerMeta.StorageOSSecretYaml
+
+	return config
+}
+
+// prepareForUpgrade writes the storageos cluster, secrets and storageclasses to disk and protects the
+// objects that must survive the uninstall with a finalizer.
+func (in *Installer) prepareForUpgrade(versionToUninstall string) error {
 	// write storageoscluster, secret and storageclass manifests to disk
 	if err := in.writeBackupFileSystem(in.storageOSCluster); err != nil {
 		return errors.WithStack(err)
@@ -109,6 +279,12 @@ func (in *Installer) prepareForUpgrade(installConfig *apiv1.KubectlStorageOSConf
 		}
 	}
 
+	return nil
+}
+
+// loadUpgradeBackup copies the backed-up storageos cluster and secret data into the installer, so that
+// the new version is installed with the specs of the original cluster.
+func (in *Installer) loadUpgradeBackup(installConfig *apiv1.KubectlStorageOSConfig, installer *Installer) error {
 	// if no storageos-cluster.yaml has been passed to the cli, use the backed-up storageos cluster.
 	if installConfig.Spec.Install.StorageOSClusterYaml == "" {
 		if err := in.copyStorageOSClusterToMemory(installer); err != nil {
@@ -118,9 +294,7 @@ func (in *Installer) prepareForUpgrade(installConfig *apiv1.KubectlStorageOSConf
 	// discover uninstalled secret username and password for upgrade. Here we use (1) the (un)installer
 	// as it contains the on-disk FS of the uninstalled secrets and (2) the installConfig so we can
 	// set secret username and password in the secret manifest to be installed later
-	err = in.copyStorageOSSecretData(installConfig)
-
-	return err
+	return in.copyStorageOSSecretData(installConfig)
 }
 
 // copyStorageOSClusterToMemory takes the (uninstalled) on-disk storageos-cluster manifest and combines it with
@@ -147,10 +321,16 @@ func (in *Installer) copyStorageOSClusterToMemory(installer *Installer) error {
 		return err
 	}
 
-	stosClusterManifest := makeMultiDoc(string(onDiskStosClusterManifest), inMemStosAPISecret)
+	return installer.useStorageOSClusterManifest(string(onDiskStosClusterManifest), inMemStosAPISecret)
+}
+
+// useStorageOSClusterManifest writes the storageos cluster of stosClusterManifest, along with the
+// storageos-api secret, to the in-memory fs for installation. The images of the original cluster are
+// removed, so that those of the version installed are used.
+func (in *Installer) useStorageOSClusterManifest(stosClusterManifest, stosAPISecret string) error {
+	stosClusterManifest = makeMultiDoc(stosClusterManifest, stosAPISecret)
 
-	// write uninstalled manifest to installer filesystem
-	if err = installer.fileSys.WriteFile(filepath.Join(stosDir, clusterDir, stosClusterFile), []byte(stosClusterManifest)); err != nil {
+	if err := in.fileSys.WriteFile(filepath.Join(stosDir, clusterDir, stosClusterFile), []byte(stosClusterManifest)); err != nil {
 		return err
 	}
 
@@ -164,7 +344,7 @@ func (in *Installer) copyStorageOSClusterToMemory(installer *Installer) error {
 		Path: "/spec/images",
 	}
 
-	return installer.addPatchesToFSKustomize(filepath.Join(stosDir, clusterDir, kustomizationFile), stosClusterKind, clusterName, []pluginutils.KustomizePatch{removeImagesPatch})
+	return in.addPatchesToFSKustomize(filepath.Join(stosDir, clusterDir, kustomizationFile), stosClusterKind, clusterName, []pluginutils.KustomizePatch{removeImagesPatch})
 }
 
 // copyStorageOSAPIData uses the (un)installer's on-disk filesystem to read the username and password
@@ -266,9 +446,9 @@ func (in *Installer) copyStorageOSSecretData(installConfig *apiv1.KubectlStorage
 	if err != nil {
 		return err
 	}
-	stosSecrets, err := in.onDiskFileSys.ReadFile(filepath.Join(backupPath, stosSecretsFile))
+	stosSecrets, err := in.readBackupFile(filepath.Join(backupPath, stosSecretsFile))
 	if err != nil {
-		return errors.WithStack(err)
+		return err
 	}
 
 	if err := in.copyStorageOSAPIData(installConfig, string(stosSecrets)); err != nil {
@@ -307,12 +487,17 @@ func (in *Installer) applyBackupManifestWithFinalizer(file string) error {
 		return err
 	}
 
-	multidoc, err := in.onDiskFileSys.ReadFile(filepath.Join(backupPath, file))
+	multidoc, err := in.readBackupFile(filepath.Join(backupPath, file))
 	if err != nil {
-		return errors.WithStack(err)
+		return err
 	}
 
-	manifests := splitMultiDoc(string(multidoc))
+	return in.applyManifestsWithFinalizer(splitMultiDoc(string(multidoc)))
+}
+
+// applyManifestsWithFinalizer applies manifests with the storageos finalizer, unless they already have
+// finalizers set
+func (in *Installer) applyManifestsWithFinalizer(manifests []string) error {
 	for _, manifest := range manifests {
 		// if a finalizer already exists for this object, continue.
 		// This may be the case if an upgrade has already occurred.
@@ -330,7 +515,7 @@ func (in *Installer) applyBackupManifestWithFinalizer(file string) error {
 			return err
 		}
 
-		if err = in.kubectlClient.Apply(context.TODO(), "", string(manifestWithFinaliser), true); err != nil {
+		if err = in.apply("", string(manifestWithFinaliser)); err != nil {
 			return errors.WithStack(err)
 		}
 	}
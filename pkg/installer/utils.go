@@ -1,20 +1,23 @@
 package installer
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/mholt/archiver"
 	"github.com/pkg/errors"
 
 	gyaml "github.com/ghodss/yaml"
 	gocontainerv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	corev1 "k8s.io/api/core/v1"
 	kstoragev1 "k8s.io/api/storage/v1"
 	"sigs.k8s.io/kustomize/kyaml/filesys"
 
+	"github.com/storageos/kubectl-storageos/pkg/cache"
 	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
 	pluginversion "github.com/storageos/kubectl-storageos/pkg/version"
 	operatorapi "github.com/storageos/operator/api/v1"
@@ -71,13 +74,23 @@ func createDirAndFiles(fs filesys.FileSystem, fsData fsData) (filesys.FileSystem
 	return fs, nil
 }
 
-// pullManifest returns a string of contents at url
+// pullManifest returns a string of contents at url. Only manifests of storageos releases are cached
+// for repeated pulls, as the content at any other url, eg of develop builds, may change.
 func pullManifest(url string) (string, error) {
 	if !pluginutils.IsURL(url) {
 		return "", errors.WithStack(fmt.Errorf("%s is not a URL and was not found", url))
 	}
 
-	contents, err := pluginutils.FetchHttpContent(url, nil)
+	fetch := func() ([]byte, error) {
+		return pluginutils.FetchHttpContent(url, nil)
+	}
+	var contents []byte
+	var err error
+	if pluginversion.IsReleaseManifestURL(url) {
+		contents, err = cache.Fetch(cache.KindManifest, url, fetch)
+	} else {
+		contents, err = fetch()
+	}
 	if err != nil {
 		return "", errors.WithStack(err)
 	}
@@ -104,14 +117,31 @@ func fetchImageAndExtractFileFromTarball(imageURL, filePath string) (string, err
 	return extractFileFromImage(image, filePath)
 }
 
-// extractFileFromImage creates a tarball from an OCI image and returns the file at filePath
+// OperatorMinKubernetesVersion returns the minimum version of Kubernetes supported by operatorVersion,
+// read from its manifests image. Versions before 2.5.0 don't declare a minimum version.
+func OperatorMinKubernetesVersion(operatorVersion string) (string, error) {
+	minVersion, err := fetchImageAndExtractFileFromTarball(pluginversion.OperatorManifestsImageURL(operatorVersion), "MIN_KUBE_VERSION")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(minVersion), nil
+}
+
+// extractFileFromImage returns the file at filePath of the filesystem of an OCI image. Files are cached
+// by image digest, as reading one streams the layers of the image.
 func extractFileFromImage(image gocontainerv1.Image, filePath string) (string, error) {
-	exported, err := pluginutils.ExportTarball(image)
+	digest, err := image.Digest()
 	if err != nil {
 		return "", errors.WithStack(err)
 	}
 
-	file, err := pluginutils.ExtractFile(filePath, bytes.NewReader(exported.Bytes()))
+	file, err := cache.Fetch(cache.KindImageFile, digest.String()+"/"+filePath, func() ([]byte, error) {
+		filesystem := mutate.Extract(image)
+		defer filesystem.Close()
+
+		return pluginutils.ExtractFile(filePath, filesystem)
+	})
 	if err != nil {
 		return "", errors.WithStack(err)
 	}
@@ -338,3 +368,34 @@ func FlagsAreSet(flags map[string]string) error {
 
 	return fmt.Errorf(errStr)
 }
+
+// archiveDir writes the files of dir to a tar.gz archive at archivePath.
+func archiveDir(dir, archivePath string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		paths = append(paths, filepath.Join(dir, entry.Name()))
+	}
+
+	tarGz := archiver.TarGz{
+		Tar: &archiver.Tar{
+			ImplicitTopLevelFolder: false,
+		},
+	}
+
+	return errors.Wrapf(tarGz.Archive(paths, archivePath), "create archive %s", archivePath)
+}
+
+// unarchiveDir extracts the tar.gz archive at archivePath to dir.
+func unarchiveDir(archivePath, dir string) error {
+	tarGz := archiver.TarGz{
+		Tar: &archiver.Tar{
+			ImplicitTopLevelFolder: false,
+		},
+	}
+
+	return errors.Wrapf(tarGz.Unarchive(archivePath, dir), "extract archive %s", archivePath)
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/manifoldco/promptui"
 )
@@ -15,6 +16,14 @@ type Logger struct {
 	writerMu      sync.Mutex
 	smartTerminal bool
 	Verbose       bool
+
+	// output is the output format, see SetOutput.
+	output string
+	now    func() time.Time
+	// components, warnings and errors are recorded for the result document.
+	components []Component
+	warnings   []string
+	errors     []string
 }
 
 func NewLogger() *Logger {
@@ -22,46 +31,69 @@ func NewLogger() *Logger {
 		Writer:        os.Stdout,
 		smartTerminal: IsSmartTerminal(os.Stdout),
 		Verbose:       false,
+		output:        OutputText,
+		now:           time.Now,
 	}
 }
 
 func (l *Logger) Prompt(message string) {
+	if l.logEvent(LevelPrompt, message) {
+		return
+	}
 	l.println(l.formatPrompt(message))
 }
 
 func (l *Logger) Info(message string) {
-	if l.Verbose {
+	if l.Verbose && !l.logEvent(LevelInfo, message) {
 		l.println(message)
 	}
 }
 
 func (l *Logger) Infof(message string, args ...interface{}) {
-	if l.Verbose {
+	if l.Verbose && !l.logEvent(LevelInfo, message, args...) {
 		l.println(message, args...)
 	}
 }
 
 func (l *Logger) Warn(message string) {
+	if l.logEvent(LevelWarning, message) {
+		return
+	}
 	l.println(l.formatWithIcon(promptui.IconWarn, message))
 }
 
 func (l *Logger) Warnf(message string, args ...interface{}) {
+	if l.logEvent(LevelWarning, message, args...) {
+		return
+	}
 	l.println(l.formatWithIcon(promptui.IconWarn, message), args...)
 }
 
 func (l *Logger) Error(message string) {
+	if l.logEvent(LevelError, message) {
+		return
+	}
 	l.println(l.formatWithIcon(promptui.IconBad, message))
 }
 
 func (l *Logger) Errorf(message string, args ...interface{}) {
+	if l.logEvent(LevelError, message, args...) {
+		return
+	}
 	l.println(l.formatWithIcon(promptui.IconBad, message), args...)
 }
 
 func (l *Logger) Success(message string) {
+	if l.logEvent(LevelSuccess, message) {
+		return
+	}
 	l.println(l.formatWithIcon(promptui.IconGood, message))
 }
 
 func (l *Logger) Successf(message string, args ...interface{}) {
+	if l.logEvent(LevelSuccess, message, args...) {
+		return
+	}
 	l.println(l.formatWithIcon(promptui.IconGood, message), args...)
 }
 
@@ -91,6 +123,9 @@ func (l *Logger) formatWithIcon(icon, message string) string {
 
 func (l *Logger) Commencing(command string) {
 	commencingMessage := fmt.Sprintf("Commencing %s, this may take a few moments.", command)
+	if l.logEvent(LevelInfo, commencingMessage) {
+		return
+	}
 	if l.smartTerminal {
 		timer := ("⏳")
 		commencingMessage = promptui.Styler(promptui.FGBold)(commencingMessage)
@@ -0,0 +1,193 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Output formats of the logger. Text is meant for humans, JSON and YAML emit a stream of events and a
+// final result document for machines.
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+)
+
+// Types of the documents emitted in structured output mode.
+const (
+	EventTypeLog       = "log"
+	EventTypeComponent = "component"
+	EventTypeResult    = "result"
+)
+
+// Levels of log events.
+const (
+	LevelInfo    = "info"
+	LevelWarning = "warning"
+	LevelError   = "error"
+	LevelSuccess = "success"
+	LevelPrompt  = "prompt"
+)
+
+// Actions performed on components.
+const (
+	ComponentApplied  = "applied"
+	ComponentDeleted  = "deleted"
+	ComponentRendered = "rendered"
+)
+
+// Event is a document of the event stream emitted in structured output mode.
+type Event struct {
+	Type      string     `json:"type"`
+	Time      time.Time  `json:"time"`
+	Level     string     `json:"level,omitempty"`
+	Message   string     `json:"message,omitempty"`
+	Component *Component `json:"component,omitempty"`
+}
+
+// Component is a set of manifests applied or deleted by a lifecycle command.
+type Component struct {
+	Name      string `json:"name"`
+	Action    string `json:"action"`
+	Version   string `json:"version,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Result is the final document emitted by a lifecycle command in structured output mode.
+type Result struct {
+	Type       string      `json:"type"`
+	Time       time.Time   `json:"time"`
+	Command    string      `json:"command"`
+	Succeeded  bool        `json:"succeeded"`
+	Message    string      `json:"message"`
+	Components []Component `json:"components"`
+	Warnings   []string    `json:"warnings"`
+	Errors     []string    `json:"errors"`
+}
+
+// SetOutput sets the output format of the logger to one of text, json or yaml. An empty format is text.
+func (l *Logger) SetOutput(format string) error {
+	switch format {
+	case "":
+		format = OutputText
+	case OutputText, OutputJSON, OutputYAML:
+	default:
+		return fmt.Errorf("unknown output format %q, expected %q, %q or %q", format, OutputText, OutputJSON, OutputYAML)
+	}
+
+	l.writerMu.Lock()
+	defer l.writerMu.Unlock()
+	l.output = format
+	if l.now == nil {
+		l.now = time.Now
+	}
+
+	return nil
+}
+
+// Structured returns true if the logger emits json or yaml documents instead of text.
+func (l *Logger) Structured() bool {
+	return l.output == OutputJSON || l.output == OutputYAML
+}
+
+// Component records that component has been applied or deleted, to be listed by Result. An event is
+// emitted for it in structured output mode.
+func (l *Logger) Component(component Component) {
+	l.writerMu.Lock()
+	l.components = append(l.components, component)
+	l.writerMu.Unlock()
+
+	if l.Structured() {
+		l.emit(Event{Type: EventTypeComponent, Time: l.now(), Component: &component})
+		return
+	}
+	l.Infof("%s %s.", component.Name, component.Action)
+}
+
+// Result reports the outcome of command. In text output mode, successMessage is printed on success and
+// a failure line otherwise. In structured output mode, the result document is emitted, listing the
+// components recorded, along with every warning and error logged.
+func (l *Logger) Result(command string, err error, successMessage string) {
+	if !l.Structured() {
+		if err != nil {
+			l.Error(fmt.Sprintf("%s%s", command, " has failed"))
+			return
+		}
+		l.Success(successMessage)
+		return
+	}
+
+	result := Result{
+		Type:       EventTypeResult,
+		Time:       l.now(),
+		Command:    command,
+		Succeeded:  err == nil,
+		Message:    successMessage,
+		Components: []Component{},
+		Warnings:   []string{},
+		Errors:     []string{},
+	}
+	if err != nil {
+		result.Message = fmt.Sprintf("%s%s", command, " has failed")
+	}
+
+	l.writerMu.Lock()
+	result.Components = append(result.Components, l.components...)
+	result.Warnings = append(result.Warnings, l.warnings...)
+	result.Errors = append(result.Errors, l.errors...)
+	l.writerMu.Unlock()
+
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	l.emit(result)
+}
+
+// logEvent records warnings and errors for the result, and emits a log event in structured output
+// mode. It returns false in text output mode, for the caller to print message itself.
+func (l *Logger) logEvent(level, message string, args ...interface{}) bool {
+	if len(args) > 0 {
+		message = fmt.Sprintf(message, args...)
+	}
+
+	l.writerMu.Lock()
+	switch level {
+	case LevelWarning:
+		l.warnings = append(l.warnings, message)
+	case LevelError:
+		l.errors = append(l.errors, message)
+	}
+	l.writerMu.Unlock()
+
+	if !l.Structured() {
+		return false
+	}
+	l.emit(Event{Type: EventTypeLog, Time: l.now(), Level: level, Message: message})
+
+	return true
+}
+
+// emit writes document to the logger's writer, as a line of json or a yaml document.
+func (l *Logger) emit(document interface{}) {
+	var data []byte
+	var err error
+	if l.output == OutputYAML {
+		data, err = yaml.Marshal(document)
+		data = append([]byte("---\n"), data...)
+	} else {
+		data, err = json.Marshal(document)
+		data = append(data, '\n')
+	}
+	if err != nil {
+		// documents are plain structs, this can't happen in practice.
+		data = []byte(fmt.Sprintf("%v\n", err))
+	}
+
+	l.writerMu.Lock()
+	defer l.writerMu.Unlock()
+	_, _ = l.Writer.Write(data)
+}
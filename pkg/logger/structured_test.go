@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update golden files in test-data")
+
+func TestStructuredOutput(t *testing.T) {
+	tcases := []struct {
+		name   string
+		output string
+		err    error
+	}{
+		{
+			name:   "text-success",
+			output: OutputText,
+		},
+		{
+			name:   "text-failure",
+			output: OutputText,
+			err:    errors.New("failed to apply storageos cluster"),
+		},
+		{
+			name:   "json-success",
+			output: OutputJSON,
+		},
+		{
+			name:   "json-failure",
+			output: OutputJSON,
+			err:    errors.New("failed to apply storageos cluster"),
+		},
+		{
+			name:   "yaml-success",
+			output: OutputYAML,
+		},
+		{
+			name:   "yaml-failure",
+			output: OutputYAML,
+			err:    errors.New("failed to apply storageos cluster"),
+		},
+	}
+
+	for _, tc := range tcases {
+		buf := &bytes.Buffer{}
+		log := &Logger{Writer: buf, Verbose: true}
+		if err := log.SetOutput(tc.output); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		log.now = func() time.Time {
+			return time.Date(2022, time.May, 4, 12, 0, 0, 0, time.UTC)
+		}
+
+		log.Commencing("install")
+		log.Warnf("%s is deprecated", "--etcd-tls-enabled")
+		log.Component(Component{Name: "storageos-operator", Action: ComponentApplied, Version: "v2.7.0", Namespace: "storageos"})
+		log.Component(Component{Name: "storageos-cluster", Action: ComponentApplied, Version: "v2.7.0", Namespace: "storageos"})
+		log.Result("install", tc.err, "StorageOS installed successfully.")
+
+		golden := filepath.Join("test-data", tc.name+".golden")
+		if *update {
+			if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+		}
+		expected, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !bytes.Equal(buf.Bytes(), expected) {
+			t.Errorf("%s: expected %s, got %s", tc.name, expected, buf.Bytes())
+		}
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	tcases := []struct {
+		output     string
+		structured bool
+		expectErr  bool
+	}{
+		{output: "", structured: false},
+		{output: OutputText, structured: false},
+		{output: OutputJSON, structured: true},
+		{output: OutputYAML, structured: true},
+		{output: "xml", expectErr: true},
+	}
+
+	for _, tc := range tcases {
+		log := NewLogger()
+		err := log.SetOutput(tc.output)
+		if tc.expectErr != (err != nil) {
+			t.Errorf("expected error %v, got %v", tc.expectErr, err)
+			continue
+		}
+		if log.Structured() != tc.structured {
+			t.Errorf("expected %v, got %v", tc.structured, log.Structured())
+		}
+	}
+}
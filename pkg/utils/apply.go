@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+// ApplyConflictError is returned by server-side apply when fields of an object are owned by other
+// field managers.
+type ApplyConflictError struct {
+	Kind      string
+	Name      string
+	Conflicts []string
+}
+
+// Error generates error message
+func (e ApplyConflictError) Error() string {
+	return fmt.Sprintf("apply of %s %s conflicts with fields owned by other managers:\n\t%s", e.Kind, e.Name, strings.Join(e.Conflicts, "\n\t"))
+}
+
+// ServerSideApply applies each object of multi-doc manifest with server-side apply as fieldManager.
+// Namespaced objects without a namespace are applied to namespace, or to the default namespace. Fields
+// owned by other field managers are only taken over if force is set, an ApplyConflictError is returned
+// otherwise.
+func ServerSideApply(config *rest.Config, namespace, multiDoc, fieldManager string, force bool) error {
+	objs, err := manifest.ParseObjects(context.Background(), multiDoc)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+
+	dynamicClient, mapper, err := newDynamicClient(config)
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range objs.Items {
+		if _, err = serverSideApplyObject(dynamicClient, mapper, obj.UnstructuredObject(), namespace, fieldManager, force, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newDynamicClient returns a dynamic client and a REST mapper for config.
+func newDynamicClient(config *rest.Config) (dynamic.Interface, *restmapper.DeferredDiscoveryRESTMapper, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	return dynamicClient, restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient)), nil
+}
+
+// resourceFor returns the dynamic resource interface of obj. Namespaced objects without a namespace are
+// set to namespace. The mapper is reset once if the kind of obj is unknown, as its CRD may have just been
+// applied.
+func resourceFor(dynamicClient dynamic.Interface, mapper *restmapper.DeferredDiscoveryRESTMapper, obj *unstructured.Unstructured, namespace string) (dynamic.ResourceInterface, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		mapper.Reset()
+		mapping, err = mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return dynamicClient.Resource(mapping.Resource), nil
+	}
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(namespace)
+	}
+
+	return dynamicClient.Resource(mapping.Resource).Namespace(obj.GetNamespace()), nil
+}
+
+// serverSideApplyObject applies obj with server-side apply, returning the object applied. Nothing is
+// persisted if dryRun is set.
+func serverSideApplyObject(dynamicClient dynamic.Interface, mapper *restmapper.DeferredDiscoveryRESTMapper, obj *unstructured.Unstructured, namespace, fieldManager string, force, dryRun bool) (*unstructured.Unstructured, error) {
+	resource, err := resourceFor(dynamicClient, mapper, obj, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	options := metav1.PatchOptions{
+		FieldManager: fieldManager,
+		Force:        &force,
+	}
+	if dryRun {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+	applied, err := resource.Patch(context.TODO(), obj.GetName(), types.ApplyPatchType, data, options)
+	if kerrors.IsConflict(err) {
+		return nil, errors.WithStack(ApplyConflictError{
+			Kind:      obj.GetKind(),
+			Name:      obj.GetName(),
+			Conflicts: applyConflicts(err),
+		})
+	}
+
+	return applied, errors.WithStack(err)
+}
+
+// applyConflicts returns the conflicting fields and their managers of a server-side apply conflict error.
+func applyConflicts(err error) []string {
+	status, ok := err.(kerrors.APIStatus)
+	if !ok || status.Status().Details == nil || len(status.Status().Details.Causes) == 0 {
+		return []string{err.Error()}
+	}
+
+	conflicts := []string{}
+	for _, cause := range status.Status().Details.Causes {
+		conflicts = append(conflicts, cause.Message)
+	}
+
+	return conflicts
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplyConflicts(t *testing.T) {
+	tcases := []struct {
+		name     string
+		err      error
+		expected []string
+	}{
+		{
+			name: "conflict causes",
+			err: kerrors.NewApplyConflict([]metav1.StatusCause{
+				{Type: metav1.CauseTypeFieldManagerConflict, Message: `conflict with "argocd-controller": .spec.kvBackend.address`, Field: ".spec.kvBackend.address"},
+				{Type: metav1.CauseTypeFieldManagerConflict, Message: `conflict with "argocd-controller": .spec.images.nodeContainer`, Field: ".spec.images.nodeContainer"},
+			}, "Apply failed with 2 conflicts"),
+			expected: []string{
+				`conflict with "argocd-controller": .spec.kvBackend.address`,
+				`conflict with "argocd-controller": .spec.images.nodeContainer`,
+			},
+		},
+		{
+			name:     "conflict without causes",
+			err:      kerrors.NewApplyConflict(nil, "Apply failed"),
+			expected: []string{"Apply failed"},
+		},
+		{
+			name:     "not an api error",
+			err:      errors.New("connection refused"),
+			expected: []string{"connection refused"},
+		},
+	}
+
+	for _, tc := range tcases {
+		conflicts := applyConflicts(tc.err)
+		if !reflect.DeepEqual(conflicts, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, conflicts)
+		}
+	}
+}
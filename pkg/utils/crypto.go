@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// encryptedFileHeader is the first line of every file written by Encrypt, it is used to tell
+	// encrypted files apart from plaintext ones.
+	encryptedFileHeader  = "# kubectl-storageos encrypted file\n"
+	encryptedFileVersion = "scrypt-xchacha20poly1305-v1"
+
+	// scrypt parameters recommended for interactive use.
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptSaltSz = 16
+)
+
+// encryptedFile is the on-disk form of data encrypted by Encrypt.
+type encryptedFile struct {
+	Version string `json:"version"`
+	// KeySource records where the passphrase used for encryption comes from, so that it can be
+	// found again for decryption.
+	KeySource string `json:"keySource"`
+	Salt      []byte `json:"salt"`
+	Nonce     []byte `json:"nonce"`
+	Data      []byte `json:"data"`
+}
+
+// IsEncrypted returns true if data has been written by Encrypt.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedFileHeader))
+}
+
+// Encrypt encrypts plaintext with a key derived from passphrase. keySource is stored in the clear
+// alongside the encrypted data and can be retrieved with EncryptedKeySource.
+func Encrypt(plaintext, passphrase []byte, keySource string) ([]byte, error) {
+	salt := make([]byte, scryptSaltSz)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	data, err := yaml.Marshal(encryptedFile{
+		Version:   encryptedFileVersion,
+		KeySource: keySource,
+		Salt:      salt,
+		Nonce:     nonce,
+		Data:      aead.Seal(nil, nonce, plaintext, []byte(keySource)),
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return append([]byte(encryptedFileHeader), data...), nil
+}
+
+// EncryptedKeySource returns the key source recorded by Encrypt in data.
+func EncryptedKeySource(data []byte) (string, error) {
+	file, err := parseEncryptedFile(data)
+	if err != nil {
+		return "", err
+	}
+
+	return file.KeySource, nil
+}
+
+// Decrypt returns the plaintext of data written by Encrypt with passphrase.
+func Decrypt(data, passphrase []byte) ([]byte, error) {
+	file, err := parseEncryptedFile(data)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(passphrase, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := aead.Open(nil, file.Nonce, file.Data, []byte(file.KeySource))
+	if err != nil {
+		return nil, errors.New("unable to decrypt file, the passphrase may be incorrect")
+	}
+
+	return plaintext, nil
+}
+
+func parseEncryptedFile(data []byte) (*encryptedFile, error) {
+	if !IsEncrypted(data) {
+		return nil, errors.New("file is not encrypted")
+	}
+
+	file := &encryptedFile{}
+	if err := yaml.Unmarshal(bytes.TrimPrefix(data, []byte(encryptedFileHeader)), file); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if file.Version != encryptedFileVersion {
+		return nil, fmt.Errorf("encrypted file version %q is not supported, expected %q", file.Version, encryptedFileVersion)
+	}
+
+	return file, nil
+}
+
+// newAEAD returns an XChaCha20-Poly1305 AEAD keyed with the scrypt derivation of passphrase and salt.
+func newAEAD(passphrase, salt []byte) (cipher.AEAD, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase must not be empty")
+	}
+
+	key, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return aead, nil
+}
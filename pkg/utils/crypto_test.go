@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	plaintext := []byte("apiVersion: v1\nkind: Secret\n")
+
+	encrypted, err := Encrypt(plaintext, []byte("passphrase"), "env")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !IsEncrypted(encrypted) {
+		t.Errorf("expected encrypted data to be detected")
+	}
+	if IsEncrypted(plaintext) {
+		t.Errorf("expected plaintext not to be detected as encrypted")
+	}
+	if bytes.Contains(encrypted, plaintext) {
+		t.Errorf("expected plaintext not to appear in encrypted data")
+	}
+
+	keySource, err := EncryptedKeySource(encrypted)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if keySource != "env" {
+		t.Errorf("expected %v, got %v", "env", keySource)
+	}
+
+	decrypted, err := Decrypt(encrypted, []byte("passphrase"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("expected %s, got %s", plaintext, decrypted)
+	}
+
+	if _, err = Decrypt(encrypted, []byte("wrong")); err == nil {
+		t.Errorf("expected error decrypting with wrong passphrase")
+	}
+	if _, err = Encrypt(plaintext, nil, "env"); err == nil {
+		t.Errorf("expected error encrypting with empty passphrase")
+	}
+}
@@ -0,0 +1,303 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+// Actions of an object diff.
+const (
+	DiffCreate = "create"
+	DiffUpdate = "update"
+	DiffDelete = "delete"
+)
+
+// lastAppliedAnnotation is set by client-side apply, it changes with any other field.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// ObjectDiff holds the changes that applying an object would make to its live state.
+type ObjectDiff struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+	Action     string
+	Changes    []FieldChange
+}
+
+// FieldChange is a change of the value of the field at Path. Live is empty for added fields, Applied is
+// empty for removed fields. Values are json encoded, Secret data is replaced by a digest.
+type FieldChange struct {
+	Path    string
+	Live    string
+	Applied string
+}
+
+// DiffManifest returns the changes that applying each object of multi-doc manifest as fieldManager would
+// make to the live objects, predicted with a server-side dry-run apply. Objects that would be unchanged
+// are omitted. Namespaced objects without a namespace are compared to those of namespace, or of the
+// default namespace.
+func DiffManifest(config *rest.Config, namespace, multiDoc, fieldManager string) ([]ObjectDiff, error) {
+	objs, err := manifest.ParseObjects(context.Background(), multiDoc)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+
+	dynamicClient, mapper, err := newDynamicClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := []ObjectDiff{}
+	for _, item := range objs.Items {
+		obj := item.UnstructuredObject()
+		diff := ObjectDiff{
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Name:       obj.GetName(),
+			Action:     DiffUpdate,
+		}
+
+		resource, err := resourceFor(dynamicClient, mapper, obj, namespace)
+		if err != nil {
+			// the CRD of obj is yet to be applied, and so is obj.
+			if !meta.IsNoMatchError(errors.Cause(err)) {
+				return nil, err
+			}
+			diff.Namespace = obj.GetNamespace()
+			diff.Action = DiffCreate
+			diff.Changes = DiffObjects(nil, obj)
+			diffs = append(diffs, diff)
+			continue
+		}
+		diff.Namespace = obj.GetNamespace()
+
+		live, err := resource.Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			diff.Action = DiffCreate
+			diff.Changes = DiffObjects(nil, obj)
+			diffs = append(diffs, diff)
+			continue
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		applied, err := serverSideApplyObject(dynamicClient, mapper, obj, namespace, fieldManager, true, true)
+		if err != nil {
+			return nil, err
+		}
+		if diff.Changes = DiffObjects(live, applied); len(diff.Changes) > 0 {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	return diffs, nil
+}
+
+// DiffManifestRemovals returns the live objects of the multi-doc manifests removed which are in none of
+// the multi-doc manifests kept, as deleted. Namespaced objects without a namespace are looked up in
+// namespace, or in the default namespace.
+func DiffManifestRemovals(config *rest.Config, namespace string, removed, kept []string) ([]ObjectDiff, error) {
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+
+	keptObjects := map[string]bool{}
+	for _, multiDoc := range kept {
+		objs, err := manifest.ParseObjects(context.Background(), multiDoc)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, item := range objs.Items {
+			keptObjects[objectKey(item.UnstructuredObject())] = true
+		}
+	}
+
+	dynamicClient, mapper, err := newDynamicClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := []ObjectDiff{}
+	for _, multiDoc := range removed {
+		objs, err := manifest.ParseObjects(context.Background(), multiDoc)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, item := range objs.Items {
+			obj := item.UnstructuredObject()
+			if keptObjects[objectKey(obj)] {
+				continue
+			}
+
+			resource, err := resourceFor(dynamicClient, mapper, obj, namespace)
+			if err != nil {
+				// the CRD of obj is gone, and so is obj.
+				if meta.IsNoMatchError(errors.Cause(err)) {
+					continue
+				}
+				return nil, err
+			}
+			live, err := resource.Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			diffs = append(diffs, ObjectDiff{
+				APIVersion: obj.GetAPIVersion(),
+				Kind:       obj.GetKind(),
+				Namespace:  obj.GetNamespace(),
+				Name:       obj.GetName(),
+				Action:     DiffDelete,
+				Changes:    DiffObjects(live, nil),
+			})
+		}
+	}
+
+	return diffs, nil
+}
+
+// objectKey identifies obj across versions of its kind.
+func objectKey(obj *unstructured.Unstructured) string {
+	return strings.Join([]string{obj.GroupVersionKind().Group, obj.GetKind(), obj.GetNamespace(), obj.GetName()}, "/")
+}
+
+// DiffObjects returns the changes of the fields of live to those of applied, sorted by path. A nil live
+// object has all fields of applied added, a nil applied object has all fields of live removed. Status and
+// fields maintained by the API server are ignored.
+func DiffObjects(live, applied *unstructured.Unstructured) []FieldChange {
+	liveFields, appliedFields := map[string]string{}, map[string]string{}
+	if live != nil {
+		flattenFields("", comparableObject(live), liveFields)
+	}
+	if applied != nil {
+		flattenFields("", comparableObject(applied), appliedFields)
+	}
+
+	paths := []string{}
+	for path := range liveFields {
+		paths = append(paths, path)
+	}
+	for path := range appliedFields {
+		if _, ok := liveFields[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	changes := []FieldChange{}
+	for _, path := range paths {
+		if liveFields[path] != appliedFields[path] {
+			changes = append(changes, FieldChange{Path: path, Live: liveFields[path], Applied: appliedFields[path]})
+		}
+	}
+
+	return changes
+}
+
+// comparableObject returns the content of obj without status and the metadata maintained by the API
+// server. The data of secrets is replaced by a digest of it.
+func comparableObject(obj *unstructured.Unstructured) map[string]interface{} {
+	content := obj.DeepCopy().UnstructuredContent()
+	delete(content, "status")
+	if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"managedFields", "resourceVersion", "generation", "creationTimestamp", "uid", "selfLink"} {
+			delete(metadata, field)
+		}
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, lastAppliedAnnotation)
+			if len(annotations) == 0 {
+				delete(metadata, "annotations")
+			}
+		}
+	}
+
+	if obj.GetKind() != "Secret" || obj.GetAPIVersion() != "v1" {
+		return content
+	}
+	data, ok := content["data"].(map[string]interface{})
+	if !ok {
+		data = map[string]interface{}{}
+	}
+	// stringData is merged into data by the API server
+	if stringData, ok := content["stringData"].(map[string]interface{}); ok {
+		for key, value := range stringData {
+			data[key] = base64.StdEncoding.EncodeToString([]byte(fmt.Sprint(value)))
+		}
+		delete(content, "stringData")
+	}
+	for key, value := range data {
+		data[key] = sensitiveDigest(value)
+	}
+	if len(data) > 0 {
+		content["data"] = data
+	}
+
+	return content
+}
+
+// sensitiveDigest returns a short digest of value, to tell sensitive values apart without revealing them.
+func sensitiveDigest(value interface{}) string {
+	sum := sha256.Sum256([]byte(fmt.Sprint(value)))
+	return fmt.Sprintf("(sensitive sha256:%x)", sum[:6])
+}
+
+// flattenFields sets the json encoded value of each leaf field of value to fields, by path.
+func flattenFields(path string, value interface{}, fields map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 && path != "" {
+			fields[path] = "{}"
+		}
+		for key, field := range v {
+			flattenFields(fieldPath(path, key), field, fields)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			fields[path] = "[]"
+		}
+		for i, item := range v {
+			flattenFields(path+"["+strconv.Itoa(i)+"]", item, fields)
+		}
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			data = []byte(fmt.Sprint(v))
+		}
+		fields[path] = string(data)
+	}
+}
+
+// fieldPath returns the path of field key of the object at path. Keys that are not plain identifiers,
+// such as those of labels, are quoted.
+func fieldPath(path, key string) string {
+	if strings.ContainsAny(key, "./[]") {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
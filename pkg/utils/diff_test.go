@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestDiffObjects(t *testing.T) {
+	tcases := []struct {
+		name     string
+		live     *unstructured.Unstructured
+		applied  *unstructured.Unstructured
+		expected []FieldChange
+	}{
+		{
+			name: "changed, added and removed fields",
+			live: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]interface{}{
+					"name":            "storageos-operator",
+					"resourceVersion": "1234",
+					"labels":          map[string]interface{}{"app.kubernetes.io/name": "storageos"},
+				},
+				"spec": map[string]interface{}{
+					"replicas": int64(1),
+					"template": map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+						map[string]interface{}{"name": "operator", "image": "storageos/operator:v2.6.0", "args": []interface{}{"--leader-elect"}},
+					}}},
+				},
+				"status": map[string]interface{}{"readyReplicas": int64(1)},
+			}},
+			applied: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]interface{}{
+					"name":            "storageos-operator",
+					"resourceVersion": "1235",
+					"labels":          map[string]interface{}{"app.kubernetes.io/name": "storageos", "app.kubernetes.io/version": "v2.7.0"},
+				},
+				"spec": map[string]interface{}{
+					"replicas": int64(1),
+					"template": map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+						map[string]interface{}{"name": "operator", "image": "storageos/operator:v2.7.0"},
+					}}},
+				},
+			}},
+			expected: []FieldChange{
+				{Path: `metadata.labels["app.kubernetes.io/version"]`, Applied: `"v2.7.0"`},
+				{Path: "spec.template.spec.containers[0].args[0]", Live: `"--leader-elect"`},
+				{Path: "spec.template.spec.containers[0].image", Live: `"storageos/operator:v2.6.0"`, Applied: `"storageos/operator:v2.7.0"`},
+			},
+		},
+		{
+			name: "unchanged object",
+			live: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"name":        "storageos-portal-manager",
+					"uid":         "a8b1",
+					"annotations": map[string]interface{}{lastAppliedAnnotation: "{}"},
+				},
+				"data": map[string]interface{}{"portal_config.yaml": "url: https://portal.storageos.com"},
+			}},
+			applied: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": "storageos-portal-manager", "uid": "a8b1"},
+				"data":       map[string]interface{}{"portal_config.yaml": "url: https://portal.storageos.com"},
+			}},
+			expected: []FieldChange{},
+		},
+		{
+			name: "created secret",
+			applied: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"metadata":   map[string]interface{}{"name": "storageos-api"},
+				"stringData": map[string]interface{}{"password": "storageos"},
+			}},
+			expected: []FieldChange{
+				{Path: "apiVersion", Applied: `"v1"`},
+				{Path: "data.password", Applied: `"(sensitive sha256:8666b6840cf5)"`},
+				{Path: "kind", Applied: `"Secret"`},
+				{Path: "metadata.name", Applied: `"storageos-api"`},
+			},
+		},
+		{
+			name: "changed secret data",
+			live: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"metadata":   map[string]interface{}{"name": "storageos-api"},
+				"data":       map[string]interface{}{"password": "c3RvcmFnZW9z"},
+			}},
+			applied: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"metadata":   map[string]interface{}{"name": "storageos-api"},
+				"data":       map[string]interface{}{"password": "bmV3LXBhc3N3b3Jk"},
+			}},
+			expected: []FieldChange{
+				{Path: "data.password", Live: `"(sensitive sha256:8666b6840cf5)"`, Applied: `"(sensitive sha256:6ea82a351a75)"`},
+			},
+		},
+		{
+			name: "deleted config map",
+			live: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": "storageos-related-images", "uid": "c2d9"},
+				"data":       map[string]interface{}{"RELATED_IMAGE_API_MANAGER": "storageos/api-manager:v1.2.0"},
+			}},
+			expected: []FieldChange{
+				{Path: "apiVersion", Live: `"v1"`},
+				{Path: "data.RELATED_IMAGE_API_MANAGER", Live: `"storageos/api-manager:v1.2.0"`},
+				{Path: "kind", Live: `"ConfigMap"`},
+				{Path: "metadata.name", Live: `"storageos-related-images"`},
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		changes := DiffObjects(tc.live, tc.applied)
+		if !reflect.DeepEqual(changes, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, changes)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// relatedImagePrefix prefixes the config map keys and environment variables that pass the images of
+	// operands to an operator.
+	relatedImagePrefix = "RELATED_IMAGE_"
+
+	// defaultRegistry is the registry of images which name none.
+	defaultRegistry = "docker.io"
+	// defaultRepositoryPrefix prefixes the repository of official images of the default registry.
+	defaultRepositoryPrefix = "library/"
+
+	// envImageFieldPath is the path of the environment variables of pod templates, which pass
+	// RELATED_IMAGE_ values to operators.
+	envImageFieldPath = "spec/template/spec/containers[]/env[]/value"
+)
+
+// ManifestImages returns the sorted container images referenced by multi-doc manifest: the images of
+// containers, the RELATED_IMAGE_ values passed to operators and the images of a StorageOSCluster.
+func ManifestImages(multiDoc string) ([]string, error) {
+	objs, err := manifest.ParseObjects(context.Background(), multiDoc)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	images := map[string]bool{}
+	for _, obj := range objs.Items {
+		content := obj.UnstructuredObject().UnstructuredContent()
+		collectImages(content, images)
+
+		if obj.Kind != "StorageOSCluster" {
+			continue
+		}
+		spec, _ := content["spec"].(map[string]interface{})
+		clusterImages, _ := spec["images"].(map[string]interface{})
+		for _, image := range clusterImages {
+			if image, ok := image.(string); ok && image != "" {
+				images[image] = true
+			}
+		}
+	}
+
+	sorted := []string{}
+	for image := range images {
+		sorted = append(sorted, image)
+	}
+	sort.Strings(sorted)
+
+	return sorted, nil
+}
+
+// collectImages adds the images referenced by the fields of value to images.
+func collectImages(value interface{}, images map[string]bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		// environment variable of an operator container
+		if name, ok := v["name"].(string); ok && strings.HasPrefix(name, relatedImagePrefix) {
+			if image, ok := v["value"].(string); ok && image != "" {
+				images[image] = true
+			}
+		}
+		for key, field := range v {
+			image, ok := field.(string)
+			if !ok {
+				collectImages(field, images)
+				continue
+			}
+			// image of a container, or config map data of an operator
+			if (key == "image" || strings.HasPrefix(key, relatedImagePrefix)) && image != "" {
+				images[image] = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectImages(item, images)
+		}
+	}
+}
+
+// ImageRewriter rewrites the names of images to be pulled from a registry mirror, or to the names they are
+// mapped to. Tags and digests are kept.
+type ImageRewriter struct {
+	mirror  string
+	mapping map[string]string
+}
+
+// NewImageRewriter returns an ImageRewriter rewriting images to registry mirror, unless mapped by the
+// mapping file at mappingPath. Either may be empty. The mapping file maps image names, or registry and
+// repository prefixes of them, to their replacement, eg:
+//
+// quay.io/k8scsi: registry.example.com/k8scsi
+// storageos/node: registry.example.com/storageos/storageos-node
+func NewImageRewriter(mirror, mappingPath string) (*ImageRewriter, error) {
+	rewriter := &ImageRewriter{
+		mirror:  strings.TrimSuffix(mirror, "/"),
+		mapping: map[string]string{},
+	}
+	if mappingPath == "" {
+		return rewriter, nil
+	}
+
+	data, err := os.ReadFile(mappingPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = yaml.Unmarshal(data, &rewriter.mapping); err != nil {
+		return nil, errors.Wrapf(err, "invalid image mapping file %s", mappingPath)
+	}
+	for source, target := range rewriter.mapping {
+		if source == "" || target == "" {
+			return nil, errors.Errorf("invalid image mapping file %s: %q maps to %q", mappingPath, source, target)
+		}
+	}
+
+	return rewriter, nil
+}
+
+// Enabled returns true if the rewriter has a mirror or a mapping.
+func (r *ImageRewriter) Enabled() bool {
+	return r.mirror != "" || len(r.mapping) > 0
+}
+
+// RewriteName returns the name image name is rewritten to. A mapping of the name, or else of its longest
+// mapped prefix, wins over the mirror. Names neither mapped nor mirrored are returned as they are.
+func (r *ImageRewriter) RewriteName(name string) string {
+	canonical := canonicalImageName(name)
+
+	match := ""
+	for source := range r.mapping {
+		for _, candidate := range []string{name, canonical} {
+			if (candidate == source || strings.HasPrefix(candidate, source+"/")) && len(source) > len(match) {
+				match = source
+			}
+		}
+	}
+	if match != "" {
+		if strings.HasPrefix(name, match) {
+			return r.mapping[match] + strings.TrimPrefix(name, match)
+		}
+		return r.mapping[match] + strings.TrimPrefix(canonical, match)
+	}
+
+	if r.mirror == "" {
+		return name
+	}
+	// the repository is kept, the registry replaced
+	return r.mirror + "/" + strings.SplitN(canonical, "/", 2)[1]
+}
+
+// Rewrite returns image, with its tag or digest, rewritten as RewriteName.
+func (r *ImageRewriter) Rewrite(image string) string {
+	name, suffix := splitImage(image)
+
+	return r.RewriteName(name) + suffix
+}
+
+// NameRewrites returns the rewritten names of the images, by their original name. Images which names are
+// not rewritten are omitted.
+func (r *ImageRewriter) NameRewrites(images []string) map[string]string {
+	rewrites := map[string]string{}
+	for _, image := range images {
+		name, _ := splitImage(image)
+		if newName := r.RewriteName(name); newName != name {
+			rewrites[name] = newName
+		}
+	}
+
+	return rewrites
+}
+
+// splitImage returns the name of image and its tag or digest, prefixed by its separator.
+func splitImage(image string) (string, string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[:i], image[i:]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i:]
+	}
+
+	return image, ""
+}
+
+// canonicalImageName returns image name prefixed by its registry, docker.io if it names none.
+func canonicalImageName(name string) string {
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		return name
+	}
+	if len(parts) == 1 {
+		name = defaultRepositoryPrefix + name
+	}
+
+	return defaultRegistry + "/" + name
+}
+
+// ImageTransformerConfig returns a kustomize transformer configuration for the image fields of multi-doc
+// manifest which kustomize doesn't know of: the RELATED_IMAGE_ data of config maps, the images of a
+// StorageOSCluster and the environment variables of pod templates. Values of environment variables are
+// only rewritten if they match the name of a rewritten image.
+func ImageTransformerConfig(multiDoc string) (string, error) {
+	objs, err := manifest.ParseObjects(context.Background(), multiDoc)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	// field paths by kind
+	kindPaths := map[string]map[string]bool{}
+	addPath := func(kind, path string) {
+		if kindPaths[kind] == nil {
+			kindPaths[kind] = map[string]bool{}
+		}
+		kindPaths[kind][path] = true
+	}
+	for _, obj := range objs.Items {
+		content := obj.UnstructuredObject().UnstructuredContent()
+		switch obj.Kind {
+		case "ConfigMap":
+			data, _ := content["data"].(map[string]interface{})
+			for key := range data {
+				if strings.HasPrefix(key, relatedImagePrefix) {
+					addPath(obj.Kind, "data/"+key)
+				}
+			}
+		case "StorageOSCluster":
+			spec, _ := content["spec"].(map[string]interface{})
+			clusterImages, _ := spec["images"].(map[string]interface{})
+			for key := range clusterImages {
+				addPath(obj.Kind, "spec/images/"+key)
+			}
+		}
+	}
+
+	fieldSpecs := []map[string]string{{"path": envImageFieldPath}}
+	for _, kind := range []string{"ConfigMap", "StorageOSCluster"} {
+		paths := []string{}
+		for path := range kindPaths[kind] {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			fieldSpecs = append(fieldSpecs, map[string]string{"kind": kind, "path": path})
+		}
+	}
+
+	config, err := yaml.Marshal(map[string]interface{}{"images": fieldSpecs})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return string(config), nil
+}
+
+// AddImagesToKustomize adds an image entry for each name of rewrites, setting it to its new name, to
+// kustomizationFile and adds configuration to its transformer configurations.
+//
+// images:
+//   - name: storageos/operator
+//     newName: registry.example.com/storageos/operator
+//
+// configurations:
+// - image-fields.yaml
+func AddImagesToKustomize(kustomizationFile string, rewrites map[string]string, configuration string) (string, error) {
+	obj, err := kyaml.Parse(kustomizationFile)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	names := make([]string, 0, len(rewrites))
+	for name := range rewrites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	images := []string{}
+	for _, name := range names {
+		images = append(images, fmt.Sprintf("- name: %s\n  newName: %s", name, rewrites[name]))
+	}
+	fields := map[string]string{
+		"images":         strings.Join(images, "\n"),
+		"configurations": "- " + configuration,
+	}
+	for _, field := range []string{"images", "configurations"} {
+		values, err := kyaml.Parse(fields[field])
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		if _, err = obj.Pipe(
+			kyaml.LookupCreate(kyaml.SequenceNode, field),
+			kyaml.Append(values.YNode().Content...)); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+
+	return obj.MustString(), nil
+}
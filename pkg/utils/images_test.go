@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestManifestImages(t *testing.T) {
+	tcases := []struct {
+		name     string
+		manifest string
+		expected []string
+	}{
+		{
+			name: "operator deployment and related images",
+			manifest: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: storageos-related-images
+  namespace: storageos
+data:
+  RELATED_IMAGE_API_MANAGER: storageos/api-manager:v1.2.7
+  RELATED_IMAGE_STORAGEOS_NODE: storageos/node:v2.7.0
+  LOG_LEVEL: info
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: storageos-operator
+  namespace: storageos
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: storageos/init:v2.1.0
+      containers:
+      - name: manager
+        image: storageos/operator:v2.7.0
+        env:
+        - name: RELATED_IMAGE_PORTAL_MANAGER
+          value: storageos/portal-manager:v1.0.2
+        - name: WATCH_NAMESPACE
+          value: storageos
+      - name: kube-rbac-proxy
+        image: quay.io/brancz/kube-rbac-proxy:v0.10.0
+`,
+			expected: []string{
+				"quay.io/brancz/kube-rbac-proxy:v0.10.0",
+				"storageos/api-manager:v1.2.7",
+				"storageos/init:v2.1.0",
+				"storageos/node:v2.7.0",
+				"storageos/operator:v2.7.0",
+				"storageos/portal-manager:v1.0.2",
+			},
+		},
+		{
+			name: "storageos cluster images",
+			manifest: `apiVersion: storageos.com/v1
+kind: StorageOSCluster
+metadata:
+  name: storageoscluster
+  namespace: storageos
+spec:
+  images:
+    nodeContainer: storageos/node:v2.7.0
+    csiExternalProvisionerContainer: quay.io/k8scsi/csi-provisioner:v2.1.1
+`,
+			expected: []string{
+				"quay.io/k8scsi/csi-provisioner:v2.1.1",
+				"storageos/node:v2.7.0",
+			},
+		},
+		{
+			name: "no images",
+			manifest: `apiVersion: v1
+kind: Namespace
+metadata:
+  name: storageos
+`,
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range tcases {
+		images, err := ManifestImages(tc.manifest)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(images, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, images)
+		}
+	}
+}
+
+func TestImageRewriterRewrite(t *testing.T) {
+	mappingPath := filepath.Join(t.TempDir(), "mapping.yaml")
+	mapping := `quay.io/k8scsi: registry.example.com/csi
+docker.io/storageos/node: registry.example.com/storageos/storageos-node
+`
+	if err := os.WriteFile(mappingPath, []byte(mapping), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tcases := []struct {
+		name        string
+		mirror      string
+		mappingPath string
+		image       string
+		expected    string
+	}{
+		{
+			name:     "no mirror",
+			image:    "storageos/operator:v2.7.0",
+			expected: "storageos/operator:v2.7.0",
+		},
+		{
+			name:     "mirror of docker hub image",
+			mirror:   "registry.example.com/",
+			image:    "storageos/operator:v2.7.0",
+			expected: "registry.example.com/storageos/operator:v2.7.0",
+		},
+		{
+			name:     "mirror of official image",
+			mirror:   "registry.example.com",
+			image:    "busybox",
+			expected: "registry.example.com/library/busybox",
+		},
+		{
+			name:     "mirror of registry with port and digest",
+			mirror:   "registry.example.com",
+			image:    "localhost:5000/storageos/node@sha256:abc",
+			expected: "registry.example.com/storageos/node@sha256:abc",
+		},
+		{
+			name:     "mirrored image is kept",
+			mirror:   "registry.example.com",
+			image:    "registry.example.com/storageos/node:v2.7.0",
+			expected: "registry.example.com/storageos/node:v2.7.0",
+		},
+		{
+			name:        "mapped prefix",
+			mirror:      "mirror.example.com",
+			mappingPath: mappingPath,
+			image:       "quay.io/k8scsi/csi-provisioner:v2.1.1",
+			expected:    "registry.example.com/csi/csi-provisioner:v2.1.1",
+		},
+		{
+			name:        "mapped docker hub image",
+			mappingPath: mappingPath,
+			image:       "storageos/node:v2.7.0",
+			expected:    "registry.example.com/storageos/storageos-node:v2.7.0",
+		},
+		{
+			name:        "unmapped image without mirror",
+			mappingPath: mappingPath,
+			image:       "quay.io/k8scsi2/csi-provisioner:v2.1.1",
+			expected:    "quay.io/k8scsi2/csi-provisioner:v2.1.1",
+		},
+		{
+			name:        "unmapped image with mirror",
+			mirror:      "mirror.example.com",
+			mappingPath: mappingPath,
+			image:       "storageos/operator:v2.7.0",
+			expected:    "mirror.example.com/storageos/operator:v2.7.0",
+		},
+	}
+
+	for _, tc := range tcases {
+		rewriter, err := NewImageRewriter(tc.mirror, tc.mappingPath)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+			continue
+		}
+		if got := rewriter.Rewrite(tc.image); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
@@ -5,15 +5,19 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"regexp"
 	"strings"
 	"time"
 
 	etcdoperatorapi "github.com/improbable-eng/etcd-cluster-operator/api/v1alpha1"
 	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	kstoragev1 "k8s.io/api/storage/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -23,9 +27,12 @@ import (
 	"k8s.io/client-go/kubernetes"
 	storagev1 "k8s.io/client-go/kubernetes/typed/storage/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
 	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	apiv1 "github.com/storageos/kubectl-storageos/api/v1"
 	"github.com/storageos/kubectl-storageos/pkg/consts"
 	operatorapi "github.com/storageos/operator/api/v1"
 )
@@ -118,9 +125,37 @@ func GetKubernetesVersion(config *rest.Config) (*kversion.Info, error) {
 //
 // Returnes both STDOUT and STDERR as strings.
 func ExecToPod(config *rest.Config, command []string, containerName, podName, namespace string, stdin io.Reader) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	if err := StreamExecToPod(config, command, containerName, podName, namespace, ExecStreams{
+		Stdin:  stdin,
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}); err != nil {
+		return stdout.String(), stderr.String(), errors.WithStack(fmt.Errorf("error in Stream: %v", err))
+	}
+
+	return stdout.String(), stderr.String(), nil
+}
+
+// ExecStreams are the streams of a command executed by StreamExecToPod.
+type ExecStreams struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	// Stderr is ignored with a TTY, which merges it into Stdout.
+	Stderr io.Writer
+	TTY    bool
+	// TerminalSizeQueue resizes the TTY of the command, if any, along with the local terminal.
+	TerminalSizeQueue remotecommand.TerminalSizeQueue
+}
+
+// StreamExecToPod execs into a pod and executes command from inside that pod, streaming its input and
+// output as they come. containerName can be "" if the pod contains only a single container.
+//
+// The error of a command which exits with a non-zero code implements k8s.io/client-go/util/exec.ExitError.
+func StreamExecToPod(config *rest.Config, command []string, containerName, podName, namespace string, streams ExecStreams) error {
 	clientset, err := GetClientsetFromConfig(config)
 	if err != nil {
-		return "", "", err
+		return err
 	}
 	req := clientset.CoreV1().RESTClient().Post().
 		Resource("pods").
@@ -129,35 +164,145 @@ func ExecToPod(config *rest.Config, command []string, containerName, podName, na
 		SubResource("exec")
 	scheme := runtime.NewScheme()
 	if err := corev1.AddToScheme(scheme); err != nil {
-		return "", "", errors.WithStack(fmt.Errorf("error adding to scheme: %v", err))
+		return errors.WithStack(fmt.Errorf("error adding to scheme: %v", err))
+	}
+
+	stderr := streams.Stderr
+	if streams.TTY {
+		stderr = nil
 	}
 
 	parameterCodec := runtime.NewParameterCodec(scheme)
 	req.VersionedParams(&corev1.PodExecOptions{
 		Command:   command,
 		Container: containerName,
-		Stdin:     stdin != nil,
-		Stdout:    true,
-		Stderr:    true,
-		TTY:       false,
+		Stdin:     streams.Stdin != nil,
+		Stdout:    streams.Stdout != nil,
+		Stderr:    stderr != nil,
+		TTY:       streams.TTY,
 	}, parameterCodec)
 
 	exec, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
 	if err != nil {
-		return "", "", errors.WithStack(fmt.Errorf("error while creating Executor: %v", err))
+		return errors.WithStack(fmt.Errorf("error while creating Executor: %v", err))
 	}
 
-	var stdout, stderr bytes.Buffer
-	if err = exec.Stream(remotecommand.StreamOptions{
-		Stdin:  stdin,
-		Stdout: &stdout,
-		Stderr: &stderr,
-		Tty:    false,
-	}); err != nil {
-		return stdout.String(), stderr.String(), errors.WithStack(fmt.Errorf("error in Stream: %v", err))
+	return errors.WithStack(exec.Stream(remotecommand.StreamOptions{
+		Stdin:             streams.Stdin,
+		Stdout:            streams.Stdout,
+		Stderr:            stderr,
+		Tty:               streams.TTY,
+		TerminalSizeQueue: streams.TerminalSizeQueue,
+	}))
+}
+
+// PortForwardToPod forwards a random local port to port of the pod, returning the local port and a
+// function which stops forwarding. The port-forward goes through the API server, so the pod does not
+// need to be reachable from the host running the plugin.
+func PortForwardToPod(config *rest.Config, podName, namespace string, port int32) (uint16, func(), error) {
+	clientset, err := GetClientsetFromConfig(config)
+	if err != nil {
+		return 0, nil, err
 	}
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(namespace).
+		SubResource("portforward")
 
-	return stdout.String(), stderr.String(), nil
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return 0, nil, errors.WithStack(err)
+	}
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", req.URL())
+
+	stopChan, readyChan := make(chan struct{}), make(chan struct{})
+	forwarder, err := portforward.New(dialer, []string{fmt.Sprintf("0:%d", port)}, stopChan, readyChan, io.Discard, io.Discard)
+	if err != nil {
+		return 0, nil, errors.WithStack(err)
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- forwarder.ForwardPorts()
+	}()
+
+	select {
+	case err = <-errChan:
+		return 0, nil, errors.WithStack(fmt.Errorf("error forwarding to pod %s; %s: %v", podName, namespace, err))
+	case <-readyChan:
+	}
+
+	ports, err := forwarder.GetPorts()
+	if err != nil {
+		close(stopChan)
+		return 0, nil, errors.WithStack(err)
+	}
+
+	return ports[0].Local, func() { close(stopChan) }, nil
+}
+
+// GetServiceBackendPod returns the name of a ready pod backing port of the service, along with the
+// port of the pod the service port targets.
+func GetServiceBackendPod(config *rest.Config, name, namespace string, port int32) (string, int32, error) {
+	clientset, err := GetClientsetFromConfig(config)
+	if err != nil {
+		return "", 0, err
+	}
+
+	svc, err := clientset.CoreV1().Services(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return "", 0, err
+	}
+	var svcPort *corev1.ServicePort
+	for i := range svc.Spec.Ports {
+		if svc.Spec.Ports[i].Port == port {
+			svcPort = &svc.Spec.Ports[i]
+			break
+		}
+	}
+	if svcPort == nil {
+		return "", 0, fmt.Errorf("service %s; %s has no port %d", name, namespace, port)
+	}
+
+	ep, err := clientset.CoreV1().Endpoints(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return "", 0, err
+	}
+	for _, subset := range ep.Subsets {
+		for _, epPort := range subset.Ports {
+			if epPort.Name != svcPort.Name {
+				continue
+			}
+			for _, address := range subset.Addresses {
+				if address.TargetRef != nil && address.TargetRef.Kind == "Pod" {
+					return address.TargetRef.Name, epPort.Port, nil
+				}
+			}
+		}
+	}
+
+	return "", 0, fmt.Errorf("no ready pods back port %d of service %s; %s", port, name, namespace)
+}
+
+// FindPodByIP returns the running pod with the given IP, or a NotFound error if there is none.
+func FindPodByIP(config *rest.Config, ip string) (*corev1.Pod, error) {
+	clientset, err := GetClientsetFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := clientset.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{FieldSelector: "status.podIP=" + ip})
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase == corev1.PodRunning {
+			return &pods.Items[i], nil
+		}
+	}
+
+	return nil, kerrors.NewNotFound(corev1.Resource("pods"), ip)
 }
 
 func FetchPodLogs(config *rest.Config, name, namespace string) (string, error) {
@@ -329,6 +474,94 @@ func WaitFor(fn func() error, limit, interval time.Duration) error {
 	}
 }
 
+// Gate is a readiness condition, it returns no error once the condition has been met.
+type Gate func() error
+
+// WaitForGates runs 'gates' every 'interval' for duration of 'limit', returning no error only if every
+// gate has passed inside 'limit'. Gates which have passed are not run again.
+func WaitForGates(limit, interval time.Duration, gates ...Gate) error {
+	pending := gates
+	return WaitFor(func() error {
+		var err error
+		remaining := make([]Gate, 0, len(pending))
+		for _, gate := range pending {
+			if gateErr := gate(); gateErr != nil {
+				remaining = append(remaining, gate)
+				if err == nil {
+					err = gateErr
+				}
+			}
+		}
+		pending = remaining
+
+		return err
+	}, limit, interval)
+}
+
+// CRDDoesNotExist returns no error only if the CustomResourceDefinition of name does not exist in the k8s cluster
+func CRDDoesNotExist(config *rest.Config, name string) error {
+	clientset, err := apiextensionsclientset.NewForConfig(config)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err = clientset.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), name, metav1.GetOptions{}); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	return fmt.Errorf("customresourcedefinition %s exists in cluster", name)
+}
+
+// IsCRDEstablished attempts to `get` a CustomResourceDefinition by name, the function returns no error
+// only if the CustomResourceDefinition reports the Established condition.
+func IsCRDEstablished(config *rest.Config, name string) error {
+	clientset, err := apiextensionsclientset.NewForConfig(config)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	crd, err := clientset.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	for _, condition := range crd.Status.Conditions {
+		if condition.Type == apiextensionsv1.Established && condition.Status == apiextensionsv1.ConditionTrue {
+			return nil
+		}
+	}
+	return fmt.Errorf("customresourcedefinition %s is not established", name)
+}
+
+// DeploymentDoesNotExist returns no error only if the deployment of name and namespace does not exist in the k8s cluster
+func DeploymentDoesNotExist(config *rest.Config, name, namespace string) error {
+	clientset, err := GetClientsetFromConfig(config)
+	if err != nil {
+		return err
+	}
+
+	if _, err = clientset.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{}); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	return fmt.Errorf("deployment %s; %s exists in cluster", name, namespace)
+}
+
+// PodsDoNotExist returns no error only if no pods matching label exist in namespace
+func PodsDoNotExist(config *rest.Config, namespace, label string) error {
+	pods, err := ListPods(config, namespace, label)
+	if err != nil {
+		return err
+	}
+	if len(pods.Items) != 0 {
+		return fmt.Errorf("%d pods with label %s exist in namespace %s", len(pods.Items), label, namespace)
+	}
+	return nil
+}
+
 // IsDeploymentReady attempts to `get` a deployment by name and namespace, the function returns no error
 // if no deployment replicas are ready.
 func IsDeploymentReady(config *rest.Config, name, namespace string) error {
@@ -397,6 +630,37 @@ func IsPodRunning(config *rest.Config, name, namespace string) error {
 	return nil
 }
 
+// CreatePodAndWaitForRunning creates pod and waits for it to enter running phase, returning the created
+// pod and a function which deletes it. The pod is deleted if it does not run in time.
+func CreatePodAndWaitForRunning(config *rest.Config, pod *corev1.Pod) (*corev1.Pod, func(), error) {
+	clientset, err := GetClientsetFromConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	podClient := clientset.CoreV1().Pods(pod.Namespace)
+
+	created, err := podClient.Create(context.TODO(), pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	deletePod := func() {
+		gracePeriod := int64(0)
+		delErr := podClient.Delete(context.Background(), created.Name, metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+		if delErr != nil && !kerrors.IsNotFound(delErr) {
+			println(fmt.Sprintf(helperDeletionErrorMessage, "pod", delErr.Error(), "pod", created.Namespace, created.Name))
+		}
+	}
+
+	if err = WaitFor(func() error {
+		return IsPodRunning(config, created.Name, created.Namespace)
+	}, 120, 1); err != nil {
+		deletePod()
+		return nil, nil, err
+	}
+
+	return created, deletePod, nil
+}
+
 // GetNamespace return namespace object
 func GetNamespace(config *rest.Config, namespace string) (*corev1.Namespace, error) {
 	clientset, err := GetClientsetFromConfig(config)
@@ -507,6 +771,20 @@ func GetSecret(config *rest.Config, name, namespace string) (*corev1.Secret, err
 	return secret, nil
 }
 
+// GetDaemonSet returns the daemonset called name in namespace.
+func GetDaemonSet(config *rest.Config, name, namespace string) (*appsv1.DaemonSet, error) {
+	clientset, err := GetClientsetFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	daemonSet, err := clientset.AppsV1().DaemonSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return daemonSet, nil
+}
+
 // ListSecrets returns SecretList
 func ListSecrets(config *rest.Config, listOptions metav1.ListOptions) (*corev1.SecretList, error) {
 	clientset, err := GetClientsetFromConfig(config)
@@ -545,27 +823,99 @@ func SecretDoesNotExist(config *rest.Config, name, namespace string) error {
 	return fmt.Errorf("secret %v exists in cluster", namespace)
 }
 
-// GetFirstStorageOSCluster returns the storageoscluster object if it exists in the k8s cluster.
-// Use 'List' to discover as there can only be one object per k8s cluster and 'List' does not
-// require name/namespace.
-func GetFirstStorageOSCluster(config *rest.Config) (*operatorapi.StorageOSCluster, error) {
-	stosCluster := &operatorapi.StorageOSCluster{}
+// ClusterSelector selects a storageoscluster by name and, optionally, namespace. The zero value
+// selects the only storageoscluster of the k8s cluster.
+type ClusterSelector struct {
+	Name      string
+	Namespace string
+}
+
+// clusterSelector is the selector set by the global --cluster flag.
+var clusterSelector ClusterSelector
+
+// ParseClusterSelector parses a selector of the form name[/namespace].
+func ParseClusterSelector(value string) (ClusterSelector, error) {
+	if value == "" {
+		return ClusterSelector{}, nil
+	}
+	parts := strings.Split(value, "/")
+	if len(parts) > 2 || parts[0] == "" || (len(parts) == 2 && parts[1] == "") {
+		return ClusterSelector{}, errors.Errorf("invalid storageoscluster %q, expected name or name/namespace", value)
+	}
+	selector := ClusterSelector{Name: parts[0]}
+	if len(parts) == 2 {
+		selector.Namespace = parts[1]
+	}
+	return selector, nil
+}
+
+// SetClusterSelector sets the selector used by GetStorageOSCluster.
+func SetClusterSelector(selector ClusterSelector) {
+	clusterSelector = selector
+}
+
+func (s ClusterSelector) String() string {
+	if s.Namespace == "" {
+		return s.Name
+	}
+	return s.Name + "/" + s.Namespace
+}
+
+// ListStorageOSClusters returns the storageoscluster objects of all namespaces.
+func ListStorageOSClusters(config *rest.Config) ([]operatorapi.StorageOSCluster, error) {
 	stosClusterList := &operatorapi.StorageOSClusterList{}
 	newClient, err := storageOSOperatorClient(config)
 	if err != nil {
-		return stosCluster, err
+		return nil, err
+	}
+	if err = newClient.List(context.TODO(), stosClusterList, &client.ListOptions{}); err != nil {
+		return nil, errors.WithStack(err)
 	}
-	err = newClient.List(context.TODO(), stosClusterList, &client.ListOptions{})
+
+	return stosClusterList.Items, nil
+}
+
+// GetStorageOSCluster returns the storageoscluster object selected by the --cluster flag, or the
+// only one of the k8s cluster if none is selected.
+func GetStorageOSCluster(config *rest.Config) (*operatorapi.StorageOSCluster, error) {
+	return GetSelectedStorageOSCluster(config, clusterSelector)
+}
+
+// GetSelectedStorageOSCluster returns the storageoscluster object matching selector. It fails if
+// there is no match, or if the selector is empty and more than one storageoscluster exists.
+func GetSelectedStorageOSCluster(config *rest.Config, selector ClusterSelector) (*operatorapi.StorageOSCluster, error) {
+	stosClusters, err := ListStorageOSClusters(config)
 	if err != nil {
-		return stosCluster, errors.WithStack(err)
+		return &operatorapi.StorageOSCluster{}, err
 	}
 
-	if len(stosClusterList.Items) == 0 {
-		return stosCluster, kerrors.NewNotFound(operatorapi.SchemeBuilder.GroupVersion.WithResource("StorageOSCluster").GroupResource(), "")
+	return selectStorageOSCluster(stosClusters, selector)
+}
+
+func selectStorageOSCluster(stosClusters []operatorapi.StorageOSCluster, selector ClusterSelector) (*operatorapi.StorageOSCluster, error) {
+	matches := []operatorapi.StorageOSCluster{}
+	for _, stosCluster := range stosClusters {
+		if selector.Name != "" && stosCluster.Name != selector.Name {
+			continue
+		}
+		if selector.Namespace != "" && stosCluster.Namespace != selector.Namespace {
+			continue
+		}
+		matches = append(matches, stosCluster)
+	}
+
+	switch len(matches) {
+	case 0:
+		return &operatorapi.StorageOSCluster{}, kerrors.NewNotFound(operatorapi.SchemeBuilder.GroupVersion.WithResource("StorageOSCluster").GroupResource(), selector.String())
+	case 1:
+		return &matches[0], nil
 	}
 
-	stosCluster = &stosClusterList.Items[0]
-	return stosCluster, nil
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, match.Name+"/"+match.Namespace)
+	}
+	return &operatorapi.StorageOSCluster{}, errors.Errorf("found %d storageoscluster objects (%s), select one with --cluster name/namespace", len(matches), strings.Join(names, ", "))
 }
 
 func storageOSOperatorClient(config *rest.Config) (client.Client, error) {
@@ -576,9 +926,17 @@ func storageOSOperatorClient(config *rest.Config) (client.Client, error) {
 	return client.New(config, client.Options{Scheme: scheme})
 }
 
-// StorageOSClusterDoesNotExist return no error only if no storageoscluster object exists in k8s cluster
+// GetAPISecretName returns the name of the secret holding the api credentials of stosCluster.
+func GetAPISecretName(stosCluster *operatorapi.StorageOSCluster) string {
+	if stosCluster.Spec.SecretRefName == "" {
+		return consts.APISecretName
+	}
+	return stosCluster.Spec.SecretRefName
+}
+
+// StorageOSClusterDoesNotExist return no error only if the selected storageoscluster object does not exist in k8s cluster
 func StorageOSClusterDoesNotExist(config *rest.Config) error {
-	stosCluster, err := GetFirstStorageOSCluster(config)
+	stosCluster, err := GetStorageOSCluster(config)
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			return nil
@@ -646,6 +1004,56 @@ func UpdateEtcdClusterWithoutFinalizers(config *rest.Config, etcdCluster *etcdop
 	return newClient.Update(context.TODO(), etcdCluster)
 }
 
+// GetKubectlStorageOSConfig returns the kubectlstorageosconfig object of name and namespace.
+func GetKubectlStorageOSConfig(config *rest.Config, name, namespace string) (*apiv1.KubectlStorageOSConfig, error) {
+	stosConfig := &apiv1.KubectlStorageOSConfig{}
+	newClient, err := kubectlStorageOSClient(config)
+	if err != nil {
+		return stosConfig, err
+	}
+	if err = newClient.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, stosConfig); err != nil {
+		return stosConfig, err
+	}
+	return stosConfig, nil
+}
+
+// CreateOrUpdateKubectlStorageOSConfigStatus creates the kubectlstorageosconfig object if it does not
+// exist yet and writes its status subresource.
+func CreateOrUpdateKubectlStorageOSConfigStatus(config *rest.Config, stosConfig *apiv1.KubectlStorageOSConfig) error {
+	newClient, err := kubectlStorageOSClient(config)
+	if err != nil {
+		return err
+	}
+
+	// status is dropped by the api server on create, so keep hold of it for the update below.
+	status := stosConfig.Status.DeepCopy()
+
+	existing := &apiv1.KubectlStorageOSConfig{}
+	err = newClient.Get(context.TODO(), types.NamespacedName{Name: stosConfig.Name, Namespace: stosConfig.Namespace}, existing)
+	switch {
+	case err == nil:
+		stosConfig.ResourceVersion = existing.ResourceVersion
+	case kerrors.IsNotFound(err):
+		if err = newClient.Create(context.TODO(), stosConfig); err != nil {
+			return errors.WithStack(err)
+		}
+	default:
+		return errors.WithStack(err)
+	}
+
+	stosConfig.Status = *status
+
+	return errors.WithStack(newClient.Status().Update(context.TODO(), stosConfig))
+}
+
+func kubectlStorageOSClient(config *rest.Config) (client.Client, error) {
+	scheme := runtime.NewScheme()
+	if err := apiv1.AddToScheme(scheme); err != nil {
+		return nil, errors.Wrap(err, "failed to add to scheme")
+	}
+	return client.New(config, client.Options{Scheme: scheme})
+}
+
 // CreateNamespaceIfNotPresent creates a namespace if it does not exists yet.
 func CreateNamespaceIfNotPresent(config *rest.Config, namespace string) error {
 	if _, err := GetNamespace(config, namespace); err == nil {
@@ -1,6 +1,14 @@
 package utils
 
-import "testing"
+import (
+	"errors"
+	"testing"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorapi "github.com/storageos/operator/api/v1"
+)
 
 func TestDetermineDistribution(t *testing.T) {
 	tests := map[string]struct {
@@ -31,3 +39,133 @@ func TestDetermineDistribution(t *testing.T) {
 		})
 	}
 }
+
+func TestWaitForGates(t *testing.T) {
+	tests := map[string]struct {
+		passAfter   []int
+		expectedErr bool
+	}{
+		"all pass": {
+			passAfter: []int{1, 2},
+		},
+		"one never passes": {
+			passAfter:   []int{1, 10},
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		tt := test
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			calls := make([]int, len(tt.passAfter))
+			gates := make([]Gate, len(tt.passAfter))
+			for i := range tt.passAfter {
+				i := i
+				gates[i] = func() error {
+					calls[i]++
+					if calls[i] < tt.passAfter[i] {
+						return errors.New("not ready")
+					}
+					return nil
+				}
+			}
+
+			err := WaitForGates(3, 1, gates...)
+			if (err != nil) != tt.expectedErr {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			// a gate which has passed must not be evaluated again
+			if calls[0] != 1 {
+				t.Errorf("expected first gate to be evaluated once, got %d", calls[0])
+			}
+		})
+	}
+}
+
+func TestSelectStorageOSCluster(t *testing.T) {
+	stosCluster := func(name, namespace string) operatorapi.StorageOSCluster {
+		return operatorapi.StorageOSCluster{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	}
+	clusters := []operatorapi.StorageOSCluster{
+		stosCluster("storageos", "storageos"),
+		stosCluster("storageos", "other"),
+		stosCluster("dev", "other"),
+	}
+
+	tests := map[string]struct {
+		selector    string
+		clusters    []operatorapi.StorageOSCluster
+		expected    string
+		notFound    bool
+		expectError bool
+	}{
+		"Only cluster": {
+			clusters: clusters[:1],
+			expected: "storageos/storageos",
+		},
+		"No cluster": {
+			notFound:    true,
+			expectError: true,
+		},
+		"Ambiguous": {
+			clusters:    clusters,
+			expectError: true,
+		},
+		"Name": {
+			selector: "dev",
+			clusters: clusters,
+			expected: "dev/other",
+		},
+		"Ambiguous name": {
+			selector:    "storageos",
+			clusters:    clusters,
+			expectError: true,
+		},
+		"Name and namespace": {
+			selector: "storageos/other",
+			clusters: clusters,
+			expected: "storageos/other",
+		},
+		"No match": {
+			selector:    "dev/storageos",
+			clusters:    clusters,
+			notFound:    true,
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		tt := test
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			selector, err := ParseClusterSelector(tt.selector)
+			if err != nil {
+				t.Fatalf("unable to parse selector: %s", err.Error())
+			}
+
+			actual, err := selectStorageOSCluster(tt.clusters, selector)
+			if tt.expectError != (err != nil) {
+				t.Fatalf("error doesn't match: %t != %v", tt.expectError, err)
+			}
+			if tt.notFound != kerrors.IsNotFound(err) {
+				t.Errorf("not found doesn't match: %t != %v", tt.notFound, err)
+			}
+			if err == nil && actual.Name+"/"+actual.Namespace != tt.expected {
+				t.Errorf("cluster doesn't match: %s != %s/%s", tt.expected, actual.Name, actual.Namespace)
+			}
+		})
+	}
+}
+
+func TestParseClusterSelector(t *testing.T) {
+	for _, input := range []string{"/storageos", "storageos/", "a/b/c"} {
+		if _, err := ParseClusterSelector(input); err == nil {
+			t.Errorf("expected error for selector %q", input)
+		}
+	}
+}
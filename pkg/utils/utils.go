@@ -2,7 +2,6 @@ package utils
 
 import (
 	"archive/tar"
-	"bytes"
 	"context"
 	"flag"
 	"fmt"
@@ -176,15 +175,6 @@ func PullImage(imageUrl string) (gocontainerv1.Image, error) {
 	return pulledImage, nil
 }
 
-// ExportTarball take image and return an tarball of that image
-func ExportTarball(image gocontainerv1.Image) (bytes.Buffer, error) {
-	var buf bytes.Buffer
-	if err := crane.Export(image, &buf); err != nil {
-		return buf, errors.WithStack(err)
-	}
-	return buf, nil
-}
-
 // ExtractFile returns the contents of filename from tarball stored in r
 func ExtractFile(filename string, r io.Reader) ([]byte, error) {
 	tr := tar.NewReader(r)
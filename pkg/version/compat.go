@@ -0,0 +1,166 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// KubernetesComponent is the Kubernetes cluster, which version features may require.
+const KubernetesComponent Component = "kubernetes"
+
+// Feature is a feature of a StorageOS install which requires versions of its components.
+type Feature string
+
+const (
+	// FeatureOperator is the management of the StorageOS operator by the plugin.
+	FeatureOperator Feature = "operator"
+	// FeatureClusterOperator is the deprecated storageos-cluster-operator, replaced by the operator.
+	FeatureClusterOperator Feature = "cluster-operator"
+	FeaturePortalManager   Feature = "portal-manager"
+	FeatureMetricsExporter Feature = "metrics-exporter"
+)
+
+// Requirement requires the version of a component between a minimum and a maximum, inclusive, either of
+// which may be unbounded.
+type Requirement struct {
+	Component  Component
+	MinVersion string
+	MaxVersion string
+}
+
+// FeatureSupport is a feature and the requirements supporting it.
+type FeatureSupport struct {
+	Feature      Feature
+	Description  string
+	Requirements []Requirement
+}
+
+// Compatibility is a compatibility matrix, the requirements of each feature.
+type Compatibility []FeatureSupport
+
+// Matrix is the compatibility matrix of the plugin.
+var Matrix = Compatibility{
+	{
+		Feature:     FeatureOperator,
+		Description: "install, upgrade and uninstall StorageOS",
+		Requirements: []Requirement{
+			{Component: OperatorComponent, MinVersion: "v2.2.0"},
+		},
+	},
+	{
+		Feature:     FeatureClusterOperator,
+		Description: "storageos-cluster-operator, replaced by storageos-operator",
+		Requirements: []Requirement{
+			{Component: OperatorComponent, MaxVersion: "v2.4.4"},
+		},
+	},
+	{
+		Feature:     FeaturePortalManager,
+		Description: "connect StorageOS to the StorageOS portal",
+		Requirements: []Requirement{
+			{Component: OperatorComponent, MinVersion: "v2.6.0"},
+			{Component: PortalManagerComponent, MinVersion: "v1.0.0"},
+		},
+	},
+	{
+		Feature:     FeatureMetricsExporter,
+		Description: "export Prometheus metrics of StorageOS",
+		Requirements: []Requirement{
+			{Component: OperatorComponent, MinVersion: "v2.8.0"},
+		},
+	},
+}
+
+// Components are the components of the matrix, in the order they are printed.
+var Components = []Component{OperatorComponent, EtcdOperatorComponent, PortalManagerComponent, KubernetesComponent}
+
+// Satisfied returns true if version meets the requirement. Develop versions meet every requirement.
+func (r Requirement) Satisfied(version string) (bool, error) {
+	if IsDevelop(version) {
+		return true, nil
+	}
+	if r.MinVersion != "" {
+		supported, err := IsSupported(version, r.MinVersion)
+		if err != nil || !supported {
+			return false, err
+		}
+	}
+	if r.MaxVersion != "" {
+		return IsSupported(r.MaxVersion, version)
+	}
+
+	return true, nil
+}
+
+// Range returns the versions meeting the requirement, eg ">= v2.6.0".
+func (r Requirement) Range() string {
+	bounds := []string{}
+	if r.MinVersion != "" {
+		bounds = append(bounds, ">= "+r.MinVersion)
+	}
+	if r.MaxVersion != "" {
+		bounds = append(bounds, "<= "+r.MaxVersion)
+	}
+	if len(bounds) == 0 {
+		return "any"
+	}
+
+	return strings.Join(bounds, ", ")
+}
+
+// Requirement returns the requirement of feature on component, and false if there is none.
+func (c Compatibility) Requirement(feature Feature, component Component) (Requirement, bool) {
+	for _, support := range c {
+		if support.Feature != feature {
+			continue
+		}
+		for _, requirement := range support.Requirements {
+			if requirement.Component == component {
+				return requirement, true
+			}
+		}
+	}
+
+	return Requirement{}, false
+}
+
+// Supports returns an error naming the first requirement of feature not met by versions, the versions
+// of each component. Components without a version are not checked.
+func (c Compatibility) Supports(feature Feature, versions map[Component]string) error {
+	for _, support := range c {
+		if support.Feature != feature {
+			continue
+		}
+		for _, requirement := range support.Requirements {
+			version := versions[requirement.Component]
+			if version == "" {
+				continue
+			}
+			satisfied, err := requirement.Satisfied(version)
+			if err != nil {
+				return errors.Wrapf(err, "unable to check %s version %s", requirement.Component, version)
+			}
+			if !satisfied {
+				return fmt.Errorf("%s is not supported by %s %s, requires %s %s", feature, requirement.Component, version, requirement.Component, requirement.Range())
+			}
+		}
+		return nil
+	}
+
+	return errors.Errorf("unknown feature %s", feature)
+}
+
+// WithRequirement returns a copy of c in which feature also requires requirement.
+func (c Compatibility) WithRequirement(feature Feature, requirement Requirement) Compatibility {
+	matrix := make(Compatibility, 0, len(c))
+	for _, support := range c {
+		if support.Feature == feature {
+			support.Requirements = append(append([]Requirement{}, support.Requirements...), requirement)
+		}
+		matrix = append(matrix, support)
+	}
+
+	return matrix
+}
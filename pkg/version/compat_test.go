@@ -0,0 +1,75 @@
+package version
+
+import (
+	"testing"
+)
+
+func TestCompatibilitySupports(t *testing.T) {
+	tcases := []struct {
+		name          string
+		feature       Feature
+		versions      map[Component]string
+		expectedError bool
+	}{
+		{
+			name:     "supported operator",
+			feature:  FeatureOperator,
+			versions: map[Component]string{OperatorComponent: "v2.7.0"},
+		},
+		{
+			name:          "operator too old",
+			feature:       FeatureOperator,
+			versions:      map[Component]string{OperatorComponent: "v2.1.0"},
+			expectedError: true,
+		},
+		{
+			name:     "develop operator",
+			feature:  FeatureMetricsExporter,
+			versions: map[Component]string{OperatorComponent: "develop"},
+		},
+		{
+			name:     "last cluster operator",
+			feature:  FeatureClusterOperator,
+			versions: map[Component]string{OperatorComponent: "v2.4.4"},
+		},
+		{
+			name:          "operator replaced cluster operator",
+			feature:       FeatureClusterOperator,
+			versions:      map[Component]string{OperatorComponent: "v2.5.0"},
+			expectedError: true,
+		},
+		{
+			name:     "portal manager version not yet known",
+			feature:  FeaturePortalManager,
+			versions: map[Component]string{OperatorComponent: "v2.6.0"},
+		},
+		{
+			name:          "portal manager unsupported by operator",
+			feature:       FeaturePortalManager,
+			versions:      map[Component]string{OperatorComponent: "v2.5.0", PortalManagerComponent: "v1.0.2"},
+			expectedError: true,
+		},
+		{
+			name:          "unknown feature",
+			feature:       Feature("unknown"),
+			versions:      map[Component]string{OperatorComponent: "v2.7.0"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tcases {
+		err := Matrix.Supports(tc.feature, tc.versions)
+		if tc.expectedError != (err != nil) {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectedError, err)
+		}
+	}
+
+	kubernetes := Requirement{Component: KubernetesComponent, MinVersion: "v1.21.0"}
+	matrix := Matrix.WithRequirement(FeatureOperator, kubernetes)
+	if err := matrix.Supports(FeatureOperator, map[Component]string{OperatorComponent: "v2.7.0", KubernetesComponent: "v1.20.4-gke.100"}); err == nil {
+		t.Errorf("expected kubernetes requirement to be enforced")
+	}
+	if _, ok := Matrix.Requirement(FeatureOperator, KubernetesComponent); ok {
+		t.Errorf("expected matrix to be left unchanged")
+	}
+}
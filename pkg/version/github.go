@@ -3,10 +3,11 @@ package version
 import (
 	"encoding/json"
 	"sort"
-	"sync"
 	"time"
 
-	"github.com/storageos/kubectl-storageos/pkg/consts"
+	"github.com/pkg/errors"
+
+	"github.com/storageos/kubectl-storageos/pkg/cache"
 	pluginutils "github.com/storageos/kubectl-storageos/pkg/utils"
 )
 
@@ -19,16 +20,6 @@ const (
 	portalManagerReleasesApiUrl = "https://api.github.com/repos/storageos/portal-manager/releases"
 )
 
-var (
-	operatorLatestVersion      string
-	etcdOperatorLatestVersion  string
-	portalManagerLatestVersion string
-
-	fetchOperatorVersionOnce      = sync.Once{}
-	fetchEtcdOperatorVersionOnce  = sync.Once{}
-	fetchPortalManagerVersionOnce = sync.Once{}
-)
-
 type GithubRelease struct {
 	URL       string `json:"url,omitempty"`
 	AssetsURL string `json:"assets_url,omitempty"`
@@ -69,42 +60,6 @@ type GithubRelease struct {
 	Body            string        `json:"body,omitempty"`
 }
 
-func OperatorLatestSupportedVersion() string {
-	fetchOperatorVersionOnce.Do(func() {
-		if operatorLatestVersion != "" {
-			return
-		}
-		releases := fetchVersionsOrPanic(operatorReleasesApiUrl)
-		operatorLatestVersion = selectLatestVersionOrPanic(releases)
-	})
-
-	return operatorLatestVersion
-}
-
-func EtcdOperatorLatestSupportedVersion() string {
-	fetchEtcdOperatorVersionOnce.Do(func() {
-		if etcdOperatorLatestVersion != "" {
-			return
-		}
-		releases := fetchVersionsOrPanic(etcdOperatorReleasesApiUrl)
-		etcdOperatorLatestVersion = selectLatestVersionOrPanic(releases)
-	})
-
-	return etcdOperatorLatestVersion
-}
-
-func PortalManagerLatestSupportedVersion() string {
-	fetchPortalManagerVersionOnce.Do(func() {
-		if portalManagerLatestVersion != "" {
-			return
-		}
-		releases := fetchVersionsOrPanic(portalManagerReleasesApiUrl)
-		portalManagerLatestVersion = selectLatestVersionOrPanic(releases)
-	})
-
-	return portalManagerLatestVersion
-}
-
 func OperatorReleasesURL() string {
 	return operatorReleasesUrl
 }
@@ -122,38 +77,41 @@ func LocalPathProvisionerLatestSupportVersion() string {
 	return "https://raw.githubusercontent.com/rancher/local-path-provisioner/v0.0.22/deploy/local-path-storage.yaml"
 }
 
-func SetOperatorLatestSupportedVersion(version string) {
-	operatorLatestVersion = version
-}
-
-func SetEtcdOperatorLatestSupportedVersion(version string) {
-	etcdOperatorLatestVersion = version
-}
-
-func SetPortalManagerLatestSupportedVersion(version string) {
-	portalManagerLatestVersion = version
-}
-
+// ClusterOperatorLastVersion returns the last version of storageos-cluster-operator.
 func ClusterOperatorLastVersion() string {
-	return consts.ClusterOperatorLastVersion
+	requirement, _ := Matrix.Requirement(FeatureClusterOperator, OperatorComponent)
+	return requirement.MaxVersion
 }
 
-func fetchVersionsOrPanic(url string) []GithubRelease {
-	rawVersions, err := pluginutils.FetchHttpContent(url, nil)
+// fetchReleases returns the releases listed by the GitHub API at url, authenticated by token if set.
+// Responses are cached.
+func fetchReleases(url, token string) ([]GithubRelease, error) {
+	headers := map[string]string{}
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	rawVersions, err := cache.Fetch(cache.KindRelease, url, func() ([]byte, error) {
+		return pluginutils.FetchHttpContent(url, headers)
+	})
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
+	return parseReleases(rawVersions)
+}
+
+// parseReleases returns the releases of a GitHub API releases response.
+func parseReleases(rawVersions []byte) ([]GithubRelease, error) {
 	releases := []GithubRelease{}
-	err = json.Unmarshal(rawVersions, &releases)
-	if err != nil {
-		panic(err)
+	if err := json.Unmarshal(rawVersions, &releases); err != nil {
+		return nil, errors.Wrap(err, "unable to parse releases")
 	}
 
-	return releases
+	return releases, nil
 }
 
-func selectLatestVersionOrPanic(releases []GithubRelease) string {
+// selectLatestVersion returns the tag of the latest release, pre-releases are only selected if enabled.
+func selectLatestVersion(releases []GithubRelease) (string, error) {
 	versions := []GithubRelease{}
 
 	for _, release := range releases {
@@ -167,9 +125,10 @@ func selectLatestVersionOrPanic(releases []GithubRelease) string {
 	}
 
 	if len(versions) == 0 {
-		panic("release not found")
+		return "", errors.New("release not found")
 	}
 
+	var sortErr error
 	sort.SliceStable(versions, func(i, j int) bool {
 		versionI := cleanupVersion(versions[i].TagName)
 		versionJ := cleanupVersion(versions[j].TagName)
@@ -180,10 +139,13 @@ func selectLatestVersionOrPanic(releases []GithubRelease) string {
 
 		less, err := VersionIsLessThan(versionI, versionJ)
 		if err != nil {
-			panic(err)
+			sortErr = err
 		}
 		return !less
 	})
+	if sortErr != nil {
+		return "", sortErr
+	}
 
-	return versions[0].TagName
+	return versions[0].TagName, nil
 }
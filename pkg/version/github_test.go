@@ -7,19 +7,19 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
-)
 
-func TestFetchVersionsOrPanic(t *testing.T) {
-	defer func() {
-		if r := recover(); r != nil {
-			t.Errorf("panic not allowed: %v", r)
-		}
-	}()
+	"github.com/storageos/kubectl-storageos/pkg/cache"
+)
 
+func TestFetchReleases(t *testing.T) {
+	t.Setenv(cache.DirEnvVar, t.TempDir())
 	fakeUrl, close := startGithubServerMock(t)
 	defer close()
 
-	releases := fetchVersionsOrPanic(fakeUrl)
+	releases, err := fetchReleases(fakeUrl, "")
+	if err != nil {
+		t.Fatalf("failed to fetch releases: %s", err.Error())
+	}
 
 	if len(releases) != 31 {
 		t.Error("not all the releases were parsed")
@@ -39,12 +39,6 @@ func startGithubServerMock(t *testing.T) (string, func()) {
 }
 
 func TestSelectLatestVersionEnablePreReleasesFalse(t *testing.T) {
-	defer func() {
-		if r := recover(); r != nil {
-			t.Errorf("panic not allowed: %v", r)
-		}
-	}()
-
 	rawVersions, err := os.ReadFile("test-data/cluster-operator-releases.json")
 	if err != nil {
 		t.Fatalf("failed to read testdata: %s", err.Error())
@@ -56,7 +50,10 @@ func TestSelectLatestVersionEnablePreReleasesFalse(t *testing.T) {
 		t.Fatalf("failed to parse testdata: %s", err.Error())
 	}
 
-	latest := selectLatestVersionOrPanic(releases)
+	latest, err := selectLatestVersion(releases)
+	if err != nil {
+		t.Fatalf("failed to select latest version: %s", err.Error())
+	}
 
 	if latest != "v2.4.4" {
 		t.Errorf("latest version doesn't match: v2.4.4 != %s", latest)
@@ -64,12 +61,6 @@ func TestSelectLatestVersionEnablePreReleasesFalse(t *testing.T) {
 }
 
 func TestSelectLatestVersionEnablePreReleasesTrue(t *testing.T) {
-	defer func() {
-		if r := recover(); r != nil {
-			t.Errorf("panic not allowed: %v", r)
-		}
-	}()
-
 	enablePreReleases = true
 	defer func() {
 		enablePreReleases = false
@@ -86,7 +77,10 @@ func TestSelectLatestVersionEnablePreReleasesTrue(t *testing.T) {
 		t.Fatalf("failed to parse testdata: %s", err.Error())
 	}
 
-	latest := selectLatestVersionOrPanic(releases)
+	latest, err := selectLatestVersion(releases)
+	if err != nil {
+		t.Fatalf("failed to select latest version: %s", err.Error())
+	}
 
 	if latest != "v2.4.4" {
 		t.Errorf("latest version doesn't match: v2.4.4 != %s", latest)
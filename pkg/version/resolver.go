@@ -0,0 +1,299 @@
+package version
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/storageos/kubectl-storageos/pkg/cache"
+)
+
+// Component is a component which latest supported version is resolved.
+type Component string
+
+const (
+	OperatorComponent      Component = "operator"
+	EtcdOperatorComponent  Component = "etcd-operator"
+	PortalManagerComponent Component = "portal-manager"
+)
+
+const (
+	// GitHubTokenEnvVar holds the token authenticating requests to the GitHub API, which raises its
+	// rate limit.
+	GitHubTokenEnvVar = "GITHUB_TOKEN"
+	// VersionsFileEnvVar holds the path of a versions file, which takes precedence over every other
+	// source of versions.
+	VersionsFileEnvVar = "KUBECTL_STORAGEOS_VERSIONS_FILE"
+	// StaticVersionsEnvVar opts into the compiled-in versions as the last resort of DefaultResolver,
+	// when set to true.
+	StaticVersionsEnvVar = "KUBECTL_STORAGEOS_STATIC_VERSIONS"
+)
+
+// Compiled-in versions of the static resolver, the latest known to be supported when the plugin was
+// built. They go stale, so they are only a last resort once opted into with StaticVersionsEnvVar, and
+// can be defined at build time:
+// -X github.com/storageos/kubectl-storageos/pkg/version.StaticOperatorVersion=v2.8.0
+var (
+	StaticOperatorVersion      = "v2.7.0"
+	StaticEtcdOperatorVersion  string
+	StaticPortalManagerVersion = "v1.0.2"
+)
+
+// Warnings receives the warnings of a ChainResolver answered by a fallback.
+var Warnings io.Writer = os.Stderr
+
+// releasesAPIURLs are the GitHub API urls of the releases of each component.
+var releasesAPIURLs = map[Component]string{
+	OperatorComponent:      operatorReleasesApiUrl,
+	EtcdOperatorComponent:  etcdOperatorReleasesApiUrl,
+	PortalManagerComponent: portalManagerReleasesApiUrl,
+}
+
+// Resolver resolves the latest supported version of components.
+type Resolver interface {
+	// Name names the source of versions.
+	Name() string
+	// LatestVersion returns the latest supported version of component.
+	LatestVersion(component Component) (string, error)
+}
+
+// GitHubResolver resolves the latest release of components with the GitHub API.
+type GitHubResolver struct {
+	// Token authenticates requests if set.
+	Token string
+	// URLs are the GitHub API releases urls of each component.
+	URLs map[Component]string
+}
+
+// NewGitHubResolver returns a GitHubResolver of the storageos repositories, authenticated by
+// $GITHUB_TOKEN if set.
+func NewGitHubResolver() *GitHubResolver {
+	return &GitHubResolver{
+		Token: os.Getenv(GitHubTokenEnvVar),
+		URLs:  releasesAPIURLs,
+	}
+}
+
+func (r *GitHubResolver) Name() string {
+	return "github"
+}
+
+func (r *GitHubResolver) LatestVersion(component Component) (string, error) {
+	url, ok := r.URLs[component]
+	if !ok {
+		return "", errors.Errorf("no releases url for %s", component)
+	}
+	releases, err := fetchReleases(url, r.Token)
+	if err != nil {
+		if r.Token == "" {
+			return "", errors.Wrapf(err, "set %s to authenticate if rate limited", GitHubTokenEnvVar)
+		}
+		return "", err
+	}
+
+	return selectLatestVersion(releases)
+}
+
+// CacheResolver resolves the latest release of components from the release metadata cached by a
+// GitHubResolver, even if expired.
+type CacheResolver struct {
+	Cache *cache.Cache
+	// URLs are the GitHub API releases urls of each component, the keys of the cached metadata.
+	URLs map[Component]string
+}
+
+func (r *CacheResolver) Name() string {
+	return "cache"
+}
+
+func (r *CacheResolver) LatestVersion(component Component) (string, error) {
+	rawVersions, ok := r.Cache.GetStale(cache.KindRelease, r.URLs[component])
+	if !ok {
+		return "", errors.Errorf("no cached releases of %s", component)
+	}
+	releases, err := parseReleases(rawVersions)
+	if err != nil {
+		return "", err
+	}
+
+	return selectLatestVersion(releases)
+}
+
+// FileResolver resolves versions from a yaml file mapping components to versions, eg:
+//
+// operator: v2.7.0
+// etcd-operator: v0.3.2
+// portal-manager: v1.0.2
+type FileResolver struct {
+	Path string
+}
+
+func (r *FileResolver) Name() string {
+	return "file " + r.Path
+}
+
+func (r *FileResolver) LatestVersion(component Component) (string, error) {
+	data, err := os.ReadFile(r.Path)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	versions := map[Component]string{}
+	if err = yaml.Unmarshal(data, &versions); err != nil {
+		return "", errors.Wrapf(err, "invalid versions file %s", r.Path)
+	}
+	if versions[component] == "" {
+		return "", errors.Errorf("no version of %s", component)
+	}
+
+	return versions[component], nil
+}
+
+// StaticResolver resolves the versions compiled into the plugin.
+type StaticResolver struct {
+	Versions map[Component]string
+}
+
+// NewStaticResolver returns a StaticResolver of the compiled-in versions.
+func NewStaticResolver() *StaticResolver {
+	return &StaticResolver{
+		Versions: map[Component]string{
+			OperatorComponent:      StaticOperatorVersion,
+			EtcdOperatorComponent:  StaticEtcdOperatorVersion,
+			PortalManagerComponent: StaticPortalManagerVersion,
+		},
+	}
+}
+
+func (r *StaticResolver) Name() string {
+	return "static"
+}
+
+func (r *StaticResolver) LatestVersion(component Component) (string, error) {
+	if r.Versions[component] == "" {
+		return "", errors.Errorf("no compiled-in version of %s", component)
+	}
+
+	return r.Versions[component], nil
+}
+
+// ChainResolver resolves versions with the first of its resolvers to succeed. A version not resolved by
+// the first resolver is warned about, naming its source and the errors of the resolvers before it.
+type ChainResolver []Resolver
+
+func (r ChainResolver) Name() string {
+	names := make([]string, 0, len(r))
+	for _, resolver := range r {
+		names = append(names, resolver.Name())
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func (r ChainResolver) LatestVersion(component Component) (string, error) {
+	failures := []string{}
+	for _, resolver := range r {
+		version, err := resolver.LatestVersion(component)
+		if err == nil {
+			if len(failures) != 0 {
+				fmt.Fprintf(Warnings, "Warning: resolved the latest version of %s to %s from %s, as these sources failed:\n  %s\n",
+					component, version, resolver.Name(), strings.Join(failures, "\n  "))
+			}
+			return version, nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", resolver.Name(), err))
+	}
+
+	return "", errors.Errorf("unable to resolve the latest version of %s:\n  %s", component, strings.Join(failures, "\n  "))
+}
+
+// DefaultResolver returns a chain of the versions file of $KUBECTL_STORAGEOS_VERSIONS_FILE if set, the
+// GitHub API, the cached release metadata and, if opted into with $KUBECTL_STORAGEOS_STATIC_VERSIONS,
+// the compiled-in versions.
+func DefaultResolver() Resolver {
+	chain := ChainResolver{}
+	if path := os.Getenv(VersionsFileEnvVar); path != "" {
+		chain = append(chain, &FileResolver{Path: path})
+	}
+	chain = append(chain, NewGitHubResolver())
+	if c, err := cache.Default(); err == nil {
+		chain = append(chain, &CacheResolver{Cache: c, URLs: releasesAPIURLs})
+	}
+	if static, _ := strconv.ParseBool(os.Getenv(StaticVersionsEnvVar)); static {
+		chain = append(chain, NewStaticResolver())
+	}
+
+	return chain
+}
+
+var (
+	resolverMu sync.Mutex
+	resolver   Resolver
+
+	latestVersions = map[Component]string{}
+)
+
+// SetResolver sets the resolver of latest versions, DefaultResolver unless set.
+func SetResolver(r Resolver) {
+	resolverMu.Lock()
+	defer resolverMu.Unlock()
+
+	resolver = r
+}
+
+// latestSupportedVersion returns the version of component set, or else the latest version resolved,
+// which is then kept.
+func latestSupportedVersion(component Component) (string, error) {
+	resolverMu.Lock()
+	defer resolverMu.Unlock()
+
+	if version := latestVersions[component]; version != "" {
+		return version, nil
+	}
+	if resolver == nil {
+		resolver = DefaultResolver()
+	}
+	version, err := resolver.LatestVersion(component)
+	if err != nil {
+		return "", err
+	}
+	latestVersions[component] = version
+
+	return version, nil
+}
+
+func setLatestSupportedVersion(component Component, version string) {
+	resolverMu.Lock()
+	defer resolverMu.Unlock()
+
+	latestVersions[component] = version
+}
+
+func OperatorLatestSupportedVersion() (string, error) {
+	return latestSupportedVersion(OperatorComponent)
+}
+
+func EtcdOperatorLatestSupportedVersion() (string, error) {
+	return latestSupportedVersion(EtcdOperatorComponent)
+}
+
+func PortalManagerLatestSupportedVersion() (string, error) {
+	return latestSupportedVersion(PortalManagerComponent)
+}
+
+func SetOperatorLatestSupportedVersion(version string) {
+	setLatestSupportedVersion(OperatorComponent, version)
+}
+
+func SetEtcdOperatorLatestSupportedVersion(version string) {
+	setLatestSupportedVersion(EtcdOperatorComponent, version)
+}
+
+func SetPortalManagerLatestSupportedVersion(version string) {
+	setLatestSupportedVersion(PortalManagerComponent, version)
+}
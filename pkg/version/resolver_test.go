@@ -0,0 +1,180 @@
+package version
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/storageos/kubectl-storageos/pkg/cache"
+)
+
+func TestGitHubResolver(t *testing.T) {
+	releases, err := os.ReadFile("test-data/cluster-operator-releases.json")
+	if err != nil {
+		t.Fatalf("failed to read testdata: %s", err.Error())
+	}
+
+	tcases := []struct {
+		name          string
+		token         string
+		status        int
+		expAuth       string
+		expVersion    string
+		expectedError bool
+	}{
+		{
+			name:       "anonymous",
+			status:     http.StatusOK,
+			expVersion: "v2.4.4",
+		},
+		{
+			name:       "authenticated",
+			token:      "secret",
+			status:     http.StatusOK,
+			expAuth:    "Bearer secret",
+			expVersion: "v2.4.4",
+		},
+		{
+			name:          "rate limited",
+			status:        http.StatusForbidden,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv(cache.DirEnvVar, t.TempDir())
+		auth := ""
+		ms := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth = r.Header.Get("Authorization")
+			w.WriteHeader(tc.status)
+			w.Write(releases)
+		}))
+
+		resolver := &GitHubResolver{Token: tc.token, URLs: map[Component]string{OperatorComponent: ms.URL}}
+		version, err := resolver.LatestVersion(OperatorComponent)
+		ms.Close()
+
+		if tc.expectedError != (err != nil) {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectedError, err)
+		}
+		if version != tc.expVersion {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expVersion, version)
+		}
+		if auth != tc.expAuth {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expAuth, auth)
+		}
+	}
+}
+
+func TestChainResolver(t *testing.T) {
+	releases, err := os.ReadFile("test-data/cluster-operator-releases.json")
+	if err != nil {
+		t.Fatalf("failed to read testdata: %s", err.Error())
+	}
+	ms := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ms.Close()
+	urls := map[Component]string{OperatorComponent: ms.URL}
+
+	tcases := []struct {
+		name          string
+		versionsFile  string
+		cached        bool
+		static        string
+		expVersion    string
+		expWarning    string
+		expectedError bool
+	}{
+		{
+			name:         "versions file takes precedence",
+			versionsFile: "operator: v2.6.0\n",
+			cached:       true,
+			static:       "v2.7.0",
+			expVersion:   "v2.6.0",
+		},
+		{
+			name:       "cached releases when github is unavailable",
+			cached:     true,
+			static:     "v2.7.0",
+			expVersion: "v2.4.4",
+			expWarning: "Warning: resolved the latest version of operator to v2.4.4 from cache, as these sources failed:\n  github: ",
+		},
+		{
+			name:         "static version when nothing else is available",
+			versionsFile: "portal-manager: v1.0.2\n",
+			static:       "v2.7.0",
+			expVersion:   "v2.7.0",
+			expWarning:   "Warning: resolved the latest version of operator to v2.7.0 from static, as these sources failed:\n  file ",
+		},
+		{
+			name:          "no source",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tcases {
+		dir := t.TempDir()
+		// github responses aren't cached alongside the releases served by the cache resolver
+		t.Setenv(cache.DirEnvVar, filepath.Join(dir, "github"))
+		c := cache.New(filepath.Join(dir, "cache"))
+		if tc.cached {
+			if err := c.Put(cache.KindRelease, ms.URL, releases); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		chain := ChainResolver{}
+		if tc.versionsFile != "" {
+			path := filepath.Join(dir, "versions.yaml")
+			if err := os.WriteFile(path, []byte(tc.versionsFile), 0644); err != nil {
+				t.Fatal(err)
+			}
+			chain = append(chain, &FileResolver{Path: path})
+		}
+		chain = append(chain,
+			&GitHubResolver{URLs: urls},
+			&CacheResolver{Cache: c, URLs: urls},
+			&StaticResolver{Versions: map[Component]string{OperatorComponent: tc.static}},
+		)
+
+		warnings := &bytes.Buffer{}
+		Warnings = warnings
+		version, err := chain.LatestVersion(OperatorComponent)
+		Warnings = os.Stderr
+		if tc.expectedError != (err != nil) {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.expectedError, err)
+		}
+		if version != tc.expVersion {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expVersion, version)
+		}
+		if !strings.HasPrefix(warnings.String(), tc.expWarning) || (tc.expWarning == "") != (warnings.Len() == 0) {
+			t.Errorf("%s: expected warning %q, got %q", tc.name, tc.expWarning, warnings.String())
+		}
+	}
+}
+
+func TestDefaultResolverStaticVersions(t *testing.T) {
+	tcases := []struct {
+		name      string
+		static    string
+		expStatic bool
+	}{
+		{name: "unset", static: "", expStatic: false},
+		{name: "opted out", static: "false", expStatic: false},
+		{name: "opted in", static: "true", expStatic: true},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv(StaticVersionsEnvVar, tc.static)
+		chain := DefaultResolver().(ChainResolver)
+		_, static := chain[len(chain)-1].(*StaticResolver)
+		if static != tc.expStatic {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expStatic, static)
+		}
+	}
+}
@@ -89,7 +89,22 @@ func IsDevelop(version string) bool {
 	return false
 }
 
+// GetExistingOperatorVersion returns the version of the installed operator, or an error if the plugin
+// does not support it.
 func GetExistingOperatorVersion(namespace string) (string, error) {
+	version, err := GetInstalledOperatorVersion(namespace)
+	if err != nil {
+		return "", err
+	}
+	if err := Matrix.Supports(FeatureOperator, map[Component]string{OperatorComponent: version}); err != nil {
+		return "", errors.Wrap(err, "kubectl storageos does not support the existing storageos operator")
+	}
+
+	return version, nil
+}
+
+// GetInstalledOperatorVersion returns the version of the installed operator, whether supported or not.
+func GetInstalledOperatorVersion(namespace string) (string, error) {
 	oldNS := consts.OldOperatorNamespace
 	newNS := consts.NewOperatorNamespace
 	if namespace != "" {
@@ -119,14 +134,6 @@ func GetExistingOperatorVersion(namespace string) (string, error) {
 	splitImageName := strings.SplitAfter(imageName, ":")
 	version := splitImageName[len(splitImageName)-1]
 
-	lessThan, err := VersionIsLessThan(version, consts.OperatorOldestSupportedVersion)
-	if err != nil {
-		return "", err
-	}
-	if lessThan {
-		return "", fmt.Errorf("kubectl storageos does not support storageos operator version less than %s", consts.OperatorOldestSupportedVersion)
-	}
-
 	return version, nil
 }
 
@@ -136,7 +143,7 @@ func GetExistingPortalManagerVersion() (string, error) {
 		return "", err
 	}
 
-	stosCluster, err := pluginutils.GetFirstStorageOSCluster(config)
+	stosCluster, err := pluginutils.GetStorageOSCluster(config)
 	if err != nil {
 		return "", err
 	}
@@ -295,12 +302,21 @@ func VersionIsEqualTo(version, marker string) (bool, error) {
 	return ver.Equal(mar), nil
 }
 
-func OperatorLatestSupportedImageURL() string {
-	return fmt.Sprintf("%s:%s", stosOperatorManifestsImageUrl, OperatorLatestSupportedVersion())
+// releaseManifestURLRegexp matches the manifests attached to versioned releases of storageos repositories.
+var releaseManifestURLRegexp = regexp.MustCompile(`^https://github\.com/storageos/[^/]+/releases/download/v?[0-9]+\.[0-9]+\.[0-9]+[^/]*/[^/]+$`)
+
+// IsReleaseManifestURL returns true if url is a manifest of a versioned storageos release, whose content
+// doesn't change, rather than of a development build or any other location.
+func IsReleaseManifestURL(url string) bool {
+	return releaseManifestURLRegexp.MatchString(url)
+}
+
+func OperatorManifestsImageURL(version string) string {
+	return fmt.Sprintf("%s:%s", stosOperatorManifestsImageUrl, version)
 }
 
-func OperatorLatestSupportedURL() string {
-	return fmt.Sprintf(stosOperatorManifestsUrl, OperatorLatestSupportedVersion())
+func OperatorManifestsURL(version string) string {
+	return fmt.Sprintf(stosOperatorManifestsUrl, version)
 }
 
 func ClusterLatestSupportedURL() string {
@@ -311,8 +327,8 @@ func ResourceQuotaLatestSupportedURL() string {
 	return fmt.Sprintf(resourceQuotaYamlUrl, PluginVersion)
 }
 
-func PortalManagerLatestSupportedImageURL() string {
-	return fmt.Sprintf("%s:%s", portalManagerManifestsImageUrl, PortalManagerLatestSupportedVersion())
+func PortalManagerManifestsImageURL(version string) string {
+	return fmt.Sprintf("%s:%s", portalManagerManifestsImageUrl, version)
 }
 
 func PortalSecretLatestSupportedURL() string {
@@ -327,16 +343,16 @@ func PortalConfigLatestSupportedURL() string {
 	return fmt.Sprintf(portalConfigYamlUrl, PluginVersion)
 }
 
-func EtcdOperatorLatestSupportedImageURL() string {
-	return fmt.Sprintf("%s:%s", etcdOperatorManifestsImageUrl, EtcdOperatorLatestSupportedVersion())
+func EtcdOperatorManifestsImageURL(version string) string {
+	return fmt.Sprintf("%s:%s", etcdOperatorManifestsImageUrl, version)
 }
 
-func EtcdOperatorLatestSupportedURL() string {
-	return fmt.Sprintf(etcdOperatorYamlUrl, EtcdOperatorLatestSupportedVersion())
+func EtcdOperatorManifestsURL(version string) string {
+	return fmt.Sprintf(etcdOperatorYamlUrl, version)
 }
 
-func EtcdClusterLatestSupportedURL() string {
-	return fmt.Sprintf(etcdClusterYamlUrl, EtcdOperatorLatestSupportedVersion())
+func EtcdClusterManifestsURL(version string) string {
+	return fmt.Sprintf(etcdClusterYamlUrl, version)
 }
 
 // IsSupported takes two versions, current version (haveVersion) and a
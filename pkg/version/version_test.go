@@ -137,3 +137,51 @@ func TestIsSupported(t *testing.T) {
 		})
 	}
 }
+
+func TestIsReleaseManifestURL(t *testing.T) {
+	tests := map[string]struct {
+		url      string
+		expected bool
+	}{
+		"operator release": {
+			url:      OperatorManifestsURL("v2.8.0"),
+			expected: true,
+		},
+		"etcd cluster release": {
+			url:      EtcdClusterManifestsURL("v0.3.2"),
+			expected: true,
+		},
+		"develop": {
+			url: OperatorManifestsURL("develop"),
+		},
+		"sha": {
+			url: OperatorManifestsURL("24582d9a8f60c7f6d3ce7eea6833281f548826eacdd75122d842231bdf1fe89e"),
+		},
+		"unset plugin version": {
+			url: "https://github.com/storageos/kubectl-storageos/releases/download//storageos-cluster.yaml",
+		},
+		"branch": {
+			url: "https://raw.githubusercontent.com/storageos/cluster-operator/master/deploy/secret.yaml",
+		},
+		"user supplied": {
+			url: "https://example.com/storageos-operator.yaml",
+		},
+		"other owner": {
+			url: "https://github.com/example/operator/releases/download/v2.8.0/storageos-operator.yaml",
+		},
+	}
+
+	for name, test := range tests {
+		tt := test
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := IsReleaseManifestURL(tt.url)
+
+			if tt.expected != actual {
+				t.Errorf("is release manifest url value doesn't match: %t != %t", tt.expected, actual)
+			}
+		})
+	}
+}